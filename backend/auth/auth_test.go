@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := CheckPassword(hash, "correct horse"); !ok || err != nil {
+		t.Errorf("right password: got %v, %v", ok, err)
+	}
+	if ok, err := CheckPassword(hash, "wrong horse"); ok || err != nil {
+		t.Errorf("wrong password: got %v, %v", ok, err)
+	}
+	if _, err := CheckPassword("plaintext", "plaintext"); err != ErrInvalidHash {
+		t.Errorf("malformed hash: got %v, want ErrInvalidHash", err)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tokens := NewTokens([]byte("secret"), time.Hour)
+	tokens.now = func() time.Time { return now }
+	userID := primitive.NewObjectID()
+
+	token, expiresAt := tokens.Issue(userID)
+	if !expiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("expires at %v", expiresAt)
+	}
+	if got, err := tokens.Verify(token); err != nil || got != userID {
+		t.Errorf("verify: got %v, %v", got, err)
+	}
+
+	other := NewTokens([]byte("other secret"), time.Hour)
+	if _, err := other.Verify(token); err != ErrInvalidToken {
+		t.Errorf("foreign token: got %v, want ErrInvalidToken", err)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := tokens.Verify(token); err != ErrInvalidToken {
+		t.Errorf("expired token: got %v, want ErrInvalidToken", err)
+	}
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"context"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type userIDKey struct{}
+
+// WithUserID returns a copy of ctx carrying the authenticated user's ID.
+func WithUserID(ctx context.Context, id primitive.ObjectID) context.Context {
+	return context.WithValue(ctx, userIDKey{}, id)
+}
+
+// UserID returns the authenticated user's ID, or NilObjectID outside an
+// authenticated request.
+func UserID(ctx context.Context) primitive.ObjectID {
+	id, _ := ctx.Value(userIDKey{}).(primitive.ObjectID)
+	return id
+}
+
+type ledgerKey struct{}
+
+type ledgerAccess struct {
+	id   primitive.ObjectID
+	role models.Role
+}
+
+// WithLedger returns a copy of ctx carrying the ledger the request works on
+// and the user's role in it.
+func WithLedger(ctx context.Context, id primitive.ObjectID, role models.Role) context.Context {
+	return context.WithValue(ctx, ledgerKey{}, ledgerAccess{id: id, role: role})
+}
+
+// LedgerID returns the ledger the request works on, or NilObjectID.
+func LedgerID(ctx context.Context) primitive.ObjectID {
+	access, _ := ctx.Value(ledgerKey{}).(ledgerAccess)
+	return access.id
+}
+
+// LedgerRole returns the user's role in the ledger the request works on.
+func LedgerRole(ctx context.Context) models.Role {
+	access, _ := ctx.Value(ledgerKey{}).(ledgerAccess)
+	return access.role
+}
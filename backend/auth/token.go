@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// Tokens issues and verifies session tokens: a JSON payload and its
+// HMAC-SHA256 signature, both base64url encoded and joined by a dot.
+type Tokens struct {
+	secret []byte
+	ttl    time.Duration
+	now    func() time.Time
+}
+
+type tokenPayload struct {
+	UserID    primitive.ObjectID `json:"sub"`
+	ExpiresAt int64              `json:"exp"`
+}
+
+func NewTokens(secret []byte, ttl time.Duration) *Tokens {
+	return &Tokens{secret: secret, ttl: ttl, now: time.Now}
+}
+
+// Issue returns a token for userID and the time it expires.
+func (t *Tokens) Issue(userID primitive.ObjectID) (string, time.Time) {
+	expiresAt := t.now().Add(t.ttl).Truncate(time.Second)
+	payload, _ := json.Marshal(tokenPayload{UserID: userID, ExpiresAt: expiresAt.Unix()})
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(t.sign(encoded)), expiresAt
+}
+
+// Verify checks the signature and expiry of token and returns its user ID.
+func (t *Tokens) Verify(token string) (primitive.ObjectID, error) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return primitive.NilObjectID, ErrInvalidToken
+	}
+	gotSig, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(gotSig, t.sign(encoded)) {
+		return primitive.NilObjectID, ErrInvalidToken
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return primitive.NilObjectID, ErrInvalidToken
+	}
+	var payload tokenPayload
+	if err := json.Unmarshal(data, &payload); err != nil || payload.UserID.IsZero() {
+		return primitive.NilObjectID, ErrInvalidToken
+	}
+	if t.now().Unix() >= payload.ExpiresAt {
+		return primitive.NilObjectID, ErrInvalidToken
+	}
+	return payload.UserID, nil
+}
+
+func (t *Tokens) sign(encoded string) []byte {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
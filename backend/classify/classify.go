@@ -0,0 +1,118 @@
+// Package classify learns which category an expense belongs in from the
+// expenses already categorised. It is a multinomial naive Bayes model over
+// the words of expense names and merchants, trained in process.
+package classify
+
+import (
+	"math"
+	"strings"
+	"unicode"
+)
+
+// Example is a categorised expense to learn from.
+type Example struct {
+	Name     string
+	Merchant string
+	Label    string
+}
+
+// Model is a trained classifier. It is safe for concurrent use once
+// trained.
+type Model struct {
+	labels map[string]*labelCounts
+	vocab  map[string]bool
+	docs   int
+}
+
+type labelCounts struct {
+	docs   int
+	words  map[string]int
+	nwords int
+}
+
+// Train returns a model learnt from examples. Examples without a label or
+// without any word are ignored.
+func Train(examples []Example) *Model {
+	m := &Model{labels: map[string]*labelCounts{}, vocab: map[string]bool{}}
+	for _, ex := range examples {
+		words := Tokens(ex.Name, ex.Merchant)
+		if ex.Label == "" || len(words) == 0 {
+			continue
+		}
+		c := m.labels[ex.Label]
+		if c == nil {
+			c = &labelCounts{words: map[string]int{}}
+			m.labels[ex.Label] = c
+		}
+		c.docs++
+		for _, w := range words {
+			c.words[w]++
+			c.nwords++
+			m.vocab[w] = true
+		}
+		m.docs++
+	}
+	return m
+}
+
+// Predict returns the most likely label for an expense and its posterior
+// probability, between 0 and 1. ok is false when the model has nothing to
+// go on: it learnt no labels, or none of the expense's words.
+func (m *Model) Predict(name, merchant string) (label string, confidence float64, ok bool) {
+	var known []string
+	for _, w := range Tokens(name, merchant) {
+		if m.vocab[w] {
+			known = append(known, w)
+		}
+	}
+	if len(known) == 0 {
+		return "", 0, false
+	}
+
+	// Log probabilities with add-one smoothing, normalised with the
+	// log-sum-exp trick so long names do not underflow.
+	scores := make(map[string]float64, len(m.labels))
+	best := math.Inf(-1)
+	vocab := float64(len(m.vocab))
+	for l, c := range m.labels {
+		score := math.Log(float64(c.docs) / float64(m.docs))
+		for _, w := range known {
+			score += math.Log((float64(c.words[w]) + 1) / (float64(c.nwords) + vocab))
+		}
+		scores[l] = score
+		if score > best || (score == best && l < label) {
+			best, label = score, l
+		}
+	}
+	var sum float64
+	for _, score := range scores {
+		sum += math.Exp(score - best)
+	}
+	return label, 1 / sum, true
+}
+
+// Labels returns how many labels the model learnt.
+func (m *Model) Labels() int {
+	return len(m.labels)
+}
+
+// Tokens returns the words an expense is classified by: the lower-cased
+// words of its name, and those of its merchant marked as such. Words
+// without letters, like prices and quantities, and single letters are
+// left out.
+func Tokens(name, merchant string) []string {
+	return append(words(name, ""), words(merchant, "merchant:")...)
+}
+
+func words(s, prefix string) []string {
+	var out []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(w)) < 2 || strings.IndexFunc(w, unicode.IsLetter) < 0 {
+			continue
+		}
+		out = append(out, prefix+w)
+	}
+	return out
+}
@@ -0,0 +1,53 @@
+package classify
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	got := Tokens("BANANAS 2 @ 0.59/lb x", "Corner-Grocer")
+	want := []string{"bananas", "lb", "merchant:corner", "merchant:grocer"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Tokens = %q, want %q", got, want)
+	}
+}
+
+func TestPredict(t *testing.T) {
+	m := Train([]Example{
+		{Name: "BANANAS", Merchant: "Corner Grocer", Label: "groceries"},
+		{Name: "ORGANIC BANANAS", Merchant: "Fresh Market", Label: "groceries"},
+		{Name: "MILK 2%", Merchant: "Corner Grocer", Label: "groceries"},
+		{Name: "Burrito bowl", Merchant: "Taqueria", Label: "dining"},
+		{Name: "Lunch", Merchant: "Corner Cafe", Label: "dining"},
+		{Name: "12.50", Label: "dining"}, // no words
+		{Name: "Unlabelled"},
+	})
+	if m.Labels() != 2 {
+		t.Fatalf("learnt %d labels, want 2", m.Labels())
+	}
+
+	tests := []struct {
+		name, merchant string
+		want           string
+		minConfidence  float64
+	}{
+		{"BANANAS", "", "groceries", 0.7},
+		{"bananas 1.2 lb", "Somewhere New", "groceries", 0.7},
+		{"LUNCH SPECIAL", "", "dining", 0.6},
+		{"Burrito", "Taqueria", "dining", 0.75},
+	}
+	for _, tt := range tests {
+		label, confidence, ok := m.Predict(tt.name, tt.merchant)
+		if !ok || label != tt.want || confidence < tt.minConfidence || confidence > 1 {
+			t.Errorf("Predict(%q, %q) = %q, %.2f, %t; want %q with at least %.2f", tt.name, tt.merchant, label, confidence, ok, tt.want, tt.minConfidence)
+		}
+	}
+
+	if label, _, ok := m.Predict("Parking", "City Garage"); ok {
+		t.Errorf("Predict with unknown words = %q, want no prediction", label)
+	}
+	if _, _, ok := Train(nil).Predict("BANANAS", ""); ok {
+		t.Error("untrained model made a prediction")
+	}
+}
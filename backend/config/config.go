@@ -2,14 +2,51 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
 	MongoURI             string
+	SQLitePath           string
+	PostgresURI          string
+	StorageDriver        string // "mongo" (default), "sqlite", "postgres" or "memory"
 	Port                 string
 	HUGGING_FACE_API_KEY string
+	// AuthSecret signs session tokens. When empty a random secret is used,
+	// which signs everybody out on restart.
+	AuthSecret string
+	TokenTTL   time.Duration
+	// BaseCurrency is what totals are converted to in ledgers that do not
+	// choose their own.
+	BaseCurrency string
+	// RecurringInterval is how often due recurring expenses are created.
+	RecurringInterval time.Duration
+	// BillWorkers is how many bills are processed at once, and
+	// BillMaxAttempts how often processing one is tried before giving up.
+	BillWorkers     int
+	BillMaxAttempts int
+	// OCREngine reads bills: "vision" (default, Google Cloud Vision),
+	// "tesseract", "fixture" (uploads of plain text are read as is) or
+	// "none".
+	OCREngine         string
+	TesseractPath     string
+	TesseractLanguage string
+	// ImagePreprocessing lists the steps run on uploaded images before
+	// OCR, see utils.ParseImageOptions, and ImageMaxDimension is what
+	// larger images are downscaled to.
+	ImagePreprocessing string
+	ImageMaxDimension  int
+	// PdftoppmPath is the binary that renders scanned PDF pages, at PDFDPI
+	// dots per inch, for OCR.
+	PdftoppmPath string
+	PDFDPI       int
+	// BlobStore keeps uploaded bill files: "fs" (default, in BlobDir),
+	// "gridfs" (in MongoDB, with the mongo storage driver) or "memory".
+	BlobStore string
+	BlobDir   string
 }
 
 func Load() (*Config, error) {
@@ -20,7 +57,46 @@ func Load() (*Config, error) {
 
 	return &Config{
 		MongoURI:             os.Getenv("MONGODB_CONN_URI"),
+		SQLitePath:           getEnv("SQLITE_PATH", "expenses.db"),
+		PostgresURI:          os.Getenv("POSTGRES_CONN_URI"),
+		StorageDriver:        getEnv("STORAGE_DRIVER", "mongo"),
 		Port:                 os.Getenv("PORT"),
 		HUGGING_FACE_API_KEY: os.Getenv("HUGGING_FACE_API_KEY"),
+		AuthSecret:           os.Getenv("AUTH_SECRET"),
+		TokenTTL:             getDuration("TOKEN_TTL", 7*24*time.Hour),
+		BaseCurrency:         getEnv("BASE_CURRENCY", "USD"),
+		RecurringInterval:    getDuration("RECURRING_INTERVAL", 15*time.Minute),
+		BillWorkers:          getInt("BILL_WORKERS", 2),
+		BillMaxAttempts:      getInt("BILL_MAX_ATTEMPTS", 5),
+		OCREngine:            getEnv("OCR_ENGINE", "vision"),
+		TesseractPath:        getEnv("TESSERACT_PATH", "tesseract"),
+		TesseractLanguage:    getEnv("TESSERACT_LANG", "eng"),
+		ImagePreprocessing:   getEnv("IMAGE_PREPROCESSING", "autorotate,downscale,grayscale,contrast,deskew,crop"),
+		ImageMaxDimension:    getInt("IMAGE_MAX_DIMENSION", 2000),
+		PdftoppmPath:         getEnv("PDFTOPPM_PATH", "pdftoppm"),
+		PDFDPI:               getInt("PDF_DPI", 300),
+		BlobStore:            getEnv("BLOB_STORE", "fs"),
+		BlobDir:              getEnv("BLOB_DIR", "bill-files"),
 	}, nil
 }
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func getDuration(key string, fallback time.Duration) time.Duration {
+	if d, err := time.ParseDuration(os.Getenv(key)); err == nil && d > 0 {
+		return d
+	}
+	return fallback
+}
+
+func getInt(key string, fallback int) int {
+	if n, err := strconv.Atoi(os.Getenv(key)); err == nil && n > 0 {
+		return n
+	}
+	return fallback
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/dhruwanga19/expense-tracker/auth"
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/services"
+
+	"github.com/gorilla/mux"
+)
+
+// SetupAuthRoutes registers sign-up and login on public and the routes that
+// need a signed-in user on protected.
+func SetupAuthRoutes(public, protected *mux.Router, authService *services.AuthService) {
+	public.HandleFunc("/api/auth/register", registerHandler(authService)).Methods("POST")
+	public.HandleFunc("/api/auth/login", loginHandler(authService)).Methods("POST")
+	protected.HandleFunc("/api/auth/me", meHandler(authService)).Methods("GET")
+}
+
+func registerHandler(s *services.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var creds models.Credentials
+		if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		resp, err := s.Register(r.Context(), creds)
+		if err != nil {
+			switch err {
+			case services.ErrInvalidEmail, services.ErrPasswordTooShort:
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			case services.ErrEmailTaken:
+				http.Error(w, err.Error(), http.StatusConflict)
+			default:
+				log.Printf("Error registering user: %v", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func loginHandler(s *services.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var creds models.Credentials
+		if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		resp, err := s.Login(r.Context(), creds)
+		if err != nil {
+			if err == services.ErrInvalidCredentials {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+			} else {
+				log.Printf("Error logging in: %v", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func meHandler(s *services.AuthService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := s.GetUser(r.Context(), auth.UserID(r.Context()))
+		if err != nil {
+			// The token is valid but its account is gone.
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(user)
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+)
+
+func TestAuthFlow(t *testing.T) {
+	r := newTestRouter()
+
+	if rec := doJSON(t, r, "GET", "/api/expenses", nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous request: status %d, want 401", rec.Code)
+	}
+
+	creds := models.Credentials{Email: "Me@Example.com", Password: "correct horse"}
+	if rec := doJSON(t, r, "POST", "/api/auth/register", creds); rec.Code != http.StatusCreated {
+		t.Fatalf("register: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := doJSON(t, r, "POST", "/api/auth/register", models.Credentials{Email: "me@example.com", Password: "another one"}); rec.Code != http.StatusConflict {
+		t.Errorf("register taken email: status %d, want 409", rec.Code)
+	}
+	if rec := doJSON(t, r, "POST", "/api/auth/register", models.Credentials{Email: "new@example.com", Password: "short"}); rec.Code != http.StatusBadRequest {
+		t.Errorf("register short password: status %d, want 400", rec.Code)
+	}
+	if rec := doJSON(t, r, "POST", "/api/auth/login", models.Credentials{Email: "me@example.com", Password: "wrong horse"}); rec.Code != http.StatusUnauthorized {
+		t.Errorf("login with wrong password: status %d, want 401", rec.Code)
+	}
+
+	rec := doJSON(t, r, "POST", "/api/auth/login", models.Credentials{Email: "me@example.com", Password: "correct horse"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("login: status %d: %s", rec.Code, rec.Body)
+	}
+	var resp models.AuthResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+
+	rec = doRequest(r, "GET", "/api/auth/me", "Bearer "+resp.Token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("me: status %d: %s", rec.Code, rec.Body)
+	}
+	var user models.User
+	json.NewDecoder(rec.Body).Decode(&user)
+	if user.Email != "me@example.com" || user.ID != resp.User.ID {
+		t.Errorf("me: got %+v", user)
+	}
+	if rec := doRequest(r, "GET", "/api/auth/me", "Bearer "+resp.Token+"x"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("tampered token: status %d, want 401", rec.Code)
+	}
+}
+
+func TestUsersDoNotSeeEachOther(t *testing.T) {
+	r := newTestRouter()
+	alice := asUser(t, r, "alice@example.com")
+	bob := asUser(t, r, "bob@example.com")
+
+	rec := doJSON(t, alice, "POST", "/api/categories", models.Category{Name: "Food", Color: "#00ff00"})
+	var category models.Category
+	json.NewDecoder(rec.Body).Decode(&category)
+	doJSON(t, alice, "POST", "/api/expenses", models.Expense{Name: "Lunch", Amount: 12, CategoryID: category.ID})
+
+	rec = doJSON(t, bob, "GET", "/api/expenses", nil)
+	var page models.ExpensePage
+	json.NewDecoder(rec.Body).Decode(&page)
+	if len(page.Expenses) != 0 {
+		t.Errorf("bob sees alice's expenses: %+v", page.Expenses)
+	}
+	if rec := doJSON(t, bob, "POST", "/api/expenses", models.Expense{Name: "Sneaky", Amount: 1, CategoryID: category.ID}); rec.Code != http.StatusBadRequest {
+		t.Errorf("expense in another user's category: status %d, want 400", rec.Code)
+	}
+	if rec := doJSON(t, bob, "DELETE", "/api/categories/"+category.ID.Hex(), nil); rec.Code != http.StatusNotFound {
+		t.Errorf("delete another user's category: status %d, want 404", rec.Code)
+	}
+}
+
+func doRequest(h http.Handler, method, path, authorization string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("Authorization", authorization)
+	h.ServeHTTP(rec, req)
+	return rec
+}
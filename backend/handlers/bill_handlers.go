@@ -2,22 +2,102 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/dhruwanga19/expense-tracker/auth"
 	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
 	"github.com/dhruwanga19/expense-tracker/services"
+	"github.com/dhruwanga19/expense-tracker/store"
 	"github.com/gorilla/mux"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 func SetupBillRoutes(r *mux.Router, billService *services.BillService) {
+	r.HandleFunc("/api/bills", getBillsHandler(billService)).Methods("GET")
 	r.HandleFunc("/api/bills", uploadBillHandler(billService)).Methods("POST")
 	r.HandleFunc("/api/bills/{id}", getBillHandler(billService)).Methods("GET")
+	r.HandleFunc("/api/bills/{id}", deleteBillHandler(billService)).Methods("DELETE")
+	r.HandleFunc("/api/bills/{id}/discard", discardBillHandler(billService)).Methods("POST")
+	r.HandleFunc("/api/bills/{id}/reprocess", reprocessBillHandler(billService)).Methods("POST")
+	r.HandleFunc("/api/bills/{id}/file", getBillFileHandler(billService)).Methods("GET")
 	r.HandleFunc("/api/bills/{id}/expenses/{expenseId}", updateBillExpenseHandler(billService)).Methods("PUT")
 	r.HandleFunc("/api/bills/{id}/confirm", confirmExpensesHandler(billService)).Methods("POST")
 }
 
+func getBillsHandler(s *services.BillService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query, err := parseBillQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		query.LedgerID = auth.LedgerID(r.Context())
+
+		page, err := s.GetBills(r.Context(), query, r.URL.Query().Get("cursor"))
+		if err != nil {
+			billError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(page)
+	}
+}
+
+// parseBillQuery reads the filters of GET /api/bills:
+//
+//	status    repeated or comma-separated statuses
+//	from, to  upload dates (YYYY-MM-DD or RFC 3339); a bare "to" date is inclusive
+//	merchant  case-insensitive substring of the merchant
+//	limit     page size, capped at services.MaxBillPageSize
+func parseBillQuery(r *http.Request) (store.BillQuery, error) {
+	params := r.URL.Query()
+	query := store.BillQuery{Merchant: strings.TrimSpace(params.Get("merchant"))}
+
+	for _, v := range params["status"] {
+		for _, status := range strings.Split(v, ",") {
+			status = strings.TrimSpace(status)
+			if !models.IsBillStatus(status) {
+				return query, fmt.Errorf("invalid status %q", status)
+			}
+			query.Statuses = append(query.Statuses, status)
+		}
+	}
+	if v := params.Get("from"); v != "" {
+		from, _, err := parseDateParam(v)
+		if err != nil {
+			return query, fmt.Errorf("invalid from: %v", err)
+		}
+		query.From = from
+	}
+	if v := params.Get("to"); v != "" {
+		to, dateOnly, err := parseDateParam(v)
+		if err != nil {
+			return query, fmt.Errorf("invalid to: %v", err)
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		query.To = to
+	}
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return query, fmt.Errorf("invalid limit")
+		}
+		query.Limit = limit
+	}
+	return query, nil
+}
+
 func uploadBillHandler(s *services.BillService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Println("Received bill upload request")
@@ -41,26 +121,25 @@ func uploadBillHandler(s *services.BillService) http.HandlerFunc {
 
 		log.Printf("Received file: %s, size: %d bytes", header.Filename, header.Size)
 
-		// Create a new bill document
-		bill, err := s.CreateBill(r.Context(), header.Filename, header.Header.Get("Content-Type"))
+		content, err := io.ReadAll(file)
 		if err != nil {
-			log.Printf("Error creating bill document: %v", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			log.Printf("Error reading uploaded file: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		// Process the bill
-		log.Printf("Processing bill with ID: %s", bill.ID.Hex())
-		err = s.ProcessBill(r.Context(), bill.ID, file)
+		// Store the bill and queue it for processing
+		bill, err := s.SubmitBill(r.Context(), auth.LedgerID(r.Context()), header.Filename, header.Header.Get("Content-Type"), content)
 		if err != nil {
-			log.Printf("Error processing bill: %v", err)
+			log.Printf("Error creating bill document: %v", err)
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
-		log.Println("Bill processed successfully")
+		log.Printf("Queued bill with ID: %s", bill.ID.Hex())
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]string{"id": bill.ID.Hex()})
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"id": bill.ID.Hex(), "status": bill.Status})
 	}
 }
 
@@ -75,18 +154,186 @@ func getBillHandler(s *services.BillService) http.HandlerFunc {
 			return
 		}
 
-		bill, err := s.GetBill(r.Context(), id)
+		bill, err := s.GetBill(r.Context(), auth.LedgerID(r.Context()), id)
 		if err != nil {
 			log.Printf("Error getting bill: %v", err)
+			if err == store.ErrNotFound {
+				http.Error(w, "Bill not found", http.StatusNotFound)
+			} else {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(bill)
+	}
+}
+
+// getBillFileHandler streams the bill's file as uploaded, or with
+// ?kind=processed the image that was handed to OCR.
+func getBillFileHandler(s *services.BillService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid bill ID", http.StatusBadRequest)
+			return
+		}
+		kind := r.URL.Query().Get("kind")
+		switch kind {
+		case "":
+			kind = models.OriginalBillFile
+		case models.OriginalBillFile, models.ProcessedBillFile:
+		default:
+			http.Error(w, "kind must be original or processed", http.StatusBadRequest)
+			return
+		}
+
+		ledgerID := auth.LedgerID(r.Context())
+		bill, err := s.GetBill(r.Context(), ledgerID, id)
+		if err == store.ErrNotFound {
+			http.Error(w, "Bill not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		file, content, err := s.OpenBillFile(r.Context(), ledgerID, id, kind)
+		if err == store.ErrNotFound {
+			http.Error(w, "Bill file not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("Error opening bill file: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer content.Close()
+
+		// The content of a hash never changes.
+		etag := `"` + file.Hash + `"`
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("Content-Type", file.ContentType)
+		w.Header().Set("Content-Length", strconv.FormatInt(file.Size, 10))
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		disposition := map[string]string(nil)
+		if kind == models.OriginalBillFile && bill.FileName != "" {
+			disposition = map[string]string{"filename": bill.FileName}
+		}
+		w.Header().Set("Content-Disposition", mime.FormatMediaType(billFileDisposition(file.ContentType), disposition))
+		if _, err := io.Copy(w, content); err != nil {
+			log.Printf("Error sending bill file: %v", err)
+		}
+	}
+}
+
+// inlineBillTypes are the types of bill files shown in the browser. The
+// type is the one declared at upload, so anything else, such as HTML or SVG
+// that could run scripts from the API's origin, is only downloaded.
+var inlineBillTypes = map[string]bool{
+	"application/pdf": true,
+	"image/bmp":       true,
+	"image/gif":       true,
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/tiff":      true,
+	"image/webp":      true,
+}
+
+func billFileDisposition(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil && inlineBillTypes[mediaType] {
+		return "inline"
+	}
+	return "attachment"
+}
+
+// deleteBillHandler deletes a bill. With ?deleteExpenses=true the expenses
+// confirmed from it are deleted too; otherwise they are kept.
+func deleteBillHandler(s *services.BillService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid bill ID", http.StatusBadRequest)
+			return
+		}
+		var deleteExpenses bool
+		if v := r.URL.Query().Get("deleteExpenses"); v != "" {
+			deleteExpenses, err = strconv.ParseBool(v)
+			if err != nil {
+				http.Error(w, "Invalid deleteExpenses", http.StatusBadRequest)
+				return
+			}
+		}
+		if err := s.DeleteBill(r.Context(), auth.LedgerID(r.Context()), id, deleteExpenses); err != nil {
+			billError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
 
+func discardBillHandler(s *services.BillService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid bill ID", http.StatusBadRequest)
+			return
+		}
+		bill, err := s.DiscardBill(r.Context(), auth.LedgerID(r.Context()), id)
+		if err != nil {
+			billError(w, err)
+			return
+		}
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(bill)
 	}
 }
 
+// reprocessBillHandler queues the bill to be read again; like an upload, it
+// answers 202 Accepted and the bill's status tells when it is done.
+func reprocessBillHandler(s *services.BillService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid bill ID", http.StatusBadRequest)
+			return
+		}
+		bill, err := s.ReprocessBill(r.Context(), auth.LedgerID(r.Context()), id)
+		if err != nil {
+			billError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"id": bill.ID.Hex(), "status": bill.Status})
+	}
+}
+
+// billError writes the status for err returned by the bill service.
+func billError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCursor), errors.Is(err, services.ErrUnknownCategory),
+		errors.Is(err, services.ErrExpenseMissingCategory), errors.Is(err, money.ErrUnknownCurrency):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrBillExpenseNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, store.ErrNotFound):
+		http.Error(w, "Bill not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrBillStatus), errors.Is(err, services.ErrBillFileMissing):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		log.Printf("Error handling bill request: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
 func updateBillExpenseHandler(s *services.BillService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		params := mux.Vars(r)
@@ -108,9 +355,9 @@ func updateBillExpenseHandler(s *services.BillService) http.HandlerFunc {
 			return
 		}
 
-		err = s.UpdateBillExpense(r.Context(), billID, expenseID, &updatedExpense)
+		err = s.UpdateBillExpense(r.Context(), auth.LedgerID(r.Context()), billID, expenseID, &updatedExpense)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			billError(w, err)
 			return
 		}
 
@@ -137,10 +384,9 @@ func confirmExpensesHandler(s *services.BillService) http.HandlerFunc {
 			return
 		}
 
-		err = s.ConfirmExpenses(r.Context(), billID, expenses)
+		err = s.ConfirmExpenses(r.Context(), auth.LedgerID(r.Context()), billID, expenses)
 		if err != nil {
-			log.Println("Error confirming expenses:", err)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			billError(w, err)
 			return
 		}
 
@@ -0,0 +1,491 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"image/jpeg"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/ocr"
+	"github.com/dhruwanga19/expense-tracker/pdf"
+	"github.com/dhruwanga19/expense-tracker/services"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"github.com/dhruwanga19/expense-tracker/store/memstore"
+	"github.com/dhruwanga19/expense-tracker/utils"
+
+	"github.com/disintegration/imaging"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// uploadBill posts content as the bill file and returns the new bill's ID.
+func uploadBill(t *testing.T, h http.Handler, fileName string, content []byte) string {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("bill", fileName)
+	part.Write(content)
+	form.Close()
+
+	req := httptest.NewRequest("POST", "/api/bills", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("upload bill: status %d: %s", rec.Code, rec.Body)
+	}
+	var resp map[string]string
+	json.NewDecoder(rec.Body).Decode(&resp)
+	if resp["status"] != models.BillUploaded {
+		t.Errorf("uploaded bill status %q, want %q", resp["status"], models.BillUploaded)
+	}
+	return resp["id"]
+}
+
+// waitForBill polls the bill until it is no longer queued or processing.
+func waitForBill(t *testing.T, h http.Handler, id string) models.Bill {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		rec := doJSON(t, h, "GET", "/api/bills/"+id, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("get bill: status %d: %s", rec.Code, rec.Body)
+		}
+		var bill models.Bill
+		json.NewDecoder(rec.Body).Decode(&bill)
+		if bill.Status != models.BillUploaded && bill.Status != models.BillProcessing {
+			return bill
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("bill still %s", bill.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// newBillRouter returns a test router whose bills are read by engine, with
+// workers running until the test ends.
+func newBillRouter(t *testing.T, engine ocr.Engine) http.Handler {
+	return newBillRouterOn(t, memstore.New(), engine, utils.ImageOptions{}, nil)
+}
+
+func newBillRouterOn(t *testing.T, st store.Store, engine ocr.Engine, images utils.ImageOptions, rasterizer pdf.Rasterizer) http.Handler {
+	suggester := services.NewCategorySuggester(st)
+	bills := services.NewBillService(st, memstore.NewBlobStore(), engine, images, rasterizer, suggester)
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	bills.RunWorkers(ctx, 2, 3)
+	return asUser(t, newTestRouterOn(st, bills, suggester), "shopper@example.com")
+}
+
+func TestBillProcessedInBackground(t *testing.T) {
+	engine := ocr.NewFixture()
+	r := newBillRouter(t, engine)
+
+	image := []byte{0xff, 0xd8, 0xff, 0xe0, 0x00}
+	engine.Add(image, "MILK\n$4.50\nEGGS\n$3.00")
+	bill := waitForBill(t, r, uploadBill(t, r, "receipt.jpg", image))
+	if bill.Status != models.BillProcessed || bill.Error != "" || len(bill.GeneratedExpenses) != 2 || bill.AnalysisResults.Total != 750 {
+		t.Errorf("processed bill: %+v", bill)
+	}
+
+	// What the receipt says about the purchase becomes the expenses' default.
+	receipt := "Corner Grocer\n05/03/2024 14:32\nBREAD 2.25\nTOTAL 2.25\nVISA XXXX1234"
+	bill = waitForBill(t, r, uploadBill(t, r, "receipt.txt", []byte(receipt)))
+	purchased := time.Date(2024, 5, 3, 14, 32, 0, 0, time.UTC)
+	if bill.Merchant != "Corner Grocer" || bill.TransactionDate == nil || !bill.TransactionDate.Equal(purchased) ||
+		bill.PaymentMethod != "visa" || bill.CardLast4 != "1234" || !bill.AnalysisResults.Reconciled {
+		t.Errorf("receipt details: %+v", bill)
+	}
+	if len(bill.GeneratedExpenses) != 1 {
+		t.Fatalf("generated expenses: %+v", bill.GeneratedExpenses)
+	}
+	if e := bill.GeneratedExpenses[0]; !e.Date.Equal(purchased) || e.Merchant != "Corner Grocer" || e.PaymentMethod != "visa" {
+		t.Errorf("generated expense defaults: %+v", e)
+	}
+
+	// The fixture finds no text in an unknown image, which is not retried.
+	bill = waitForBill(t, r, uploadBill(t, r, "blank.jpg", []byte{0xff, 0xd8, 0x00}))
+	if bill.Status != models.BillError || bill.Error != ocr.ErrNoText.Error() {
+		t.Errorf("unreadable bill: %+v", bill)
+	}
+}
+
+func TestBillImagePreprocessed(t *testing.T) {
+	photo := imaging.New(300, 200, color.Gray{Y: 90})
+	for x := 40; x < 260; x++ {
+		photo.Set(x, 100, color.Gray{Y: 30})
+	}
+	var original bytes.Buffer
+	if err := jpeg.Encode(&original, photo, nil); err != nil {
+		t.Fatal(err)
+	}
+	images := utils.ImageOptions{Grayscale: true, Contrast: true, CropBorders: true}
+	processed, err := utils.PreprocessImage(original.Bytes(), images)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// OCR is run on the processed image only.
+	engine := ocr.NewFixture()
+	engine.Add(processed, "TEA 3.00")
+	r := newBillRouterOn(t, memstore.New(), engine, images, nil)
+	bill := waitForBill(t, r, uploadBill(t, r, "photo.jpg", original.Bytes()))
+	if bill.Status != models.BillProcessed || len(bill.GeneratedExpenses) != 1 {
+		t.Fatalf("preprocessed bill: %+v", bill)
+	}
+
+	for kind, want := range map[string][]byte{models.OriginalBillFile: original.Bytes(), models.ProcessedBillFile: processed} {
+		rec := doJSON(t, r, "GET", "/api/bills/"+bill.ID.Hex()+"/file?kind="+kind, nil)
+		if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), want) {
+			t.Errorf("%s file not kept: status %d", kind, rec.Code)
+		}
+	}
+}
+
+func TestBillFile(t *testing.T) {
+	engine := ocr.NewFixture()
+	r := newBillRouter(t, engine)
+	image := []byte{0xff, 0xd8, 0xff, 0xe0, 0x00, 0x10}
+	engine.Add(image, "MILK 4.50")
+	id := uploadBill(t, r, "receipt.jpg", image)
+
+	// The original is kept from the upload on, with its type sniffed.
+	rec := doJSON(t, r, "GET", "/api/bills/"+id+"/file", nil)
+	if rec.Code != http.StatusOK || !bytes.Equal(rec.Body.Bytes(), image) {
+		t.Fatalf("get file: status %d: %q", rec.Code, rec.Body)
+	}
+	h := rec.Header()
+	if h.Get("Content-Type") != "image/jpeg" || h.Get("Content-Disposition") != `inline; filename=receipt.jpg` ||
+		h.Get("ETag") != `"`+store.ContentHash(image)+`"` || h.Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("file headers: %v", h)
+	}
+
+	req := httptest.NewRequest("GET", "/api/bills/"+id+"/file", nil)
+	req.Header.Set("If-None-Match", h.Get("ETag"))
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("unchanged file: status %d", rec.Code)
+	}
+
+	// Files of other types are not shown from the API's origin.
+	page := uploadBill(t, r, "receipt.html", []byte("<html><script>alert(1)</script></html>"))
+	rec = doJSON(t, r, "GET", "/api/bills/"+page+"/file", nil)
+	if h := rec.Header(); rec.Code != http.StatusOK || h.Get("Content-Disposition") != `attachment; filename=receipt.html` ||
+		h.Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("html file: status %d, headers %v", rec.Code, h)
+	}
+
+	// Without preprocessing there is no processed image.
+	waitForBill(t, r, id)
+	if rec := doJSON(t, r, "GET", "/api/bills/"+id+"/file?kind=processed", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("processed file: status %d", rec.Code)
+	}
+	if rec := doJSON(t, r, "GET", "/api/bills/"+id+"/file?kind=thumbnail", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown kind: status %d", rec.Code)
+	}
+	if rec := doJSON(t, r, "GET", "/api/bills/"+primitive.NewObjectID().Hex()+"/file", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("file of unknown bill: status %d", rec.Code)
+	}
+}
+
+func TestBillWithoutOCREngine(t *testing.T) {
+	r := newBillRouter(t, nil)
+	bill := waitForBill(t, r, uploadBill(t, r, "receipt.txt", []byte("MILK\n$4.50")))
+	if bill.Status != models.BillError || bill.Error != services.ErrOCRUnavailable.Error() {
+		t.Errorf("bill without OCR: %+v", bill)
+	}
+}
+
+// pageImages renders every PDF page as an image saying which page it is.
+type pageImages struct{}
+
+func (pageImages) Rasterize(ctx context.Context, document []byte, page int) ([]byte, error) {
+	return []byte(fmt.Sprintf("page %d", page)), nil
+}
+
+func TestBillFromPDF(t *testing.T) {
+	// The first page has a text layer, the second is scanned.
+	document, err := os.ReadFile("../pdf/testdata/text_and_scan.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine := ocr.NewFixture()
+	engine.Add([]byte("page 2"), "JAM 3.00\nTOTAL 9.75")
+	r := newBillRouterOn(t, memstore.New(), engine, utils.ImageOptions{}, pageImages{})
+
+	bill := waitForBill(t, r, uploadBill(t, r, "invoice.pdf", document))
+	if bill.Status != models.BillProcessed || bill.Merchant != "Corner Grocer" || !bill.AnalysisResults.Reconciled {
+		t.Fatalf("PDF bill: %+v", bill)
+	}
+	var names []string
+	for _, e := range bill.GeneratedExpenses {
+		names = append(names, e.Name)
+	}
+	if fmt.Sprint(names) != "[BREAD MILK 2% JAM]" {
+		t.Errorf("expenses from both pages: %v", names)
+	}
+
+	// Without a rasterizer the scanned page cannot be read.
+	r = newBillRouterOn(t, memstore.New(), engine, utils.ImageOptions{}, nil)
+	bill = waitForBill(t, r, uploadBill(t, r, "invoice.pdf", document))
+	if bill.Status != models.BillError || bill.Error != services.ErrRasterizerUnavailable.Error() {
+		t.Errorf("scanned PDF without a rasterizer: %+v", bill)
+	}
+}
+
+func TestBillListAndLifecycle(t *testing.T) {
+	r := newBillRouter(t, ocr.NewFixture())
+	grocer := uploadBill(t, r, "grocer.txt", []byte("Corner Grocer\nMILK 4.50\nTOTAL 4.50"))
+	fuel := uploadBill(t, r, "fuel.txt", []byte("Fuel Stop\nUNLEADED 40.00\nTOTAL 40.00"))
+	waitForBill(t, r, grocer)
+	waitForBill(t, r, fuel)
+
+	list := func(query string) models.BillPage {
+		t.Helper()
+		rec := doJSON(t, r, "GET", "/api/bills?"+query, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("list bills %q: status %d: %s", query, rec.Code, rec.Body)
+		}
+		var page models.BillPage
+		json.NewDecoder(rec.Body).Decode(&page)
+		return page
+	}
+	ids := func(page models.BillPage) []string {
+		var ids []string
+		for _, b := range page.Bills {
+			ids = append(ids, b.ID.Hex())
+		}
+		return ids
+	}
+
+	// Newest first, a page at a time.
+	page := list("limit=1")
+	if fmt.Sprint(ids(page)) != fmt.Sprint([]string{fuel}) || page.NextCursor == "" {
+		t.Fatalf("first page: %+v", page)
+	}
+	page = list("limit=1&cursor=" + page.NextCursor)
+	if fmt.Sprint(ids(page)) != fmt.Sprint([]string{grocer}) || page.NextCursor != "" {
+		t.Errorf("last page: %+v", page)
+	}
+	if page := list("merchant=grocer&status=processed"); fmt.Sprint(ids(page)) != fmt.Sprint([]string{grocer}) {
+		t.Errorf("filtered: %v", ids(page))
+	}
+	for _, query := range []string{"status=lost", "cursor=nope", "from=May"} {
+		if rec := doJSON(t, r, "GET", "/api/bills?"+query, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("list bills %q: status %d", query, rec.Code)
+		}
+	}
+
+	// Discarding is a status change; a discarded bill cannot be discarded
+	// again, but it can be read again from its file.
+	rec := doJSON(t, r, "POST", "/api/bills/"+fuel+"/discard", nil)
+	var bill models.Bill
+	json.NewDecoder(rec.Body).Decode(&bill)
+	if rec.Code != http.StatusOK || bill.Status != models.BillDiscarded {
+		t.Fatalf("discard: status %d: %+v", rec.Code, bill)
+	}
+	if rec := doJSON(t, r, "POST", "/api/bills/"+fuel+"/discard", nil); rec.Code != http.StatusConflict {
+		t.Errorf("discard twice: status %d", rec.Code)
+	}
+	if page := list("status=processed"); fmt.Sprint(ids(page)) != fmt.Sprint([]string{grocer}) {
+		t.Errorf("processed after discard: %v", ids(page))
+	}
+	if rec := doJSON(t, r, "POST", "/api/bills/"+fuel+"/reprocess", nil); rec.Code != http.StatusAccepted {
+		t.Fatalf("reprocess: status %d: %s", rec.Code, rec.Body)
+	}
+	if bill := waitForBill(t, r, fuel); bill.Status != models.BillProcessed || bill.Merchant != "Fuel Stop" || len(bill.GeneratedExpenses) != 1 {
+		t.Errorf("reprocessed bill: %+v", bill)
+	}
+
+	// Deleting takes the file with it.
+	if rec := doJSON(t, r, "DELETE", "/api/bills/"+fuel, nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete: status %d: %s", rec.Code, rec.Body)
+	}
+	for _, path := range []string{"/api/bills/" + fuel, "/api/bills/" + fuel + "/file"} {
+		if rec := doJSON(t, r, "GET", path, nil); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s after delete: status %d", path, rec.Code)
+		}
+	}
+	if rec := doJSON(t, r, "DELETE", "/api/bills/"+fuel, nil); rec.Code != http.StatusNotFound {
+		t.Errorf("delete twice: status %d", rec.Code)
+	}
+}
+
+func TestBillConfirmLinksExpenses(t *testing.T) {
+	r := newBillRouter(t, ocr.NewFixture())
+	rec := doJSON(t, r, "POST", "/api/categories", models.Category{Name: "Groceries", Color: "#00FF00"})
+	var category models.Category
+	json.NewDecoder(rec.Body).Decode(&category)
+
+	confirm := func(bill models.Bill) *httptest.ResponseRecorder {
+		t.Helper()
+		expenses := bill.GeneratedExpenses
+		for i := range expenses {
+			expenses[i].CategoryID = category.ID
+		}
+		return doJSON(t, r, "POST", "/api/bills/"+bill.ID.Hex()+"/confirm", expenses)
+	}
+	billExpenses := func(bill models.Bill) []models.Expense {
+		t.Helper()
+		rec := doJSON(t, r, "GET", "/api/expenses?billId="+bill.ID.Hex(), nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("list expenses of bill: status %d: %s", rec.Code, rec.Body)
+		}
+		var page models.ExpensePage
+		json.NewDecoder(rec.Body).Decode(&page)
+		return page.Expenses
+	}
+
+	grocer := waitForBill(t, r, uploadBill(t, r, "grocer.txt", []byte("Corner Grocer\nMILK 4.50\nBREAD 2.25\nTOTAL 6.75")))
+	fuel := waitForBill(t, r, uploadBill(t, r, "fuel.txt", []byte("Fuel Stop\nUNLEADED 40.00\nTOTAL 40.00")))
+
+	// Expenses with a category the ledger does not have are refused, so are
+	// edits to expenses the bill did not generate.
+	edited := grocer.GeneratedExpenses[0]
+	edited.CategoryID = primitive.NewObjectID()
+	expensePath := "/api/bills/" + grocer.ID.Hex() + "/expenses/"
+	if rec := doJSON(t, r, "PUT", expensePath+edited.ID.Hex(), edited); rec.Code != http.StatusBadRequest {
+		t.Errorf("edit with an unknown category: status %d: %s", rec.Code, rec.Body)
+	}
+	edited.CategoryID, edited.Currency = category.ID, "ZZZ"
+	if rec := doJSON(t, r, "PUT", expensePath+edited.ID.Hex(), edited); rec.Code != http.StatusBadRequest {
+		t.Errorf("edit with an unknown currency: status %d: %s", rec.Code, rec.Body)
+	}
+	edited.Currency = ""
+	if rec := doJSON(t, r, "PUT", expensePath+primitive.NewObjectID().Hex(), edited); rec.Code != http.StatusNotFound {
+		t.Errorf("edit an expense the bill does not have: status %d: %s", rec.Code, rec.Body)
+	}
+	for _, categoryID := range []primitive.ObjectID{primitive.NilObjectID, primitive.NewObjectID()} {
+		expenses := append([]models.Expense(nil), grocer.GeneratedExpenses...)
+		expenses[0].CategoryID = categoryID
+		if rec := doJSON(t, r, "POST", "/api/bills/"+grocer.ID.Hex()+"/confirm", expenses); rec.Code != http.StatusBadRequest {
+			t.Errorf("confirm with category %s: status %d: %s", categoryID.Hex(), rec.Code, rec.Body)
+		}
+	}
+	invalid := append([]models.Expense(nil), grocer.GeneratedExpenses...)
+	invalid[0].CategoryID, invalid[0].Currency = category.ID, "ZZZ"
+	if rec := doJSON(t, r, "POST", "/api/bills/"+grocer.ID.Hex()+"/confirm", invalid); rec.Code != http.StatusBadRequest {
+		t.Errorf("confirm with an unknown currency: status %d: %s", rec.Code, rec.Body)
+	}
+
+	for _, bill := range []models.Bill{grocer, fuel} {
+		if rec := confirm(bill); rec.Code != http.StatusOK {
+			t.Fatalf("confirm %s: status %d: %s", bill.FileName, rec.Code, rec.Body)
+		}
+	}
+	expenses := billExpenses(grocer)
+	if len(expenses) != 2 || expenses[0].BillID != grocer.ID || expenses[1].BillID != grocer.ID {
+		t.Errorf("expenses of bill: %+v", expenses)
+	}
+
+	// Only processed bills can be confirmed, so expenses are added once.
+	if rec := confirm(grocer); rec.Code != http.StatusConflict {
+		t.Errorf("confirm twice: status %d: %s", rec.Code, rec.Body)
+	}
+	// Nor can what was confirmed be edited afterwards.
+	if rec := doJSON(t, r, "PUT", expensePath+edited.ID.Hex(), edited); rec.Code != http.StatusConflict {
+		t.Errorf("edit a confirmed expense: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := doJSON(t, r, "PUT", "/api/bills/"+primitive.NewObjectID().Hex()+"/expenses/"+edited.ID.Hex(), edited); rec.Code != http.StatusNotFound {
+		t.Errorf("edit an expense of an unknown bill: status %d: %s", rec.Code, rec.Body)
+	}
+	blank := waitForBill(t, r, uploadBill(t, r, "blank.jpg", []byte{0xff, 0xd8, 0x00}))
+	if rec := confirm(blank); rec.Code != http.StatusConflict {
+		t.Errorf("confirm a bill that failed: status %d: %s", rec.Code, rec.Body)
+	}
+	if len(billExpenses(grocer)) != 2 {
+		t.Errorf("expenses of bill after confirming again: %+v", billExpenses(grocer))
+	}
+
+	// Deleting a confirmed bill keeps its expenses unless asked otherwise.
+	if rec := doJSON(t, r, "DELETE", "/api/bills/"+grocer.ID.Hex()+"?deleteExpenses=maybe", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("delete with an invalid flag: status %d", rec.Code)
+	}
+	if rec := doJSON(t, r, "DELETE", "/api/bills/"+grocer.ID.Hex(), nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete bill: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := doJSON(t, r, "DELETE", "/api/bills/"+fuel.ID.Hex()+"?deleteExpenses=true", nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete bill with its expenses: status %d: %s", rec.Code, rec.Body)
+	}
+	rec = doJSON(t, r, "GET", "/api/expenses", nil)
+	var page models.ExpensePage
+	json.NewDecoder(rec.Body).Decode(&page)
+	var names []string
+	for _, e := range page.Expenses {
+		if !e.BillID.IsZero() {
+			t.Errorf("%s still refers to deleted bill %s", e.Name, e.BillID.Hex())
+		}
+		names = append(names, e.Name)
+	}
+	if len(names) != 2 {
+		t.Errorf("expenses left: %v", names)
+	}
+}
+
+func TestBillCategoriesSuggested(t *testing.T) {
+	r := newBillRouter(t, ocr.NewFixture())
+	category := func(name, color string) models.Category {
+		t.Helper()
+		var c models.Category
+		json.NewDecoder(doJSON(t, r, "POST", "/api/categories", models.Category{Name: name, Color: color}).Body).Decode(&c)
+		return c
+	}
+	groceries, fuel := category("Groceries", "#00ff00"), category("Fuel", "#ff0000")
+
+	// Nothing is suggested before the ledger has categorised expenses.
+	first := waitForBill(t, r, uploadBill(t, r, "first.txt", []byte("Corner Grocer\nBANANAS 1.20\nUNLEADED 40.00\nTOTAL 41.20")))
+	for _, e := range first.GeneratedExpenses {
+		if !e.CategoryID.IsZero() || e.CategoryConfidence != 0 {
+			t.Errorf("%s suggested without examples: %+v", e.Name, e)
+		}
+	}
+	expenses := first.GeneratedExpenses
+	expenses[0].CategoryID, expenses[1].CategoryID = groceries.ID, groceries.ID
+	if rec := doJSON(t, r, "POST", "/api/bills/"+first.ID.Hex()+"/confirm", expenses); rec.Code != http.StatusOK {
+		t.Fatalf("confirm: status %d: %s", rec.Code, rec.Body)
+	}
+
+	// Confirmed expenses are learnt from.
+	receipt := []byte("Corner Grocer\nBANANAS 0.99\nTOTAL 0.99")
+	second := waitForBill(t, r, uploadBill(t, r, "second.txt", receipt))
+	if e := second.GeneratedExpenses[0]; e.CategoryID != groceries.ID || e.CategoryConfidence < 0.5 || e.CategoryConfidence > 1 {
+		t.Errorf("suggestion after confirming: %+v", e)
+	}
+
+	// So are corrections to them.
+	rec := doJSON(t, r, "GET", "/api/expenses?billId="+first.ID.Hex(), nil)
+	var page models.ExpensePage
+	json.NewDecoder(rec.Body).Decode(&page)
+	for _, e := range page.Expenses {
+		if e.CategoryConfidence != 0 {
+			t.Errorf("confirmed %s kept its confidence", e.Name)
+		}
+		if e.Name == "UNLEADED" {
+			e.CategoryID = fuel.ID
+			if rec := doJSON(t, r, "PUT", "/api/expenses/"+e.ID.Hex(), e); rec.Code != http.StatusOK {
+				t.Fatalf("update expense: status %d: %s", rec.Code, rec.Body)
+			}
+		}
+	}
+	third := waitForBill(t, r, uploadBill(t, r, "third.txt", []byte("Fuel Stop\nUNLEADED 35.00\nTOTAL 35.00")))
+	if e := third.GeneratedExpenses[0]; e.CategoryID != fuel.ID {
+		t.Errorf("suggestion after recategorising: %+v", e)
+	}
+
+	// Categories deleted since are not suggested.
+	doJSON(t, r, "DELETE", "/api/categories/"+groceries.ID.Hex(), nil)
+	fourth := waitForBill(t, r, uploadBill(t, r, "fourth.txt", receipt))
+	if e := fourth.GeneratedExpenses[0]; e.CategoryID == groceries.ID {
+		t.Errorf("deleted category suggested: %+v", e)
+	}
+}
@@ -2,10 +2,16 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
+	"github.com/dhruwanga19/expense-tracker/auth"
 	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/period"
 	"github.com/dhruwanga19/expense-tracker/services"
+	"github.com/dhruwanga19/expense-tracker/store"
 	"github.com/gorilla/mux"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -13,13 +19,15 @@ import (
 func SetupBudgetGoalRoutes(r *mux.Router, service *services.BudgetGoalService) {
 	r.HandleFunc("/api/budget-goals", getBudgetGoalsHandler(service)).Methods("GET")
 	r.HandleFunc("/api/budget-goals", createBudgetGoalHandler(service)).Methods("POST")
+	r.HandleFunc("/api/budget-goals/progress", getBudgetProgressHandler(service)).Methods("GET")
 	r.HandleFunc("/api/budget-goals/{id}", updateBudgetGoalHandler(service)).Methods("PUT")
+	r.HandleFunc("/api/budget-goals/{id}/balances", getBudgetBalancesHandler(service)).Methods("GET")
 	r.HandleFunc("/api/budget-goals/{id}", deleteBudgetGoalHandler(service)).Methods("DELETE")
 }
 
 func getBudgetGoalsHandler(s *services.BudgetGoalService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		goals, err := s.GetBudgetGoals(r.Context())
+		goals, err := s.GetBudgetGoals(r.Context(), auth.LedgerID(r.Context()))
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -28,15 +36,69 @@ func getBudgetGoalsHandler(s *services.BudgetGoalService) http.HandlerFunc {
 	}
 }
 
+// getBudgetProgressHandler answers with the progress of every goal in its
+// current period, or in the period containing ?at= (a date or an RFC 3339
+// time).
+func getBudgetProgressHandler(s *services.BudgetGoalService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		at := time.Now()
+		if v := r.URL.Query().Get("at"); v != "" {
+			t, _, err := parseDateParam(v)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid at: %v", err), http.StatusBadRequest)
+				return
+			}
+			at = t
+		}
+
+		progress, err := s.GetProgress(r.Context(), auth.LedgerID(r.Context()), at)
+		if err != nil {
+			if errors.Is(err, services.ErrNoExchangeRate) || errors.Is(err, period.ErrInvalid) {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			} else {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(progress)
+	}
+}
+
+// getBudgetBalancesHandler answers with how the goal ended each of its past
+// periods, and what it carried into the next one.
+func getBudgetBalancesHandler(s *services.BudgetGoalService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid budget goal ID", http.StatusBadRequest)
+			return
+		}
+		balances, err := s.GetBalances(r.Context(), auth.LedgerID(r.Context()), id)
+		if err != nil {
+			if errors.Is(err, services.ErrNoExchangeRate) || errors.Is(err, period.ErrInvalid) {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			} else {
+				budgetGoalError(w, err)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(balances)
+	}
+}
+
 func createBudgetGoalHandler(s *services.BudgetGoalService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var goal models.BudgetGoal
-		if err := json.NewDecoder(r.Body).Decode(&goal); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+		if err := decodeBudgetGoal(r, &goal); err != nil {
+			budgetGoalError(w, err)
 			return
 		}
-		if err := s.CreateBudgetGoal(r.Context(), &goal); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		if err := s.CreateBudgetGoal(r.Context(), auth.LedgerID(r.Context()), &goal); err != nil {
+			budgetGoalError(w, err)
 			return
 		}
 		w.WriteHeader(http.StatusCreated)
@@ -46,15 +108,19 @@ func createBudgetGoalHandler(s *services.BudgetGoalService) http.HandlerFunc {
 
 func updateBudgetGoalHandler(s *services.BudgetGoalService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id, _ := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+		id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid budget goal ID", http.StatusBadRequest)
+			return
+		}
 		var goal models.BudgetGoal
-		if err := json.NewDecoder(r.Body).Decode(&goal); err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
+		if err := decodeBudgetGoal(r, &goal); err != nil {
+			budgetGoalError(w, err)
 			return
 		}
 		goal.ID = id
-		if err := s.UpdateBudgetGoal(r.Context(), &goal); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		if err := s.UpdateBudgetGoal(r.Context(), auth.LedgerID(r.Context()), &goal); err != nil {
+			budgetGoalError(w, err)
 			return
 		}
 		json.NewEncoder(w).Encode(goal)
@@ -63,11 +129,62 @@ func updateBudgetGoalHandler(s *services.BudgetGoalService) http.HandlerFunc {
 
 func deleteBudgetGoalHandler(s *services.BudgetGoalService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id, _ := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
-		if err := s.DeleteBudgetGoal(r.Context(), id); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid budget goal ID", http.StatusBadRequest)
+			return
+		}
+		if err := s.DeleteBudgetGoal(r.Context(), auth.LedgerID(r.Context()), id); err != nil {
+			budgetGoalError(w, err)
 			return
 		}
 		w.WriteHeader(http.StatusNoContent)
 	}
 }
+
+// decodeBudgetGoal reads the goal in the request body. Periods that cannot
+// be read are reported against the period field.
+func decodeBudgetGoal(r *http.Request, goal *models.BudgetGoal) error {
+	err := json.NewDecoder(r.Body).Decode(goal)
+	if errors.Is(err, period.ErrInvalid) {
+		return &services.ValidationError{Fields: map[string]string{"period": err.Error()}}
+	}
+	if err != nil {
+		return errBadRequest{err}
+	}
+	return nil
+}
+
+// errBadRequest is a request body that could not be read.
+type errBadRequest struct{ error }
+
+func budgetGoalError(w http.ResponseWriter, err error) {
+	var invalid *services.ValidationError
+	if errors.As(err, &invalid) {
+		writeValidationError(w, invalid)
+		return
+	}
+	if bad, ok := err.(errBadRequest); ok {
+		http.Error(w, bad.Error(), http.StatusBadRequest)
+		return
+	}
+	switch err {
+	case services.ErrDuplicateBudgetGoal:
+		http.Error(w, err.Error(), http.StatusConflict)
+	case store.ErrNotFound:
+		http.Error(w, "Budget goal not found", http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// writeValidationError answers 400 with what is wrong with each field:
+// {"error": "...", "fields": {"amount": "..."}}.
+func writeValidationError(w http.ResponseWriter, invalid *services.ValidationError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(struct {
+		Error  string            `json:"error"`
+		Fields map[string]string `json:"fields"`
+	}{invalid.Error(), invalid.Fields})
+}
@@ -0,0 +1,469 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/period"
+	"github.com/dhruwanga19/expense-tracker/store/memstore"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestBudgetProgress(t *testing.T) {
+	r := asUser(t, newTestRouter(), "saver@example.com")
+
+	var food, travel models.Category
+	json.NewDecoder(doJSON(t, r, "POST", "/api/categories", models.Category{Name: "Food", Color: "#000001"}).Body).Decode(&food)
+	json.NewDecoder(doJSON(t, r, "POST", "/api/categories", models.Category{Name: "Travel", Color: "#000002"}).Body).Decode(&travel)
+	for _, e := range []models.Expense{
+		{Name: "Groceries", Amount: 2000, Currency: "USD", Date: time.Date(2024, 3, 3, 9, 0, 0, 0, time.UTC), CategoryID: food.ID},
+		{Name: "Bakery", Amount: 3000, Currency: "EUR", Date: time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC), CategoryID: food.ID},
+		{Name: "Market", Amount: 4000, Currency: "USD", Date: time.Date(2024, 3, 20, 9, 0, 0, 0, time.UTC), CategoryID: food.ID},
+		{Name: "Train", Amount: 9900, Currency: "USD", Date: time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC), CategoryID: travel.ID},
+	} {
+		if rec := doJSON(t, r, "POST", "/api/expenses", e); rec.Code != http.StatusCreated {
+			t.Fatalf("add expense: status %d: %s", rec.Code, rec.Body)
+		}
+	}
+	for _, goal := range []models.BudgetGoal{
+		{CategoryID: food.ID, Amount: 10000, Currency: "USD", Period: period.Period{Kind: period.Monthly, Day: 1}},
+		{CategoryID: food.ID, Amount: 5000, Currency: "USD", Period: period.Period{Kind: period.Weekly}},
+		// Pay day is on the 15th.
+		{CategoryID: food.ID, Amount: 20000, Currency: "USD", Period: period.Period{Kind: period.Monthly, Day: 15}},
+		{CategoryID: food.ID, Amount: 5000, Currency: "USD", Period: period.Period{Kind: period.Custom,
+			From: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC)}},
+	} {
+		if rec := doJSON(t, r, "POST", "/api/budget-goals", goal); rec.Code != http.StatusCreated {
+			t.Fatalf("create goal: status %d: %s", rec.Code, rec.Body)
+		}
+	}
+
+	progress := func(query string) []models.BudgetProgress {
+		t.Helper()
+		rec := doJSON(t, r, "GET", "/api/budget-goals/progress"+query, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("progress%s: status %d: %s", query, rec.Code, rec.Body)
+		}
+		var progress []models.BudgetProgress
+		json.NewDecoder(rec.Body).Decode(&progress)
+		return progress
+	}
+
+	if rec := doJSON(t, r, "GET", "/api/budget-goals/progress?at=2024-03-05", nil); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("progress without rates: status %d, want 422: %s", rec.Code, rec.Body)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest("POST", "/api/exchange-rates/import", strings.NewReader("2024-01-01,EUR,USD,1.10\n")))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("import rates: status %d: %s", rec.Code, rec.Body)
+	}
+
+	// On Tuesday 5 March, 20.00 USD and 30.00 EUR at 1.10 were spent on
+	// food in the 5 days of March and the 3 days of the week so far.
+	got := progress("?at=2024-03-05")
+	if len(got) != 4 {
+		t.Fatalf("progress: %+v", got)
+	}
+	monthly, weekly, payMonth, custom := got[0], got[1], got[2], got[3]
+	if !monthly.From.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) || !monthly.To.Equal(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)) ||
+		!monthly.AsOf.Equal(time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("monthly window: %s to %s as of %s", monthly.From, monthly.To, monthly.AsOf)
+	}
+	if monthly.Spent != 5300 || monthly.Count != 2 || monthly.Remaining != 4700 || monthly.Percent != 53 ||
+		monthly.Projected != 5300*31/5 || monthly.Status != models.BudgetAtRisk {
+		t.Errorf("monthly progress: %+v", monthly)
+	}
+	if !weekly.From.Equal(time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)) || !weekly.To.Equal(time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("weekly window: %s to %s", weekly.From, weekly.To)
+	}
+	if weekly.Spent != 5300 || weekly.Remaining != -300 || weekly.Status != models.BudgetOver {
+		t.Errorf("weekly progress: %+v", weekly)
+	}
+	if !payMonth.From.Equal(time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC)) || !payMonth.To.Equal(time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)) ||
+		payMonth.Spent != 5300 {
+		t.Errorf("progress from pay day: %+v", payMonth)
+	}
+	// The custom period started on Monday 4 March and ends on Friday 8.
+	if !custom.From.Equal(time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)) || !custom.To.Equal(time.Date(2024, 3, 9, 0, 0, 0, 0, time.UTC)) ||
+		custom.Spent != 3300 || custom.Projected != 3300*5/2 || custom.Status != models.BudgetAtRisk {
+		t.Errorf("custom progress: %+v", custom)
+	}
+
+	// At the end of a period the projection is what was spent.
+	monthly = progress("?at=2024-03-31")[0]
+	if monthly.Spent != 9300 || monthly.Projected != 9300 || monthly.Status != models.BudgetOnTrack {
+		t.Errorf("progress at the end of March: %+v", monthly)
+	}
+	if monthly = progress("?at=2024-04-10T12:00:00Z")[0]; monthly.Spent != 0 || monthly.Status != models.BudgetOnTrack {
+		t.Errorf("progress in April: %+v", monthly)
+	}
+
+	if rec := doJSON(t, r, "GET", "/api/budget-goals/progress?at=yesterday", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("progress at an invalid date: status %d", rec.Code)
+	}
+
+	for _, body := range []string{
+		`{"categoryId": "` + food.ID.Hex() + `", "amount": 100, "period": "fortnightly"}`,
+		`{"categoryId": "` + food.ID.Hex() + `", "amount": 100, "period": "monthly:32"}`,
+		`{"categoryId": "` + food.ID.Hex() + `", "amount": 100, "period": "custom:2024-03-08..2024-03-04"}`,
+		`{"categoryId": "` + food.ID.Hex() + `", "amount": 100}`,
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest("POST", "/api/budget-goals", strings.NewReader(body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("create goal %s: status %d", body, rec.Code)
+		}
+	}
+}
+
+func TestBudgetRollover(t *testing.T) {
+	st := memstore.New()
+	r := asUser(t, newTestRouterOn(st, nil, nil), "saver@example.com")
+
+	// Each goal has its own category, with the same spending: 40.00 is left
+	// in January, then 20.00 too much is spent in February counting what
+	// January carried in.
+	monthly := period.Period{Kind: period.Monthly, Day: 1}
+	var goals []models.BudgetGoal
+	for i, goal := range []models.BudgetGoal{
+		{Amount: 10000, Currency: "USD", Period: monthly},
+		{Amount: 10000, Currency: "USD", Period: monthly, Rollover: models.RolloverSurplus},
+		{Amount: 10000, Currency: "USD", Period: monthly, Rollover: models.RolloverBoth},
+		{Amount: 10000, Currency: "USD", Period: monthly, Rollover: models.RolloverCapped, RolloverCap: 1000},
+	} {
+		var category models.Category
+		rec := doJSON(t, r, "POST", "/api/categories", models.Category{Name: fmt.Sprintf("Food %d", i), Color: fmt.Sprintf("#00000%d", i)})
+		json.NewDecoder(rec.Body).Decode(&category)
+		for _, e := range []models.Expense{
+			{Name: "January", Amount: 6000, Currency: "USD", Date: time.Date(2024, 1, 20, 9, 0, 0, 0, time.UTC)},
+			{Name: "February", Amount: 16000, Currency: "USD", Date: time.Date(2024, 2, 10, 9, 0, 0, 0, time.UTC)},
+			{Name: "March", Amount: 1000, Currency: "USD", Date: time.Date(2024, 3, 2, 9, 0, 0, 0, time.UTC)},
+		} {
+			e.CategoryID = category.ID
+			if rec := doJSON(t, r, "POST", "/api/expenses", e); rec.Code != http.StatusCreated {
+				t.Fatalf("add expense: status %d: %s", rec.Code, rec.Body)
+			}
+		}
+
+		goal.CategoryID = category.ID
+		rec = doJSON(t, r, "POST", "/api/budget-goals", goal)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create goal: status %d: %s", rec.Code, rec.Body)
+		}
+		json.NewDecoder(rec.Body).Decode(&goal)
+		// The goals were set up in January.
+		goal.CreatedAt = time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+		if err := st.BudgetGoals().Update(context.Background(), &goal); err != nil {
+			t.Fatal(err)
+		}
+		goals = append(goals, goal)
+	}
+	if goals[0].Rollover != models.RolloverNone {
+		t.Errorf("default rollover = %q, want none", goals[0].Rollover)
+	}
+
+	rec := doJSON(t, r, "GET", "/api/budget-goals/progress?at=2024-03-05", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("progress: status %d: %s", rec.Code, rec.Body)
+	}
+	var progress []models.BudgetProgress
+	json.NewDecoder(rec.Body).Decode(&progress)
+	for i, want := range []struct{ carriedIn, budget money.Amount }{{0, 10000}, {0, 10000}, {-2000, 8000}, {-1000, 9000}} {
+		p := progress[i]
+		if p.CarriedIn != want.carriedIn || p.Budget != want.budget || p.Remaining != want.budget-1000 {
+			t.Errorf("progress with %s rollover: carried in %d, budget %d, remaining %d, want %d, %d",
+				p.Goal.Rollover, p.CarriedIn, p.Budget, p.Remaining, want.carriedIn, want.budget)
+		}
+	}
+
+	both := goals[2]
+	balances := func() []models.BudgetBalance {
+		t.Helper()
+		rec := doJSON(t, r, "GET", "/api/budget-goals/"+both.ID.Hex()+"/balances", nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("balances: status %d: %s", rec.Code, rec.Body)
+		}
+		var balances []models.BudgetBalance
+		json.NewDecoder(rec.Body).Decode(&balances)
+		return balances
+	}
+	got := balances()
+	if len(got) < 3 || !got[0].From.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) ||
+		got[0].Spent != 6000 || got[0].CarriedOut != 4000 ||
+		got[1].CarriedIn != 4000 || got[1].Budget != 14000 || got[1].CarriedOut != -2000 ||
+		got[2].CarriedIn != -2000 || got[2].Spent != 1000 {
+		t.Fatalf("balances: %+v", got[:min(3, len(got))])
+	}
+
+	// Changing the goal works its balances out again, from the same start.
+	both.Amount = 20000
+	both.CreatedAt = time.Time{}
+	if rec := doJSON(t, r, "PUT", "/api/budget-goals/"+both.ID.Hex(), both); rec.Code != http.StatusOK {
+		t.Fatalf("update goal: status %d: %s", rec.Code, rec.Body)
+	}
+	if got = balances(); !got[0].From.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) || got[1].CarriedOut != 20000-6000+20000-16000 {
+		t.Errorf("balances after the update: %+v", got[:2])
+	}
+
+	if rec := doJSON(t, r, "GET", "/api/budget-goals/"+primitive.NewObjectID().Hex()+"/balances", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("balances of an unknown goal: status %d, want 404", rec.Code)
+	}
+	for _, goal := range []models.BudgetGoal{
+		{CategoryID: both.CategoryID, Amount: 100, Period: period.Period{Kind: period.Weekly}, Rollover: "sometimes"},
+		{CategoryID: both.CategoryID, Amount: 100, Period: period.Period{Kind: period.Weekly}, Rollover: models.RolloverCapped},
+		{CategoryID: both.CategoryID, Amount: 100, Period: period.Period{Kind: period.Weekly}, Rollover: models.RolloverBoth, RolloverCap: -1},
+	} {
+		if rec := doJSON(t, r, "POST", "/api/budget-goals", goal); rec.Code != http.StatusBadRequest {
+			t.Errorf("create goal with %s rollover, cap %d: status %d, want 400", goal.Rollover, goal.RolloverCap, rec.Code)
+		}
+	}
+}
+
+func TestBudgetGoalValidation(t *testing.T) {
+	r := asUser(t, newTestRouter(), "saver@example.com")
+
+	var food models.Category
+	json.NewDecoder(doJSON(t, r, "POST", "/api/categories", models.Category{Name: "Food", Color: "#000001"}).Body).Decode(&food)
+	monthly := period.Period{Kind: period.Monthly, Day: 1}
+
+	rec := doJSON(t, r, "POST", "/api/budget-goals", models.BudgetGoal{Amount: 0, Currency: "XYZ", Period: monthly})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("create an invalid goal: status %d, want 400", rec.Code)
+	}
+	var invalid struct {
+		Fields map[string]string `json:"fields"`
+	}
+	json.NewDecoder(rec.Body).Decode(&invalid)
+	if len(invalid.Fields) != 3 || invalid.Fields["categoryId"] == "" || invalid.Fields["amount"] == "" || invalid.Fields["currency"] == "" {
+		t.Errorf("field errors: %v", invalid.Fields)
+	}
+	rec = doJSON(t, r, "POST", "/api/budget-goals", models.BudgetGoal{CategoryID: primitive.NewObjectID(), Amount: 100, Period: monthly})
+	invalid.Fields = nil
+	json.NewDecoder(rec.Body).Decode(&invalid)
+	if rec.Code != http.StatusBadRequest || len(invalid.Fields) != 1 || invalid.Fields["categoryId"] == "" {
+		t.Errorf("create a goal for an unknown category: status %d, fields %v", rec.Code, invalid.Fields)
+	}
+
+	var goal models.BudgetGoal
+	rec = doJSON(t, r, "POST", "/api/budget-goals", models.BudgetGoal{CategoryID: food.ID, Amount: 100, Period: monthly})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create goal: status %d: %s", rec.Code, rec.Body)
+	}
+	json.NewDecoder(rec.Body).Decode(&goal)
+
+	// One goal per category and period, but the same category may have a
+	// goal over another period.
+	if rec := doJSON(t, r, "POST", "/api/budget-goals", models.BudgetGoal{CategoryID: food.ID, Amount: 200, Period: monthly}); rec.Code != http.StatusConflict {
+		t.Errorf("create a second monthly goal: status %d, want 409", rec.Code)
+	}
+	weekly := models.BudgetGoal{CategoryID: food.ID, Amount: 50, Period: period.Period{Kind: period.Weekly}}
+	if rec := doJSON(t, r, "POST", "/api/budget-goals", weekly); rec.Code != http.StatusCreated {
+		t.Fatalf("create a weekly goal: status %d: %s", rec.Code, rec.Body)
+	}
+	goal.Amount = 150
+	if rec := doJSON(t, r, "PUT", "/api/budget-goals/"+goal.ID.Hex(), goal); rec.Code != http.StatusOK {
+		t.Errorf("update goal: status %d: %s", rec.Code, rec.Body)
+	}
+	goal.Period = weekly.Period
+	if rec := doJSON(t, r, "PUT", "/api/budget-goals/"+goal.ID.Hex(), goal); rec.Code != http.StatusConflict {
+		t.Errorf("update goal onto the weekly one: status %d, want 409", rec.Code)
+	}
+
+	for _, method := range []string{"PUT", "DELETE"} {
+		if rec := doJSON(t, r, method, "/api/budget-goals/not-an-id", goal); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s with an invalid ID: status %d, want 400", method, rec.Code)
+		}
+		if rec := doJSON(t, r, method, "/api/budget-goals/"+primitive.NewObjectID().Hex(), goal); rec.Code != http.StatusNotFound {
+			t.Errorf("%s a missing goal: status %d, want 404", method, rec.Code)
+		}
+	}
+
+	// Deleting the category deletes its goals.
+	if rec := doJSON(t, r, "DELETE", "/api/categories/"+food.ID.Hex(), nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete category: status %d: %s", rec.Code, rec.Body)
+	}
+	var goals []models.BudgetGoal
+	json.NewDecoder(doJSON(t, r, "GET", "/api/budget-goals", nil).Body).Decode(&goals)
+	if len(goals) != 0 {
+		t.Errorf("goals left after deleting their category: %+v", goals)
+	}
+}
+
+func TestBudgetScopes(t *testing.T) {
+	r := asUser(t, newTestRouter(), "saver@example.com")
+
+	categories := map[string]models.Category{}
+	for i, name := range []string{"Dining", "Entertainment", "Travel", "Rent"} {
+		var category models.Category
+		json.NewDecoder(doJSON(t, r, "POST", "/api/categories", models.Category{Name: name, Color: fmt.Sprintf("#00000%d", i)}).Body).Decode(&category)
+		categories[name] = category
+	}
+	for _, e := range []models.Expense{
+		{Name: "Dinner", Amount: 4000, CategoryID: categories["Dining"].ID},
+		{Name: "Cinema", Amount: 1500, CategoryID: categories["Entertainment"].ID},
+		{Name: "Train", Amount: 6000, CategoryID: categories["Travel"].ID},
+		{Name: "Rent", Amount: 90000, CategoryID: categories["Rent"].ID},
+		{Name: "Gift", Amount: 2500},
+	} {
+		e.Currency, e.Date = "USD", time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+		if rec := doJSON(t, r, "POST", "/api/expenses", e); rec.Code != http.StatusCreated {
+			t.Fatalf("add expense: status %d: %s", rec.Code, rec.Body)
+		}
+	}
+
+	monthly := period.Period{Kind: period.Monthly, Day: 1}
+	fun := []primitive.ObjectID{categories["Travel"].ID, categories["Dining"].ID, categories["Entertainment"].ID}
+	var goals []models.BudgetGoal
+	for _, goal := range []models.BudgetGoal{
+		{Scope: models.ScopeOverall, Amount: 150000, Currency: "USD", Period: monthly},
+		{Scope: models.ScopeCategories, CategoryIDs: fun, Amount: 10000, Currency: "USD", Period: monthly},
+		{CategoryID: categories["Dining"].ID, Amount: 5000, Currency: "USD", Period: monthly},
+		{CategoryID: categories["Rent"].ID, Amount: 90000, Currency: "USD", Period: period.Period{Kind: period.Custom,
+			From: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), To: time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC)}},
+	} {
+		rec := doJSON(t, r, "POST", "/api/budget-goals", goal)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create goal: status %d: %s", rec.Code, rec.Body)
+		}
+		json.NewDecoder(rec.Body).Decode(&goal)
+		goals = append(goals, goal)
+	}
+	if goals[2].Scope != models.ScopeCategory || len(goals[1].CategoryIDs) != 3 {
+		t.Errorf("created goals: %+v", goals)
+	}
+
+	var progress []models.BudgetProgress
+	json.NewDecoder(doJSON(t, r, "GET", "/api/budget-goals/progress?at=2024-03-10", nil).Body).Decode(&progress)
+	if len(progress) != 4 {
+		t.Fatalf("progress: %+v", progress)
+	}
+	// Dinner counts towards the overall, fun and dining goals alike.
+	for i, want := range []struct {
+		spent    money.Amount
+		overlaps []primitive.ObjectID
+	}{
+		{104000, []primitive.ObjectID{goals[1].ID, goals[2].ID}},
+		{11500, []primitive.ObjectID{goals[0].ID, goals[2].ID}},
+		{4000, []primitive.ObjectID{goals[0].ID, goals[1].ID}},
+		// The April period does not overlap March.
+		{0, nil},
+	} {
+		if p := progress[i]; p.Spent != want.spent || !slices.Equal(p.Overlaps, want.overlaps) {
+			t.Errorf("progress of goal %d: spent %d, overlaps %v, want %d, %v", i, p.Spent, p.Overlaps, want.spent, want.overlaps)
+		}
+	}
+	if progress[1].Status != models.BudgetOver || progress[0].Status != models.BudgetAtRisk {
+		t.Errorf("statuses: %s, %s", progress[0].Status, progress[1].Status)
+	}
+
+	for _, goal := range []models.BudgetGoal{
+		{Scope: models.ScopeCategories, Amount: 100, Period: monthly},
+		{Scope: models.ScopeOverall, CategoryID: categories["Rent"].ID, Amount: 100, Period: monthly},
+		{Scope: models.ScopeCategories, CategoryIDs: []primitive.ObjectID{primitive.NewObjectID()}, Amount: 100, Period: monthly},
+		{Scope: "everything", Amount: 100, Period: monthly},
+	} {
+		if rec := doJSON(t, r, "POST", "/api/budget-goals", goal); rec.Code != http.StatusBadRequest {
+			t.Errorf("create goal with scope %q: status %d, want 400", goal.Scope, rec.Code)
+		}
+	}
+	// The same categories in another order are the same goal.
+	same := []primitive.ObjectID{fun[2], fun[0], fun[1], fun[0]}
+	for _, goal := range []models.BudgetGoal{
+		{Scope: models.ScopeOverall, Amount: 100, Period: monthly},
+		{Scope: models.ScopeCategories, CategoryIDs: same, Amount: 100, Period: monthly},
+	} {
+		if rec := doJSON(t, r, "POST", "/api/budget-goals", goal); rec.Code != http.StatusConflict {
+			t.Errorf("create a second %s goal: status %d, want 409", goal.Scope, rec.Code)
+		}
+	}
+
+	// Deleting a category drops it from the goals spanning others, and
+	// deletes the goals only for it. A goal left with one category counts
+	// just that one, unless another goal already does.
+	create := func(body models.BudgetGoal) models.BudgetGoal {
+		t.Helper()
+		rec := doJSON(t, r, "POST", "/api/budget-goals", body)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create goal: status %d: %s", rec.Code, rec.Body)
+		}
+		var goal models.BudgetGoal
+		json.NewDecoder(rec.Body).Decode(&goal)
+		return goal
+	}
+	entertainment := create(models.BudgetGoal{CategoryID: categories["Entertainment"].ID, Amount: 3000, Currency: "USD", Period: monthly})
+	rent := create(models.BudgetGoal{Scope: models.ScopeCategories, CategoryIDs: []primitive.ObjectID{categories["Rent"].ID, categories["Dining"].ID},
+		Amount: 95000, Currency: "USD", Period: monthly})
+	for _, name := range []string{"Travel", "Dining"} {
+		if rec := doJSON(t, r, "DELETE", "/api/categories/"+categories[name].ID.Hex(), nil); rec.Code != http.StatusNoContent {
+			t.Fatalf("delete category: status %d: %s", rec.Code, rec.Body)
+		}
+	}
+	var left []models.BudgetGoal
+	json.NewDecoder(doJSON(t, r, "GET", "/api/budget-goals", nil).Body).Decode(&left)
+	var ids []primitive.ObjectID
+	for _, goal := range left {
+		ids = append(ids, goal.ID)
+	}
+	if !slices.Equal(ids, []primitive.ObjectID{goals[0].ID, goals[3].ID, entertainment.ID, rent.ID}) {
+		t.Fatalf("goals left: %+v", left)
+	}
+	if g := left[3]; g.Scope != models.ScopeCategory || g.CategoryID != categories["Rent"].ID || len(g.CategoryIDs) != 0 {
+		t.Errorf("goal left with one category: %+v", g)
+	}
+}
+
+func TestBudgetGoalLegacyPeriod(t *testing.T) {
+	st := memstore.New()
+	r := asUser(t, newTestRouterOn(st, nil, nil), "saver@example.com")
+	var category models.Category
+	json.NewDecoder(doJSON(t, r, "POST", "/api/categories", models.Category{Name: "Food", Color: "#000001"}).Body).Decode(&category)
+	var goals []models.BudgetGoal
+	for _, p := range []period.Period{{Kind: period.Monthly, Day: 1}, {Kind: period.Weekly}} {
+		rec := doJSON(t, r, "POST", "/api/budget-goals", models.BudgetGoal{CategoryID: category.ID, Amount: 10000, Currency: "USD", Period: p})
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create goal: status %d: %s", rec.Code, rec.Body)
+		}
+		var goal models.BudgetGoal
+		json.NewDecoder(rec.Body).Decode(&goal)
+		goals = append(goals, goal)
+	}
+	// A goal saved before periods were checked.
+	legacy := goals[1]
+	legacy.Period = period.Period{Invalid: "fortnightly"}
+	if err := st.BudgetGoals().Update(context.Background(), &legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	// It is listed as it was saved, and left out of progress until it is
+	// given a period again.
+	rec := doJSON(t, r, "GET", "/api/budget-goals", nil)
+	var listed []map[string]any
+	json.NewDecoder(rec.Body).Decode(&listed)
+	if rec.Code != http.StatusOK || len(listed) != 2 || listed[1]["period"] != "fortnightly" {
+		t.Fatalf("list goals: status %d: %v", rec.Code, listed)
+	}
+	rec = doJSON(t, r, "GET", "/api/budget-goals/progress", nil)
+	var progress []models.BudgetProgress
+	json.NewDecoder(rec.Body).Decode(&progress)
+	if rec.Code != http.StatusOK || len(progress) != 1 || progress[0].Goal.ID != goals[0].ID {
+		t.Errorf("progress: status %d: %+v", rec.Code, progress)
+	}
+	rec = doJSON(t, r, "GET", "/api/budget-goals/"+legacy.ID.Hex()+"/balances", nil)
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != "[]" {
+		t.Errorf("balances: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := doJSON(t, r, "PUT", "/api/budget-goals/"+legacy.ID.Hex(), map[string]any{
+		"categoryId": category.ID, "amount": 10000, "currency": "USD", "period": "weekly:monday",
+	}); rec.Code != http.StatusOK {
+		t.Errorf("give the goal a period: status %d: %s", rec.Code, rec.Body)
+	}
+}
@@ -6,16 +6,17 @@ import (
 	"log"
 	"net/http"
 
+	"github.com/dhruwanga19/expense-tracker/auth"
 	"github.com/dhruwanga19/expense-tracker/models"
 	"github.com/dhruwanga19/expense-tracker/services"
+	"github.com/dhruwanga19/expense-tracker/store"
 
 	"github.com/gorilla/mux"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func SetupCategoryRoutes(r *mux.Router, db *mongo.Database) {
-	categoryService := services.NewCategoryService(db)
+func SetupCategoryRoutes(r *mux.Router, st store.Store) {
+	categoryService := services.NewCategoryService(st)
 
 	r.HandleFunc("/api/categories", getCategoriesHandler(categoryService)).Methods("GET")
 	r.HandleFunc("/api/categories", addCategoryHandler(categoryService)).Methods("POST")
@@ -25,7 +26,7 @@ func SetupCategoryRoutes(r *mux.Router, db *mongo.Database) {
 
 func getCategoriesHandler(s *services.CategoryService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		categories, err := s.GetCategories(r.Context())
+		categories, err := s.GetCategories(r.Context(), auth.LedgerID(r.Context()))
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -45,7 +46,7 @@ func addCategoryHandler(s *services.CategoryService) http.HandlerFunc {
 			return
 		}
 
-		err = s.AddCategory(r.Context(), &category)
+		err = s.AddCategory(r.Context(), auth.LedgerID(r.Context()), &category)
 		if err != nil {
 			http.Error(w, "Could not add category", http.StatusInternalServerError)
 			return
@@ -67,10 +68,10 @@ func deleteCategoryHandler(s *services.CategoryService) http.HandlerFunc {
 			return
 		}
 
-		err = s.DeleteCategory(r.Context(), id)
+		err = s.DeleteCategory(r.Context(), auth.LedgerID(r.Context()), id)
 		if err != nil {
 			log.Printf("Error deleting category: %v", err)
-			if err == mongo.ErrNoDocuments {
+			if err == store.ErrNotFound {
 				http.Error(w, "Category not found", http.StatusNotFound)
 			} else {
 				errMsg := fmt.Sprintf("Internal server error: %v", err)
@@ -100,14 +101,14 @@ func updateCategoryHandler(s *services.CategoryService) http.HandlerFunc {
 		}
 
 		updatedCategory.ID = id
-		err = s.UpdateCategory(r.Context(), &updatedCategory)
+		err = s.UpdateCategory(r.Context(), auth.LedgerID(r.Context()), &updatedCategory)
 		if err != nil {
 			switch err {
 			case services.ErrCategoryNameExists:
 				http.Error(w, "A category with this name already exists", http.StatusConflict)
 			case services.ErrCategoryColorExists:
 				http.Error(w, "A category with this color already exists", http.StatusConflict)
-			case mongo.ErrNoDocuments:
+			case store.ErrNotFound:
 				http.Error(w, "Category not found", http.StatusNotFound)
 			default:
 				http.Error(w, err.Error(), http.StatusInternalServerError)
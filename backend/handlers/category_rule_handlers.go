@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dhruwanga19/expense-tracker/auth"
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/services"
+	"github.com/dhruwanga19/expense-tracker/store"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func SetupCategoryRuleRoutes(r *mux.Router, ruleService *services.CategoryRuleService) {
+	r.HandleFunc("/api/category-rules", getCategoryRulesHandler(ruleService)).Methods("GET")
+	r.HandleFunc("/api/category-rules", createCategoryRuleHandler(ruleService)).Methods("POST")
+	r.HandleFunc("/api/category-rules/dry-run", dryRunCategoryRuleHandler(ruleService)).Methods("POST")
+	r.HandleFunc("/api/category-rules/{id}", updateCategoryRuleHandler(ruleService)).Methods("PUT")
+	r.HandleFunc("/api/category-rules/{id}", deleteCategoryRuleHandler(ruleService)).Methods("DELETE")
+}
+
+func getCategoryRulesHandler(s *services.CategoryRuleService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rules, err := s.GetCategoryRules(r.Context(), auth.LedgerID(r.Context()))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if rules == nil {
+			rules = []models.CategoryRule{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rules)
+	}
+}
+
+func createCategoryRuleHandler(s *services.CategoryRuleService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var rule models.CategoryRule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.CreateCategoryRule(r.Context(), auth.LedgerID(r.Context()), &rule); err != nil {
+			categoryRuleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(rule)
+	}
+}
+
+// dryRunCategoryRuleHandler takes a rule, which need not be stored, and
+// answers with the existing expenses it would recategorise.
+func dryRunCategoryRuleHandler(s *services.CategoryRuleService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var rule models.CategoryRule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		result, err := s.DryRun(r.Context(), auth.LedgerID(r.Context()), &rule)
+		if err != nil {
+			categoryRuleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+func updateCategoryRuleHandler(s *services.CategoryRuleService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid category rule ID", http.StatusBadRequest)
+			return
+		}
+		var rule models.CategoryRule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		rule.ID = id
+		if err := s.UpdateCategoryRule(r.Context(), auth.LedgerID(r.Context()), &rule); err != nil {
+			categoryRuleError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rule)
+	}
+}
+
+func deleteCategoryRuleHandler(s *services.CategoryRuleService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid category rule ID", http.StatusBadRequest)
+			return
+		}
+		if err := s.DeleteCategoryRule(r.Context(), auth.LedgerID(r.Context()), id); err != nil {
+			categoryRuleError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func categoryRuleError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrMissingRuleCategory), errors.Is(err, services.ErrEmptyRule),
+		errors.Is(err, services.ErrInvalidNamePattern), errors.Is(err, services.ErrInvalidAmountRange),
+		errors.Is(err, services.ErrInvalidWeekday), errors.Is(err, services.ErrUnknownCategory):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, store.ErrNotFound):
+		http.Error(w, "Category rule not found", http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
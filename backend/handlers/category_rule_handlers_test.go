@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/ocr"
+)
+
+func TestCategoryRules(t *testing.T) {
+	r := newBillRouter(t, ocr.NewFixture())
+	category := func(name, color string) models.Category {
+		t.Helper()
+		var c models.Category
+		json.NewDecoder(doJSON(t, r, "POST", "/api/categories", models.Category{Name: name, Color: color}).Body).Decode(&c)
+		return c
+	}
+	groceries, dining, dairy := category("Groceries", "#00ff00"), category("Dining", "#ff0000"), category("Dairy", "#ffffff")
+
+	createRule := func(rule models.CategoryRule) models.CategoryRule {
+		t.Helper()
+		rec := doJSON(t, r, "POST", "/api/category-rules", rule)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("create rule %q: status %d: %s", rule.Name, rec.Code, rec.Body)
+		}
+		json.NewDecoder(rec.Body).Decode(&rule)
+		return rule
+	}
+	max := money.Amount(2000)
+	createRule(models.CategoryRule{Name: "Corner Grocer", Priority: 2, CategoryID: groceries.ID, Merchant: "corner grocer"})
+	milk := createRule(models.CategoryRule{Name: "Milk", Priority: 1, CategoryID: dairy.ID, NamePattern: `^milk\b`})
+	createRule(models.CategoryRule{Name: "Weekday lunch", Priority: 3, CategoryID: dining.ID, NamePattern: "lunch",
+		MaxAmount: &max, Weekdays: []time.Weekday{time.Friday, time.Monday, time.Friday}})
+
+	rec := doJSON(t, r, "GET", "/api/category-rules", nil)
+	var rules []models.CategoryRule
+	json.NewDecoder(rec.Body).Decode(&rules)
+	if len(rules) != 3 || rules[0].Name != "Milk" || rules[2].Name != "Weekday lunch" {
+		t.Fatalf("rules: %+v", rules)
+	}
+	if days := rules[2].Weekdays; len(days) != 2 || days[0] != time.Monday || days[1] != time.Friday {
+		t.Errorf("weekdays stored as %v", days)
+	}
+
+	for name, rule := range map[string]models.CategoryRule{
+		"no category":      {Name: "Nothing", NamePattern: "x"},
+		"no condition":     {Name: "Everything", CategoryID: groceries.ID},
+		"bad pattern":      {Name: "Broken", CategoryID: groceries.ID, NamePattern: "(milk"},
+		"unknown category": {Name: "Lost", CategoryID: milk.ID, NamePattern: "x"},
+		"bad weekday":      {Name: "Someday", CategoryID: groceries.ID, Weekdays: []time.Weekday{7}},
+	} {
+		if rec := doJSON(t, r, "POST", "/api/category-rules", rule); rec.Code != http.StatusBadRequest {
+			t.Errorf("create rule with %s: status %d", name, rec.Code)
+		}
+	}
+
+	// New expenses without a category take the first matching rule's.
+	monday := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	add := func(expense models.Expense) models.Expense {
+		t.Helper()
+		rec := doJSON(t, r, "POST", "/api/expenses", expense)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("add expense %q: status %d: %s", expense.Name, rec.Code, rec.Body)
+		}
+		json.NewDecoder(rec.Body).Decode(&expense)
+		return expense
+	}
+	for _, tt := range []struct {
+		expense models.Expense
+		want    models.Category
+	}{
+		{models.Expense{Name: "Team lunch", Amount: 1500, Date: monday}, dining},
+		{models.Expense{Name: "Team lunch", Amount: 1500, Date: monday.AddDate(0, 0, 1)}, models.Category{}},
+		{models.Expense{Name: "Team lunch", Amount: 2500, Date: monday}, models.Category{}},
+		{models.Expense{Name: "MILK 2%", Amount: 450, Date: monday, Merchant: "Corner Grocer"}, dairy},
+		{models.Expense{Name: "BREAD", Amount: 225, Date: monday, Merchant: "Corner Grocer"}, groceries},
+		{models.Expense{Name: "Buttermilk", Amount: 300, Date: monday, CategoryID: dining.ID}, dining},
+	} {
+		if got := add(tt.expense); got.CategoryID != tt.want.ID {
+			t.Errorf("%s on %s: category %s, want %q", tt.expense.Name, tt.expense.Date.Weekday(), got.CategoryID.Hex(), tt.want.Name)
+		}
+	}
+
+	// A dry run lists what a rule would move, without moving it.
+	rec = doJSON(t, r, "POST", "/api/category-rules/dry-run", models.CategoryRule{CategoryID: dairy.ID, NamePattern: "milk"})
+	var dryRun models.CategoryRuleDryRun
+	json.NewDecoder(rec.Body).Decode(&dryRun)
+	if rec.Code != http.StatusOK || len(dryRun.Changes) != 1 || dryRun.Unchanged != 1 {
+		t.Fatalf("dry run: status %d: %+v", rec.Code, dryRun)
+	}
+	if c := dryRun.Changes[0]; c.Expense.Name != "Buttermilk" || c.Expense.CategoryID != dining.ID || c.CategoryID != dairy.ID {
+		t.Errorf("dry run change: %+v", c)
+	}
+
+	// Bills are categorised as they are processed.
+	bill := waitForBill(t, r, uploadBill(t, r, "receipt.txt", []byte("Corner Grocer\nMILK 4.50\nEGGS 3.00\nTOTAL 7.50")))
+	if len(bill.GeneratedExpenses) != 2 || bill.GeneratedExpenses[0].CategoryID != dairy.ID || bill.GeneratedExpenses[1].CategoryID != groceries.ID {
+		t.Errorf("bill expenses: %+v", bill.GeneratedExpenses)
+	}
+
+	milk.Merchant = "Corner Grocer"
+	if rec := doJSON(t, r, "PUT", "/api/category-rules/"+milk.ID.Hex(), milk); rec.Code != http.StatusOK {
+		t.Errorf("update rule: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := doJSON(t, r, "DELETE", "/api/category-rules/"+milk.ID.Hex(), nil); rec.Code != http.StatusNoContent {
+		t.Errorf("delete rule: status %d", rec.Code)
+	}
+	if rec := doJSON(t, r, "PUT", "/api/category-rules/"+milk.ID.Hex(), milk); rec.Code != http.StatusNotFound {
+		t.Errorf("update deleted rule: status %d", rec.Code)
+	}
+
+	// Deleting a category takes its rules with it.
+	doJSON(t, r, "DELETE", "/api/categories/"+groceries.ID.Hex(), nil)
+	json.NewDecoder(doJSON(t, r, "GET", "/api/category-rules", nil).Body).Decode(&rules)
+	if len(rules) != 1 || rules[0].CategoryID != dining.ID {
+		t.Errorf("rules after deleting a category: %+v", rules)
+	}
+}
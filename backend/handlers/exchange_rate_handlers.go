@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/dhruwanga19/expense-tracker/auth"
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/services"
+	"github.com/dhruwanga19/expense-tracker/store"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func SetupExchangeRateRoutes(r *mux.Router, rateService *services.ExchangeRateService) {
+	r.HandleFunc("/api/exchange-rates", getExchangeRatesHandler(rateService)).Methods("GET")
+	r.HandleFunc("/api/exchange-rates", putExchangeRateHandler(rateService)).Methods("POST")
+	r.HandleFunc("/api/exchange-rates/import", importExchangeRatesHandler(rateService)).Methods("POST")
+	r.HandleFunc("/api/exchange-rates/{id}", deleteExchangeRateHandler(rateService)).Methods("DELETE")
+}
+
+func getExchangeRatesHandler(s *services.ExchangeRateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rates, err := s.GetRates(r.Context(), auth.LedgerID(r.Context()))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if rates == nil {
+			rates = []models.ExchangeRate{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rates)
+	}
+}
+
+// putExchangeRateHandler adds a rate, or replaces the one for the same
+// currencies and day.
+func putExchangeRateHandler(s *services.ExchangeRateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var rate models.ExchangeRate
+		if err := json.NewDecoder(r.Body).Decode(&rate); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.PutRate(r.Context(), auth.LedgerID(r.Context()), &rate); err != nil {
+			exchangeRateError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(rate)
+	}
+}
+
+// importExchangeRatesHandler takes a CSV file, either as the request body or
+// as the "file" field of a multipart form.
+func importExchangeRatesHandler(s *services.ExchangeRateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = http.MaxBytesReader(w, r.Body, 10<<20)
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			if err := r.ParseMultipartForm(10 << 20); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			file, _, err := r.FormFile("file")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			defer file.Close()
+			body = file
+		}
+
+		imported, err := s.ImportRates(r.Context(), auth.LedgerID(r.Context()), body)
+		if err != nil {
+			exchangeRateError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int{"imported": imported})
+	}
+}
+
+func deleteExchangeRateHandler(s *services.ExchangeRateService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid exchange rate ID", http.StatusBadRequest)
+			return
+		}
+		if err := s.DeleteRate(r.Context(), auth.LedgerID(r.Context()), id); err != nil {
+			exchangeRateError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func exchangeRateError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, money.ErrUnknownCurrency), errors.Is(err, money.ErrInvalidRate),
+		errors.Is(err, services.ErrSameCurrency), errors.Is(err, services.ErrMissingRateDate),
+		errors.Is(err, services.ErrInvalidRateCSV):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, store.ErrNotFound):
+		http.Error(w, "Exchange rate not found", http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -2,19 +2,24 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/dhruwanga19/expense-tracker/auth"
 	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
 	"github.com/dhruwanga19/expense-tracker/services"
+	"github.com/dhruwanga19/expense-tracker/store"
 
 	"github.com/gorilla/mux"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
-func SetupExpenseRoutes(r *mux.Router, db *mongo.Database) {
-	expenseService := services.NewExpenseService(db)
+func SetupExpenseRoutes(r *mux.Router, st store.Store, suggester *services.CategorySuggester) {
+	expenseService := services.NewExpenseService(st, suggester)
 
 	r.HandleFunc("/api/expenses", getExpensesHandler(expenseService)).Methods("GET")
 	r.HandleFunc("/api/expenses", addExpenseHandler(expenseService)).Methods("POST")
@@ -24,15 +29,124 @@ func SetupExpenseRoutes(r *mux.Router, db *mongo.Database) {
 
 func getExpensesHandler(s *services.ExpenseService) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		expenses, err := s.GetExpenses(r.Context())
+		query, err := parseExpenseQuery(r)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		query.LedgerID = auth.LedgerID(r.Context())
+
+		page, err := s.GetExpenses(r.Context(), query, r.URL.Query().Get("cursor"))
+		if err != nil {
+			if err == services.ErrInvalidCursor {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			} else {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
 			return
 		}
 
 		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(expenses)
+		json.NewEncoder(w).Encode(page)
+	}
+}
+
+// parseExpenseQuery reads the filters of GET /api/expenses:
+//
+//	from, to             dates (YYYY-MM-DD or RFC 3339); a bare "to" date is inclusive
+//	categoryId           repeated or comma-separated category IDs
+//	minAmount, maxAmount inclusive amount range, in minor units (cents)
+//	name                 case-insensitive substring of the name
+//	sort, order          date|amount|name and asc|desc (default date desc)
+//	limit                page size, capped at services.MaxExpensePageSize
+func parseExpenseQuery(r *http.Request) (store.ExpenseQuery, error) {
+	params := r.URL.Query()
+	query := store.ExpenseQuery{
+		Name:     strings.TrimSpace(params.Get("name")),
+		SortBy:   store.SortByDate,
+		SortDesc: true,
+	}
+
+	if v := params.Get("from"); v != "" {
+		from, _, err := parseDateParam(v)
+		if err != nil {
+			return query, fmt.Errorf("invalid from: %v", err)
+		}
+		query.From = from
+	}
+	if v := params.Get("to"); v != "" {
+		to, dateOnly, err := parseDateParam(v)
+		if err != nil {
+			return query, fmt.Errorf("invalid to: %v", err)
+		}
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		query.To = to
+	}
+
+	for _, v := range params["categoryId"] {
+		for _, hex := range strings.Split(v, ",") {
+			id, err := primitive.ObjectIDFromHex(strings.TrimSpace(hex))
+			if err != nil {
+				return query, fmt.Errorf("invalid categoryId %q", hex)
+			}
+			query.CategoryIDs = append(query.CategoryIDs, id)
+		}
 	}
+
+	if v := params.Get("billId"); v != "" {
+		id, err := primitive.ObjectIDFromHex(v)
+		if err != nil {
+			return query, fmt.Errorf("invalid billId %q", v)
+		}
+		query.BillID = id
+	}
+
+	for name, dst := range map[string]**money.Amount{"minAmount": &query.MinAmount, "maxAmount": &query.MaxAmount} {
+		if v := params.Get(name); v != "" {
+			n, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return query, fmt.Errorf("invalid %s", name)
+			}
+			amount := money.Amount(n)
+			*dst = &amount
+		}
+	}
+
+	switch sortBy := params.Get("sort"); sortBy {
+	case "":
+	case store.SortByDate, store.SortByAmount, store.SortByName:
+		query.SortBy = sortBy
+	default:
+		return query, fmt.Errorf("invalid sort %q", sortBy)
+	}
+	switch order := params.Get("order"); order {
+	case "", "desc":
+	case "asc":
+		query.SortDesc = false
+	default:
+		return query, fmt.Errorf("invalid order %q", order)
+	}
+
+	if v := params.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return query, fmt.Errorf("invalid limit")
+		}
+		query.Limit = limit
+	}
+
+	return query, nil
+}
+
+// parseDateParam accepts YYYY-MM-DD (reported as dateOnly) or RFC 3339.
+func parseDateParam(v string) (t time.Time, dateOnly bool, err error) {
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, true, nil
+	}
+	t, err = time.Parse(time.RFC3339, v)
+	return t, false, err
 }
 
 func addExpenseHandler(s *services.ExpenseService) http.HandlerFunc {
@@ -44,9 +158,13 @@ func addExpenseHandler(s *services.ExpenseService) http.HandlerFunc {
 			return
 		}
 
-		err = s.AddExpense(r.Context(), &expense)
+		err = s.AddExpense(r.Context(), auth.LedgerID(r.Context()), &expense)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			if err == services.ErrUnknownCategory || err == money.ErrUnknownCurrency {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			} else {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
 			return
 		}
 
@@ -65,7 +183,6 @@ func updateExpenseHandler(s *services.ExpenseService) http.HandlerFunc {
 			return
 		}
 
-		filter := bson.M{"_id": id}
 		var updatedExpense models.Expense
 
 		erro := json.NewDecoder(r.Body).Decode(&updatedExpense)
@@ -74,9 +191,17 @@ func updateExpenseHandler(s *services.ExpenseService) http.HandlerFunc {
 			return
 		}
 
-		err = s.UpdateExpense(r.Context(), &updatedExpense, filter)
+		updatedExpense.ID = id
+		err = s.UpdateExpense(r.Context(), auth.LedgerID(r.Context()), &updatedExpense)
 		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			switch err {
+			case services.ErrUnknownCategory, money.ErrUnknownCurrency:
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			case store.ErrNotFound:
+				http.Error(w, "Expense not found", http.StatusNotFound)
+			default:
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
 			return
 		}
 
@@ -104,8 +229,7 @@ func deleteExpensesHandler(s *services.ExpenseService) http.HandlerFunc {
 			ids = append(ids, objID)
 		}
 
-		filter := bson.M{"_id": bson.M{"$in": ids}}
-		result, err := s.DeleteExpenses(r.Context(), filter)
+		result, err := s.DeleteExpenses(r.Context(), auth.LedgerID(r.Context()), ids)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
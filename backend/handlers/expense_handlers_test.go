@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/auth"
+	"github.com/dhruwanga19/expense-tracker/middleware"
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/ocr"
+	"github.com/dhruwanga19/expense-tracker/services"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"github.com/dhruwanga19/expense-tracker/store/memstore"
+	"github.com/dhruwanga19/expense-tracker/utils"
+	"github.com/gorilla/mux"
+)
+
+// newTestRouter wires the routes like main does, on an in-memory store.
+func newTestRouter() *mux.Router {
+	return newTestRouterOn(memstore.New(), nil, nil)
+}
+
+// newTestRouterOn is newTestRouter on a store the test can reach directly.
+// Bills are handled by bills, or by a service reading them with the fixture
+// OCR engine when it is nil. Category suggestions come from suggester, which
+// may be nil.
+func newTestRouterOn(st store.Store, bills *services.BillService, suggester *services.CategorySuggester) *mux.Router {
+	if bills == nil {
+		bills = services.NewBillService(st, memstore.NewBlobStore(), ocr.NewFixture(), utils.ImageOptions{}, nil, suggester)
+	}
+	tokens := auth.NewTokens([]byte("test secret"), time.Hour)
+	r := mux.NewRouter()
+	api := r.NewRoute().Subrouter()
+	api.Use(middleware.RequireAuth(tokens))
+	SetupAuthRoutes(r, api, services.NewAuthService(st, tokens))
+	ledgerService := services.NewLedgerService(st)
+	SetupLedgerRoutes(api, ledgerService)
+	data := api.NewRoute().Subrouter()
+	data.Use(middleware.RequireLedgerRole(ledgerService))
+	SetupExpenseRoutes(data, st, suggester)
+	SetupCategoryRoutes(data, st)
+	SetupCategoryRuleRoutes(data, services.NewCategoryRuleService(st))
+	SetupBillRoutes(data, bills)
+	exchangeRateService := services.NewExchangeRateService(st, money.DefaultCurrency)
+	SetupExchangeRateRoutes(data, exchangeRateService)
+	SetupSummaryRoutes(data, services.NewSummaryService(st, exchangeRateService))
+	SetupBudgetGoalRoutes(data, services.NewBudgetGoalService(st, exchangeRateService))
+	SetupRecurringExpenseRoutes(data, services.NewRecurringExpenseService(st))
+	return r
+}
+
+// asUser registers email and returns h with its session token attached to
+// every request.
+func asUser(t *testing.T, h http.Handler, email string) http.Handler {
+	h, _ = registerUser(t, h, email)
+	return h
+}
+
+// registerUser is asUser also returning the new user.
+func registerUser(t *testing.T, h http.Handler, email string) (http.Handler, *models.User) {
+	t.Helper()
+	rec := doJSON(t, h, "POST", "/api/auth/register", models.Credentials{Email: email, Password: "correct horse"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("register %s: status %d: %s", email, rec.Code, rec.Body)
+	}
+	var resp models.AuthResponse
+	json.NewDecoder(rec.Body).Decode(&resp)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Set("Authorization", "Bearer "+resp.Token)
+		h.ServeHTTP(w, r)
+	}), resp.User
+}
+
+func doJSON(t *testing.T, h http.Handler, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, &buf))
+	return rec
+}
+
+func TestExpenseLifecycle(t *testing.T) {
+	r := asUser(t, newTestRouter(), "me@example.com")
+
+	rec := doJSON(t, r, "POST", "/api/categories", models.Category{Name: "Groceries", Color: "#00FF00"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("add category: status %d: %s", rec.Code, rec.Body)
+	}
+	var category models.Category
+	json.NewDecoder(rec.Body).Decode(&category)
+
+	rec = doJSON(t, r, "POST", "/api/expenses", models.Expense{Name: "Milk", Amount: 450, CategoryID: category.ID})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("add expense: status %d: %s", rec.Code, rec.Body)
+	}
+	var expense models.Expense
+	json.NewDecoder(rec.Body).Decode(&expense)
+	if expense.ID.IsZero() {
+		t.Fatal("added expense has no ID")
+	}
+	if expense.Currency != money.DefaultCurrency {
+		t.Errorf("added expense currency = %q, want %q", expense.Currency, money.DefaultCurrency)
+	}
+
+	rec = doJSON(t, r, "POST", "/api/expenses", models.Expense{Name: "Milk", Amount: 450, Currency: "XYZ", CategoryID: category.ID})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("add expense with unknown currency: status %d, want 400", rec.Code)
+	}
+
+	expense.Amount = 500
+	rec = doJSON(t, r, "PUT", "/api/expenses/"+expense.ID.Hex(), expense)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update expense: status %d: %s", rec.Code, rec.Body)
+	}
+
+	rec = doJSON(t, r, "GET", "/api/expenses", nil)
+	var page models.ExpensePage
+	json.NewDecoder(rec.Body).Decode(&page)
+	expenses := page.Expenses
+	if len(expenses) != 1 || expenses[0].Amount != 500 || expenses[0].Category == nil || expenses[0].Category.Name != "Groceries" {
+		t.Fatalf("unexpected expenses: %+v", expenses)
+	}
+
+	rec = doJSON(t, r, "DELETE", "/api/categories/"+category.ID.Hex(), nil)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("delete category: status %d: %s", rec.Code, rec.Body)
+	}
+	rec = doJSON(t, r, "DELETE", "/api/categories/"+category.ID.Hex(), nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("delete missing category: status %d, want 404", rec.Code)
+	}
+
+	rec = doJSON(t, r, "POST", "/api/expenses/delete", models.DeleteExpensesRequest{IDs: []string{expense.ID.Hex()}})
+	var deleted map[string]int64
+	json.NewDecoder(rec.Body).Decode(&deleted)
+	if deleted["deleted"] != 0 {
+		t.Fatalf("expense should have been removed with its category, deleted %d", deleted["deleted"])
+	}
+}
+
+func TestGetExpensesPaging(t *testing.T) {
+	r := asUser(t, newTestRouter(), "me@example.com")
+
+	rec := doJSON(t, r, "POST", "/api/categories", models.Category{Name: "Rent", Color: "#123456"})
+	var category models.Category
+	json.NewDecoder(rec.Body).Decode(&category)
+	for month := time.January; month <= time.May; month++ {
+		expense := models.Expense{Name: month.String(), Amount: 1000, Date: time.Date(2024, month, 1, 0, 0, 0, 0, time.UTC), CategoryID: category.ID}
+		doJSON(t, r, "POST", "/api/expenses", expense)
+	}
+
+	var names []string
+	url := "/api/expenses?from=2024-02-01&to=2024-04-01&sort=date&order=asc&limit=2"
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("too many pages")
+		}
+		rec := doJSON(t, r, "GET", url, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("get expenses: status %d: %s", rec.Code, rec.Body)
+		}
+		var page models.ExpensePage
+		json.NewDecoder(rec.Body).Decode(&page)
+		for _, e := range page.Expenses {
+			names = append(names, e.Name)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		url = "/api/expenses?from=2024-02-01&to=2024-04-01&sort=date&order=asc&limit=2&cursor=" + page.NextCursor
+	}
+	if want := []string{"February", "March", "April"}; !slices.Equal(names, want) {
+		t.Errorf("got %q, want %q", names, want)
+	}
+
+	// A cursor only works with the sort order it was issued for.
+	rec = doJSON(t, r, "GET", "/api/expenses?limit=1", nil)
+	var page models.ExpensePage
+	json.NewDecoder(rec.Body).Decode(&page)
+	rec = doJSON(t, r, "GET", "/api/expenses?order=asc&cursor="+page.NextCursor, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("mismatched cursor: status %d, want 400", rec.Code)
+	}
+	rec = doJSON(t, r, "GET", "/api/expenses?sort=color", nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("invalid sort: status %d, want 400", rec.Code)
+	}
+}
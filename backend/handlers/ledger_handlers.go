@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dhruwanga19/expense-tracker/auth"
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/services"
+	"github.com/dhruwanga19/expense-tracker/store"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func SetupLedgerRoutes(r *mux.Router, ledgerService *services.LedgerService) {
+	r.HandleFunc("/api/ledgers", getLedgersHandler(ledgerService)).Methods("GET")
+	r.HandleFunc("/api/ledgers", createLedgerHandler(ledgerService)).Methods("POST")
+	r.HandleFunc("/api/ledgers/{id}", getLedgerHandler(ledgerService)).Methods("GET")
+	r.HandleFunc("/api/ledgers/{id}", updateLedgerHandler(ledgerService)).Methods("PUT")
+	r.HandleFunc("/api/ledgers/{id}/members", addLedgerMemberHandler(ledgerService)).Methods("POST")
+	r.HandleFunc("/api/ledgers/{id}/members/{userId}", removeLedgerMemberHandler(ledgerService)).Methods("DELETE")
+}
+
+func getLedgersHandler(s *services.LedgerService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ledgers, err := s.GetLedgers(r.Context(), auth.UserID(r.Context()))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if ledgers == nil {
+			ledgers = []models.Ledger{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ledgers)
+	}
+}
+
+func createLedgerHandler(s *services.LedgerService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var ledger models.Ledger
+		if err := json.NewDecoder(r.Body).Decode(&ledger); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.CreateLedger(r.Context(), auth.UserID(r.Context()), &ledger); err != nil {
+			ledgerError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(ledger)
+	}
+}
+
+func getLedgerHandler(s *services.LedgerService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid ledger ID", http.StatusBadRequest)
+			return
+		}
+		ledger, err := s.GetLedger(r.Context(), auth.UserID(r.Context()), id)
+		if err != nil {
+			ledgerError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ledger)
+	}
+}
+
+// updateLedgerHandler changes the name and base currency of a ledger.
+func updateLedgerHandler(s *services.LedgerService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid ledger ID", http.StatusBadRequest)
+			return
+		}
+		var settings models.LedgerSettings
+		if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ledger, err := s.UpdateLedger(r.Context(), auth.UserID(r.Context()), id, settings)
+		if err != nil {
+			ledgerError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ledger)
+	}
+}
+
+// addLedgerMemberHandler invites a registered user by email, or changes the
+// role of an existing member.
+func addLedgerMemberHandler(s *services.LedgerService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid ledger ID", http.StatusBadRequest)
+			return
+		}
+		var invite models.MemberInvite
+		if err := json.NewDecoder(r.Body).Decode(&invite); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		member, err := s.AddMember(r.Context(), auth.UserID(r.Context()), id, invite)
+		if err != nil {
+			ledgerError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(member)
+	}
+}
+
+func removeLedgerMemberHandler(s *services.LedgerService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		params := mux.Vars(r)
+		id, err := primitive.ObjectIDFromHex(params["id"])
+		if err != nil {
+			http.Error(w, "Invalid ledger ID", http.StatusBadRequest)
+			return
+		}
+		memberID, err := primitive.ObjectIDFromHex(params["userId"])
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+
+		if err := s.RemoveMember(r.Context(), auth.UserID(r.Context()), id, memberID); err != nil {
+			ledgerError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func ledgerError(w http.ResponseWriter, err error) {
+	switch err {
+	case services.ErrLedgerNameEmpty, services.ErrInvalidRole, services.ErrInvalidEmail, money.ErrUnknownCurrency:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case services.ErrNotLedgerMember, services.ErrNotLedgerOwner:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case services.ErrUnknownUser, store.ErrNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case services.ErrLastOwner:
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/dhruwanga19/expense-tracker/middleware"
+	"github.com/dhruwanga19/expense-tracker/models"
+)
+
+// inLedger returns h sending every request to the given ledger.
+func inLedger(h http.Handler, ledgerID string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Set(middleware.LedgerHeader, ledgerID)
+		h.ServeHTTP(w, r)
+	})
+}
+
+func TestSharedLedgerRoles(t *testing.T) {
+	r := newTestRouter()
+	alice, aliceUser := registerUser(t, r, "alice@example.com")
+	bob, bobUser := registerUser(t, r, "bob@example.com")
+
+	rec := doJSON(t, alice, "POST", "/api/ledgers", models.Ledger{Name: "House"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create ledger: status %d: %s", rec.Code, rec.Body)
+	}
+	var house models.Ledger
+	json.NewDecoder(rec.Body).Decode(&house)
+	aliceHouse := inLedger(alice, house.ID.Hex())
+	bobHouse := inLedger(bob, house.ID.Hex())
+
+	rec = doJSON(t, aliceHouse, "POST", "/api/categories", models.Category{Name: "Utilities", Color: "#0000ff"})
+	var utilities models.Category
+	json.NewDecoder(rec.Body).Decode(&utilities)
+	doJSON(t, aliceHouse, "POST", "/api/expenses", models.Expense{Name: "Power", Amount: 80, CategoryID: utilities.ID})
+
+	if rec := doJSON(t, bobHouse, "GET", "/api/expenses", nil); rec.Code != http.StatusForbidden {
+		t.Errorf("non-member read: status %d, want 403", rec.Code)
+	}
+	if rec := doJSON(t, bob, "POST", "/api/ledgers/"+house.ID.Hex()+"/members", models.MemberInvite{Email: "bob@example.com", Role: models.RoleOwner}); rec.Code != http.StatusForbidden {
+		t.Errorf("non-member invites themselves: status %d, want 403", rec.Code)
+	}
+
+	invite := models.MemberInvite{Email: "bob@example.com", Role: models.RoleViewer}
+	if rec := doJSON(t, alice, "POST", "/api/ledgers/"+house.ID.Hex()+"/members", invite); rec.Code != http.StatusOK {
+		t.Fatalf("invite viewer: status %d: %s", rec.Code, rec.Body)
+	}
+	rec = doJSON(t, bobHouse, "GET", "/api/expenses", nil)
+	var page models.ExpensePage
+	json.NewDecoder(rec.Body).Decode(&page)
+	if rec.Code != http.StatusOK || len(page.Expenses) != 1 {
+		t.Errorf("viewer read: status %d, expenses %+v", rec.Code, page.Expenses)
+	}
+	if rec := doJSON(t, bobHouse, "POST", "/api/expenses", models.Expense{Name: "Water", Amount: 30, CategoryID: utilities.ID}); rec.Code != http.StatusForbidden {
+		t.Errorf("viewer write: status %d, want 403", rec.Code)
+	}
+	if rec := doJSON(t, bobHouse, "DELETE", "/api/categories/"+utilities.ID.Hex(), nil); rec.Code != http.StatusForbidden {
+		t.Errorf("viewer delete: status %d, want 403", rec.Code)
+	}
+
+	invite.Role = models.RoleEditor
+	doJSON(t, alice, "POST", "/api/ledgers/"+house.ID.Hex()+"/members", invite)
+	if rec := doJSON(t, bobHouse, "POST", "/api/expenses", models.Expense{Name: "Water", Amount: 30, CategoryID: utilities.ID}); rec.Code != http.StatusCreated {
+		t.Errorf("editor write: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := doJSON(t, bob, "POST", "/api/ledgers/"+house.ID.Hex()+"/members", models.MemberInvite{Email: "bob@example.com", Role: models.RoleOwner}); rec.Code != http.StatusForbidden {
+		t.Errorf("editor promotes themselves: status %d, want 403", rec.Code)
+	}
+
+	// Bob's personal ledger is unaffected.
+	rec = doJSON(t, bob, "GET", "/api/expenses", nil)
+	json.NewDecoder(rec.Body).Decode(&page)
+	if len(page.Expenses) != 0 {
+		t.Errorf("personal ledger sees shared expenses: %+v", page.Expenses)
+	}
+
+	if rec := doJSON(t, alice, "DELETE", "/api/ledgers/"+house.ID.Hex()+"/members/"+aliceUser.ID.Hex(), nil); rec.Code != http.StatusConflict {
+		t.Errorf("last owner leaves: status %d, want 409", rec.Code)
+	}
+	if rec := doJSON(t, alice, "DELETE", "/api/ledgers/"+house.ID.Hex()+"/members/"+bobUser.ID.Hex(), nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("remove member: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := doJSON(t, bobHouse, "GET", "/api/expenses", nil); rec.Code != http.StatusForbidden {
+		t.Errorf("removed member read: status %d, want 403", rec.Code)
+	}
+
+	rec = doJSON(t, alice, "GET", "/api/ledgers", nil)
+	var ledgers []models.Ledger
+	json.NewDecoder(rec.Body).Decode(&ledgers)
+	if len(ledgers) != 2 {
+		t.Errorf("alice's ledgers: %+v", ledgers)
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dhruwanga19/expense-tracker/auth"
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/services"
+	"github.com/dhruwanga19/expense-tracker/store"
+
+	"github.com/gorilla/mux"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func SetupRecurringExpenseRoutes(r *mux.Router, recurringService *services.RecurringExpenseService) {
+	r.HandleFunc("/api/recurring-expenses", getRecurringExpensesHandler(recurringService)).Methods("GET")
+	r.HandleFunc("/api/recurring-expenses", createRecurringExpenseHandler(recurringService)).Methods("POST")
+	r.HandleFunc("/api/recurring-expenses/{id}", updateRecurringExpenseHandler(recurringService)).Methods("PUT")
+	r.HandleFunc("/api/recurring-expenses/{id}", deleteRecurringExpenseHandler(recurringService)).Methods("DELETE")
+}
+
+func getRecurringExpensesHandler(s *services.RecurringExpenseService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		recurring, err := s.GetRecurringExpenses(r.Context(), auth.LedgerID(r.Context()))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if recurring == nil {
+			recurring = []models.RecurringExpense{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(recurring)
+	}
+}
+
+func createRecurringExpenseHandler(s *services.RecurringExpenseService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var recurring models.RecurringExpense
+		if err := json.NewDecoder(r.Body).Decode(&recurring); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := s.CreateRecurringExpense(r.Context(), auth.LedgerID(r.Context()), &recurring); err != nil {
+			recurringExpenseError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(recurring)
+	}
+}
+
+func updateRecurringExpenseHandler(s *services.RecurringExpenseService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid recurring expense ID", http.StatusBadRequest)
+			return
+		}
+		var recurring models.RecurringExpense
+		if err := json.NewDecoder(r.Body).Decode(&recurring); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		recurring.ID = id
+		if err := s.UpdateRecurringExpense(r.Context(), auth.LedgerID(r.Context()), &recurring); err != nil {
+			recurringExpenseError(w, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(recurring)
+	}
+}
+
+func deleteRecurringExpenseHandler(s *services.RecurringExpenseService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+		if err != nil {
+			http.Error(w, "Invalid recurring expense ID", http.StatusBadRequest)
+			return
+		}
+		if err := s.DeleteRecurringExpense(r.Context(), auth.LedgerID(r.Context()), id); err != nil {
+			recurringExpenseError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func recurringExpenseError(w http.ResponseWriter, err error) {
+	switch err {
+	case services.ErrInvalidFrequency, services.ErrInvalidInterval, services.ErrMissingStartDate,
+		services.ErrEndBeforeStart, services.ErrUnknownCategory, money.ErrUnknownCurrency:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case store.ErrNotFound:
+		http.Error(w, "Recurring expense not found", http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
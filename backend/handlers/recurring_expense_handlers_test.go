@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/services"
+	"github.com/dhruwanga19/expense-tracker/store/memstore"
+)
+
+func TestRecurringExpensesMaterialiseOnce(t *testing.T) {
+	st := memstore.New()
+	r := asUser(t, newTestRouterOn(st, nil, nil), "tenant@example.com")
+	scheduler := services.NewRecurringExpenseService(st)
+
+	var rent models.Category
+	json.NewDecoder(doJSON(t, r, "POST", "/api/categories", models.Category{Name: "Rent", Color: "#000001"}).Body).Decode(&rent)
+
+	day := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	end := day(2024, 5, 31)
+	template := models.RecurringExpense{
+		Name: "Flat", Amount: 120000, CategoryID: rent.ID,
+		Frequency: "fortnightly", StartDate: day(2024, 1, 31), EndDate: &end,
+	}
+	if rec := doJSON(t, r, "POST", "/api/recurring-expenses", template); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown frequency: status %d, want 400", rec.Code)
+	}
+	template.Frequency = models.Monthly
+	rec := doJSON(t, r, "POST", "/api/recurring-expenses", template)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create recurring expense: status %d: %s", rec.Code, rec.Body)
+	}
+	json.NewDecoder(rec.Body).Decode(&template)
+	if template.Interval != 1 || template.Currency != "USD" || !template.NextDate.Equal(template.StartDate) {
+		t.Errorf("created recurring expense: %+v", template)
+	}
+
+	materialise := func(now time.Time, want int) {
+		t.Helper()
+		n, err := scheduler.MaterialiseDue(context.Background(), now)
+		if err != nil {
+			t.Fatalf("materialise: %v", err)
+		}
+		if n != want {
+			t.Errorf("materialised %d expenses at %s, want %d", n, now.Format(time.DateOnly), want)
+		}
+	}
+	// The server was down since January: catch up, and only once.
+	materialise(day(2024, 4, 15), 3)
+	materialise(day(2024, 4, 15), 0)
+	materialise(day(2025, 1, 1), 2)
+
+	var page models.ExpensePage
+	json.NewDecoder(doJSON(t, r, "GET", "/api/expenses?sort=date&order=asc", nil).Body).Decode(&page)
+	var dates []string
+	for _, e := range page.Expenses {
+		if e.Amount != 120000 || e.CategoryID != rent.ID || e.Name != "Flat" {
+			t.Errorf("unexpected expense %+v", e)
+		}
+		dates = append(dates, e.Date.Format(time.DateOnly))
+	}
+	want := []string{"2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30", "2024-05-31"}
+	if len(dates) != len(want) {
+		t.Fatalf("expense dates %v, want %v", dates, want)
+	}
+	for i := range want {
+		if dates[i] != want[i] {
+			t.Fatalf("expense dates %v, want %v", dates, want)
+		}
+	}
+
+	var list []models.RecurringExpense
+	json.NewDecoder(doJSON(t, r, "GET", "/api/recurring-expenses", nil).Body).Decode(&list)
+	if len(list) != 1 || !list[0].Done || list[0].NextIndex != 5 {
+		t.Errorf("recurring expenses after the end date: %+v", list)
+	}
+}
+
+func TestRecurringExpenseRescheduleSkipsCreated(t *testing.T) {
+	st := memstore.New()
+	r := asUser(t, newTestRouterOn(st, nil, nil), "subscriber@example.com")
+	scheduler := services.NewRecurringExpenseService(st)
+
+	template := models.RecurringExpense{
+		Name: "Newspaper", Amount: 300, Frequency: models.Weekly,
+		StartDate: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	json.NewDecoder(doJSON(t, r, "POST", "/api/recurring-expenses", template).Body).Decode(&template)
+	if _, err := scheduler.MaterialiseDue(context.Background(), time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatalf("materialise: %v", err)
+	}
+
+	// Moving to daily from the same start must not repeat 1 or 8 March.
+	template.Frequency = models.Daily
+	rec := doJSON(t, r, "PUT", "/api/recurring-expenses/"+template.ID.Hex(), template)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update recurring expense: status %d: %s", rec.Code, rec.Body)
+	}
+	json.NewDecoder(rec.Body).Decode(&template)
+	if template.NextIndex != 8 || template.NextDate.Format(time.DateOnly) != "2024-03-09" {
+		t.Errorf("rescheduled recurring expense: %+v", template)
+	}
+
+	if rec := doJSON(t, r, "DELETE", "/api/recurring-expenses/"+template.ID.Hex(), nil); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete recurring expense: status %d: %s", rec.Code, rec.Body)
+	}
+	if rec := doJSON(t, r, "PUT", "/api/recurring-expenses/"+template.ID.Hex(), template); rec.Code != http.StatusNotFound {
+		t.Errorf("update deleted recurring expense: status %d, want 404", rec.Code)
+	}
+}
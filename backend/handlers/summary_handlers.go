@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/auth"
+	"github.com/dhruwanga19/expense-tracker/period"
+	"github.com/dhruwanga19/expense-tracker/services"
+
+	"github.com/gorilla/mux"
+)
+
+func SetupSummaryRoutes(r *mux.Router, summaryService *services.SummaryService) {
+	r.HandleFunc("/api/expenses/summary", getSummaryHandler(summaryService)).Methods("GET")
+}
+
+// getSummaryHandler totals the expenses matching the filters of
+// GET /api/expenses, in the ledger's base currency and per category.
+// Instead of from and to, ?period= picks the period containing ?at= (a date
+// or an RFC 3339 time, today by default).
+func getSummaryHandler(s *services.SummaryService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query, err := parseExpenseQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		query.LedgerID = auth.LedgerID(r.Context())
+		if v := r.URL.Query().Get("period"); v != "" {
+			query.From, query.To, err = parsePeriodWindow(v, r.URL.Query().Get("at"))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		summary, err := s.GetSummary(r.Context(), query)
+		if err != nil {
+			if errors.Is(err, services.ErrNoExchangeRate) {
+				http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			} else {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(summary)
+	}
+}
+
+// parsePeriodWindow returns the window of the period spec containing at,
+// or today when at is empty.
+func parsePeriodWindow(spec, at string) (time.Time, time.Time, error) {
+	p, err := period.Parse(spec)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	t := time.Now()
+	if at != "" {
+		if t, _, err = parseDateParam(at); err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid at: %v", err)
+		}
+	}
+	return p.Window(t)
+}
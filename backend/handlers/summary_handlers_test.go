@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+)
+
+func TestSummaryConvertsCurrencies(t *testing.T) {
+	r, user := registerUser(t, newTestRouter(), "traveller@example.com")
+
+	var food, travel models.Category
+	json.NewDecoder(doJSON(t, r, "POST", "/api/categories", models.Category{Name: "Food", Color: "#000001"}).Body).Decode(&food)
+	json.NewDecoder(doJSON(t, r, "POST", "/api/categories", models.Category{Name: "Travel", Color: "#000002"}).Body).Decode(&travel)
+	for _, e := range []models.Expense{
+		{Name: "Groceries", Amount: 1000, Currency: "USD", Date: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), CategoryID: food.ID},
+		{Name: "Train", Amount: 500, Currency: "EUR", Date: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), CategoryID: travel.ID},
+		{Name: "Hotel", Amount: 2000, Currency: "EUR", Date: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), CategoryID: travel.ID},
+	} {
+		if rec := doJSON(t, r, "POST", "/api/expenses", e); rec.Code != http.StatusCreated {
+			t.Fatalf("add expense: status %d: %s", rec.Code, rec.Body)
+		}
+	}
+
+	if rec := doJSON(t, r, "GET", "/api/expenses/summary", nil); rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("summary without rates: status %d, want 422: %s", rec.Code, rec.Body)
+	}
+
+	importCSV := func(csv string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest("POST", "/api/exchange-rates/import", strings.NewReader(csv)))
+		return rec
+	}
+	if rec := importCSV("2024-01-01,EUR,USD,abc\n"); rec.Code != http.StatusBadRequest {
+		t.Errorf("import bad rate: status %d, want 400", rec.Code)
+	}
+	// The March rate is only known the other way round, and is used inverted.
+	rec := importCSV("date,from,to,rate\n2024-01-01,EUR,USD,1.10\n2024-03-01,USD,EUR,0.8\n")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"imported":2`) {
+		t.Fatalf("import rates: status %d: %s", rec.Code, rec.Body)
+	}
+
+	summary := getSummary(t, r)
+	// 10.00 USD + 5.00 EUR at 1.10 + 20.00 EUR at 1/0.8
+	if summary.BaseCurrency != "USD" || summary.Total != 1000+550+2500 || summary.Count != 3 {
+		t.Errorf("summary: %+v", summary)
+	}
+	wantOriginal := []money.Money{{Amount: 2500, Currency: "EUR"}, {Amount: 1000, Currency: "USD"}}
+	if !slices.Equal(summary.Original, wantOriginal) {
+		t.Errorf("original amounts: %+v, want %+v", summary.Original, wantOriginal)
+	}
+	if len(summary.Categories) != 2 || summary.Categories[0].Name != "Travel" || summary.Categories[0].Total != 3050 ||
+		summary.Categories[1].Total != 1000 {
+		t.Errorf("category totals: %+v", summary.Categories)
+	}
+
+	rec = doJSON(t, r, "PUT", "/api/ledgers/"+user.ID.Hex(), models.LedgerSettings{Name: "Personal", BaseCurrency: "eur"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("set base currency: status %d: %s", rec.Code, rec.Body)
+	}
+	summary = getSummary(t, r)
+	if summary.BaseCurrency != "EUR" || summary.Total != 800+500+2000 {
+		t.Errorf("summary in EUR: %+v", summary)
+	}
+
+	// A period picks the window around a date: 10 February to 9 March.
+	rec = doJSON(t, r, "GET", "/api/expenses/summary?period=monthly:10&at=2024-03-05", nil)
+	json.NewDecoder(rec.Body).Decode(&summary)
+	if rec.Code != http.StatusOK || summary.Count != 1 || summary.Total != 800 {
+		t.Errorf("summary of a month from the 10th: status %d: %+v", rec.Code, summary)
+	}
+	if rec := doJSON(t, r, "GET", "/api/expenses/summary?period=fortnightly", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("summary of an invalid period: status %d", rec.Code)
+	}
+}
+
+func TestSummaryCountsExpensesOfTheSameDay(t *testing.T) {
+	r, _ := registerUser(t, newTestRouter(), "regular@example.com")
+
+	var food, empty models.Category
+	json.NewDecoder(doJSON(t, r, "POST", "/api/categories", models.Category{Name: "Food", Color: "#000001"}).Body).Decode(&food)
+	json.NewDecoder(doJSON(t, r, "POST", "/api/categories", models.Category{Name: "Unused", Color: "#000002"}).Body).Decode(&empty)
+	for _, e := range []models.Expense{
+		{Name: "Breakfast", Amount: 450, Currency: "USD", Date: time.Date(2024, 3, 5, 8, 0, 0, 0, time.UTC), CategoryID: food.ID},
+		{Name: "Dinner", Amount: 2200, Currency: "USD", Date: time.Date(2024, 3, 5, 19, 30, 0, 0, time.UTC), CategoryID: food.ID},
+		{Name: "Lunch", Amount: 1100, Currency: "USD", Date: time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC), CategoryID: food.ID},
+	} {
+		if rec := doJSON(t, r, "POST", "/api/expenses", e); rec.Code != http.StatusCreated {
+			t.Fatalf("add expense: status %d: %s", rec.Code, rec.Body)
+		}
+	}
+
+	summary := getSummary(t, r)
+	if summary.Total != 3750 || summary.Count != 3 {
+		t.Errorf("summary: %+v", summary)
+	}
+	// Categories without expenses are left out.
+	if len(summary.Categories) != 1 || summary.Categories[0].Name != "Food" || summary.Categories[0].Color != "#000001" ||
+		summary.Categories[0].Count != 3 || summary.Categories[0].Total != 3750 {
+		t.Errorf("category totals: %+v", summary.Categories)
+	}
+}
+
+func getSummary(t *testing.T, h http.Handler) models.ExpenseSummary {
+	t.Helper()
+	rec := doJSON(t, h, "GET", "/api/expenses/summary", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("summary: status %d: %s", rec.Code, rec.Body)
+	}
+	var summary models.ExpenseSummary
+	json.NewDecoder(rec.Body).Decode(&summary)
+	return summary
+}
@@ -1,13 +1,25 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"fmt"
 	"log"
 	"net/http"
 
+	"github.com/dhruwanga19/expense-tracker/auth"
 	"github.com/dhruwanga19/expense-tracker/config"
 	"github.com/dhruwanga19/expense-tracker/handlers"
 	"github.com/dhruwanga19/expense-tracker/middleware"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/ocr"
+	"github.com/dhruwanga19/expense-tracker/pdf"
 	"github.com/dhruwanga19/expense-tracker/services"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"github.com/dhruwanga19/expense-tracker/store/fsblob"
+	"github.com/dhruwanga19/expense-tracker/store/memstore"
+	"github.com/dhruwanga19/expense-tracker/store/mongostore"
+	"github.com/dhruwanga19/expense-tracker/store/sqlstore"
 	"github.com/dhruwanga19/expense-tracker/utils"
 
 	"github.com/gorilla/mux"
@@ -20,32 +32,82 @@ func main() {
 		log.Fatal("Error loading configuration:", err)
 	}
 
-	// Connect to database
-	db, err := utils.ConnectDB(cfg.MongoURI)
+	// Connect to the storage backend
+	st, closeStore, err := openStore(cfg)
 	if err != nil {
 		log.Fatal("Error connecting to database:", err)
 	}
-	defer utils.DisconnectDB(db)
+	defer closeStore()
 
-	// Initialize router
+	// Bring SQL schemas up to date before serving requests
+	if sqlStore, ok := st.(*sqlstore.Store); ok {
+		if err := sqlStore.Migrate(context.Background()); err != nil {
+			log.Fatal("Error migrating database:", err)
+		}
+	}
+
+	// Initialize router. Everything but sign-up and login needs a session.
+	tokens, err := newTokens(cfg)
+	if err != nil {
+		log.Fatal("Error initializing auth:", err)
+	}
 	r := mux.NewRouter()
+	api := r.NewRoute().Subrouter()
+	api.Use(middleware.RequireAuth(tokens))
+	handlers.SetupAuthRoutes(r, api, services.NewAuthService(st, tokens))
+	ledgerService := services.NewLedgerService(st)
+	handlers.SetupLedgerRoutes(api, ledgerService)
+
+	// The data routes work on the ledger picked by the X-Ledger-ID header.
+	data := api.NewRoute().Subrouter()
+	data.Use(middleware.RequireLedgerRole(ledgerService))
 
-	// Initialize bill service
-	billService, err := services.NewBillService(db)
+	// Initialize bill service. Without an OCR engine the server still runs,
+	// but uploaded bills end up in error.
+	engine, err := newOCREngine(cfg)
+	if err != nil {
+		log.Println("Bill processing is unavailable:", err)
+	}
+	images, err := utils.ParseImageOptions(cfg.ImagePreprocessing, cfg.ImageMaxDimension)
+	if err != nil {
+		log.Fatal("Error reading IMAGE_PREPROCESSING:", err)
+	}
+	// Without pdftoppm, PDFs are still read from their text layer.
+	var rasterizer pdf.Rasterizer
+	if pdftoppm, err := pdf.NewPdftoppm(cfg.PdftoppmPath, cfg.PDFDPI); err == nil {
+		rasterizer = pdftoppm
+	} else {
+		log.Println("Scanned PDF pages cannot be read:", err)
+	}
+	blobs, err := openBlobStore(cfg, st)
 	if err != nil {
-		log.Fatal("Error initializing bill service:", err)
+		log.Fatal("Error opening the blob store:", err)
 	}
+	// Categories of bill items are suggested from the ledger's expenses.
+	suggester := services.NewCategorySuggester(st)
+	billService := services.NewBillService(st, blobs, engine, images, rasterizer, suggester)
 
-	budgetGoalSerive := services.NewBudgetGoalService(db)
+	baseCurrency, err := money.ParseCurrency(cfg.BaseCurrency)
 	if err != nil {
-		log.Fatal("Error initializing budget goal service:", err)
+		log.Fatal("Error reading BASE_CURRENCY:", err)
 	}
+	exchangeRateService := services.NewExchangeRateService(st, baseCurrency)
+	budgetGoalSerive := services.NewBudgetGoalService(st, exchangeRateService)
+	recurringService := services.NewRecurringExpenseService(st)
 
 	// Set up routes
-	handlers.SetupExpenseRoutes(r, db)
-	handlers.SetupCategoryRoutes(r, db)
-	handlers.SetupBillRoutes(r, billService)
-	handlers.SetupBudgetGoalRoutes(r, budgetGoalSerive)
+	handlers.SetupExpenseRoutes(data, st, suggester)
+	handlers.SetupCategoryRoutes(data, st)
+	handlers.SetupCategoryRuleRoutes(data, services.NewCategoryRuleService(st))
+	handlers.SetupBillRoutes(data, billService)
+	handlers.SetupBudgetGoalRoutes(data, budgetGoalSerive)
+	handlers.SetupExchangeRateRoutes(data, exchangeRateService)
+	handlers.SetupSummaryRoutes(data, services.NewSummaryService(st, exchangeRateService))
+	handlers.SetupRecurringExpenseRoutes(data, recurringService)
+
+	// Create due recurring expenses and process uploaded bills in the background
+	go recurringService.Run(context.Background(), cfg.RecurringInterval)
+	billService.RunWorkers(context.Background(), cfg.BillWorkers, cfg.BillMaxAttempts)
 
 	// Apply middleware
 	corsRouter := middleware.CORS(r)
@@ -54,3 +116,110 @@ func main() {
 	log.Printf("Server is running on http://localhost:%s", cfg.Port)
 	log.Fatal(http.ListenAndServe(":"+cfg.Port, corsRouter))
 }
+
+// openStore returns the store selected by cfg.StorageDriver and a function
+// that releases it.
+func openStore(cfg *config.Config) (store.Store, func(), error) {
+	switch cfg.StorageDriver {
+	case "mongo":
+		db, err := utils.ConnectDB(cfg.MongoURI)
+		if err != nil {
+			return nil, nil, err
+		}
+		st := mongostore.New(db)
+		if err := st.EnsureIndexes(context.Background()); err != nil {
+			utils.DisconnectDB(db)
+			return nil, nil, err
+		}
+		if err := st.MigrateToLedgers(context.Background()); err != nil {
+			utils.DisconnectDB(db)
+			return nil, nil, err
+		}
+		if err := st.MigrateMoney(context.Background()); err != nil {
+			utils.DisconnectDB(db)
+			return nil, nil, err
+		}
+		return st, func() { utils.DisconnectDB(db) }, nil
+	case "sqlite":
+		st, err := sqlstore.OpenSQLite(cfg.SQLitePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Printf("Using SQLite database %s", cfg.SQLitePath)
+		return st, func() { st.Close() }, nil
+	case "postgres":
+		st, err := sqlstore.OpenPostgres(context.Background(), cfg.PostgresURI)
+		if err != nil {
+			return nil, nil, err
+		}
+		log.Println("Connected to PostgreSQL")
+		return st, func() { st.Close() }, nil
+	case "memory":
+		log.Println("Using in-memory storage, data is lost on restart")
+		return memstore.New(), func() {}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown storage driver %q", cfg.StorageDriver)
+	}
+}
+
+// openBlobStore returns the blob store selected by cfg.BlobStore.
+func openBlobStore(cfg *config.Config, st store.Store) (store.BlobStore, error) {
+	switch cfg.BlobStore {
+	case "fs":
+		log.Printf("Keeping bill files in %s", cfg.BlobDir)
+		return fsblob.New(cfg.BlobDir)
+	case "gridfs":
+		mongoStore, ok := st.(*mongostore.Store)
+		if !ok {
+			return nil, fmt.Errorf("the gridfs blob store needs the mongo storage driver")
+		}
+		log.Println("Keeping bill files in GridFS")
+		return mongoStore.Blobs()
+	case "memory":
+		log.Println("Keeping bill files in memory, they are lost on restart")
+		return memstore.NewBlobStore(), nil
+	default:
+		return nil, fmt.Errorf("unknown blob store %q", cfg.BlobStore)
+	}
+}
+
+// newOCREngine returns the OCR engine selected by cfg.OCREngine, or nil and
+// the reason when it cannot be used.
+func newOCREngine(cfg *config.Config) (ocr.Engine, error) {
+	switch cfg.OCREngine {
+	case "vision":
+		engine, err := ocr.NewVision(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		log.Println("Reading bills with Google Cloud Vision")
+		return engine, nil
+	case "tesseract":
+		engine, err := ocr.NewTesseract(cfg.TesseractPath, cfg.TesseractLanguage)
+		if err != nil {
+			return nil, err
+		}
+		log.Println("Reading bills with Tesseract")
+		return engine, nil
+	case "fixture":
+		log.Println("Reading bills with the fixture OCR engine, uploads of text are read as is")
+		return ocr.NewFixture(), nil
+	case "none":
+		return nil, fmt.Errorf("OCR_ENGINE is none")
+	default:
+		return nil, fmt.Errorf("unknown OCR engine %q", cfg.OCREngine)
+	}
+}
+
+// newTokens returns the session token issuer configured by cfg.
+func newTokens(cfg *config.Config) (*auth.Tokens, error) {
+	secret := []byte(cfg.AuthSecret)
+	if len(secret) == 0 {
+		log.Println("AUTH_SECRET is not set, sessions will not survive a restart")
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+	}
+	return auth.NewTokens(secret, cfg.TokenTTL), nil
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dhruwanga19/expense-tracker/auth"
+)
+
+// RequireAuth rejects requests without a valid "Authorization: Bearer"
+// token and stores the user ID of valid ones in the request context.
+func RequireAuth(tokens *auth.Tokens) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				http.Error(w, "Missing bearer token", http.StatusUnauthorized)
+				return
+			}
+			userID, err := tokens.Verify(strings.TrimSpace(token))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithUserID(r.Context(), userID)))
+		})
+	}
+}
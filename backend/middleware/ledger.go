@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/dhruwanga19/expense-tracker/auth"
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/services"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// LedgerHeader selects the ledger a request works on. Without it requests
+// go to the user's personal ledger, which has the user's ID.
+const LedgerHeader = "X-Ledger-ID"
+
+// LedgerRoles looks up the role of a user in a ledger.
+type LedgerRoles interface {
+	Role(ctx context.Context, ledgerID, userID primitive.ObjectID) (models.Role, error)
+}
+
+// RequireLedgerRole resolves the ledger of the request for the user stored
+// by RequireAuth, and rejects non-members as well as anything but reads from
+// viewers.
+func RequireLedgerRole(ledgers LedgerRoles) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID := auth.UserID(r.Context())
+			ledgerID := userID
+			if v := r.Header.Get(LedgerHeader); v != "" {
+				id, err := primitive.ObjectIDFromHex(v)
+				if err != nil {
+					http.Error(w, "Invalid ledger ID", http.StatusBadRequest)
+					return
+				}
+				ledgerID = id
+			}
+
+			role, err := ledgers.Role(r.Context(), ledgerID, userID)
+			if err == services.ErrNotLedgerMember {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if role == models.RoleViewer && r.Method != http.MethodGet && r.Method != http.MethodHead {
+				http.Error(w, "Viewers cannot change this ledger", http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithLedger(r.Context(), ledgerID, role)))
+		})
+	}
+}
@@ -1,21 +1,105 @@
 package models
 
 import (
+	"slices"
 	"time"
 
+	"github.com/dhruwanga19/expense-tracker/money"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Bill statuses. An upload starts as uploaded, is processing while its OCR
+// job runs and ends up processed or error; confirming its expenses makes it
+// confirmed. Bills that are not wanted can be discarded instead, and bills
+// that were read badly can be queued again, back to uploaded.
+const (
+	BillUploaded   = "uploaded"
+	BillProcessing = "processing"
+	BillProcessed  = "processed"
+	BillError      = "error"
+	BillConfirmed  = "confirmed"
+	BillDiscarded  = "discarded"
+)
+
+// billTransitions lists the statuses a bill may move to from each status.
+// A processing bill stays processing while its OCR is retried.
+var billTransitions = map[string][]string{
+	BillUploaded:   {BillProcessing, BillError, BillDiscarded},
+	BillProcessing: {BillProcessing, BillProcessed, BillError},
+	BillProcessed:  {BillConfirmed, BillUploaded, BillDiscarded},
+	BillError:      {BillUploaded, BillDiscarded},
+	BillDiscarded:  {BillUploaded},
+	BillConfirmed:  {},
+}
+
+// IsBillStatus reports whether status is one of the bill statuses.
+func IsBillStatus(status string) bool {
+	_, ok := billTransitions[status]
+	return ok
+}
+
+// CanMoveBill reports whether a bill may change from status from to status
+// to.
+func CanMoveBill(from, to string) bool {
+	return slices.Contains(billTransitions[from], to)
+}
+
+// BillPage is one page of a bill listing. NextCursor is empty on the last
+// page.
+type BillPage struct {
+	Bills      []Bill `json:"bills"`
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
 type Bill struct {
-	ID              primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
-	FileName        string             `bson:"file_name" json:"fileName"`
-	FileType        string             `bson:"file_type" json:"fileType"`
-	UploadDate      time.Time          `bson:"upload_date" json:"uploadDate"`
-	ProcessedDate   time.Time          `bson:"processed_date" json:"processedDate"`
-	Status          string             `bson:"status" json:"status"` // e.g., "uploaded", "processing", "processed", "error"
-	AnalysisResults struct {
-		ExtractedText string  `bson:"extracted_text" json:"extractedText"`
-		Total         float64 `bson:"total" json:"total"`
-	} `bson:"analysis_results" json:"analysisResults"`
-	GeneratedExpenses []Expense `bson:"generated_expenses" json:"generatedExpenses"`
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	LedgerID      primitive.ObjectID `bson:"ledger_id,omitempty" json:"ledgerId,omitempty"`
+	FileName      string             `bson:"file_name" json:"fileName"`
+	FileType      string             `bson:"file_type" json:"fileType"`
+	UploadDate    time.Time          `bson:"upload_date" json:"uploadDate"`
+	ProcessedDate time.Time          `bson:"processed_date" json:"processedDate"`
+	Status        string             `bson:"status" json:"status"`
+	// Error says why processing failed when Status is BillError.
+	Error             string          `bson:"error,omitempty" json:"error,omitempty"`
+	AnalysisResults   AnalysisResults `bson:"analysis_results" json:"analysisResults"`
+	GeneratedExpenses []Expense       `bson:"generated_expenses" json:"generatedExpenses"`
+	// The purchase as printed on the receipt, where it could be read:
+	// the store, when it happened and how it was paid.
+	Merchant        string     `bson:"merchant,omitempty" json:"merchant,omitempty"`
+	TransactionDate *time.Time `bson:"transaction_date,omitempty" json:"transactionDate,omitempty"`
+	PaymentMethod   string     `bson:"payment_method,omitempty" json:"paymentMethod,omitempty"`
+	CardLast4       string     `bson:"card_last4,omitempty" json:"cardLast4,omitempty"`
+}
+
+// AnalysisResults is what was read from a bill. Amounts are in minor units
+// of Currency; the summary amounts are zero when the receipt does not
+// print them.
+type AnalysisResults struct {
+	ExtractedText string         `bson:"extracted_text" json:"extractedText"`
+	Items         []ReceiptItem  `bson:"items" json:"items"`
+	Subtotal      money.Amount   `bson:"subtotal" json:"subtotal"`
+	Discount      money.Amount   `bson:"discount" json:"discount"` // sum of the discount lines, negative
+	Tax           money.Amount   `bson:"tax" json:"tax"`
+	Tip           money.Amount   `bson:"tip" json:"tip"`
+	Total         money.Amount   `bson:"total" json:"total"`
+	Currency      money.Currency `bson:"currency" json:"currency"`
+	// Reconciled is set when the items, discounts, tax and tip add up to
+	// the printed total. Difference is the printed total minus that sum.
+	Reconciled bool         `bson:"reconciled" json:"reconciled"`
+	Difference money.Amount `bson:"difference" json:"difference"`
+}
+
+// ReceiptItem is a line item of a receipt: Quantity units at UnitPrice
+// cost Amount, less Discount (negative) for discount lines that follow it.
+type ReceiptItem struct {
+	Name      string       `bson:"name" json:"name"`
+	Quantity  float64      `bson:"quantity" json:"quantity"`
+	UnitPrice money.Amount `bson:"unit_price" json:"unitPrice"`
+	Amount    money.Amount `bson:"amount" json:"amount"`
+	Discount  money.Amount `bson:"discount" json:"discount"`
+}
+
+// Net is what the item cost after its discounts.
+func (i ReceiptItem) Net() money.Amount {
+	return i.Amount + i.Discount
 }
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Kinds of bill files. The original is the file as uploaded; the processed
+// file is what was handed to OCR after image preprocessing.
+const (
+	OriginalBillFile  = "original"
+	ProcessedBillFile = "processed"
+)
+
+// BillFile is a file kept for a bill, so that the receipt can be checked
+// again later and the OCR of the original and the preprocessed image can be
+// compared. The content is in the blob store under Hash.
+type BillFile struct {
+	LedgerID    primitive.ObjectID `bson:"ledger_id" json:"ledgerId"`
+	BillID      primitive.ObjectID `bson:"bill_id" json:"billId"`
+	Kind        string             `bson:"kind" json:"kind"`
+	ContentType string             `bson:"content_type" json:"contentType"`
+	// Hash is the SHA-256 hash of the content, hex-encoded.
+	Hash      string    `bson:"hash" json:"hash"`
+	Size      int64     `bson:"size" json:"size"`
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+}
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BillJob is a queued request to run OCR on an uploaded bill, whose
+// original file is read from the bill's files.
+type BillJob struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	LedgerID primitive.ObjectID `bson:"ledger_id" json:"ledgerId"`
+	BillID   primitive.ObjectID `bson:"bill_id" json:"billId"`
+	// Attempts counts how often a worker has claimed the job.
+	Attempts int `bson:"attempts" json:"attempts"`
+	// RunAt is when the job may next be claimed. A claimed job's RunAt is
+	// moved to the end of its lease, so a job whose worker died is picked
+	// up again once the lease runs out.
+	RunAt     time.Time `bson:"run_at" json:"runAt"`
+	LastError string    `bson:"last_error" json:"lastError"`
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+}
@@ -1,16 +1,129 @@
 package models
 
 import (
+	"slices"
 	"time"
 
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/period"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// Rollover is what of a period's balance, the budget left unspent or
+// overspent, carries into the next period.
+type Rollover string
+
+const (
+	RolloverNone    Rollover = "none"
+	RolloverSurplus Rollover = "surplus" // only what was left unspent
+	RolloverBoth    Rollover = "both"    // what was left, or what was overspent
+	RolloverCapped  Rollover = "capped"  // like both, up to RolloverCap either way
+)
+
+func (r Rollover) Valid() bool {
+	return r == RolloverNone || r == RolloverSurplus || r == RolloverBoth || r == RolloverCapped
+}
+
+// BudgetScope is the spending a budget goal counts.
+type BudgetScope string
+
+const (
+	ScopeCategory   BudgetScope = "category"   // the expenses of CategoryID
+	ScopeCategories BudgetScope = "categories" // the expenses of any of CategoryIDs
+	ScopeOverall    BudgetScope = "overall"    // all expenses, categorised or not
+)
+
+func (s BudgetScope) Valid() bool {
+	return s == ScopeCategory || s == ScopeCategories || s == ScopeOverall
+}
+
 type BudgetGoal struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	LedgerID primitive.ObjectID `bson:"ledger_id,omitempty" json:"ledgerId,omitempty"`
+	// Scope is empty on goals that predate scopes, which are ScopeCategory.
+	Scope       BudgetScope          `bson:"scope,omitempty" json:"scope"`
+	CategoryID  primitive.ObjectID   `bson:"category_id" json:"categoryId"`
+	CategoryIDs []primitive.ObjectID `bson:"category_ids,omitempty" json:"categoryIds,omitempty"`
+	Amount      money.Amount         `bson:"amount" json:"amount"` // minor units of Currency
+	Currency    money.Currency       `bson:"currency" json:"currency"`
+	Period      period.Period        `bson:"period" json:"period"`
+	// Rollover applies from the period the goal was created in on.
+	Rollover    Rollover     `bson:"rollover" json:"rollover"`
+	RolloverCap money.Amount `bson:"rollover_cap" json:"rolloverCap"`
+	CreatedAt   time.Time    `bson:"created_at" json:"createdAt"`
+	UpdatedAt   time.Time    `bson:"updated_at" json:"updatedAt"`
+}
+
+// Categories returns the categories whose expenses count towards the goal,
+// or nil when all expenses do.
+func (g BudgetGoal) Categories() []primitive.ObjectID {
+	switch g.Scope {
+	case ScopeOverall:
+		return nil
+	case ScopeCategories:
+		return g.CategoryIDs
+	}
+	return []primitive.ObjectID{g.CategoryID}
+}
+
+// Covers reports whether expenses of the category count towards the goal.
+func (g BudgetGoal) Covers(categoryID primitive.ObjectID) bool {
+	categories := g.Categories()
+	return categories == nil || slices.Contains(categories, categoryID)
+}
+
+// Overlaps reports whether some expenses count towards both goals, whatever
+// their periods.
+func (g BudgetGoal) Overlaps(other BudgetGoal) bool {
+	if g.Scope == ScopeOverall || other.Scope == ScopeOverall {
+		return true
+	}
+	return slices.ContainsFunc(g.Categories(), other.Covers)
+}
+
+// BudgetBalance is how a budget goal ended one of its past periods, From
+// (inclusive) To (exclusive). Budget is the goal's Amount plus what the
+// previous period carried in; CarriedOut is what goes on to the next one.
+type BudgetBalance struct {
 	ID         primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
-	CategoryID primitive.ObjectID `bson:"category_id" json:"categoryId"`
-	Amount     float64            `bson:"amount" json:"amount"`
-	Period     string             `bson:"period" json:"period"`
-	CreatedAt  time.Time          `bson:"created_at" json:"createdAt"`
+	LedgerID   primitive.ObjectID `bson:"ledger_id" json:"ledgerId"`
+	GoalID     primitive.ObjectID `bson:"goal_id" json:"goalId"`
+	From       time.Time          `bson:"from" json:"from"`
+	To         time.Time          `bson:"to" json:"to"`
+	CarriedIn  money.Amount       `bson:"carried_in" json:"carriedIn"`
+	Budget     money.Amount       `bson:"budget" json:"budget"`
+	Spent      money.Amount       `bson:"spent" json:"spent"`
+	CarriedOut money.Amount       `bson:"carried_out" json:"carriedOut"`
 	UpdatedAt  time.Time          `bson:"updated_at" json:"updatedAt"`
 }
+
+// Statuses of BudgetProgress.
+const (
+	BudgetOnTrack = "on_track" // on pace to stay within the goal
+	BudgetAtRisk  = "at_risk"  // on pace to go over it by the end of the period
+	BudgetOver    = "over"     // already over it
+)
+
+// BudgetProgress is how much of a budget goal has been spent in the period
+// From (inclusive) To (exclusive), up to AsOf. Amounts are in the goal's
+// currency. Budget is the goal's Amount plus what the previous periods
+// CarriedIn; Remaining is what is left of it, negative once it is exceeded.
+// Projected is what will have been spent by the end of the period if
+// spending goes on at the same pace. Overlaps lists the other goals that
+// count some of the same expenses over an overlapping period, so their
+// budgets are not to be added up.
+type BudgetProgress struct {
+	Goal      BudgetGoal           `json:"goal"`
+	From      time.Time            `json:"from"`
+	To        time.Time            `json:"to"`
+	AsOf      time.Time            `json:"asOf"`
+	CarriedIn money.Amount         `json:"carriedIn"`
+	Budget    money.Amount         `json:"budget"`
+	Spent     money.Amount         `json:"spent"`
+	Count     int                  `json:"count"`
+	Remaining money.Amount         `json:"remaining"`
+	Percent   float64              `json:"percent"`
+	Projected money.Amount         `json:"projected"`
+	Status    string               `json:"status"`
+	Overlaps  []primitive.ObjectID `json:"overlaps,omitempty"`
+}
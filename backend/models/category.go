@@ -3,7 +3,8 @@ package models
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
 type Category struct {
-	ID    primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
-	Name  string             `bson:"name" json:"name"`
-	Color string             `bson:"color" json:"color"`
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	LedgerID primitive.ObjectID `bson:"ledger_id,omitempty" json:"ledgerId,omitempty"`
+	Name     string             `bson:"name" json:"name"`
+	Color    string             `bson:"color" json:"color"`
 }
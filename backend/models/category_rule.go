@@ -0,0 +1,52 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CategoryRule puts new expenses that match every one of its conditions in
+// CategoryID. A rule has at least one condition; the ones left empty match
+// any expense. Rules are tried by ascending Priority, and then in the order
+// they were created, and the first rule that matches decides.
+type CategoryRule struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	LedgerID   primitive.ObjectID `bson:"ledger_id,omitempty" json:"ledgerId,omitempty"`
+	Name       string             `bson:"name" json:"name"`
+	Priority   int                `bson:"priority" json:"priority"`
+	CategoryID primitive.ObjectID `bson:"category_id" json:"categoryId"`
+
+	// NamePattern is a regular expression matched against the expense name,
+	// ignoring case.
+	NamePattern string `bson:"name_pattern,omitempty" json:"namePattern,omitempty"`
+	// Merchant matches expenses whose merchant contains it, ignoring case.
+	Merchant string `bson:"merchant,omitempty" json:"merchant,omitempty"`
+	// MinAmount and MaxAmount bound the amount, inclusive, in minor units of
+	// the expense's currency.
+	MinAmount *money.Amount `bson:"min_amount,omitempty" json:"minAmount,omitempty"`
+	MaxAmount *money.Amount `bson:"max_amount,omitempty" json:"maxAmount,omitempty"`
+	// Weekdays are the days of the week the expense may fall on, 0 being
+	// Sunday.
+	Weekdays []time.Weekday `bson:"weekdays,omitempty" json:"weekdays,omitempty"`
+
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updatedAt"`
+}
+
+// CategoryRuleDryRun lists the existing expenses a rule would move to
+// another category.
+type CategoryRuleDryRun struct {
+	Changes []CategoryChange `json:"changes"`
+	// Unchanged counts the expenses the rule matches that are already in
+	// its category.
+	Unchanged int `json:"unchanged"`
+}
+
+// CategoryChange is an expense, with its current category, and the category
+// it would move to.
+type CategoryChange struct {
+	Expense    Expense            `json:"expense"`
+	CategoryID primitive.ObjectID `json:"categoryId"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExchangeRate says that from Date on, one unit of From is worth Rate units
+// of To. It stays in effect until a later rate for the same pair.
+type ExchangeRate struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	LedgerID primitive.ObjectID `bson:"ledger_id,omitempty" json:"ledgerId,omitempty"`
+	From     money.Currency     `bson:"from" json:"from"`
+	To       money.Currency     `bson:"to" json:"to"`
+	Rate     money.Rate         `bson:"rate" json:"rate"`
+	Date     time.Time          `bson:"date" json:"date"`
+}
@@ -3,18 +3,38 @@ package models
 import (
 	"time"
 
+	"github.com/dhruwanga19/expense-tracker/money"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Expense struct {
 	ID         primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	LedgerID   primitive.ObjectID `bson:"ledger_id,omitempty" json:"ledgerId,omitempty"`
 	Name       string             `bson:"name" json:"name"`
-	Amount     float64            `bson:"amount" json:"amount"`
+	Amount     money.Amount       `bson:"amount" json:"amount"` // minor units of Currency
+	Currency   money.Currency     `bson:"currency" json:"currency"`
 	Date       time.Time          `bson:"date" json:"date"`
 	CategoryID primitive.ObjectID `bson:"category_id" json:"categoryId"`
 	Category   *Category          `bson:"category,omitempty" json:"category,omitempty"`
+	// CategoryConfidence is how sure the classifier is of CategoryID, from 0
+	// to 1, on the expenses of a bill whose category it suggested.
+	CategoryConfidence float64 `bson:"category_confidence,omitempty" json:"categoryConfidence,omitempty"`
+	// Merchant and PaymentMethod are where and how the expense was paid,
+	// when known.
+	Merchant      string `bson:"merchant,omitempty" json:"merchant,omitempty"`
+	PaymentMethod string `bson:"payment_method,omitempty" json:"paymentMethod,omitempty"`
+	// BillID is the bill the expense was confirmed from, if any. It is set
+	// when the expense is created and kept by updates.
+	BillID primitive.ObjectID `bson:"bill_id,omitempty" json:"billId,omitempty"`
 }
 
 type DeleteExpensesRequest struct {
 	IDs []string `json:"ids"`
 }
+
+// ExpensePage is one page of an expense listing. NextCursor is empty on the
+// last page.
+type ExpensePage struct {
+	Expenses   []Expense `json:"expenses"`
+	NextCursor string    `json:"nextCursor,omitempty"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Role is what a member may do in a ledger.
+type Role string
+
+const (
+	// RoleOwner can do everything, including managing members.
+	RoleOwner Role = "owner"
+	// RoleEditor can read and change the ledger's data.
+	RoleEditor Role = "editor"
+	// RoleViewer can only read the ledger's data.
+	RoleViewer Role = "viewer"
+)
+
+func (r Role) Valid() bool {
+	return r == RoleOwner || r == RoleEditor || r == RoleViewer
+}
+
+// Ledger is a book of expenses, categories, bills and budget goals shared by
+// its members. Every user has a personal ledger with the same ID as the user.
+type Ledger struct {
+	ID   primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	Name string             `bson:"name" json:"name"`
+	// BaseCurrency is what totals are converted to. Empty means the
+	// server's default.
+	BaseCurrency money.Currency `bson:"base_currency,omitempty" json:"baseCurrency,omitempty"`
+	CreatedAt    time.Time      `bson:"created_at" json:"createdAt"`
+	Members      []LedgerMember `bson:"members" json:"members"`
+}
+
+// Role returns the role of userID in the ledger, and false when they are
+// not a member.
+func (l *Ledger) Role(userID primitive.ObjectID) (Role, bool) {
+	for _, m := range l.Members {
+		if m.UserID == userID {
+			return m.Role, true
+		}
+	}
+	return "", false
+}
+
+type LedgerMember struct {
+	UserID primitive.ObjectID `bson:"user_id" json:"userId"`
+	Role   Role               `bson:"role" json:"role"`
+	// Email is filled in when members are listed; it is not stored.
+	Email string `bson:"-" json:"email,omitempty"`
+}
+
+// MemberInvite adds the account registered with Email to a ledger.
+type MemberInvite struct {
+	Email string `json:"email"`
+	Role  Role   `json:"role"`
+}
+
+// LedgerSettings are the ledger fields its owners may change.
+type LedgerSettings struct {
+	Name         string         `json:"name"`
+	BaseCurrency money.Currency `json:"baseCurrency"`
+}
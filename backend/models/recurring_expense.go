@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/period"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Frequency is the unit a recurring expense repeats in.
+type Frequency string
+
+const (
+	Daily   Frequency = "daily"
+	Weekly  Frequency = "weekly"
+	Monthly Frequency = "monthly"
+	Yearly  Frequency = "yearly"
+)
+
+func (f Frequency) Valid() bool {
+	return f == Daily || f == Weekly || f == Monthly || f == Yearly
+}
+
+// Unit returns the period unit f steps in.
+func (f Frequency) Unit() period.Unit {
+	switch f {
+	case Daily:
+		return period.Day
+	case Weekly:
+		return period.Week
+	case Yearly:
+		return period.Year
+	default:
+		return period.Month
+	}
+}
+
+// RecurringExpense is a template for an expense that repeats every Interval
+// days, weeks, months or years from StartDate until EndDate (inclusive, if
+// set). The scheduler turns each occurrence into an expense once it is due.
+type RecurringExpense struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"_id,omitempty"`
+	LedgerID   primitive.ObjectID `bson:"ledger_id,omitempty" json:"ledgerId,omitempty"`
+	Name       string             `bson:"name" json:"name"`
+	Amount     money.Amount       `bson:"amount" json:"amount"` // minor units of Currency
+	Currency   money.Currency     `bson:"currency" json:"currency"`
+	CategoryID primitive.ObjectID `bson:"category_id" json:"categoryId"`
+
+	Frequency Frequency  `bson:"frequency" json:"frequency"`
+	Interval  int        `bson:"interval" json:"interval"`
+	StartDate time.Time  `bson:"start_date" json:"startDate"`
+	EndDate   *time.Time `bson:"end_date,omitempty" json:"endDate,omitempty"`
+
+	// NextIndex is the number of the next occurrence to materialise, counted
+	// from StartDate, and NextDate its date. Done is set once the next
+	// occurrence would fall after EndDate.
+	NextIndex int       `bson:"next_index" json:"nextIndex"`
+	NextDate  time.Time `bson:"next_date" json:"nextDate"`
+	Done      bool      `bson:"done" json:"done"`
+
+	CreatedAt time.Time `bson:"created_at" json:"createdAt"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updatedAt"`
+}
+
+// Occurrence returns the date of occurrence n (0 is StartDate); see
+// period.Occurrence.
+func (r *RecurringExpense) Occurrence(n int) time.Time {
+	return period.Occurrence(r.StartDate, r.Frequency.Unit(), r.Interval, n)
+}
+
+// After reports whether t falls after the end of the schedule.
+func (r *RecurringExpense) After(t time.Time) bool {
+	return r.EndDate != nil && t.After(*r.EndDate)
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"github.com/dhruwanga19/expense-tracker/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ExpenseSummary totals a set of expenses. Total is in BaseCurrency, each
+// expense converted at the rate in effect on its date; Original has the
+// unconverted sums, one per currency.
+type ExpenseSummary struct {
+	BaseCurrency money.Currency  `json:"baseCurrency"`
+	Count        int             `json:"count"`
+	Total        money.Amount    `json:"total"`
+	Original     []money.Money   `json:"original"`
+	Categories   []CategoryTotal `json:"categories"`
+}
+
+// CategoryTotal is the part of an ExpenseSummary spent in one category.
+type CategoryTotal struct {
+	CategoryID primitive.ObjectID `json:"categoryId"`
+	Name       string             `json:"name"`
+	Color      string             `json:"color"`
+	Count      int                `json:"count"`
+	Total      money.Amount       `json:"total"`
+	Original   []money.Money      `json:"original"`
+}
@@ -0,0 +1,83 @@
+// Package money represents amounts of money exactly, as an integer number
+// of minor units (cents for USD) of an ISO 4217 currency.
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Amount is a number of minor units of some currency. It is encoded as
+// that integer in JSON and in every store, so sums never drift.
+type Amount int64
+
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// ParseAmount reads a decimal string such as "12.34", "-3" or "1,299.00"
+// as an amount of currency c. It never goes through a float, and rejects
+// more decimals than the currency has.
+func ParseAmount(s string, c Currency) (Amount, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), ",", "")
+	neg := false
+	switch {
+	case strings.HasPrefix(s, "-"):
+		neg = true
+		s = s[1:]
+	case strings.HasPrefix(s, "+"):
+		s = s[1:]
+	}
+
+	whole, frac, _ := strings.Cut(s, ".")
+	exp := c.Exponent()
+	if whole == "" && frac == "" || len(frac) > exp || !digits(whole) || !digits(frac) {
+		return 0, fmt.Errorf("%w %q for %s", ErrInvalidAmount, s, c)
+	}
+	frac += strings.Repeat("0", exp-len(frac))
+
+	n, err := strconv.ParseInt("0"+whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w %q: %v", ErrInvalidAmount, s, err)
+	}
+	if neg {
+		n = -n
+	}
+	return Amount(n), nil
+}
+
+func digits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// FromFloat rounds a float amount of currency c to minor units. It is only
+// meant for converting amounts stored as floats before this package existed.
+func FromFloat(f float64, c Currency) Amount {
+	return Amount(math.Round(f * math.Pow10(c.Exponent())))
+}
+
+// Format writes the amount as a decimal string in currency c, such as
+// "12.34" for 1234 USD or "1234" for 1234 JPY.
+func (a Amount) Format(c Currency) string {
+	exp := c.Exponent()
+	n := int64(a)
+	sign := ""
+	if n < 0 {
+		sign = "-"
+		n = -n
+	}
+	s := strconv.FormatInt(n, 10)
+	if exp == 0 {
+		return sign + s
+	}
+	if len(s) <= exp {
+		s = strings.Repeat("0", exp-len(s)+1) + s
+	}
+	return sign + s[:len(s)-exp] + "." + s[len(s)-exp:]
+}
@@ -0,0 +1,128 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		in   string
+		c    Currency
+		want Amount
+	}{
+		{"12.34", "USD", 1234},
+		{"12.3", "USD", 1230},
+		{"12", "USD", 1200},
+		{".5", "USD", 50},
+		{"-3.05", "EUR", -305},
+		{"1,299.00", "USD", 129900},
+		{"1500", "JPY", 1500},
+		{"1.234", "KWD", 1234},
+		{"0.1", "USD", 10},
+	}
+	for _, tt := range tests {
+		got, err := ParseAmount(tt.in, tt.c)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseAmount(%q, %s) = %d, %v, want %d", tt.in, tt.c, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "abc", "1.234", "1.2.3", "1e3", "-", "."} {
+		if _, err := ParseAmount(in, "USD"); !errors.Is(err, ErrInvalidAmount) {
+			t.Errorf("ParseAmount(%q) error = %v, want ErrInvalidAmount", in, err)
+		}
+	}
+	if _, err := ParseAmount("1.5", "JPY"); err == nil {
+		t.Error("ParseAmount accepted decimals for JPY")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		a    Amount
+		c    Currency
+		want string
+	}{
+		{1234, "USD", "12.34"},
+		{5, "USD", "0.05"},
+		{-305, "EUR", "-3.05"},
+		{1500, "JPY", "1500"},
+		{1234, "KWD", "1.234"},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Format(tt.c); got != tt.want {
+			t.Errorf("%d.Format(%s) = %q, want %q", tt.a, tt.c, got, tt.want)
+		}
+	}
+}
+
+func TestFromFloat(t *testing.T) {
+	// 0.1 + 0.2 is 0.30000000000000004 as a float.
+	if got := FromFloat(0.1+0.2, "USD"); got != 30 {
+		t.Errorf("FromFloat(0.1+0.2) = %d, want 30", got)
+	}
+	if got := FromFloat(4.35, "USD"); got != 435 {
+		t.Errorf("FromFloat(4.35) = %d, want 435", got)
+	}
+}
+
+func TestParseCurrency(t *testing.T) {
+	if c, err := ParseCurrency(" eur "); err != nil || c != "EUR" {
+		t.Errorf("ParseCurrency(eur) = %q, %v", c, err)
+	}
+	if c, err := ParseCurrency(""); err != nil || c != DefaultCurrency {
+		t.Errorf("ParseCurrency(\"\") = %q, %v", c, err)
+	}
+	if _, err := ParseCurrency("XYZ"); err != ErrUnknownCurrency {
+		t.Errorf("ParseCurrency(XYZ) error = %v", err)
+	}
+}
+
+func TestRate(t *testing.T) {
+	r, err := ParseRate("1.0842")
+	if err != nil || r != 108420000 || r.String() != "1.0842" {
+		t.Fatalf("ParseRate(1.0842) = %d (%s), %v", r, r, err)
+	}
+	for _, in := range []string{"", "0", "-1", "1.123456789", "abc"} {
+		if _, err := ParseRate(in); !errors.Is(err, ErrInvalidRate) {
+			t.Errorf("ParseRate(%q) error = %v, want ErrInvalidRate", in, err)
+		}
+	}
+
+	tests := []struct {
+		a        Amount
+		from, to Currency
+		rate     string
+		want     Amount
+	}{
+		{1000, "EUR", "USD", "1.0842", 1084}, // 10.00 EUR = 10.842 USD
+		{1005, "EUR", "USD", "1.1", 1106},    // 11.055 rounds up
+		{-1005, "EUR", "USD", "1.1", -1106},  // and away from zero
+		{1500, "JPY", "USD", "0.0067", 1005}, // 1500 JPY = 10.05 USD
+		{1000, "USD", "KWD", "0.307", 3070},  // 10.00 USD = 3.070 KWD
+		{1234, "GBP", "GBP", "1", 1234},
+	}
+	for _, tt := range tests {
+		rate, _ := ParseRate(tt.rate)
+		if got := rate.Convert(tt.a, tt.from, tt.to); got != tt.want {
+			t.Errorf("%s.Convert(%d %s to %s) = %d, want %d", tt.rate, tt.a, tt.from, tt.to, got, tt.want)
+		}
+	}
+
+	rate, _ := ParseRate("1.25")
+	if got := rate.ConvertBack(1000, "GBP", "USD"); got != 800 {
+		t.Errorf("ConvertBack(10.00 USD to GBP at 1.25) = %d, want 800", got)
+	}
+
+	var decoded struct{ Rate Rate }
+	for _, in := range []string{`{"Rate": 1.25}`, `{"Rate": "1.25"}`} {
+		if err := json.Unmarshal([]byte(in), &decoded); err != nil || decoded.Rate != rate {
+			t.Errorf("unmarshal %s = %d, %v", in, decoded.Rate, err)
+		}
+	}
+	if data, _ := json.Marshal(decoded); string(data) != `{"Rate":1.25}` {
+		t.Errorf("marshal = %s", data)
+	}
+}
@@ -0,0 +1,110 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// RateDigits is the number of decimals an exchange rate is kept to.
+const RateDigits = 8
+
+const rateScale = 100_000_000 // 10^RateDigits
+
+// Rate is an exchange rate: how many units of one currency buy a unit of
+// another, as a fixed-point number with RateDigits decimals. It is encoded
+// as a decimal number in JSON and as the scaled integer in the stores.
+type Rate int64
+
+var ErrInvalidRate = errors.New("exchange rate must be a positive decimal number")
+
+// ParseRate reads a positive decimal string such as "1.0842".
+func ParseRate(s string) (Rate, error) {
+	s = strings.TrimSpace(s)
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" || len(frac) > RateDigits || !digits(whole) || !digits(frac) {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidRate, s)
+	}
+	frac += strings.Repeat("0", RateDigits-len(frac))
+	n, err := strconv.ParseInt("0"+whole+frac, 10, 64)
+	if err != nil || n == 0 {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidRate, s)
+	}
+	return Rate(n), nil
+}
+
+func (r Rate) String() string {
+	s := strconv.FormatInt(int64(r), 10)
+	if len(s) <= RateDigits {
+		s = strings.Repeat("0", RateDigits-len(s)+1) + s
+	}
+	s = strings.TrimRight(s[:len(s)-RateDigits]+"."+s[len(s)-RateDigits:], "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+func (r Rate) MarshalJSON() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalJSON accepts the rate as a JSON number or string.
+func (r *Rate) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n json.Number
+		if err := json.Unmarshal(data, &n); err != nil {
+			return ErrInvalidRate
+		}
+		s = n.String()
+	}
+	rate, err := ParseRate(s)
+	if err != nil {
+		return err
+	}
+	*r = rate
+	return nil
+}
+
+// Convert turns a, an amount of currency from, into currency to, where r is
+// the price of one unit of from in units of to. The result is rounded to
+// the nearest minor unit of to, halves away from zero.
+func (r Rate) Convert(a Amount, from, to Currency) Amount {
+	num := new(big.Int).Mul(big.NewInt(int64(a)), big.NewInt(int64(r)))
+	num.Mul(num, pow10(to.Exponent()))
+	den := new(big.Int).Mul(big.NewInt(rateScale), pow10(from.Exponent()))
+	return divRound(num, den)
+}
+
+// ConvertBack is the inverse of Convert: it turns a, an amount of currency
+// to, into currency from.
+func (r Rate) ConvertBack(a Amount, from, to Currency) Amount {
+	num := new(big.Int).Mul(big.NewInt(int64(a)), big.NewInt(rateScale))
+	num.Mul(num, pow10(from.Exponent()))
+	den := new(big.Int).Mul(big.NewInt(int64(r)), pow10(to.Exponent()))
+	return divRound(num, den)
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// divRound divides num by the positive den, rounding halves away from zero.
+func divRound(num, den *big.Int) Amount {
+	q, m := new(big.Int).QuoRem(num, den, new(big.Int))
+	if m.Abs(m).Lsh(m, 1).Cmp(den) >= 0 {
+		if num.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return Amount(q.Int64())
+}
+
+// Money is an amount together with its currency.
+type Money struct {
+	Amount   Amount   `bson:"amount" json:"amount"`
+	Currency Currency `bson:"currency" json:"currency"`
+}
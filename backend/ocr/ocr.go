@@ -0,0 +1,17 @@
+// Package ocr turns images of receipts into text. Engine is implemented by
+// Google Cloud Vision, a local Tesseract binary and a deterministic fixture
+// for tests.
+package ocr
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoText is returned when an image contains no readable text.
+var ErrNoText = errors.New("no text detected in the image")
+
+type Engine interface {
+	// DetectText returns the text in image, the contents of an image file.
+	DetectText(ctx context.Context, image []byte) (string, error)
+}
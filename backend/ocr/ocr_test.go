@@ -0,0 +1,38 @@
+package ocr
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestFixture(t *testing.T) {
+	ctx := context.Background()
+	f := NewFixture()
+	image := []byte{0xff, 0xd8, 0xff, 0xe0}
+	if _, err := f.DetectText(ctx, image); !errors.Is(err, ErrNoText) {
+		t.Errorf("unknown binary image: got %v, want ErrNoText", err)
+	}
+
+	f.Add(image, "MILK\n$4.50")
+	if text, err := f.DetectText(ctx, image); err != nil || text != "MILK\n$4.50" {
+		t.Errorf("added image: got %q, %v", text, err)
+	}
+	if text, err := f.DetectText(ctx, []byte("EGGS\n$3.00")); err != nil || text != "EGGS\n$3.00" {
+		t.Errorf("text upload: got %q, %v", text, err)
+	}
+}
+
+func TestTesseract(t *testing.T) {
+	if _, err := NewTesseract("tesseract-that-does-not-exist", "eng"); err == nil {
+		t.Error("missing binary: want an error")
+	}
+
+	engine, err := NewTesseract("tesseract", "eng")
+	if err != nil {
+		t.Skip("tesseract is not installed")
+	}
+	if _, err := engine.DetectText(context.Background(), []byte("not an image")); err == nil {
+		t.Error("reading a non-image: want an error")
+	}
+}
@@ -0,0 +1,48 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Tesseract runs a locally installed tesseract binary, so receipts can be
+// read without sending them anywhere.
+type Tesseract struct {
+	path     string
+	language string
+}
+
+// NewTesseract finds the tesseract binary, either on the PATH or at path,
+// and reads text in language (e.g. "eng", or "eng+fra").
+func NewTesseract(path, language string) (*Tesseract, error) {
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("tesseract is not installed: %w", err)
+	}
+	return &Tesseract{path: resolved, language: language}, nil
+}
+
+func (t *Tesseract) DetectText(ctx context.Context, image []byte) (string, error) {
+	// "stdin" and "stdout" make tesseract read the image from standard
+	// input and write the text to standard output.
+	cmd := exec.CommandContext(ctx, t.path, "stdin", "stdout", "-l", t.language)
+	cmd.Stdin = bytes.NewReader(image)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("tesseract failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	text := strings.TrimSpace(stdout.String())
+	if text == "" {
+		return "", ErrNoText
+	}
+	return text, nil
+}
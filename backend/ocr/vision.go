@@ -0,0 +1,43 @@
+package ocr
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	vision "cloud.google.com/go/vision/apiv1"
+)
+
+// Vision uses Google Cloud Vision document text detection. It needs
+// application default credentials, e.g. GOOGLE_APPLICATION_CREDENTIALS.
+type Vision struct {
+	client *vision.ImageAnnotatorClient
+}
+
+func NewVision(ctx context.Context) (*Vision, error) {
+	client, err := vision.NewImageAnnotatorClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Vision{client: client}, nil
+}
+
+func (v *Vision) DetectText(ctx context.Context, image []byte) (string, error) {
+	img, err := vision.NewImageFromReader(bytes.NewReader(image))
+	if err != nil {
+		return "", fmt.Errorf("failed to create image: %w", err)
+	}
+
+	annotations, err := v.client.DetectDocumentText(ctx, img, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to detect document text: %w", err)
+	}
+	if annotations == nil || annotations.Text == "" {
+		return "", ErrNoText
+	}
+	return annotations.Text, nil
+}
+
+func (v *Vision) Close() error {
+	return v.client.Close()
+}
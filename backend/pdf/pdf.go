@@ -0,0 +1,87 @@
+// Package pdf reads bills and invoices uploaded as PDF documents: the text
+// layer of pages that have one, and page images for OCR of scanned pages
+// that do not.
+package pdf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// ErrInvalid is returned for documents that cannot be read as PDF.
+var ErrInvalid = errors.New("not a valid PDF document")
+
+// IsPDF reports whether content is a PDF document.
+func IsPDF(content []byte) bool {
+	return bytes.HasPrefix(content, []byte("%PDF-"))
+}
+
+// PageText returns the text layer of every page of document, in page order
+// and laid out in lines as printed. A page without a text layer, such as a
+// scanned one, is "".
+func PageText(document []byte) (pages []string, err error) {
+	// The reader panics on some malformed documents.
+	defer func() {
+		if r := recover(); r != nil {
+			pages, err = nil, fmt.Errorf("%w: %v", ErrInvalid, r)
+		}
+	}()
+
+	r, err := pdf.NewReader(bytes.NewReader(document), int64(len(document)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	pages = make([]string, r.NumPage())
+	for i := range pages {
+		page := r.Page(i + 1)
+		if page.V.IsNull() {
+			continue
+		}
+		pages[i] = layout(page.Content().Text)
+	}
+	return pages, nil
+}
+
+// layout arranges the glyphs of a page into lines of text, top to bottom
+// and left to right. Glyphs are on the same line when their baselines are
+// less than half a font size apart, and words are split where the gap
+// between glyphs is wider than a fifth of the font size.
+func layout(glyphs []pdf.Text) string {
+	var lines [][]pdf.Text
+	sorted := make([]pdf.Text, 0, len(glyphs))
+	for _, g := range glyphs {
+		if strings.TrimSpace(g.S) != "" {
+			sorted = append(sorted, g)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Y > sorted[j].Y })
+	for _, g := range sorted {
+		if n := len(lines); n > 0 && math.Abs(lines[n-1][0].Y-g.Y) < math.Max(g.FontSize, 1)/2 {
+			lines[n-1] = append(lines[n-1], g)
+			continue
+		}
+		lines = append(lines, []pdf.Text{g})
+	}
+
+	var b strings.Builder
+	for _, line := range lines {
+		sort.SliceStable(line, func(i, j int) bool { return line[i].X < line[j].X })
+		for i, g := range line {
+			if i > 0 {
+				prev := line[i-1]
+				if g.X-(prev.X+prev.W) > math.Max(g.FontSize, 1)/5 {
+					b.WriteByte(' ')
+				}
+			}
+			b.WriteString(g.S)
+		}
+		b.WriteByte('\n')
+	}
+	return strings.TrimSpace(b.String())
+}
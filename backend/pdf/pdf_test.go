@@ -0,0 +1,71 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image/png"
+	"os"
+	"testing"
+)
+
+func readTestdata(t *testing.T) []byte {
+	t.Helper()
+	document, err := os.ReadFile("testdata/text_and_scan.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return document
+}
+
+func TestPageText(t *testing.T) {
+	document := readTestdata(t)
+	if !IsPDF(document) {
+		t.Fatal("IsPDF = false")
+	}
+
+	pages, err := PageText(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The second page has no text layer.
+	want := []string{"Corner Grocer\n05/03/2024 14:32\nBREAD 2.25\nMILK 2% 4.50", ""}
+	if len(pages) != len(want) {
+		t.Fatalf("got %d pages, want %d", len(pages), len(want))
+	}
+	for i := range want {
+		if pages[i] != want[i] {
+			t.Errorf("page %d = %q, want %q", i+1, pages[i], want[i])
+		}
+	}
+}
+
+func TestPageTextRejectsOtherFiles(t *testing.T) {
+	for name, content := range map[string][]byte{
+		"text":      []byte("MILK 4.50"),
+		"truncated": readTestdata(t)[:200],
+	} {
+		if _, err := PageText(content); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: got %v, want ErrInvalid", name, err)
+		}
+	}
+}
+
+func TestPdftoppm(t *testing.T) {
+	p, err := NewPdftoppm("pdftoppm", 72)
+	if err != nil {
+		t.Skip(err)
+	}
+	image, err := p.Rasterize(context.Background(), readTestdata(t), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(image))
+	if err != nil {
+		t.Fatalf("page is not a PNG: %v", err)
+	}
+	// A letter-sized page at 72 dpi.
+	if b := img.Bounds(); b.Dx() != 612 || b.Dy() != 792 {
+		t.Errorf("page rendered at %v", b.Size())
+	}
+}
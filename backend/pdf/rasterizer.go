@@ -0,0 +1,63 @@
+package pdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Rasterizer renders pages of PDF documents as images, for OCR of pages
+// without a text layer.
+type Rasterizer interface {
+	// Rasterize returns page, counted from 1, of document as a PNG image.
+	Rasterize(ctx context.Context, document []byte, page int) ([]byte, error)
+}
+
+// Pdftoppm renders pages with a locally installed pdftoppm binary, part of
+// poppler-utils.
+type Pdftoppm struct {
+	path string
+	dpi  int
+}
+
+// NewPdftoppm finds the pdftoppm binary, either on the PATH or at path, and
+// renders pages at dpi dots per inch.
+func NewPdftoppm(path string, dpi int) (*Pdftoppm, error) {
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("pdftoppm is not installed: %w", err)
+	}
+	return &Pdftoppm{path: resolved, dpi: dpi}, nil
+}
+
+func (p *Pdftoppm) Rasterize(ctx context.Context, document []byte, page int) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "bill-pdf-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in := filepath.Join(dir, "bill.pdf")
+	if err := os.WriteFile(in, document, 0o600); err != nil {
+		return nil, err
+	}
+	// With -singlefile, pdftoppm writes the page to page.png rather than
+	// numbering the file.
+	n := strconv.Itoa(page)
+	out := filepath.Join(dir, "page")
+	cmd := exec.CommandContext(ctx, p.path, "-r", strconv.Itoa(p.dpi), "-png", "-f", n, "-l", n, "-singlefile", in, out)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("pdftoppm failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return os.ReadFile(out + ".png")
+}
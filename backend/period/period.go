@@ -0,0 +1,262 @@
+// Package period does the calendar arithmetic shared by budgets, summaries
+// and recurring expenses: the periods budgets are tracked over, and the
+// dates schedules repeat on. Periods are made of whole UTC days, like
+// exchange rates.
+package period
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+var ErrInvalid = errors.New("invalid period")
+
+// Kind is how a period repeats.
+type Kind string
+
+const (
+	Weekly    Kind = "weekly"
+	Monthly   Kind = "monthly"
+	Quarterly Kind = "quarterly"
+	Yearly    Kind = "yearly"
+	// Custom periods are a single range of days.
+	Custom Kind = "custom"
+)
+
+// Period is a validated period, written as a string:
+//
+//	weekly[:<weekday>]          weeks starting on weekday, Sunday by default
+//	monthly[:<day>]             months starting on day, the 1st by default
+//	quarterly[:<MM-DD>]         quarters starting on that date, January 1st by default
+//	yearly[:<MM-DD>]            years starting on that date, January 1st by default
+//	custom:<YYYY-MM-DD>..<YYYY-MM-DD>  the days from the first to the last date
+//
+// Start days past the end of a shorter month fall on its last day, so a
+// month starting on the 31st starts on the 28th or 29th in February. The
+// zero Period is unset and has no window.
+type Period struct {
+	Kind Kind
+	// WeekStart is the first day of weekly periods.
+	WeekStart time.Weekday
+	// Month and Day are the start of quarterly and yearly periods; monthly
+	// periods only use Day. Quarterly periods keep Month in 1 to 3.
+	Month time.Month
+	Day   int
+	// From and To are the first and last days of a custom period.
+	From, To time.Time
+	// Invalid is a stored period Parse rejects, such as one saved before
+	// periods were checked. It is kept, so that what it belongs to still
+	// loads, but has no window until it is replaced.
+	Invalid string
+}
+
+// Parse reads a period written as described on Period. The empty string is
+// the zero Period.
+func Parse(s string) (Period, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return Period{}, nil
+	}
+	kind, arg, hasArg := strings.Cut(s, ":")
+	p := Period{Kind: Kind(kind)}
+	var err error
+	switch p.Kind {
+	case Weekly:
+		if hasArg {
+			p.WeekStart, err = parseWeekday(arg)
+		}
+	case Monthly:
+		p.Day = 1
+		if hasArg {
+			p.Day, err = strconv.Atoi(arg)
+		}
+		if err == nil && (p.Day < 1 || p.Day > 31) {
+			err = errors.New("days go from 1 to 31")
+		}
+	case Quarterly, Yearly:
+		p.Month, p.Day = time.January, 1
+		if hasArg {
+			var t time.Time
+			t, err = time.Parse("01-02", arg)
+			p.Month, p.Day = t.Month(), t.Day()
+		}
+		if p.Kind == Quarterly {
+			p.Month = (p.Month-1)%3 + 1
+		}
+	case Custom:
+		from, to, ok := strings.Cut(arg, "..")
+		if !ok {
+			err = errors.New("custom periods are written custom:YYYY-MM-DD..YYYY-MM-DD")
+		} else if p.From, err = time.Parse(time.DateOnly, from); err == nil {
+			p.To, err = time.Parse(time.DateOnly, to)
+		}
+		if err == nil && p.To.Before(p.From) {
+			err = errors.New("it ends before it starts")
+		}
+	default:
+		err = errors.New("use weekly, monthly, quarterly, yearly or custom")
+	}
+	if err != nil {
+		return Period{}, fmt.Errorf("%w %q: %v", ErrInvalid, s, err)
+	}
+	return p, nil
+}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		name := strings.ToLower(d.String())
+		if s == name || s == name[:3] {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown weekday %q", s)
+}
+
+// String writes p the way Parse reads it, leaving out default anchors.
+func (p Period) String() string {
+	if p.Invalid != "" {
+		return p.Invalid
+	}
+	s := string(p.Kind)
+	switch p.Kind {
+	case Weekly:
+		if p.WeekStart != time.Sunday {
+			s += ":" + strings.ToLower(p.WeekStart.String())
+		}
+	case Monthly:
+		if p.Day != 1 {
+			s += ":" + strconv.Itoa(p.Day)
+		}
+	case Quarterly, Yearly:
+		if p.Month != time.January || p.Day != 1 {
+			s += fmt.Sprintf(":%02d-%02d", int(p.Month), p.Day)
+		}
+	case Custom:
+		s += ":" + p.From.Format(time.DateOnly) + ".." + p.To.Format(time.DateOnly)
+	}
+	return s
+}
+
+func (p Period) IsZero() bool {
+	return p.Kind == "" && p.Invalid == ""
+}
+
+// Valid reports whether p is set and has windows.
+func (p Period) Valid() bool {
+	return p.Kind != ""
+}
+
+// Window returns the start (inclusive) and end (exclusive) of the period
+// containing the day of t. A custom period has a single window, whatever t.
+func (p Period) Window(t time.Time) (time.Time, time.Time, error) {
+	day := StartOfDay(t)
+	switch p.Kind {
+	case Weekly:
+		from := day.AddDate(0, 0, -(int(day.Weekday())-int(p.WeekStart)+7)%7)
+		return from, from.AddDate(0, 0, 7), nil
+	case Monthly:
+		from, to := monthWindow(day, 0, p.Day, 1)
+		return from, to, nil
+	case Quarterly:
+		from, to := monthWindow(day, int(p.Month)-1, p.Day, 3)
+		return from, to, nil
+	case Yearly:
+		from, to := monthWindow(day, int(p.Month)-1, p.Day, 12)
+		return from, to, nil
+	case Custom:
+		return p.From, p.To.AddDate(0, 0, 1), nil
+	}
+	return time.Time{}, time.Time{}, fmt.Errorf("%w %q", ErrInvalid, p.String())
+}
+
+// monthWindow returns the window of step months starting on the given day
+// of the months offset, offset+step, ... (counting January as 0) that
+// contains day.
+func monthWindow(day time.Time, offset, startDay, step int) (time.Time, time.Time) {
+	months := day.Year()*12 + int(day.Month()) - 1 - offset
+	start := offset + months - months%step
+	from := dayOfMonth(start, startDay)
+	if from.After(day) {
+		start -= step
+		from = dayOfMonth(start, startDay)
+	}
+	return from, dayOfMonth(start+step, startDay)
+}
+
+// dayOfMonth returns the given day of the month months months after
+// January of year 0, or the month's last day if it is shorter.
+func dayOfMonth(months, day int) time.Time {
+	first := time.Date(months/12, time.Month(months%12+1), 1, 0, 0, 0, 0, time.UTC)
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+func (p Period) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *Period) UnmarshalText(text []byte) error {
+	parsed, err := Parse(string(text))
+	if err != nil {
+		return err
+	}
+	*p = parsed
+	return nil
+}
+
+// MarshalBSONValue stores p as its string, like in JSON and SQL.
+func (p Period) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	return bson.MarshalValue(p.String())
+}
+
+func (p *Period) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	var s string
+	if err := bson.UnmarshalValue(t, data, &s); err != nil {
+		return err
+	}
+	p.decode(s)
+	return nil
+}
+
+func (p Period) Value() (driver.Value, error) {
+	return p.String(), nil
+}
+
+func (p *Period) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case string:
+		p.decode(v)
+		return nil
+	case []byte:
+		p.decode(string(v))
+		return nil
+	case nil:
+		*p = Period{}
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into a period", src)
+}
+
+// decode reads a stored period, keeping one Parse rejects as Invalid.
+func (p *Period) decode(s string) {
+	parsed, err := Parse(s)
+	if err != nil {
+		parsed = Period{Invalid: s}
+	}
+	*p = parsed
+}
+
+// StartOfDay returns midnight UTC of the day of t.
+func StartOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,133 @@
+package period
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func day(year int, month time.Month, d int) time.Time {
+	return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"weekly", "weekly"},
+		{"Weekly:Sunday", "weekly"},
+		{"weekly:mon", "weekly:monday"},
+		{"monthly:1", "monthly"},
+		{"monthly:15", "monthly:15"},
+		{"quarterly:05-15", "quarterly:02-15"},
+		{"yearly:04-06", "yearly:04-06"},
+		{"custom:2024-03-01..2024-03-20", "custom:2024-03-01..2024-03-20"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		p, err := Parse(tt.in)
+		if err != nil || p.String() != tt.want {
+			t.Errorf("Parse(%q) = %q, %v, want %q", tt.in, p, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"fortnightly", "weekly:someday", "monthly:0", "monthly:32", "monthly:x", "yearly:13-01",
+		"custom:2024-03-01", "custom:2024-03-20..2024-03-01"} {
+		if _, err := Parse(in); !errors.Is(err, ErrInvalid) {
+			t.Errorf("Parse(%q) error = %v, want ErrInvalid", in, err)
+		}
+	}
+}
+
+func TestWindow(t *testing.T) {
+	tests := []struct {
+		period   string
+		at       time.Time
+		from, to time.Time
+	}{
+		// Tuesday 5 March 2024.
+		{"weekly", time.Date(2024, 3, 5, 23, 0, 0, 0, time.UTC), day(2024, 3, 3), day(2024, 3, 10)},
+		{"weekly:monday", day(2024, 3, 5), day(2024, 3, 4), day(2024, 3, 11)},
+		{"weekly:wednesday", day(2024, 3, 5), day(2024, 2, 28), day(2024, 3, 6)},
+		{"monthly", day(2024, 3, 5), day(2024, 3, 1), day(2024, 4, 1)},
+		{"monthly:15", day(2024, 3, 5), day(2024, 2, 15), day(2024, 3, 15)},
+		{"monthly:15", day(2024, 3, 15), day(2024, 3, 15), day(2024, 4, 15)},
+		{"monthly:31", day(2024, 2, 29), day(2024, 2, 29), day(2024, 3, 31)},
+		{"monthly:31", day(2024, 2, 28), day(2024, 1, 31), day(2024, 2, 29)},
+		{"quarterly", day(2024, 5, 20), day(2024, 4, 1), day(2024, 7, 1)},
+		{"quarterly:02-15", day(2024, 2, 1), day(2023, 11, 15), day(2024, 2, 15)},
+		{"yearly", day(2024, 12, 31), day(2024, 1, 1), day(2025, 1, 1)},
+		{"yearly:04-06", day(2024, 3, 5), day(2023, 4, 6), day(2024, 4, 6)},
+		{"custom:2024-03-01..2024-03-20", day(2025, 1, 1), day(2024, 3, 1), day(2024, 3, 21)},
+	}
+	for _, tt := range tests {
+		p, err := Parse(tt.period)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.period, err)
+		}
+		// Times are taken in UTC whatever their zone.
+		from, to, err := p.Window(tt.at.In(time.FixedZone("UTC+14", 14*3600)))
+		if err != nil || !from.Equal(tt.from) || !to.Equal(tt.to) {
+			t.Errorf("%s at %s: %s to %s, %v, want %s to %s", tt.period, tt.at.Format(time.DateOnly),
+				from.Format(time.DateOnly), to.Format(time.DateOnly), err, tt.from.Format(time.DateOnly), tt.to.Format(time.DateOnly))
+		}
+	}
+
+	if _, _, err := (Period{}).Window(day(2024, 3, 5)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("window of the zero period: %v", err)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var goal struct {
+		Period Period `json:"period"`
+	}
+	if err := json.Unmarshal([]byte(`{"period": "monthly:15"}`), &goal); err != nil || goal.Period.Day != 15 {
+		t.Fatalf("unmarshal: %+v, %v", goal, err)
+	}
+	if data, _ := json.Marshal(goal); string(data) != `{"period":"monthly:15"}` {
+		t.Errorf("marshal: %s", data)
+	}
+	if err := json.Unmarshal([]byte(`{"period": "daily"}`), &goal); !errors.Is(err, ErrInvalid) {
+		t.Errorf("unmarshal an invalid period: %v", err)
+	}
+}
+
+func TestStored(t *testing.T) {
+	// Periods stored before they were checked still load, without windows.
+	var p Period
+	if err := p.Scan("fortnightly"); err != nil || p.Valid() || p.IsZero() || p.String() != "fortnightly" {
+		t.Fatalf("scan a legacy period: %+v, %v", p, err)
+	}
+	if _, _, err := p.Window(day(2024, 3, 1)); !errors.Is(err, ErrInvalid) {
+		t.Errorf("window of a legacy period: %v", err)
+	}
+	if v, _ := p.Value(); v != "fortnightly" {
+		t.Errorf("value of a legacy period: %v", v)
+	}
+	if err := p.Scan([]byte("Monthly")); err != nil || !p.Valid() || p.String() != "monthly" {
+		t.Errorf("scan a period: %+v, %v", p, err)
+	}
+}
+
+func TestOccurrence(t *testing.T) {
+	start := day(2024, 1, 31)
+	tests := []struct {
+		unit     Unit
+		interval int
+		n        int
+		want     time.Time
+	}{
+		{Day, 3, 2, day(2024, 2, 6)},
+		{Week, 2, 1, day(2024, 2, 14)},
+		{Month, 1, 1, day(2024, 2, 29)},
+		{Month, 1, 2, day(2024, 3, 31)},
+		{Year, 1, 1, day(2025, 1, 31)},
+	}
+	for _, tt := range tests {
+		if got := Occurrence(start, tt.unit, tt.interval, tt.n); !got.Equal(tt.want) {
+			t.Errorf("Occurrence(%v, every %d, %d) = %s, want %s", tt.unit, tt.interval, tt.n, got, tt.want)
+		}
+	}
+}
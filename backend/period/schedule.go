@@ -0,0 +1,43 @@
+package period
+
+import "time"
+
+// Unit is the step a schedule repeats in.
+type Unit int
+
+const (
+	Day Unit = iota
+	Week
+	Month
+	Year
+)
+
+// Occurrence returns the date of occurrence n (0 is start) of a schedule
+// repeating every interval units. Months and years are counted from start,
+// so a schedule starting on the 31st falls on the last day of shorter
+// months and returns to the 31st after.
+func Occurrence(start time.Time, unit Unit, interval, n int) time.Time {
+	step := n * interval
+	switch unit {
+	case Day:
+		return start.AddDate(0, 0, step)
+	case Week:
+		return start.AddDate(0, 0, 7*step)
+	case Year:
+		return AddMonths(start, 12*step)
+	default:
+		return AddMonths(start, step)
+	}
+}
+
+// AddMonths adds n months to t, keeping the day of month where the target
+// month has it and using the month's last day otherwise.
+func AddMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1)
+}
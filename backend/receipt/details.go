@@ -0,0 +1,230 @@
+package receipt
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Payment methods read from receipts.
+const (
+	Cash       = "cash"
+	Visa       = "visa"
+	Mastercard = "mastercard"
+	Amex       = "amex"
+	Discover   = "discover"
+	Debit      = "debit"
+	Credit     = "credit"
+	ApplePay   = "apple pay"
+	GooglePay  = "google pay"
+	GiftCard   = "gift card"
+)
+
+// Details are the facts about a purchase that a receipt prints besides its
+// amounts. Fields are empty when the receipt does not show them.
+type Details struct {
+	Merchant string
+	// Date is when the purchase was made, read as UTC since receipts do not
+	// print a time zone. It is nil when no date was found.
+	Date          *time.Time
+	PaymentMethod string
+	CardLast4     string
+}
+
+// paymentMethods are checked in order, so that a card brand wins over the
+// generic "CREDIT" or "DEBIT" printed next to it.
+var paymentMethods = []struct {
+	method string
+	re     *regexp.Regexp
+}{
+	{ApplePay, regexp.MustCompile(`\bAPPLE\s*PAY\b`)},
+	{GooglePay, regexp.MustCompile(`\b(GOOGLE|ANDROID)\s*PAY\b`)},
+	{Visa, regexp.MustCompile(`\bVISA\b`)},
+	{Mastercard, regexp.MustCompile(`\b(MASTER\s*CARD|MC)\b`)},
+	{Amex, regexp.MustCompile(`\b(AMEX|AMERICAN EXPRESS)\b`)},
+	{Discover, regexp.MustCompile(`\bDISCOVER\b`)},
+	{GiftCard, regexp.MustCompile(`\bGIFT\s*CARD\b`)},
+	{Debit, regexp.MustCompile(`\b(DEBIT|INTERAC)\b`)},
+	{Credit, regexp.MustCompile(`\bCREDIT\b`)},
+	{Cash, regexp.MustCompile(`\bCASH\b`)},
+}
+
+var (
+	// cardRe matches masked card numbers ("XXXX XXXX XXXX 1234",
+	// "************1234") and "ENDING IN 1234".
+	cardRe = regexp.MustCompile(`(?:(?:[X*#•]{2,}[\s-]*)+|ENDING IN\s*|ACCOUNT\s*#?\s*:?\s*|ACCT\s*#?\s*:?\s*|CARD\s*(?:NO\.?|NUMBER|#)\s*:?\s*)[X*#•]*(\d{4})\b`)
+
+	monthNames = map[string]time.Month{
+		"JAN": time.January, "FEB": time.February, "MAR": time.March, "APR": time.April,
+		"MAY": time.May, "JUN": time.June, "JUL": time.July, "AUG": time.August,
+		"SEP": time.September, "OCT": time.October, "NOV": time.November, "DEC": time.December,
+	}
+	month = `(JAN(?:UARY)?|FEB(?:RUARY)?|MAR(?:CH)?|APR(?:IL)?|MAY|JUNE?|JULY?|AUG(?:UST)?|SEP(?:T(?:EMBER)?)?|OCT(?:OBER)?|NOV(?:EMBER)?|DEC(?:EMBER)?)\b\.?`
+	// dateEnd keeps the year of a date from being the whole part of a
+	// price, as in "1 DEC 12.50".
+	dateEnd = `(?:$|[^.,\d]|[.,](?:$|\D))`
+
+	// Dates in the formats receipts use: 2024-05-03, 05/03/2024, 05/03/24,
+	// 03.05.2024, 3 May 2024, 03-MAY-24 and May 3, 2024.
+	isoDateRe     = regexp.MustCompile(`\b(\d{4})[-/.](\d{1,2})[-/.](\d{1,2})` + dateEnd)
+	numericDateRe = regexp.MustCompile(`\b(\d{1,2})([-/.])(\d{1,2})[-/.](\d{4}|\d{2})` + dateEnd)
+	dayMonthRe    = regexp.MustCompile(`\b(\d{1,2})[\s-]*` + month + `[\s-]*,?\s*'?(\d{4}|\d{2})` + dateEnd)
+	monthDayRe    = regexp.MustCompile(`\b` + month + `\s*(\d{1,2})(?:ST|ND|RD|TH)?,?\s*'?(\d{4}|\d{2})` + dateEnd)
+	// timeRe matches 14:32, 14:32:05 and 2:32 PM.
+	timeRe = regexp.MustCompile(`\b(\d{1,2}):(\d{2})(?::(\d{2}))?\s*([AP]\.?M\.?)?`)
+
+	// notMerchantRe matches header lines that are not the store's name.
+	notMerchantRe = regexp.MustCompile(`\b(RECEIPT|INVOICE|TEL|PHONE|FAX|WWW|HTTP|STORE\s*#|STORE NO|CASHIER|REGISTER|TRANS|ORDER|TABLE|SERVER|GST\s*#|HST\s*#|VAT\s*(NO|#)|ST|STREET|AVE|AVENUE|RD|ROAD|BLVD|DR|DRIVE|HWY|SUITE|UNIT)\b|\.COM\b|\d{3}[-.\s)]\d{3}[-.\s]\d{4}`)
+	welcomeRe     = regexp.MustCompile(`^(WELCOME TO|THANK YOU FOR SHOPPING AT)\s+`)
+)
+
+// merchantLines is how far down the receipt the merchant name is looked for.
+const merchantLines = 5
+
+// ParseDetails reads the merchant, purchase date, payment method and card
+// digits from text, the OCR output of a receipt.
+func ParseDetails(text string) Details {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	var d Details
+	// The merchant's name heads the receipt, before the first price.
+	for i := 0; i < len(lines) && i < merchantLines && d.Merchant == ""; i++ {
+		if priced(lines[i]) {
+			break
+		}
+		if i+1 < len(lines) && isAmount(lines[i+1]) {
+			// An item with its price on the next line.
+			break
+		}
+		d.Merchant = merchant(lines[i])
+	}
+	for i, line := range lines {
+		upper := strings.ToUpper(line)
+		if d.Date == nil {
+			d.Date = parseDate(upper)
+			if d.Date != nil && !timeRe.MatchString(upper) && i+1 < len(lines) {
+				// The time may be printed under the date.
+				if c, ok := clock(strings.ToUpper(lines[i+1])); ok {
+					*d.Date = d.Date.Add(c)
+				}
+			}
+		}
+		if d.CardLast4 == "" {
+			if m := cardRe.FindStringSubmatch(upper); m != nil {
+				d.CardLast4 = m[1]
+			}
+		}
+	}
+
+	upper := strings.ToUpper(text)
+	for _, p := range paymentMethods {
+		if p.re.MatchString(upper) {
+			d.PaymentMethod = p.method
+			break
+		}
+	}
+	return d
+}
+
+// isAmount reports whether line is a price on its own.
+func isAmount(line string) bool {
+	return amountRe.MatchString(strings.ToUpper(line))
+}
+
+// priced reports whether line is or ends in a price.
+func priced(line string) bool {
+	return isAmount(line) || trailingAmountRe.MatchString(line)
+}
+
+// merchant returns line without any greeting if it may be the store's name,
+// or "" when it is an address, a phone number or some other header.
+func merchant(line string) string {
+	upper := strings.ToUpper(line)
+	if len(lettersRe.FindAllString(line, 3)) < 3 || notMerchantRe.MatchString(upper) || parseDate(upper) != nil {
+		return ""
+	}
+	if loc := welcomeRe.FindStringIndex(upper); loc != nil {
+		line = line[loc[1]:]
+	}
+	return strings.TrimSpace(line)
+}
+
+// parseDate returns the date, and the time of day when there is one, on
+// line. Numeric dates are read month first as in the US, unless the first
+// number cannot be a month or the date is written with dots.
+func parseDate(line string) *time.Time {
+	var year, day int
+	var mon time.Month
+	if m := isoDateRe.FindStringSubmatch(line); m != nil {
+		year, mon, day = atoi(m[1]), time.Month(atoi(m[2])), atoi(m[3])
+	} else if m := numericDateRe.FindStringSubmatch(line); m != nil {
+		a, b := atoi(m[1]), atoi(m[3])
+		if a > 12 || m[2] == "." {
+			a, b = b, a
+		}
+		year, mon, day = fullYear(m[4]), time.Month(a), b
+	} else if m := dayMonthRe.FindStringSubmatch(line); m != nil {
+		year, mon, day = fullYear(m[3]), monthNames[m[2][:3]], atoi(m[1])
+	} else if m := monthDayRe.FindStringSubmatch(line); m != nil {
+		year, mon, day = fullYear(m[3]), monthNames[m[1][:3]], atoi(m[2])
+	} else {
+		return nil
+	}
+	if mon < time.January || mon > time.December || day < 1 || day > 31 || year < 2000 {
+		return nil
+	}
+	date := time.Date(year, mon, day, 0, 0, 0, 0, time.UTC)
+	if date.Day() != day {
+		// 31 February and the like.
+		return nil
+	}
+
+	if c, ok := clock(line); ok {
+		date = date.Add(c)
+	}
+	return &date
+}
+
+// clock returns the time of day on line.
+func clock(line string) (time.Duration, bool) {
+	m := timeRe.FindStringSubmatch(line)
+	if m == nil {
+		return 0, false
+	}
+	hour, minute, second := atoi(m[1]), atoi(m[2]), atoi(m[3])
+	switch strings.ReplaceAll(m[4], ".", "") {
+	case "PM":
+		if hour < 12 {
+			hour += 12
+		}
+	case "AM":
+		if hour == 12 {
+			hour = 0
+		}
+	}
+	if hour >= 24 || minute >= 60 || second >= 60 {
+		return 0, false
+	}
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute + time.Duration(second)*time.Second, true
+}
+
+func fullYear(s string) int {
+	year := atoi(s)
+	if len(s) == 2 {
+		year += 2000
+	}
+	return year
+}
+
+// atoi is strconv.Atoi for strings the regexps have checked to be digits;
+// "" is 0.
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,73 @@
+package receipt
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDetails(t *testing.T) {
+	at := func(s string) *time.Time {
+		d, err := time.Parse("2006-01-02 15:04:05", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &d
+	}
+	tests := []struct {
+		name string
+		text string
+		want Details
+	}{
+		{
+			name: "grocery receipt",
+			text: "WELCOME TO Corner Grocer\n123 Main St\n(555) 123-4567\n05/03/2024 2:32 PM\nMILK 4.50\nTOTAL 4.50\nVISA CREDIT\nXXXX XXXX XXXX 1234",
+			want: Details{Merchant: "Corner Grocer", Date: at("2024-05-03 14:32:00"), PaymentMethod: Visa, CardLast4: "1234"},
+		},
+		{
+			name: "day first and time on the next line",
+			text: "CAFE LUMIERE\nTVA # 123456\n23/11/2023\n08:05:12\nCROISSANT 3.20\nCASH 5.00\nCHANGE 1.80",
+			want: Details{Merchant: "CAFE LUMIERE", Date: at("2023-11-23 08:05:12"), PaymentMethod: Cash},
+		},
+		{
+			name: "dotted date and debit card",
+			text: "Bäckerei Müller\n03.05.2024 07:15\nBROT 2.50\nINTERAC DEBIT\nACCT: ************9876",
+			want: Details{Merchant: "Bäckerei Müller", Date: at("2024-05-03 07:15:00"), PaymentMethod: Debit, CardLast4: "9876"},
+		},
+		{
+			name: "month names",
+			text: "THE GREEN FORK\nServer: Ana  Table 4\nMay 3, 2024 7:45 PM\nPASTA 18.00\nAPPLE PAY\nMASTERCARD ENDING IN 4321",
+			want: Details{Merchant: "THE GREEN FORK", Date: at("2024-05-03 19:45:00"), PaymentMethod: ApplePay, CardLast4: "4321"},
+		},
+		{
+			name: "day month year",
+			text: "HARDWARE HUT\n03-MAY-24\n1 DEC 12.50\nAMEX",
+			want: Details{Merchant: "HARDWARE HUT", Date: at("2024-05-03 00:00:00"), PaymentMethod: Amex},
+		},
+		{
+			name: "ISO date",
+			text: "Fuel Stop\nDate: 2024-02-29 23:59\nUNLEADED 40.00",
+			want: Details{Merchant: "Fuel Stop", Date: at("2024-02-29 23:59:00")},
+		},
+		{
+			name: "no header",
+			text: "MILK\n$4.50\nEGGS\n$3.00",
+			want: Details{},
+		},
+		{
+			name: "impossible date",
+			text: "SHOP\n02/30/2024\nSOAP 1.00",
+			want: Details{Merchant: "SHOP"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseDetails(tt.text)
+			if got.Merchant != tt.want.Merchant || got.PaymentMethod != tt.want.PaymentMethod || got.CardLast4 != tt.want.CardLast4 {
+				t.Errorf("ParseDetails = %+v, want %+v", got, tt.want)
+			}
+			if (got.Date == nil) != (tt.want.Date == nil) || got.Date != nil && !got.Date.Equal(*tt.want.Date) {
+				t.Errorf("date = %v, want %v", got.Date, tt.want.Date)
+			}
+		})
+	}
+}
@@ -0,0 +1,278 @@
+// Package receipt reads the structure of a receipt out of its OCR text:
+// line items with quantities and unit prices, discounts, subtotal, tax, tip
+// and the grand total.
+package receipt
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+)
+
+// kind is what a labelled amount on a receipt stands for.
+type kind int
+
+const (
+	item kind = iota
+	discount
+	subtotal
+	tax
+	tip
+	total
+	// ignored covers payment, change and savings summary lines.
+	ignored
+)
+
+// keywords classify labels, checked in order so that e.g. "SUBTOTAL" and
+// "TOTAL TAX" are not read as the grand total.
+var keywords = []struct {
+	kind kind
+	re   *regexp.Regexp
+}{
+	{ignored, regexp.MustCompile(`\b(YOU SAVED|TOTAL SAVINGS|SAVINGS TOTAL|TOTAL DISCOUNTS?|ITEMS SOLD|CHANGE|CASH|TEND(ER|ERED)?|VISA|MASTERCARD|MASTER CARD|AMEX|DEBIT|CREDIT|PAYMENT|ROUNDING)\b`)},
+	{subtotal, regexp.MustCompile(`\bSUB\s*-?\s*TOTAL\b`)},
+	{tax, regexp.MustCompile(`\b(TAX|TAXES|HST|GST|PST|QST|VAT|TPS|TVQ)\b`)},
+	{tip, regexp.MustCompile(`\b(TIP|GRATUITY|SERVICE CHARGE)\b`)},
+	{total, regexp.MustCompile(`\b(TOTAL|AMOUNT DUE|BALANCE DUE|TOTAL DUE|GRAND TOTAL)\b`)},
+	{discount, regexp.MustCompile(`\b(DISCOUNT|COUPON|SAVINGS|PROMO|MARKDOWN|REBATE|INSTANT SAVINGS)\b|\d+\s*% OFF\b`)},
+}
+
+var (
+	// amountRe matches a price on its own: "4.50", "$4.50", "-1.00",
+	// "1.00-", "4,50" or "1,299.00", optionally followed by a tax flag such
+	// as "F".
+	amountRe = regexp.MustCompile(`^(-)?\s*\$?\s*(\d{1,3}(?:,\d{3})+|\d+)[.,](\d{2})\s*(-)?(?:\s+[A-Z*]{1,2})?$`)
+	// trailingAmountRe splits "MILK 2% 4.50 F" into label and price.
+	trailingAmountRe = regexp.MustCompile(`(?i)^(.*?)\s+((?:-\s*)?\$?\s*(?:\d{1,3}(?:,\d{3})+|\d+)[.,]\d{2}-?)(?:\s+[A-Z*]{1,2})?$`)
+	// quantityRe matches "2 @ 1.50", "2 x $1.50" or "1.25 kg @ 3.99/kg".
+	quantityRe = regexp.MustCompile(`(?i)(\d+(?:[.,]\d+)?)\s*(?:KG|LB|LBS|EA|PC|PCS)?\s*(?:@|X|×)\s*\$?\s*((?:\d{1,3}(?:,\d{3})+|\d+)[.,]\d{2})(?:\s*/\s*(?:KG|LB|EA))?`)
+	lettersRe  = regexp.MustCompile(`[A-Za-z]`)
+)
+
+type label struct {
+	text     string
+	kind     kind
+	quantity float64
+	unit     money.Amount
+	hasQty   bool
+}
+
+type parser struct {
+	currency money.Currency
+	results  models.AnalysisResults
+	// labels and amounts are the runs of lines seen since the last pair
+	// was made. OCR often returns the names and prices of a receipt as
+	// separate blocks, so they are paired up when a run ends.
+	labels  []label
+	amounts []money.Amount
+	// pendingQty is a quantity line that came before its item's name.
+	pendingQty *label
+	totalSeen  bool
+}
+
+// Parse reads text, the OCR output of a receipt priced in currency.
+func Parse(text string, currency money.Currency) models.AnalysisResults {
+	p := &parser{currency: currency}
+	p.results.ExtractedText = text
+	p.results.Currency = currency
+
+	for _, line := range strings.Split(text, "\n") {
+		p.line(strings.TrimSpace(line))
+	}
+	p.flush()
+	p.reconcile()
+	return p.results
+}
+
+func (p *parser) line(line string) {
+	if line == "" {
+		return
+	}
+	upper := strings.ToUpper(line)
+
+	if amount, ok := p.parseAmount(upper); ok {
+		p.amounts = append(p.amounts, amount)
+		return
+	}
+
+	// A quantity, possibly with the line's price, on a line of its own.
+	if m := quantityRe.FindStringSubmatchIndex(upper); m != nil {
+		rest := strings.TrimSpace(upper[:m[0]] + " " + upper[m[1]:])
+		if !lettersRe.MatchString(rest) {
+			qty := p.quantity(upper[m[2]:m[3]], upper[m[4]:m[5]])
+			if len(p.amounts) == 0 && len(p.labels) > 0 && !p.labels[len(p.labels)-1].hasQty {
+				last := &p.labels[len(p.labels)-1]
+				last.quantity, last.unit, last.hasQty = qty.quantity, qty.unit, true
+			} else {
+				p.flushIfAmounts()
+				p.pendingQty = &qty
+			}
+			if amount, ok := p.parseAmount(rest); ok {
+				p.amounts = append(p.amounts, amount)
+			}
+			return
+		}
+	}
+
+	if !lettersRe.MatchString(line) {
+		return
+	}
+
+	// A label with its price on the same line is complete by itself. The
+	// unit price of "MILK 2 @ 1.50" is not such a price.
+	if m := trailingAmountRe.FindStringSubmatchIndex(line); m != nil && lettersRe.MatchString(line[m[2]:m[3]]) {
+		q := quantityRe.FindStringIndex(line)
+		if amount, ok := p.parseAmount(strings.ToUpper(line[m[4]:m[5]])); ok && (q == nil || m[4] >= q[1]) {
+			p.flush()
+			p.add(p.label(line[m[2]:m[3]]), amount, true)
+			return
+		}
+	}
+
+	p.flushIfAmounts()
+	p.labels = append(p.labels, p.label(line))
+}
+
+// label classifies text and takes any quantity out of it.
+func (p *parser) label(text string) label {
+	l := label{text: strings.TrimSpace(text), kind: item}
+	upper := strings.ToUpper(l.text)
+	for _, k := range keywords {
+		if k.re.MatchString(upper) {
+			l.kind = k.kind
+			break
+		}
+	}
+	if l.kind == item {
+		if m := quantityRe.FindStringSubmatchIndex(l.text); m != nil {
+			qty := p.quantity(l.text[m[2]:m[3]], l.text[m[4]:m[5]])
+			l.quantity, l.unit, l.hasQty = qty.quantity, qty.unit, true
+			l.text = strings.Join(strings.Fields(l.text[:m[0]]+" "+l.text[m[1]:]), " ")
+		} else if p.pendingQty != nil {
+			l.quantity, l.unit, l.hasQty = p.pendingQty.quantity, p.pendingQty.unit, true
+		}
+		p.pendingQty = nil
+	}
+	return l
+}
+
+func (p *parser) quantity(qty, unit string) label {
+	q, _ := strconv.ParseFloat(strings.Replace(qty, ",", ".", 1), 64)
+	u, _ := p.parseAmount(unit)
+	return label{quantity: q, unit: u, hasQty: true}
+}
+
+// flushIfAmounts pairs the current runs when a new label starts after
+// some amounts.
+func (p *parser) flushIfAmounts() {
+	if len(p.amounts) > 0 {
+		p.flush()
+	}
+}
+
+// flush pairs the run of labels with the run of amounts that followed it.
+// Amounts go to the last labels of the run, so that header lines such as
+// the store's address before the first item are left out.
+func (p *parser) flush() {
+	labels, amounts := p.labels, p.amounts
+	p.labels, p.amounts = nil, nil
+
+	if len(labels) > len(amounts) {
+		// Labels without a price are items only if their quantity says
+		// what they cost.
+		for _, l := range labels[:len(labels)-len(amounts)] {
+			if l.kind == item && l.hasQty {
+				p.add(l, 0, false)
+			}
+		}
+		labels = labels[len(labels)-len(amounts):]
+	}
+	for i, l := range labels {
+		p.add(l, amounts[i], true)
+	}
+}
+
+// add records a labelled amount.
+func (p *parser) add(l label, amount money.Amount, priced bool) {
+	r := &p.results
+	if l.kind == item && amount < 0 {
+		l.kind = discount
+	}
+	switch l.kind {
+	case item:
+		if p.totalSeen {
+			// Whatever follows the total is about paying for it.
+			return
+		}
+		it := models.ReceiptItem{Name: l.text, Quantity: 1, UnitPrice: amount, Amount: amount}
+		if l.hasQty {
+			it.Quantity, it.UnitPrice = l.quantity, l.unit
+			if !priced {
+				it.Amount = money.Amount(math.Round(l.quantity * float64(l.unit)))
+			}
+		}
+		r.Items = append(r.Items, it)
+	case discount:
+		if p.totalSeen {
+			return
+		}
+		if amount > 0 {
+			amount = -amount
+		}
+		r.Discount += amount
+		if len(r.Items) > 0 {
+			r.Items[len(r.Items)-1].Discount += amount
+		}
+	case subtotal:
+		r.Subtotal = amount
+	case tax:
+		r.Tax += amount
+	case tip:
+		r.Tip += amount
+	case total:
+		// The first total is the grand total; later ones repeat it on the
+		// payment slip.
+		if !p.totalSeen {
+			r.Total = amount
+			p.totalSeen = true
+		}
+	}
+}
+
+// reconcile checks the items against the printed total, or computes the
+// total when none was printed.
+func (p *parser) reconcile() {
+	r := &p.results
+	var sum money.Amount
+	for _, it := range r.Items {
+		sum += it.Amount
+	}
+	sum += r.Discount + r.Tax + r.Tip
+	if !p.totalSeen {
+		r.Total = sum
+		return
+	}
+	r.Difference = r.Total - sum
+	r.Reconciled = r.Difference == 0 && len(r.Items) > 0
+}
+
+// parseAmount reads a price such as "$4.50", "4,50" or "1.00-".
+func (p *parser) parseAmount(s string) (money.Amount, bool) {
+	m := amountRe.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, false
+	}
+	// Thousands separators are dropped; the decimal one is read as a point.
+	amount, err := money.ParseAmount(strings.ReplaceAll(m[2], ",", "")+"."+m[3], p.currency)
+	if err != nil {
+		return 0, false
+	}
+	if m[1] != "" || m[4] != "" {
+		amount = -amount
+	}
+	return amount, true
+}
@@ -0,0 +1,119 @@
+package receipt
+
+import (
+	"testing"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		items []models.ReceiptItem
+		// want holds the expected summary; its Items are ignored.
+		want models.AnalysisResults
+	}{
+		{
+			name: "names and prices on the same line",
+			text: "CORNER GROCER\n123 MAIN ST\nMILK 2% 4.50 F\nEGGS $3.00\nSUBTOTAL 7.50\nHST 0.98\nTOTAL 8.48\nVISA 8.48\nCHANGE 0.00",
+			items: []models.ReceiptItem{
+				{Name: "MILK 2%", Quantity: 1, UnitPrice: 450, Amount: 450},
+				{Name: "EGGS", Quantity: 1, UnitPrice: 300, Amount: 300},
+			},
+			want: models.AnalysisResults{Subtotal: 750, Tax: 98, Total: 848, Reconciled: true},
+		},
+		{
+			name: "names and prices in separate blocks",
+			text: "Corner Grocer\nMilk\nBread\nSubtotal\nTax\nTotal\n4.50\n2.25\n6.75\n0.50\n7.25",
+			items: []models.ReceiptItem{
+				{Name: "Milk", Quantity: 1, UnitPrice: 450, Amount: 450},
+				{Name: "Bread", Quantity: 1, UnitPrice: 225, Amount: 225},
+			},
+			want: models.AnalysisResults{Subtotal: 675, Tax: 50, Total: 725, Reconciled: true},
+		},
+		{
+			name: "quantities and unit prices",
+			text: "APPLES\n3 @ 0.50\n1.50\nBANANAS 1.25 kg @ 2.00/kg\nYOGURT 2 x $1.25 2.50\nTOTAL 6.50",
+			items: []models.ReceiptItem{
+				{Name: "APPLES", Quantity: 3, UnitPrice: 50, Amount: 150},
+				{Name: "BANANAS", Quantity: 1.25, UnitPrice: 200, Amount: 250},
+				{Name: "YOGURT", Quantity: 2, UnitPrice: 125, Amount: 250},
+			},
+			want: models.AnalysisResults{Total: 650, Reconciled: true},
+		},
+		{
+			name: "thousands separators",
+			text: "LAPTOP 1,299.00\nDOCK 2 @ 1,050.00 2,100.00\nTOTAL $3,399.00",
+			items: []models.ReceiptItem{
+				{Name: "LAPTOP", Quantity: 1, UnitPrice: 129900, Amount: 129900},
+				{Name: "DOCK", Quantity: 2, UnitPrice: 105000, Amount: 210000},
+			},
+			want: models.AnalysisResults{Total: 339900, Reconciled: true},
+		},
+		{
+			name: "quantity before the name",
+			text: "2 @ 3.00\nCOFFEE BEANS\n6.00",
+			items: []models.ReceiptItem{
+				{Name: "COFFEE BEANS", Quantity: 2, UnitPrice: 300, Amount: 600},
+			},
+			want: models.AnalysisResults{Total: 600},
+		},
+		{
+			name: "discounts",
+			text: "CEREAL 5.00\nCOUPON 1.00-\nJUICE 4.00\nMEMBER SAVINGS -0.50\nYOU SAVED 1.50\nTOTAL 7.50",
+			items: []models.ReceiptItem{
+				{Name: "CEREAL", Quantity: 1, UnitPrice: 500, Amount: 500, Discount: -100},
+				{Name: "JUICE", Quantity: 1, UnitPrice: 400, Amount: 400, Discount: -50},
+			},
+			want: models.AnalysisResults{Discount: -150, Total: 750, Reconciled: true},
+		},
+		{
+			name: "tip",
+			text: "BURGER 12.00\nFRIES 4.00\nSUB-TOTAL 16.00\nTAX 1.28\nTIP 3.00\nAMOUNT DUE 20.28",
+			items: []models.ReceiptItem{
+				{Name: "BURGER", Quantity: 1, UnitPrice: 1200, Amount: 1200},
+				{Name: "FRIES", Quantity: 1, UnitPrice: 400, Amount: 400},
+			},
+			want: models.AnalysisResults{Subtotal: 1600, Tax: 128, Tip: 300, Total: 2028, Reconciled: true},
+		},
+		{
+			name: "total that does not add up",
+			text: "SOAP 2.00\nTOWELS 9.99\nTOTAL 13.00",
+			items: []models.ReceiptItem{
+				{Name: "SOAP", Quantity: 1, UnitPrice: 200, Amount: 200},
+				{Name: "TOWELS", Quantity: 1, UnitPrice: 999, Amount: 999},
+			},
+			want: models.AnalysisResults{Total: 1300, Difference: 101},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.text, "USD")
+			if len(got.Items) != len(tt.items) {
+				t.Fatalf("items = %+v, want %+v", got.Items, tt.items)
+			}
+			for i := range tt.items {
+				if got.Items[i] != tt.items[i] {
+					t.Errorf("item %d = %+v, want %+v", i, got.Items[i], tt.items[i])
+				}
+			}
+			if got.ExtractedText != tt.text || got.Currency != "USD" {
+				t.Errorf("text %q in %s", got.ExtractedText, got.Currency)
+			}
+			if summary(got) != summary(tt.want) {
+				t.Errorf("summary = %+v, want %+v", summary(got), summary(tt.want))
+			}
+		})
+	}
+}
+
+type totals struct {
+	subtotal, discount, tax, tip, total, difference money.Amount
+	reconciled                                      bool
+}
+
+func summary(r models.AnalysisResults) totals {
+	return totals{r.Subtotal, r.Discount, r.Tax, r.Tip, r.Total, r.Difference, r.Reconciled}
+}
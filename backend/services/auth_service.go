@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/auth"
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const MinPasswordLength = 8
+
+var (
+	ErrInvalidEmail       = errors.New("invalid email address")
+	ErrPasswordTooShort   = errors.New("password must be at least 8 characters")
+	ErrEmailTaken         = errors.New("an account with this email already exists")
+	ErrInvalidCredentials = errors.New("invalid email or password")
+)
+
+type AuthService struct {
+	store   store.Store
+	users   store.UserStore
+	ledgers store.LedgerStore
+	tokens  *auth.Tokens
+}
+
+func NewAuthService(st store.Store, tokens *auth.Tokens) *AuthService {
+	return &AuthService{
+		store:   st,
+		users:   st.Users(),
+		ledgers: st.Ledgers(),
+		tokens:  tokens,
+	}
+}
+
+// Register creates an account with its personal ledger and signs it in. The
+// first account to be registered takes over the data stored before accounts
+// existed.
+func (s *AuthService) Register(ctx context.Context, creds models.Credentials) (*models.AuthResponse, error) {
+	email, err := normalizeEmail(creds.Email)
+	if err != nil {
+		return nil, err
+	}
+	if len(creds.Password) < MinPasswordLength {
+		return nil, ErrPasswordTooShort
+	}
+	hash, err := auth.HashPassword(creds.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{Email: email, PasswordHash: hash, CreatedAt: time.Now()}
+	err = s.store.WithTransaction(ctx, func(ctx context.Context) error {
+		count, err := s.users.Count(ctx)
+		if err != nil {
+			return err
+		}
+		if err := s.users.Insert(ctx, user); err != nil {
+			return err
+		}
+		personal := &models.Ledger{
+			ID:        user.ID,
+			Name:      "Personal",
+			CreatedAt: user.CreatedAt,
+			Members:   []models.LedgerMember{{UserID: user.ID, Role: models.RoleOwner}},
+		}
+		if err := s.ledgers.Insert(ctx, personal); err != nil {
+			return err
+		}
+		if count == 0 {
+			log.Printf("First user %s registered, claiming existing data", user.ID.Hex())
+			return s.store.ClaimUnowned(ctx, personal.ID)
+		}
+		return nil
+	})
+	if errors.Is(err, store.ErrDuplicate) {
+		return nil, ErrEmailTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.signIn(user), nil
+}
+
+func (s *AuthService) Login(ctx context.Context, creds models.Credentials) (*models.AuthResponse, error) {
+	email, err := normalizeEmail(creds.Email)
+	if err != nil {
+		return nil, ErrInvalidCredentials
+	}
+	user, err := s.users.GetByEmail(ctx, email)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := auth.CheckPassword(user.PasswordHash, creds.Password)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	return s.signIn(user), nil
+}
+
+func (s *AuthService) GetUser(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	return s.users.Get(ctx, id)
+}
+
+func (s *AuthService) signIn(user *models.User) *models.AuthResponse {
+	token, expiresAt := s.tokens.Issue(user.ID)
+	return &models.AuthResponse{Token: token, ExpiresAt: expiresAt, User: user}
+}
+
+// normalizeEmail validates email and lower-cases it, so lookups ignore case.
+func normalizeEmail(email string) (string, error) {
+	addr, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil || addr.Name != "" {
+		return "", ErrInvalidEmail
+	}
+	return strings.ToLower(addr.Address), nil
+}
@@ -2,270 +2,798 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
-	"regexp"
-	"strconv"
+	"net/http"
 	"strings"
 	"time"
 
-	vision "cloud.google.com/go/vision/apiv1"
 	"github.com/dhruwanga19/expense-tracker/models"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/ocr"
+	"github.com/dhruwanga19/expense-tracker/pdf"
+	"github.com/dhruwanga19/expense-tracker/receipt"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"github.com/dhruwanga19/expense-tracker/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Bill jobs are claimed for billJobLease, which bounds how long one OCR
+// attempt may take. Failed attempts are retried after billRetryBackoff,
+// doubled after every further failure up to maxBillRetryBackoff. Idle
+// workers look for due jobs every billJobPoll.
+const (
+	billJobLease        = 5 * time.Minute
+	billRetryBackoff    = 10 * time.Second
+	maxBillRetryBackoff = 10 * time.Minute
+	billJobPoll         = 5 * time.Second
+)
+
+const (
+	DefaultBillPageSize = 50
+	MaxBillPageSize     = 200
+)
+
+var (
+	// ErrOCRUnavailable fails bills uploaded while no OCR engine is configured.
+	ErrOCRUnavailable = errors.New("no OCR engine is available to read bills")
+	// ErrRasterizerUnavailable fails PDF bills with scanned pages while
+	// there is no way to render them for OCR.
+	ErrRasterizerUnavailable = errors.New("no PDF rasterizer is available to read scanned pages")
+	// ErrBillFileMissing fails bills whose uploaded file is no longer kept.
+	ErrBillFileMissing = errors.New("the uploaded file was not kept, upload the bill again")
+	// ErrBillStatus is returned for changes the bill's status does not
+	// allow, see models.CanMoveBill.
+	ErrBillStatus = errors.New("not allowed in the bill's status")
+	// ErrBillExpenseNotFound is returned for edits to an expense the bill
+	// did not generate.
+	ErrBillExpenseNotFound = errors.New("the bill has no expense with this ID")
+	// ErrExpenseMissingCategory fails confirming expenses left without a
+	// category.
+	ErrExpenseMissingCategory = errors.New("missing a category")
 )
 
 type BillService struct {
-	billsCollection      *mongo.Collection
-	expensesCollection   *mongo.Collection
-	categoriesCollection *mongo.Collection
-	visionClient         *vision.ImageAnnotatorClient
+	store      store.Transactor
+	bills      store.BillStore
+	jobs       store.BillJobStore
+	files      store.BillFileStore
+	blobs      store.BlobStore
+	expenses   store.ExpenseStore
+	categories store.CategoryStore
+	rules      store.CategoryRuleStore
+	// suggester fills in the categories no rule picks; nil suggests none.
+	suggester *CategorySuggester
+	// ocr is nil when no OCR engine is available, and bills fail.
+	ocr ocr.Engine
+	// images selects how uploaded images are prepared for OCR.
+	images utils.ImageOptions
+	// pdf renders scanned PDF pages for OCR; it is nil when there is no
+	// rasterizer, and such bills fail.
+	pdf pdf.Rasterizer
+	// wake tells an idle worker that a job was queued.
+	wake chan struct{}
 }
 
-func NewBillService(db *mongo.Database) (*BillService, error) {
-	ctx := context.Background()
-	client, err := vision.NewImageAnnotatorClient(ctx)
+// NewBillService returns a BillService keeping the files of bills in blobs
+// and reading them with engine, which may be nil when there is no OCR engine
+// available, after preprocessing images as selected by images. Scanned PDF
+// pages are rendered by rasterizer, which may be nil too. Categories that
+// no rule picks are suggested by suggester, if not nil, which learns from
+// confirmed expenses.
+func NewBillService(st store.Store, blobs store.BlobStore, engine ocr.Engine, images utils.ImageOptions, rasterizer pdf.Rasterizer,
+	suggester *CategorySuggester) *BillService {
+	return &BillService{
+		store:      st,
+		bills:      st.Bills(),
+		jobs:       st.BillJobs(),
+		files:      st.BillFiles(),
+		blobs:      blobs,
+		expenses:   st.Expenses(),
+		categories: st.Categories(),
+		rules:      st.CategoryRules(),
+		suggester:  suggester,
+		ocr:        engine,
+		images:     images,
+		pdf:        rasterizer,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// SubmitBill stores a new bill with content, the uploaded file, together
+// with a job to process it. When fileType is missing it is sniffed from the
+// content. The bill is returned as uploaded; the workers started by
+// RunWorkers take it from there.
+func (s *BillService) SubmitBill(ctx context.Context, ledgerID primitive.ObjectID, fileName, fileType string, content []byte) (*models.Bill, error) {
+	if fileType == "" || fileType == "application/octet-stream" {
+		fileType = http.DetectContentType(content)
+	}
+	// The blob is written first; it is not rolled back with the bill.
+	hash, err := s.blobs.Put(ctx, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to store the uploaded file: %w", err)
+	}
+
+	var bill *models.Bill
+	err = s.store.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		bill, err = s.CreateBill(ctx, ledgerID, fileName, fileType)
+		if err != nil {
+			return err
+		}
+		original := billFile(bill, models.OriginalBillFile, fileType, hash, len(content))
+		if err := s.files.Put(ctx, &original); err != nil {
+			return err
+		}
+		return s.jobs.Insert(ctx, &models.BillJob{
+			LedgerID:  ledgerID,
+			BillID:    bill.ID,
+			RunAt:     bill.UploadDate,
+			CreatedAt: bill.UploadDate,
+		})
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	return &BillService{
-		billsCollection:      db.Collection("bills"),
-		expensesCollection:   db.Collection("my-expenses"),
-		categoriesCollection: db.Collection("categories"),
-		visionClient:         client,
-	}, nil
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return bill, nil
 }
 
-func (s *BillService) CreateBill(ctx context.Context, fileName string, fileType string) (*models.Bill, error) {
+func (s *BillService) CreateBill(ctx context.Context, ledgerID primitive.ObjectID, fileName string, fileType string) (*models.Bill, error) {
 	bill := &models.Bill{
 		ID:         primitive.NewObjectID(),
+		LedgerID:   ledgerID,
 		FileName:   fileName,
 		FileType:   fileType,
 		UploadDate: time.Now(),
-		Status:     "uploaded",
+		Status:     models.BillUploaded,
 	}
 
-	result, err := s.billsCollection.InsertOne(ctx, bill)
-	if err != nil {
+	if err := s.bills.Insert(ctx, bill); err != nil {
 		return nil, err
 	}
 
-	bill.ID = result.InsertedID.(primitive.ObjectID)
 	return bill, nil
 }
 
-func (s *BillService) ProcessBill(ctx context.Context, billID primitive.ObjectID, fileContent io.Reader) error {
-	// Start a session for the transaction
-	log.Printf("Starting to process bill with ID: %s", billID.Hex())
+// RunWorkers processes queued bills with the given number of workers until
+// ctx is cancelled. A job is given up after maxAttempts attempts, or after
+// the first failure that retrying cannot fix.
+func (s *BillService) RunWorkers(ctx context.Context, workers, maxAttempts int) {
+	for i := 0; i < workers; i++ {
+		go s.work(ctx, maxAttempts)
+	}
+}
+
+func (s *BillService) work(ctx context.Context, maxAttempts int) {
+	for {
+		found, err := s.processNext(ctx, maxAttempts)
+		if err != nil {
+			log.Printf("Error running bill job: %v", err)
+		}
+		if found && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.wake:
+		case <-time.After(billJobPoll):
+		}
+	}
+}
 
-	// Perform OCR
-	image, err := vision.NewImageFromReader(fileContent)
+// processNext claims the next due job and processes its bill. It reports
+// whether there was a job.
+func (s *BillService) processNext(ctx context.Context, maxAttempts int) (bool, error) {
+	now := time.Now()
+	job, err := s.jobs.Claim(ctx, now, now.Add(billJobLease))
+	if err == store.ErrNotFound {
+		return false, nil
+	}
 	if err != nil {
-		log.Printf("Error creating image from reader: %v", err)
-		return fmt.Errorf("failed to create image: %v", err)
+		return false, err
 	}
 
-	annotations, err := s.visionClient.DetectDocumentText(ctx, image, nil)
+	bill, err := s.bills.Get(ctx, job.LedgerID, job.BillID)
+	if err == store.ErrNotFound {
+		// The bill is gone, so is the point of processing it.
+		return true, s.jobs.Delete(ctx, job.ID)
+	}
 	if err != nil {
-		log.Printf("Error detecting document text: %v", err)
-		return fmt.Errorf("failed to detect document text: %v", err)
+		return true, err
 	}
 
-	if annotations == nil {
-		log.Println("No text detected in the image")
-		return fmt.Errorf("no text detected in the image")
+	if job.Attempts > maxAttempts {
+		return true, s.fail(ctx, job, bill, fmt.Errorf("gave up after %d attempts: %s", maxAttempts, job.LastError))
+	}
+	bill.Error = ""
+	err = s.store.WithTransaction(ctx, func(ctx context.Context) error {
+		return s.moveBill(ctx, bill, models.BillProcessing)
+	})
+	if billChanged(err) {
+		return true, s.dropJob(ctx, job)
+	}
+	if err != nil {
+		return true, err
 	}
 
-	extractedText := annotations.Text
+	log.Printf("Processing bill %s, attempt %d", bill.ID.Hex(), job.Attempts)
+	attemptCtx, cancel := context.WithDeadline(ctx, job.RunAt)
+	defer cancel()
+	err = s.processBill(attemptCtx, job, bill)
+	if err == nil {
+		return true, nil
+	}
+	if billChanged(err) {
+		log.Printf("Bill %s was changed while it was processed, dropping the results", bill.ID.Hex())
+		return true, s.dropJob(ctx, job)
+	}
+
+	log.Printf("Error processing bill %s: %v", bill.ID.Hex(), err)
+	if !isTransient(err) || job.Attempts >= maxAttempts {
+		return true, s.fail(ctx, job, bill, err)
+	}
+	job.RunAt = time.Now().Add(retryBackoff(job.Attempts))
+	job.LastError = err.Error()
+	return true, s.jobs.Retry(ctx, job)
+}
+
+// fail records err on the bill and drops its job.
+func (s *BillService) fail(ctx context.Context, job *models.BillJob, bill *models.Bill, err error) error {
+	err = s.store.WithTransaction(ctx, func(ctx context.Context) error {
+		bill.Error = err.Error()
+		bill.ProcessedDate = time.Now()
+		if err := s.moveBill(ctx, bill, models.BillError); err != nil {
+			return err
+		}
+		return s.jobs.Delete(ctx, job.ID)
+	})
+	if billChanged(err) {
+		return s.dropJob(ctx, job)
+	}
+	return err
+}
+
+// dropJob deletes job, which may already be gone with its bill.
+func (s *BillService) dropJob(ctx context.Context, job *models.BillJob) error {
+	if err := s.jobs.Delete(ctx, job.ID); err != nil && err != store.ErrNotFound {
+		return err
+	}
+	return nil
+}
+
+// billChanged reports whether err says that a bill was deleted, or moved to
+// a status it cannot leave for the one it was about to be given.
+func billChanged(err error) bool {
+	return errors.Is(err, ErrBillStatus) || errors.Is(err, store.ErrNotFound)
+}
+
+// moveBill stores bill with its status changed to status, provided the
+// stored bill is in a status that may change to it. It is meant to run in a
+// transaction, so that the stored status does not change in between.
+func (s *BillService) moveBill(ctx context.Context, bill *models.Bill, status string) error {
+	stored, err := s.bills.Get(ctx, bill.LedgerID, bill.ID)
+	if err != nil {
+		return err
+	}
+	if !models.CanMoveBill(stored.Status, status) {
+		return fmt.Errorf("%w: a %s bill cannot become %s", ErrBillStatus, stored.Status, status)
+	}
+	bill.Status = status
+	return s.bills.Update(ctx, bill)
+}
+
+// billFile returns the file of the given kind for bill, whose content is
+// stored under hash.
+func billFile(bill *models.Bill, kind, contentType, hash string, size int) models.BillFile {
+	return models.BillFile{
+		LedgerID:    bill.LedgerID,
+		BillID:      bill.ID,
+		Kind:        kind,
+		ContentType: contentType,
+		Hash:        hash,
+		Size:        int64(size),
+		CreatedAt:   time.Now(),
+	}
+}
+
+// readBillFile returns the content of the bill's file of the given kind.
+func (s *BillService) readBillFile(ctx context.Context, bill *models.Bill, kind string) ([]byte, error) {
+	file, content, err := s.OpenBillFile(ctx, bill.LedgerID, bill.ID, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+	b, err := io.ReadAll(content)
+	if err != nil {
+		return nil, err
+	}
+	if store.ContentHash(b) != file.Hash {
+		return nil, fmt.Errorf("the %s file of bill %s does not match its hash", kind, bill.ID.Hex())
+	}
+	return b, nil
+}
+
+// retryBackoff is how long to wait after the given number of failed attempts.
+func retryBackoff(attempts int) time.Duration {
+	backoff := billRetryBackoff
+	for i := 1; i < attempts && backoff < maxBillRetryBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxBillRetryBackoff)
+}
+
+// isTransient reports whether err is worth retrying: the OCR service being
+// unreachable, overloaded or too slow.
+func isTransient(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted, codes.Aborted, codes.Internal:
+		return true
+	}
+	return false
+}
+
+// processBill reads the text of the bill's original file and stores the
+// results on bill, together with any preprocessed file, dropping the job in
+// the same transaction.
+func (s *BillService) processBill(ctx context.Context, job *models.BillJob, bill *models.Bill) error {
+	content, err := s.readBillFile(ctx, bill, models.OriginalBillFile)
+	if err == store.ErrNotFound {
+		return ErrBillFileMissing
+	}
+	if err != nil {
+		return err
+	}
+
+	var files []models.BillFile
+	var extractedText string
+	if pdf.IsPDF(content) {
+		extractedText, err = s.readPDF(ctx, bill, content)
+	} else {
+		var processed []byte
+		extractedText, processed, err = s.readImage(ctx, bill, content)
+		if processed != nil {
+			hash, err := s.blobs.Put(ctx, processed)
+			if err != nil {
+				return fmt.Errorf("failed to store the processed image: %w", err)
+			}
+			files = append(files, billFile(bill, models.ProcessedBillFile, "image/png", hash, len(processed)))
+		}
+	}
+	if err != nil {
+		return err
+	}
 	log.Printf("Extracted text: %s", extractedText)
 
-	// Parse OCR results
-	expenses, total := s.parseOCRResult(extractedText)
-	log.Printf("Parsed %d expenses, total amount: %f", len(expenses), total)
-	for _, expense := range expenses {
-		log.Printf("Expense: %s, Price: %f", expense.name, expense.price)
+	results := receipt.Parse(extractedText, money.DefaultCurrency)
+	log.Printf("Parsed %d items, total amount: %s, reconciled: %t", len(results.Items), results.Total.Format(results.Currency), results.Reconciled)
+
+	details := receipt.ParseDetails(extractedText)
+	date := time.Now()
+	if details.Date != nil {
+		date = *details.Date
+	}
+
+	rules, err := loadCategorizer(ctx, s.rules, bill.LedgerID)
+	if err != nil {
+		return fmt.Errorf("failed to load category rules: %w", err)
 	}
 
-	// Convert expenses to the format we want to store in the database
-	generatedExpenses := make([]models.Expense, len(expenses))
-	for i, expense := range expenses {
+	// Every item, net of its discounts, becomes an expense to review, in
+	// the category the ledger's rules pick for it or else the one its past
+	// expenses suggest
+	generatedExpenses := make([]models.Expense, len(results.Items))
+	for i, item := range results.Items {
 		generatedExpenses[i] = models.Expense{
-			ID:     primitive.NewObjectID(),
-			Name:   expense.name,
-			Amount: expense.price,
-			Date:   time.Now(),
+			ID:            primitive.NewObjectID(),
+			Name:          item.Name,
+			Amount:        item.Net(),
+			Currency:      results.Currency,
+			Date:          date,
+			Merchant:      details.Merchant,
+			PaymentMethod: details.PaymentMethod,
 		}
+		rules.categorize(&generatedExpenses[i])
+	}
+	if err := s.suggester.Suggest(ctx, bill.LedgerID, generatedExpenses); err != nil {
+		return fmt.Errorf("failed to suggest categories: %w", err)
 	}
 
 	// Update the bill with the processing results
-	update := bson.M{
-		"$set": bson.M{
-			"status":         "processed",
-			"processed_date": time.Now(),
-			"analysis_results": bson.M{
-				"extracted_text": extractedText,
-				"total":          total,
-			},
-			"generated_expenses": generatedExpenses,
-		},
-	}
-
-	_, err = s.billsCollection.UpdateOne(ctx, bson.M{"_id": billID}, update)
+	bill.Error = ""
+	bill.ProcessedDate = time.Now()
+	bill.AnalysisResults = results
+	bill.GeneratedExpenses = generatedExpenses
+	bill.Merchant = details.Merchant
+	bill.TransactionDate = details.Date
+	bill.PaymentMethod = details.PaymentMethod
+	bill.CardLast4 = details.CardLast4
+
+	err = s.store.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.moveBill(ctx, bill, models.BillProcessed); err != nil {
+			return err
+		}
+		for i := range files {
+			if err := s.files.Put(ctx, &files[i]); err != nil {
+				return err
+			}
+		}
+		return s.jobs.Delete(ctx, job.ID)
+	})
 	if err != nil {
-		log.Printf("Error updating bill: %v", err)
-		return fmt.Errorf("failed to update bill: %v", err)
+		return fmt.Errorf("failed to update bill: %w", err)
 	}
 
 	log.Println("Bill processed successfully")
 	return nil
-
 }
 
-func (s *BillService) parseOCRResult(text string) ([]struct {
-	name  string
-	price float64
-}, float64) {
-
-	if text == "" {
-		return nil, 0
+// readImage runs OCR on image, after preparing it as selected by s.images.
+// It returns the text and the preprocessed image, or nil when the image was
+// read as uploaded.
+func (s *BillService) readImage(ctx context.Context, bill *models.Bill, image []byte) (string, []byte, error) {
+	if s.ocr == nil {
+		return "", nil, ErrOCRUnavailable
 	}
 
-	lines := strings.Split(text, "\n")
-	var items []struct {
-		name  string
-		price float64
-	}
-	var total float64 = 0
-	var currentExpense struct {
-		name  string
-		price float64
+	// Prepare images for OCR. Other files are read as uploaded.
+	var processed []byte
+	if s.images.Enabled() {
+		var err error
+		processed, err = utils.PreprocessImage(image, s.images)
+		switch {
+		case err == nil:
+			image = processed
+		case errors.Is(err, utils.ErrNotAnImage):
+			// Such as a text file, which the OCR engine may still read.
+		default:
+			log.Printf("Error preprocessing bill %s, reading the original: %v", bill.ID.Hex(), err)
+		}
 	}
 
-	// Regular expressions for matching expense names and prices
-	nameRegex := regexp.MustCompile(`^[A-Za-z][A-Za-z0-9\s/]+`)
-	priceRegex := regexp.MustCompile(`^(\$)?(\d+\.\d{2})([\s\S]*)?$`)
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
+	text, err := s.ocr.DetectText(ctx, image)
+	if err != nil {
+		return "", nil, err
+	}
+	return text, processed, nil
+}
 
-		if nameRegex.MatchString(line) {
+// readPDF returns the text of every page of document, one after the other:
+// the text layer of pages that have one, and OCR of the others, which are
+// rendered as images. Pages without any text are skipped, so that a total
+// at the end of an invoice is parsed together with the items on the pages
+// before it.
+func (s *BillService) readPDF(ctx context.Context, bill *models.Bill, document []byte) (string, error) {
+	pages, err := pdf.PageText(document)
+	if err != nil {
+		return "", err
+	}
 
-			// If we have a previous expense with a price, add it to the list
-			if currentExpense.name != "" && currentExpense.price != 0 {
-				items = append(items, currentExpense)
-				total += currentExpense.price
+	texts := make([]string, 0, len(pages))
+	for i, text := range pages {
+		if text == "" {
+			if s.pdf == nil {
+				return "", ErrRasterizerUnavailable
 			}
-			currentExpense.name = line
-		} else if priceMatch := priceRegex.FindStringSubmatch(line); priceMatch != nil {
-			price, err := strconv.ParseFloat(priceMatch[2], 64)
-			if err == nil && currentExpense.name != "" {
-				currentExpense.price = price
+			image, err := s.pdf.Rasterize(ctx, document, i+1)
+			if err != nil {
+				return "", fmt.Errorf("page %d: %w", i+1, err)
+			}
+			text, _, err = s.readImage(ctx, bill, image)
+			if errors.Is(err, ocr.ErrNoText) {
+				continue
+			}
+			if err != nil {
+				return "", fmt.Errorf("page %d: %w", i+1, err)
 			}
 		}
+		texts = append(texts, text)
+	}
+	if len(texts) == 0 {
+		return "", ocr.ErrNoText
+	}
+	return strings.Join(texts, "\n"), nil
+}
+
+// OpenBillFile returns the bill's file of the given kind and its content,
+// which the caller closes.
+func (s *BillService) OpenBillFile(ctx context.Context, ledgerID, billID primitive.ObjectID, kind string) (*models.BillFile, io.ReadCloser, error) {
+	file, err := s.files.Get(ctx, ledgerID, billID, kind)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := s.blobs.Open(ctx, file.Hash)
+	if err != nil {
+		return nil, nil, err
+	}
+	return file, content, nil
+}
+
+// GetBills returns one page of the bills matching query, which must carry
+// the ledger. cursor is the NextCursor of the previous page, or empty for
+// the first one.
+func (s *BillService) GetBills(ctx context.Context, query store.BillQuery, cursor string) (*models.BillPage, error) {
+	if query.Limit <= 0 {
+		query.Limit = DefaultBillPageSize
+	}
+	if query.Limit > MaxBillPageSize {
+		query.Limit = MaxBillPageSize
+	}
+	if cursor != "" {
+		after, err := decodeBillCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		query.After = after
 	}
 
-	// Add the last expense if it exists
-	if currentExpense.name != "" && currentExpense.price != 0 {
-		items = append(items, currentExpense)
-		total += currentExpense.price
+	// Ask for one more than a page to know whether there is a next one.
+	pageSize := query.Limit
+	query.Limit++
+	bills, err := s.bills.List(ctx, query)
+	if err != nil {
+		return nil, err
 	}
 
-	return items, total
+	page := &models.BillPage{Bills: bills}
+	if len(bills) > pageSize {
+		page.Bills = bills[:pageSize]
+		page.NextCursor = encodeBillCursor(page.Bills[pageSize-1])
+	}
+	if page.Bills == nil {
+		page.Bills = []models.Bill{}
+	}
+	return page, nil
 }
 
-func (s *BillService) GetBill(ctx context.Context, billID primitive.ObjectID) (*models.Bill, error) {
-	log.Printf("Getting bill with ID: %s", billID.Hex())
-	var bill models.Bill
-	err := s.billsCollection.FindOne(ctx, bson.M{"_id": billID}).Decode(&bill)
+// billCursor is the opaque page cursor handed to clients.
+type billCursor struct {
+	ID         primitive.ObjectID `json:"i"`
+	UploadDate time.Time          `json:"t"`
+}
+
+func encodeBillCursor(last models.Bill) string {
+	data, _ := json.Marshal(billCursor{ID: last.ID, UploadDate: last.UploadDate})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeBillCursor(cursor string) (*store.BillCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c billCursor
+	if err := json.Unmarshal(data, &c); err != nil || c.ID.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &store.BillCursor{ID: c.ID, UploadDate: c.UploadDate}, nil
+}
+
+// DiscardBill marks a bill that is not wanted as discarded, and stops any
+// processing of it.
+func (s *BillService) DiscardBill(ctx context.Context, ledgerID, billID primitive.ObjectID) (*models.Bill, error) {
+	var bill *models.Bill
+	err := s.store.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		bill, err = s.bills.Get(ctx, ledgerID, billID)
+		if err != nil {
+			return err
+		}
+		if err := s.moveBill(ctx, bill, models.BillDiscarded); err != nil {
+			return err
+		}
+		return s.jobs.DeleteForBill(ctx, billID)
+	})
 	if err != nil {
-		log.Printf("Error getting bill in bill_service: %v", err)
 		return nil, err
 	}
-	return &bill, nil
+	return bill, nil
 }
 
-func (s *BillService) UpdateBillExpense(ctx context.Context, billID, expenseID primitive.ObjectID, updatedExpense *models.Expense) error {
+// ReprocessBill queues the bill to be read again from its original file,
+// dropping what was read from it before. It returns the bill as queued.
+func (s *BillService) ReprocessBill(ctx context.Context, ledgerID, billID primitive.ObjectID) (*models.Bill, error) {
+	var bill *models.Bill
+	err := s.store.WithTransaction(ctx, func(ctx context.Context) error {
+		stored, err := s.bills.Get(ctx, ledgerID, billID)
+		if err != nil {
+			return err
+		}
+		if _, err := s.files.Get(ctx, ledgerID, billID, models.OriginalBillFile); err == store.ErrNotFound {
+			return ErrBillFileMissing
+		} else if err != nil {
+			return err
+		}
 
-	filter := bson.M{"_id": billID, "generated_expenses._id": expenseID}
-	update := bson.M{
-		"$set": bson.M{
-			"generated_expenses.$": updatedExpense,
-		},
+		now := time.Now()
+		bill = &models.Bill{
+			ID:         stored.ID,
+			LedgerID:   stored.LedgerID,
+			FileName:   stored.FileName,
+			FileType:   stored.FileType,
+			UploadDate: stored.UploadDate,
+		}
+		if err := s.moveBill(ctx, bill, models.BillUploaded); err != nil {
+			return err
+		}
+		return s.jobs.Insert(ctx, &models.BillJob{
+			LedgerID:  ledgerID,
+			BillID:    billID,
+			RunAt:     now,
+			CreatedAt: now,
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	result, err := s.billsCollection.UpdateOne(ctx, filter, update)
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return bill, nil
+}
+
+// DeleteBill removes a bill together with its files. The expenses confirmed
+// from the bill are deleted with it when deleteExpenses is set, and are
+// otherwise kept without a reference to the bill. File contents are removed
+// from the blob store unless another bill has the same file.
+func (s *BillService) DeleteBill(ctx context.Context, ledgerID, billID primitive.ObjectID, deleteExpenses bool) error {
+	var files []models.BillFile
+	err := s.store.WithTransaction(ctx, func(ctx context.Context) error {
+		if _, err := s.bills.Get(ctx, ledgerID, billID); err != nil {
+			return err
+		}
+		var err error
+		files, err = s.files.List(ctx, ledgerID, billID)
+		if err != nil {
+			return err
+		}
+		if deleteExpenses {
+			_, err = s.expenses.DeleteByBill(ctx, ledgerID, billID)
+		} else {
+			err = s.expenses.UnlinkBill(ctx, ledgerID, billID)
+		}
+		if err != nil {
+			return err
+		}
+		if err := s.jobs.DeleteForBill(ctx, billID); err != nil {
+			return err
+		}
+		if err := s.files.DeleteForBill(ctx, ledgerID, billID); err != nil {
+			return err
+		}
+		return s.bills.Delete(ctx, ledgerID, billID)
+	})
 	if err != nil {
 		return err
 	}
 
-	if result.ModifiedCount == 0 {
-		return fmt.Errorf("no expense found with id %s in bill %s", expenseID.Hex(), billID.Hex())
+	// The bill is gone either way; a blob left behind only takes space.
+	for _, file := range files {
+		used, err := s.files.HashUsed(ctx, file.Hash)
+		if err == nil && !used {
+			err = s.blobs.Delete(ctx, file.Hash)
+		}
+		if err != nil {
+			log.Printf("Error deleting the %s file of bill %s: %v", file.Kind, billID.Hex(), err)
+		}
 	}
-
 	return nil
 }
 
-func (s *BillService) ConfirmExpenses(ctx context.Context, billID primitive.ObjectID, expenses []models.Expense) error {
-	session, err := s.billsCollection.Database().Client().StartSession()
+func (s *BillService) GetBill(ctx context.Context, ledgerID, billID primitive.ObjectID) (*models.Bill, error) {
+	log.Printf("Getting bill with ID: %s", billID.Hex())
+	bill, err := s.bills.Get(ctx, ledgerID, billID)
 	if err != nil {
-		log.Printf("Error starting session: %v", err)
+		log.Printf("Error getting bill in bill_service: %v", err)
+		return nil, err
+	}
+	return bill, nil
+}
+
+// UpdateBillExpense replaces one of the bill's generated expenses with the
+// user's edit, whose category is then no longer a suggestion. Only the
+// expenses of processed bills can be edited; once confirmed they are the
+// record of what was added to the ledger.
+func (s *BillService) UpdateBillExpense(ctx context.Context, ledgerID, billID, expenseID primitive.ObjectID, updatedExpense *models.Expense) error {
+	updatedExpense.ID = expenseID
+	updatedExpense.CategoryConfidence = 0
+	if err := checkCurrency(&updatedExpense.Currency); err != nil {
 		return err
 	}
-	defer session.EndSession(ctx)
+	if err := checkCategory(ctx, s.categories, ledgerID, updatedExpense.CategoryID); err != nil {
+		return err
+	}
+
+	return s.store.WithTransaction(ctx, func(ctx context.Context) error {
+		bill, err := s.bills.Get(ctx, ledgerID, billID)
+		if err != nil {
+			return err
+		}
+		if bill.Status != models.BillProcessed {
+			return fmt.Errorf("%w: only the expenses of processed bills can be edited, this one is %s", ErrBillStatus, bill.Status)
+		}
+		err = s.bills.UpdateGeneratedExpense(ctx, ledgerID, billID, updatedExpense)
+		if err == store.ErrNotFound {
+			return fmt.Errorf("%w: %s", ErrBillExpenseNotFound, expenseID.Hex())
+		}
+		return err
+	})
+}
 
-	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+// ConfirmExpenses adds the reviewed expenses of a processed bill to the
+// ledger, linked to the bill, and marks the bill confirmed.
+func (s *BillService) ConfirmExpenses(ctx context.Context, ledgerID, billID primitive.ObjectID, expenses []models.Expense) error {
+	err := s.store.WithTransaction(ctx, func(ctx context.Context) error {
 		// Get the bill
-		var bill models.Bill
-		err := s.billsCollection.FindOne(sessCtx, bson.M{"_id": billID}).Decode(&bill)
+		bill, err := s.bills.Get(ctx, ledgerID, billID)
 		if err != nil {
 			log.Printf("Error finding bill: %v", err)
-			return nil, err
+			return err
+		}
+		if bill.Status != models.BillProcessed {
+			return fmt.Errorf("%w: only processed bills can be confirmed, this one is %s", ErrBillStatus, bill.Status)
 		}
 
 		// Validate and process expenses
 		for i, expense := range expenses {
 			if expense.CategoryID == primitive.NilObjectID {
-				return nil, fmt.Errorf("expense %d is missing a category", i+1)
+				return fmt.Errorf("expense %d is %w", i+1, ErrExpenseMissingCategory)
 			}
+			if err := checkCategory(ctx, s.categories, ledgerID, expense.CategoryID); err != nil {
+				return fmt.Errorf("expense %d: %w", i+1, err)
+			}
+			if err := checkCurrency(&expenses[i].Currency); err != nil {
+				return fmt.Errorf("expense %d: %w", i+1, err)
+			}
+			expenses[i].LedgerID = ledgerID
+			expenses[i].BillID = billID
+			expenses[i].CategoryConfidence = 0
 
 			if expense.ID.IsZero() {
 				// This is a new expense, generate a new ID
 				expenses[i].ID = primitive.NewObjectID()
 			}
 
-			_, err := s.expensesCollection.InsertOne(sessCtx, expenses[i])
+			err := s.expenses.Insert(ctx, &expenses[i])
 			if err != nil {
 				log.Printf("Error inserting expense: %v", err)
-				return nil, err
+				return err
 			}
 		}
 
 		// Update the bill with confirmed expenses and status
-		_, err = s.billsCollection.UpdateOne(
-			sessCtx,
-			bson.M{"_id": billID},
-			bson.M{
-				"$set": bson.M{
-					"status":             "confirmed",
-					"generated_expenses": expenses,
-				},
-			},
-		)
+		bill.GeneratedExpenses = expenses
+		err = s.moveBill(ctx, bill, models.BillConfirmed)
 		if err != nil {
 			log.Printf("Error updating bill status: %v", err)
-			return nil, err
+			return err
 		}
 
-		return nil, nil
+		return nil
 	})
 
 	if err != nil {
 		log.Printf("Transaction failed: %v", err)
-		return fmt.Errorf("failed to confirm expenses: %v", err)
+		return fmt.Errorf("failed to confirm expenses: %w", err)
 	}
+	s.suggester.Retrain(ledgerID)
 
 	log.Println("Expenses confirmed successfully")
 	return nil
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BudgetBalanceService keeps the history of the periods budget goals have
+// been through: what each period's budget was, what was spent in it and what
+// it carried into the next one.
+type BudgetBalanceService struct {
+	balances store.BudgetBalanceStore
+	expenses store.ExpenseStore
+}
+
+func NewBudgetBalanceService(st store.Store) *BudgetBalanceService {
+	return &BudgetBalanceService{balances: st.BudgetBalances(), expenses: st.Expenses()}
+}
+
+// Refresh brings the balances of goal up to date for its periods from the
+// one it was created in to the last one ending by before, and returns them.
+// Expenses are converted to the goal's currency by conv. Balances are only
+// written when they changed, so an expense added to a past period, or a rate
+// imported for it, shows up in the periods after it.
+func (s *BudgetBalanceService) Refresh(ctx context.Context, goal models.BudgetGoal, conv *converter, before time.Time) ([]models.BudgetBalance, error) {
+	windows, err := pastWindows(goal, before)
+	if err != nil || len(windows) == 0 {
+		return nil, err
+	}
+
+	totals, err := s.expenses.Totals(ctx, store.ExpenseQuery{
+		LedgerID:    goal.LedgerID,
+		From:        windows[0].From,
+		To:          windows[len(windows)-1].To,
+		CategoryIDs: goal.Categories(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	toGoal := conv.to(goalCurrency(goal, conv))
+	spent := make(map[time.Time]money.Amount, len(windows))
+	for _, t := range totals {
+		if !goal.Covers(t.CategoryID) {
+			continue
+		}
+		from, _, err := goal.Period.Window(t.Day)
+		if err != nil {
+			return nil, err
+		}
+		amount, err := toGoal.convert(money.Money{Amount: t.Amount, Currency: t.Currency}, t.Day)
+		if err != nil {
+			return nil, err
+		}
+		spent[from] += amount
+	}
+
+	stored, err := s.balances.List(ctx, goal.LedgerID, goal.ID)
+	if err != nil {
+		return nil, err
+	}
+	byStart := make(map[time.Time]models.BudgetBalance, len(stored))
+	for _, b := range stored {
+		byStart[b.From.UTC()] = b
+	}
+
+	var carried money.Amount
+	for i := range windows {
+		b := &windows[i]
+		b.LedgerID, b.GoalID = goal.LedgerID, goal.ID
+		b.CarriedIn = carried
+		b.Budget = goal.Amount + carried
+		b.Spent = spent[b.From]
+		b.CarriedOut = carryOut(goal, b.Budget-b.Spent)
+		carried = b.CarriedOut
+
+		old, ok := byStart[b.From]
+		if ok && old.To.Equal(b.To) && old.CarriedIn == b.CarriedIn && old.Budget == b.Budget &&
+			old.Spent == b.Spent && old.CarriedOut == b.CarriedOut {
+			*b = old
+			continue
+		}
+		b.ID = old.ID
+		b.UpdatedAt = time.Now()
+		if err := s.balances.Put(ctx, b); err != nil {
+			return nil, err
+		}
+	}
+	return windows, nil
+}
+
+// Reset drops the balances of the goal, for them to be worked out again.
+func (s *BudgetBalanceService) Reset(ctx context.Context, ledgerID, goalID primitive.ObjectID) error {
+	return s.balances.DeleteForGoal(ctx, ledgerID, goalID)
+}
+
+// pastWindows returns empty balances for the periods of goal from the one it
+// was created in to the last one ending by before.
+func pastWindows(goal models.BudgetGoal, before time.Time) ([]models.BudgetBalance, error) {
+	if goal.CreatedAt.IsZero() {
+		return nil, nil
+	}
+	var windows []models.BudgetBalance
+	for at := goal.CreatedAt; ; {
+		from, to, err := goal.Period.Window(at)
+		if err != nil {
+			return nil, err
+		}
+		// Custom periods have a single window, whatever the day.
+		if to.After(before) || len(windows) > 0 && !from.After(windows[len(windows)-1].From) {
+			return windows, nil
+		}
+		windows = append(windows, models.BudgetBalance{From: from, To: to})
+		at = to
+	}
+}
+
+// carryOut returns what of balance, the budget left at the end of a period,
+// goal carries into the next one.
+func carryOut(goal models.BudgetGoal, balance money.Amount) money.Amount {
+	switch goal.Rollover {
+	case models.RolloverSurplus:
+		return max(balance, 0)
+	case models.RolloverBoth:
+		return balance
+	case models.RolloverCapped:
+		return min(max(balance, -goal.RolloverCap), goal.RolloverCap)
+	}
+	return 0
+}
+
+// goalCurrency returns the currency amounts of goal are in, the ledger's
+// base currency for goals that predate currencies.
+func goalCurrency(goal models.BudgetGoal, conv *converter) money.Currency {
+	if goal.Currency == "" {
+		return conv.base
+	}
+	return goal.Currency
+}
@@ -1,55 +1,362 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
 	"time"
 
 	"github.com/dhruwanga19/expense-tracker/models"
-	"go.mongodb.org/mongo-driver/bson"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/period"
+	"github.com/dhruwanga19/expense-tracker/store"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrMissingPeriod        = errors.New("period is required")
+	ErrMissingCategory      = errors.New("category is required")
+	ErrInvalidScope         = errors.New("scope must be category, categories or overall")
+	ErrUnexpectedCategories = errors.New("categories are not used with this scope")
+	ErrInvalidGoalAmount    = errors.New("amount must be greater than 0")
+	ErrInvalidRollover      = errors.New("rollover must be none, surplus, both or capped")
+	ErrInvalidRolloverCap   = errors.New("capped rollover needs a rollover cap above 0")
+	ErrDuplicateBudgetGoal  = errors.New("the category already has a goal over this period")
 )
 
 type BudgetGoalService struct {
-	collection *mongo.Collection
+	store      store.Store
+	goals      store.BudgetGoalStore
+	balances   *BudgetBalanceService
+	categories store.CategoryStore
+	expenses   store.ExpenseStore
+	rates      *ExchangeRateService
 }
 
-func NewBudgetGoalService(db *mongo.Database) *BudgetGoalService {
+func NewBudgetGoalService(st store.Store, rates *ExchangeRateService) *BudgetGoalService {
 	return &BudgetGoalService{
-		collection: db.Collection("budget_goals"),
+		store:      st,
+		goals:      st.BudgetGoals(),
+		balances:   NewBudgetBalanceService(st),
+		categories: st.Categories(),
+		expenses:   st.Expenses(),
+		rates:      rates,
 	}
 }
 
-func (s *BudgetGoalService) CreateBudgetGoal(ctx context.Context, goal *models.BudgetGoal) error {
-	goal.CreatedAt = time.Now()
-	goal.UpdatedAt = time.Now()
-	_, err := s.collection.InsertOne(ctx, goal)
-	return err
+func (s *BudgetGoalService) CreateBudgetGoal(ctx context.Context, ledgerID primitive.ObjectID, goal *models.BudgetGoal) error {
+	goal.LedgerID = ledgerID
+	return s.store.WithTransaction(ctx, func(ctx context.Context) error {
+		goals, err := s.goals.List(ctx, ledgerID)
+		if err != nil {
+			return err
+		}
+		if err := s.checkGoal(ctx, goal, goals); err != nil {
+			return err
+		}
+		goal.CreatedAt = time.Now()
+		goal.UpdatedAt = time.Now()
+		return duplicateGoalError(s.goals.Insert(ctx, goal))
+	})
+}
+
+func (s *BudgetGoalService) GetBudgetGoals(ctx context.Context, ledgerID primitive.ObjectID) ([]models.BudgetGoal, error) {
+	return s.goals.List(ctx, ledgerID)
+}
+
+// UpdateBudgetGoal replaces the goal, keeping the date it was created on.
+// Its balances are dropped, to be worked out again with the new settings.
+func (s *BudgetGoalService) UpdateBudgetGoal(ctx context.Context, ledgerID primitive.ObjectID, goal *models.BudgetGoal) error {
+	goal.LedgerID = ledgerID
+	return s.store.WithTransaction(ctx, func(ctx context.Context) error {
+		existing, err := s.goals.Get(ctx, ledgerID, goal.ID)
+		if err != nil {
+			return err
+		}
+		goals, err := s.goals.List(ctx, ledgerID)
+		if err != nil {
+			return err
+		}
+		if err := s.checkGoal(ctx, goal, goals); err != nil {
+			return err
+		}
+		goal.CreatedAt = existing.CreatedAt
+		goal.UpdatedAt = time.Now()
+		if err := s.goals.Update(ctx, goal); err != nil {
+			return duplicateGoalError(err)
+		}
+		return s.balances.Reset(ctx, ledgerID, goal.ID)
+	})
 }
 
-func (s *BudgetGoalService) GetBudgetGoals(ctx context.Context) ([]models.BudgetGoal, error) {
-	cursor, err := s.collection.Find(ctx, bson.M{})
+func (s *BudgetGoalService) DeleteBudgetGoal(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	return s.store.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.balances.Reset(ctx, ledgerID, id); err != nil {
+			return err
+		}
+		return s.goals.Delete(ctx, ledgerID, id)
+	})
+}
+
+// GetBalances returns the balances of the goal's past periods, brought up to
+// date. Goals whose stored period is no longer understood have none.
+func (s *BudgetGoalService) GetBalances(ctx context.Context, ledgerID, id primitive.ObjectID) ([]models.BudgetBalance, error) {
+	goal, err := s.goals.Get(ctx, ledgerID, id)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
-
-	var goals []models.BudgetGoal
-	if err = cursor.All(ctx, &goals); err != nil {
+	if !goal.Period.Valid() {
+		return []models.BudgetBalance{}, nil
+	}
+	conv, err := s.rates.converter(ctx, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	from, _, err := goal.Period.Window(time.Now())
+	if err != nil {
 		return nil, err
 	}
-	return goals, nil
+	balances, err := s.balances.Refresh(ctx, *goal, conv, from)
+	if balances == nil && err == nil {
+		balances = []models.BudgetBalance{}
+	}
+	return balances, err
 }
 
-func (s *BudgetGoalService) UpdateBudgetGoal(ctx context.Context, goal *models.BudgetGoal) error {
-	goal.UpdatedAt = time.Now()
-	filter := bson.M{"_id": goal.ID}
-	update := bson.M{"$set": goal}
-	_, err := s.collection.UpdateOne(ctx, filter, update)
-	return err
+// checkGoal validates goal and fills in its defaults. Field errors are
+// reported together as a *ValidationError; a goal counting the same
+// expenses over the same period as another of goals is
+// ErrDuplicateBudgetGoal.
+func (s *BudgetGoalService) checkGoal(ctx context.Context, goal *models.BudgetGoal, goals []models.BudgetGoal) error {
+	var invalid ValidationError
+	if err := s.checkScope(ctx, goal, &invalid); err != nil {
+		return err
+	}
+	if goal.Amount <= 0 {
+		invalid.add("amount", ErrInvalidGoalAmount)
+	}
+	if err := checkCurrency(&goal.Currency); err != nil {
+		invalid.add("currency", err)
+	}
+	if goal.Period.IsZero() {
+		invalid.add("period", ErrMissingPeriod)
+	}
+	if goal.Rollover == "" {
+		goal.Rollover = models.RolloverNone
+	}
+	if !goal.Rollover.Valid() {
+		invalid.add("rollover", ErrInvalidRollover)
+	} else if goal.RolloverCap < 0 || goal.Rollover == models.RolloverCapped && goal.RolloverCap == 0 {
+		invalid.add("rolloverCap", ErrInvalidRolloverCap)
+	}
+	if err := invalid.err(); err != nil {
+		return err
+	}
+
+	for _, other := range goals {
+		if duplicateGoals(*goal, other) {
+			return ErrDuplicateBudgetGoal
+		}
+	}
+	return nil
 }
 
-func (s *BudgetGoalService) DeleteBudgetGoal(ctx context.Context, id primitive.ObjectID) error {
-	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": id})
+// duplicateGoals reports whether a and b are different goals counting the
+// same expenses over the same period, which a ledger cannot have.
+func duplicateGoals(a, b models.BudgetGoal) bool {
+	return a.ID != b.ID && a.Period == b.Period && slices.Equal(a.Categories(), b.Categories())
+}
+
+// duplicateGoalError reports a goal the store refused as a duplicate, saved
+// by a concurrent request after checkGoal looked, as ErrDuplicateBudgetGoal.
+func duplicateGoalError(err error) error {
+	if errors.Is(err, store.ErrDuplicate) {
+		return ErrDuplicateBudgetGoal
+	}
 	return err
 }
+
+// checkScope checks that goal names the categories its scope needs, and
+// only those. The categories of multi-category goals are sorted, without
+// repeats.
+func (s *BudgetGoalService) checkScope(ctx context.Context, goal *models.BudgetGoal, invalid *ValidationError) error {
+	if goal.Scope == "" {
+		goal.Scope = models.ScopeCategory
+	}
+	var categories []primitive.ObjectID
+	switch goal.Scope {
+	case models.ScopeCategory:
+		if goal.CategoryID.IsZero() {
+			invalid.add("categoryId", ErrMissingCategory)
+		}
+		if len(goal.CategoryIDs) > 0 {
+			invalid.add("categoryIds", ErrUnexpectedCategories)
+		}
+		categories = []primitive.ObjectID{goal.CategoryID}
+	case models.ScopeCategories:
+		if !goal.CategoryID.IsZero() {
+			invalid.add("categoryId", ErrUnexpectedCategories)
+		}
+		if len(goal.CategoryIDs) == 0 {
+			invalid.add("categoryIds", ErrMissingCategory)
+		}
+		goal.CategoryIDs = slices.Clone(goal.CategoryIDs)
+		slices.SortFunc(goal.CategoryIDs, func(a, b primitive.ObjectID) int { return bytes.Compare(a[:], b[:]) })
+		goal.CategoryIDs = slices.Compact(goal.CategoryIDs)
+		categories = goal.CategoryIDs
+	case models.ScopeOverall:
+		if !goal.CategoryID.IsZero() {
+			invalid.add("categoryId", ErrUnexpectedCategories)
+		}
+		if len(goal.CategoryIDs) > 0 {
+			invalid.add("categoryIds", ErrUnexpectedCategories)
+		}
+	default:
+		invalid.add("scope", ErrInvalidScope)
+	}
+
+	field := "categoryId"
+	if goal.Scope == models.ScopeCategories {
+		field = "categoryIds"
+	}
+	for _, id := range categories {
+		if id.IsZero() {
+			continue
+		}
+		if err := checkCategory(ctx, s.categories, goal.LedgerID, id); err == ErrUnknownCategory {
+			invalid.add(field, err)
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetProgress returns the progress of every goal of the ledger in its
+// period containing at, counting the expenses up to the end of at's day. The
+// expenses are summed by the store, once per period, and converted to the
+// currency of each goal at the rate of their day. Goals that roll over add
+// what their previous periods carried in, bringing their balances up to date.
+// Every goal counts all the expenses in its scope, even those that another
+// goal counts too; such goals list each other in Overlaps. Goals without a
+// valid period are left out.
+func (s *BudgetGoalService) GetProgress(ctx context.Context, ledgerID primitive.ObjectID, at time.Time) ([]models.BudgetProgress, error) {
+	goals, err := s.goals.List(ctx, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	conv, err := s.rates.converter(ctx, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := make([]models.BudgetProgress, 0, len(goals))
+	windows := make(map[[2]time.Time]store.ExpenseQuery)
+	// Windows where an overall goal needs the totals of every category.
+	overall := make(map[[2]time.Time]bool)
+	for _, goal := range goals {
+		// Goals stored with a period that is no longer understood have no
+		// window to report on until they are given a new one.
+		if !goal.Period.Valid() {
+			continue
+		}
+		from, to, err := goal.Period.Window(at)
+		if err != nil {
+			return nil, fmt.Errorf("budget goal %s: %w", goal.ID.Hex(), err)
+		}
+		asOf := period.StartOfDay(at).AddDate(0, 0, 1)
+		if asOf.After(to) {
+			asOf = to
+		}
+		progress = append(progress, models.BudgetProgress{Goal: goal, From: from, To: to, AsOf: asOf, Budget: goal.Amount})
+		p := &progress[len(progress)-1]
+		if goal.Rollover != "" && goal.Rollover != models.RolloverNone {
+			balances, err := s.balances.Refresh(ctx, goal, conv, from)
+			if err != nil {
+				return nil, err
+			}
+			if len(balances) > 0 {
+				p.CarriedIn = balances[len(balances)-1].CarriedOut
+				p.Budget += p.CarriedIn
+			}
+		}
+
+		key := [2]time.Time{from, asOf}
+		query := windows[key]
+		query.LedgerID, query.From, query.To = ledgerID, from, asOf
+		query.CategoryIDs = append(query.CategoryIDs, goal.Categories()...)
+		windows[key] = query
+		if goal.Categories() == nil {
+			overall[key] = true
+		}
+	}
+
+	totals := make(map[[2]time.Time][]store.ExpenseTotal, len(windows))
+	for key, query := range windows {
+		if overall[key] {
+			query.CategoryIDs = nil
+		}
+		if totals[key], err = s.expenses.Totals(ctx, query); err != nil {
+			return nil, err
+		}
+	}
+
+	for i := range progress {
+		p := &progress[i]
+		toGoal := conv.to(goalCurrency(p.Goal, conv))
+		for _, t := range totals[[2]time.Time{p.From, p.AsOf}] {
+			if !p.Goal.Covers(t.CategoryID) {
+				continue
+			}
+			amount, err := toGoal.convert(money.Money{Amount: t.Amount, Currency: t.Currency}, t.Day)
+			if err != nil {
+				return nil, err
+			}
+			p.Spent += amount
+			p.Count += t.Count
+		}
+		setPace(p)
+	}
+	setOverlaps(progress)
+	return progress, nil
+}
+
+// setOverlaps lists in the progress of each goal the other goals that count
+// some of the same expenses over periods that overlap.
+func setOverlaps(progress []models.BudgetProgress) {
+	for i := range progress {
+		p := &progress[i]
+		for j := i + 1; j < len(progress); j++ {
+			q := &progress[j]
+			if p.From.Before(q.To) && q.From.Before(p.To) && p.Goal.Overlaps(q.Goal) {
+				p.Overlaps = append(p.Overlaps, q.Goal.ID)
+				q.Overlaps = append(q.Overlaps, p.Goal.ID)
+			}
+		}
+	}
+}
+
+// setPace fills in what follows from the amount spent so far: the remaining
+// amount, the percentage of the budget used, the projection to the end of the
+// period and the status.
+func setPace(p *models.BudgetProgress) {
+	p.Remaining = p.Budget - p.Spent
+	if p.Budget > 0 {
+		p.Percent = math.Round(float64(p.Spent)/float64(p.Budget)*10000) / 100
+	}
+	p.Projected = p.Spent
+	if elapsed := p.AsOf.Sub(p.From); elapsed > 0 {
+		p.Projected = money.Amount(math.Round(float64(p.Spent) * float64(p.To.Sub(p.From)) / float64(elapsed)))
+	}
+	switch {
+	case p.Spent > p.Budget:
+		p.Status = models.BudgetOver
+	case p.Projected > p.Budget:
+		p.Status = models.BudgetAtRisk
+	default:
+		p.Status = models.BudgetOnTrack
+	}
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrMissingRuleCategory = errors.New("category is required")
+	ErrEmptyRule           = errors.New("a rule needs at least one condition")
+	ErrInvalidNamePattern  = errors.New("invalid name pattern")
+	ErrInvalidAmountRange  = errors.New("minimum amount is above the maximum amount")
+	ErrInvalidWeekday      = errors.New("weekdays go from 0 (Sunday) to 6 (Saturday)")
+)
+
+type CategoryRuleService struct {
+	rules      store.CategoryRuleStore
+	expenses   store.ExpenseStore
+	categories store.CategoryStore
+}
+
+func NewCategoryRuleService(st store.Store) *CategoryRuleService {
+	return &CategoryRuleService{
+		rules:      st.CategoryRules(),
+		expenses:   st.Expenses(),
+		categories: st.Categories(),
+	}
+}
+
+// GetCategoryRules returns the ledger's rules in the order they are tried.
+func (s *CategoryRuleService) GetCategoryRules(ctx context.Context, ledgerID primitive.ObjectID) ([]models.CategoryRule, error) {
+	return s.rules.List(ctx, ledgerID)
+}
+
+func (s *CategoryRuleService) CreateCategoryRule(ctx context.Context, ledgerID primitive.ObjectID, rule *models.CategoryRule) error {
+	rule.LedgerID = ledgerID
+	if err := s.check(ctx, rule); err != nil {
+		return err
+	}
+	rule.CreatedAt = time.Now()
+	rule.UpdatedAt = rule.CreatedAt
+	return s.rules.Insert(ctx, rule)
+}
+
+func (s *CategoryRuleService) UpdateCategoryRule(ctx context.Context, ledgerID primitive.ObjectID, rule *models.CategoryRule) error {
+	rule.LedgerID = ledgerID
+	if err := s.check(ctx, rule); err != nil {
+		return err
+	}
+	old, err := s.rules.Get(ctx, ledgerID, rule.ID)
+	if err != nil {
+		return err
+	}
+	rule.CreatedAt = old.CreatedAt
+	rule.UpdatedAt = time.Now()
+	return s.rules.Update(ctx, rule)
+}
+
+func (s *CategoryRuleService) DeleteCategoryRule(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	return s.rules.Delete(ctx, ledgerID, id)
+}
+
+// DryRun returns the ledger's expenses that rule, stored or not, matches,
+// split into those it would move to its category and those already there.
+// Rules only categorise new expenses, so nothing is changed.
+func (s *CategoryRuleService) DryRun(ctx context.Context, ledgerID primitive.ObjectID, rule *models.CategoryRule) (*models.CategoryRuleDryRun, error) {
+	rule.LedgerID = ledgerID
+	if err := s.check(ctx, rule); err != nil {
+		return nil, err
+	}
+	compiled, err := compileRule(*rule)
+	if err != nil {
+		return nil, err
+	}
+
+	// The amount range is left to the store; expenses listed are the ones
+	// with a category, as everywhere else.
+	expenses, err := s.expenses.List(ctx, store.ExpenseQuery{
+		LedgerID:  ledgerID,
+		MinAmount: rule.MinAmount,
+		MaxAmount: rule.MaxAmount,
+		SortBy:    store.SortByDate,
+		SortDesc:  true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	result := &models.CategoryRuleDryRun{Changes: []models.CategoryChange{}}
+	for _, e := range expenses {
+		switch {
+		case !compiled.matches(&e):
+		case e.CategoryID == rule.CategoryID:
+			result.Unchanged++
+		default:
+			result.Changes = append(result.Changes, models.CategoryChange{Expense: e, CategoryID: rule.CategoryID})
+		}
+	}
+	return result, nil
+}
+
+// check validates rule and puts its conditions in canonical form: the
+// merchant trimmed and the weekdays sorted without repeats.
+func (s *CategoryRuleService) check(ctx context.Context, rule *models.CategoryRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	rule.Merchant = strings.TrimSpace(rule.Merchant)
+	if rule.CategoryID.IsZero() {
+		return ErrMissingRuleCategory
+	}
+	if rule.NamePattern == "" && rule.Merchant == "" && rule.MinAmount == nil && rule.MaxAmount == nil && len(rule.Weekdays) == 0 {
+		return ErrEmptyRule
+	}
+	if _, err := compileRule(*rule); err != nil {
+		return err
+	}
+	if rule.MinAmount != nil && rule.MaxAmount != nil && *rule.MinAmount > *rule.MaxAmount {
+		return ErrInvalidAmountRange
+	}
+	for _, d := range rule.Weekdays {
+		if d < time.Sunday || d > time.Saturday {
+			return ErrInvalidWeekday
+		}
+	}
+	slices.Sort(rule.Weekdays)
+	rule.Weekdays = slices.Compact(rule.Weekdays)
+	return checkCategory(ctx, s.categories, rule.LedgerID, rule.CategoryID)
+}
+
+// compiledRule is a rule ready to be matched against expenses.
+type compiledRule struct {
+	models.CategoryRule
+	name     *regexp.Regexp
+	merchant string
+}
+
+func compileRule(rule models.CategoryRule) (compiledRule, error) {
+	c := compiledRule{CategoryRule: rule, merchant: strings.ToLower(rule.Merchant)}
+	if rule.NamePattern != "" {
+		name, err := regexp.Compile("(?i)" + rule.NamePattern)
+		if err != nil {
+			return c, fmt.Errorf("%w: %v", ErrInvalidNamePattern, err)
+		}
+		c.name = name
+	}
+	return c, nil
+}
+
+func (r *compiledRule) matches(e *models.Expense) bool {
+	if r.name != nil && !r.name.MatchString(e.Name) {
+		return false
+	}
+	if r.merchant != "" && !strings.Contains(strings.ToLower(e.Merchant), r.merchant) {
+		return false
+	}
+	if r.MinAmount != nil && e.Amount < *r.MinAmount {
+		return false
+	}
+	if r.MaxAmount != nil && e.Amount > *r.MaxAmount {
+		return false
+	}
+	if len(r.Weekdays) > 0 && !slices.Contains(r.Weekdays, e.Date.Weekday()) {
+		return false
+	}
+	return true
+}
+
+// categorizer applies a ledger's rules, in the order they are tried.
+type categorizer []compiledRule
+
+// loadCategorizer compiles the rules of the ledger.
+func loadCategorizer(ctx context.Context, rules store.CategoryRuleStore, ledgerID primitive.ObjectID) (categorizer, error) {
+	stored, err := rules.List(ctx, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	c := make(categorizer, 0, len(stored))
+	for _, rule := range stored {
+		compiled, err := compileRule(rule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rule.ID.Hex(), err)
+		}
+		c = append(c, compiled)
+	}
+	return c, nil
+}
+
+// categorize puts an expense that has no category in the category of the
+// first rule it matches, and reports whether there was one.
+func (c categorizer) categorize(e *models.Expense) bool {
+	if !e.CategoryID.IsZero() {
+		return false
+	}
+	for i := range c {
+		if c[i].matches(e) {
+			e.CategoryID = c[i].CategoryID
+			return true
+		}
+	}
+	return false
+}
@@ -4,138 +4,168 @@ import (
 	"context"
 	"errors"
 	"log"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/store"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 type CategoryService struct {
-	categoriesCollection *mongo.Collection
-	expensesCollection   *mongo.Collection
+	store      store.Transactor
+	categories store.CategoryStore
+	expenses   store.ExpenseStore
+	recurring  store.RecurringExpenseStore
+	rules      store.CategoryRuleStore
+	goals      store.BudgetGoalStore
+	balances   store.BudgetBalanceStore
 }
 
 var (
 	ErrCategoryNameExists  = errors.New("a category with this name already exists")
 	ErrCategoryColorExists = errors.New("a category with this color already exists")
+	ErrUnknownCategory     = errors.New("category does not exist")
 )
 
-func NewCategoryService(db *mongo.Database) *CategoryService {
+func NewCategoryService(st store.Store) *CategoryService {
 	return &CategoryService{
-		categoriesCollection: db.Collection("categories"),
-		expensesCollection:   db.Collection("my-expenses"),
+		store:      st,
+		categories: st.Categories(),
+		expenses:   st.Expenses(),
+		recurring:  st.RecurringExpenses(),
+		rules:      st.CategoryRules(),
+		goals:      st.BudgetGoals(),
+		balances:   st.BudgetBalances(),
 	}
 }
 
-func (s *CategoryService) GetCategories(ctx context.Context) ([]models.Category, error) {
-	cursor, err := s.categoriesCollection.Find(ctx, bson.M{})
-	if err != nil {
-		return nil, err
-	}
-	defer cursor.Close(ctx)
-
-	var categories []models.Category
-	if err = cursor.All(ctx, &categories); err != nil {
-		return nil, err
-	}
-
-	return categories, nil
+func (s *CategoryService) GetCategories(ctx context.Context, ledgerID primitive.ObjectID) ([]models.Category, error) {
+	return s.categories.List(ctx, ledgerID)
 }
 
-func (s *CategoryService) AddCategory(ctx context.Context, category *models.Category) error {
-	result, err := s.categoriesCollection.InsertOne(ctx, category)
-	if err != nil {
-		return err
-	}
-	category.ID = result.InsertedID.(primitive.ObjectID)
-	return nil
+func (s *CategoryService) AddCategory(ctx context.Context, ledgerID primitive.ObjectID, category *models.Category) error {
+	category.LedgerID = ledgerID
+	return s.categories.Insert(ctx, category)
 }
 
-func (s *CategoryService) DeleteCategory(ctx context.Context, id primitive.ObjectID) error {
-
-	// Start a mongodb session
-	session, err := s.categoriesCollection.Database().Client().StartSession()
-	if err != nil {
-		return err
-	}
-	defer session.EndSession(ctx)
-
-	// Start a transaction
-	// err = session.StartTransaction()
-	// if err != nil {
-	// 	return err
-	// }
-
-	//Define a callback function to run delete operation
-	callback := func(sessionContext mongo.SessionContext) (interface{}, error) {
-
-		// Delete the category
-		_, err := s.categoriesCollection.DeleteOne(sessionContext, bson.M{"_id": id})
-		if err != nil {
-			return nil, err
-
+func (s *CategoryService) DeleteCategory(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	// Delete the category and every expense in it as one transaction
+	err := s.store.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.categories.Delete(ctx, ledgerID, id); err != nil {
+			return err
 		}
 
 		// Delete all expenses with the category id
-		_, err = s.expensesCollection.DeleteMany(sessionContext, bson.M{"category_id": id})
-		if err != nil {
-			return nil, err
+		if _, err := s.expenses.DeleteByCategory(ctx, ledgerID, id); err != nil {
+			return err
 		}
 
-		return nil, nil
-	}
+		// and the recurring expenses and rules that would create more of them
+		if _, err := s.recurring.DeleteByCategory(ctx, ledgerID, id); err != nil {
+			return err
+		}
+		if _, err := s.rules.DeleteByCategory(ctx, ledgerID, id); err != nil {
+			return err
+		}
 
-	// Run the callback function
-	_, err = session.WithTransaction(ctx, callback)
+		// Budget goals for the category go too, with their balances; goals
+		// spanning other categories as well keep those, unless another goal
+		// already counts them over the same period
+		goals, err := s.goals.List(ctx, ledgerID)
+		if err != nil {
+			return err
+		}
+		var kept []models.BudgetGoal
+		for _, goal := range goals {
+			if !slices.Contains(goal.Categories(), id) {
+				kept = append(kept, goal)
+			}
+		}
+		for _, goal := range goals {
+			categories := goal.Categories()
+			if !slices.Contains(categories, id) {
+				continue
+			}
+			if err := s.balances.DeleteForGoal(ctx, ledgerID, goal.ID); err != nil {
+				return err
+			}
+			categories = slices.DeleteFunc(slices.Clone(categories), func(c primitive.ObjectID) bool { return c == id })
+			if len(categories) == 1 {
+				goal.Scope, goal.CategoryID, goal.CategoryIDs = models.ScopeCategory, categories[0], nil
+			} else {
+				goal.CategoryIDs = categories
+			}
+			if len(categories) == 0 || slices.ContainsFunc(kept, func(other models.BudgetGoal) bool { return duplicateGoals(goal, other) }) {
+				if err := s.goals.Delete(ctx, ledgerID, goal.ID); err != nil {
+					return err
+				}
+				continue
+			}
+			goal.UpdatedAt = time.Now()
+			if err := s.goals.Update(ctx, &goal); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		log.Printf("Transaction failed: %v", err)
 		return err
 	}
 
 	return nil
-
 }
-func (s *CategoryService) UpdateCategory(ctx context.Context, category *models.Category) error {
+
+func (s *CategoryService) UpdateCategory(ctx context.Context, ledgerID primitive.ObjectID, category *models.Category) error {
+	category.LedgerID = ledgerID
+	// Convert color to lowercase before checking and saving
+	category.Color = strings.ToLower(category.Color)
+
 	// Check if the name already exists (excluding the current category)
-	var existingCategory models.Category
-	err := s.categoriesCollection.FindOne(ctx, bson.M{
-		"_id":  bson.M{"$ne": category.ID},
-		"name": bson.M{"$regex": primitive.Regex{Pattern: "^" + category.Name + "$", Options: "i"}},
-	}).Decode(&existingCategory)
-	if err == nil {
-		return ErrCategoryNameExists
-	} else if err != mongo.ErrNoDocuments {
+	exists, err := s.categories.NameExists(ctx, ledgerID, category.Name, category.ID)
+	if err != nil {
 		return err
 	}
+	if exists {
+		return ErrCategoryNameExists
+	}
 
 	// Check if the color already exists (excluding the current category)
-	err = s.categoriesCollection.FindOne(ctx, bson.M{
-		"_id":   bson.M{"$ne": category.ID},
-		"color": strings.ToLower(category.Color),
-	}).Decode(&existingCategory)
-	if err == nil {
-		return ErrCategoryColorExists
-	} else if err != mongo.ErrNoDocuments {
+	exists, err = s.categories.ColorExists(ctx, ledgerID, category.Color, category.ID)
+	if err != nil {
 		return err
 	}
+	if exists {
+		return ErrCategoryColorExists
+	}
 
-	// Convert color to lowercase before saving
-	category.Color = strings.ToLower(category.Color)
+	return s.categories.Update(ctx, category)
+}
 
-	filter := bson.M{"_id": category.ID}
-	update := bson.M{"$set": bson.M{"name": category.Name, "color": category.Color}}
+// checkCategory returns ErrUnknownCategory unless categoryID is empty or one
+// of the ledger's categories.
+func checkCategory(ctx context.Context, categories store.CategoryStore, ledgerID, categoryID primitive.ObjectID) error {
+	if categoryID.IsZero() {
+		return nil
+	}
+	_, err := categories.Get(ctx, ledgerID, categoryID)
+	if err == store.ErrNotFound {
+		return ErrUnknownCategory
+	}
+	return err
+}
 
-	result, err := s.categoriesCollection.UpdateOne(ctx, filter, update)
+// checkCurrency upper-cases *currency, fills in money.DefaultCurrency when
+// it is empty and rejects codes that are not ISO 4217.
+func checkCurrency(currency *money.Currency) error {
+	c, err := money.ParseCurrency(string(*currency))
 	if err != nil {
 		return err
 	}
-
-	if result.ModifiedCount == 0 {
-		return mongo.ErrNoDocuments
-	}
-
+	*currency = c
 	return nil
 }
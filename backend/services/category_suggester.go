@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dhruwanga19/expense-tracker/classify"
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// minSuggestionConfidence is how sure the classifier has to be of a
+// category before it is filled in.
+const minSuggestionConfidence = 0.5
+
+// CategorySuggester suggests categories for new expenses from the ones a
+// ledger already has. A ledger's model is trained from its expenses when
+// it is first needed, and again after Retrain.
+type CategorySuggester struct {
+	expenses   store.ExpenseStore
+	categories store.CategoryStore
+
+	mu     sync.Mutex
+	models map[primitive.ObjectID]*classify.Model
+	// generations counts the Retrain calls per ledger, so a model trained
+	// while one happened is not kept.
+	generations map[primitive.ObjectID]int
+}
+
+func NewCategorySuggester(st store.Store) *CategorySuggester {
+	return &CategorySuggester{
+		expenses:    st.Expenses(),
+		categories:  st.Categories(),
+		models:      map[primitive.ObjectID]*classify.Model{},
+		generations: map[primitive.ObjectID]int{},
+	}
+}
+
+// Suggest fills in the category of expenses that have none with the one the
+// ledger's expenses suggest, together with the CategoryConfidence of the
+// suggestion. Expenses the model is not sure enough about are left alone.
+// A nil CategorySuggester suggests nothing.
+func (s *CategorySuggester) Suggest(ctx context.Context, ledgerID primitive.ObjectID, expenses []models.Expense) error {
+	if s == nil {
+		return nil
+	}
+	model, err := s.model(ctx, ledgerID)
+	if err != nil {
+		return err
+	}
+	for i := range expenses {
+		e := &expenses[i]
+		if !e.CategoryID.IsZero() {
+			continue
+		}
+		label, confidence, ok := model.Predict(e.Name, e.Merchant)
+		if !ok || confidence < minSuggestionConfidence {
+			continue
+		}
+		categoryID, err := primitive.ObjectIDFromHex(label)
+		if err != nil {
+			return err
+		}
+		// The category may have been deleted since the model was trained.
+		if err := checkCategory(ctx, s.categories, ledgerID, categoryID); err == ErrUnknownCategory {
+			s.Retrain(ledgerID)
+			continue
+		} else if err != nil {
+			return err
+		}
+		e.CategoryID = categoryID
+		e.CategoryConfidence = confidence
+	}
+	return nil
+}
+
+// Retrain drops the ledger's model after its expenses were categorised,
+// so the next suggestion learns from them.
+func (s *CategorySuggester) Retrain(ledgerID primitive.ObjectID) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.models, ledgerID)
+	s.generations[ledgerID]++
+}
+
+// model returns the ledger's model, training it from the ledger's
+// categorised expenses when there is none.
+func (s *CategorySuggester) model(ctx context.Context, ledgerID primitive.ObjectID) (*classify.Model, error) {
+	s.mu.Lock()
+	model, ok := s.models[ledgerID]
+	generation := s.generations[ledgerID]
+	s.mu.Unlock()
+	if ok {
+		return model, nil
+	}
+
+	expenses, err := s.expenses.List(ctx, store.ExpenseQuery{LedgerID: ledgerID})
+	if err != nil {
+		return nil, err
+	}
+	examples := make([]classify.Example, len(expenses))
+	for i, e := range expenses {
+		examples[i] = classify.Example{Name: e.Name, Merchant: e.Merchant, Label: e.CategoryID.Hex()}
+	}
+	model = classify.Train(examples)
+
+	s.mu.Lock()
+	if s.generations[ledgerID] == generation {
+		s.models[ledgerID] = model
+	}
+	s.mu.Unlock()
+	return model, nil
+}
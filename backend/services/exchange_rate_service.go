@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/period"
+	"github.com/dhruwanga19/expense-tracker/store"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrSameCurrency    = errors.New("an exchange rate needs two different currencies")
+	ErrMissingRateDate = errors.New("exchange rate date is required")
+	ErrInvalidRateCSV  = errors.New("exchange rate files need the columns date,from,to,rate")
+	ErrNoExchangeRate  = errors.New("no exchange rate")
+)
+
+type ExchangeRateService struct {
+	store   store.Transactor
+	rates   store.ExchangeRateStore
+	ledgers store.LedgerStore
+	// baseCurrency is used by ledgers that do not set their own.
+	baseCurrency money.Currency
+}
+
+func NewExchangeRateService(st store.Store, baseCurrency money.Currency) *ExchangeRateService {
+	return &ExchangeRateService{
+		store:        st,
+		rates:        st.ExchangeRates(),
+		ledgers:      st.Ledgers(),
+		baseCurrency: baseCurrency,
+	}
+}
+
+func (s *ExchangeRateService) GetRates(ctx context.Context, ledgerID primitive.ObjectID) ([]models.ExchangeRate, error) {
+	return s.rates.List(ctx, ledgerID)
+}
+
+// PutRate stores rate, replacing the ledger's rate for the same currencies
+// and day. Rates apply from the start (UTC) of their day.
+func (s *ExchangeRateService) PutRate(ctx context.Context, ledgerID primitive.ObjectID, rate *models.ExchangeRate) error {
+	rate.LedgerID = ledgerID
+	if err := checkExchangeRate(rate); err != nil {
+		return err
+	}
+	return s.rates.Put(ctx, rate)
+}
+
+func checkExchangeRate(rate *models.ExchangeRate) error {
+	if rate.From == "" || rate.To == "" {
+		return money.ErrUnknownCurrency
+	}
+	if err := checkCurrency(&rate.From); err != nil {
+		return err
+	}
+	if err := checkCurrency(&rate.To); err != nil {
+		return err
+	}
+	if rate.From == rate.To {
+		return ErrSameCurrency
+	}
+	if rate.Rate <= 0 {
+		return money.ErrInvalidRate
+	}
+	if rate.Date.IsZero() {
+		return ErrMissingRateDate
+	}
+	rate.Date = period.StartOfDay(rate.Date)
+	return nil
+}
+
+// ImportRates reads CSV lines of date (YYYY-MM-DD), from, to and rate, with
+// an optional header line, and stores them all or none. It returns how many
+// rates were stored.
+func (s *ExchangeRateService) ImportRates(ctx context.Context, ledgerID primitive.ObjectID, r io.Reader) (int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+
+	var rates []models.ExchangeRate
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", ErrInvalidRateCSV, err)
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "date") {
+			continue
+		}
+
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(record[0]))
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %w: bad date %q", line, ErrInvalidRateCSV, record[0])
+		}
+		value, err := money.ParseRate(record[3])
+		if err != nil {
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		rate := models.ExchangeRate{
+			LedgerID: ledgerID,
+			From:     money.Currency(record[1]),
+			To:       money.Currency(record[2]),
+			Rate:     value,
+			Date:     date,
+		}
+		if err := checkExchangeRate(&rate); err != nil {
+			return 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		rates = append(rates, rate)
+	}
+
+	err := s.store.WithTransaction(ctx, func(ctx context.Context) error {
+		for i := range rates {
+			if err := s.rates.Put(ctx, &rates[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(rates), nil
+}
+
+func (s *ExchangeRateService) DeleteRate(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	return s.rates.Delete(ctx, ledgerID, id)
+}
+
+// BaseCurrency returns the currency the ledger's totals are converted to.
+func (s *ExchangeRateService) BaseCurrency(ctx context.Context, ledgerID primitive.ObjectID) (money.Currency, error) {
+	ledger, err := s.ledgers.Get(ctx, ledgerID)
+	if err != nil {
+		return "", err
+	}
+	if ledger.BaseCurrency != "" {
+		return ledger.BaseCurrency, nil
+	}
+	return s.baseCurrency, nil
+}
+
+// converter returns a converter to the ledger's base currency that uses
+// the ledger's rates.
+func (s *ExchangeRateService) converter(ctx context.Context, ledgerID primitive.ObjectID) (*converter, error) {
+	base, err := s.BaseCurrency(ctx, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	rates, err := s.rates.List(ctx, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	c := &converter{base: base, rates: make(map[currencyPair][]models.ExchangeRate)}
+	for _, r := range rates {
+		pair := currencyPair{r.From, r.To}
+		c.rates[pair] = append(c.rates[pair], r)
+	}
+	return c, nil
+}
+
+type currencyPair struct {
+	from, to money.Currency
+}
+
+// converter converts amounts to base at the rate in effect on a given day.
+// A rate from A to B is also used, inverted, to convert from B to A.
+type converter struct {
+	base  money.Currency
+	rates map[currencyPair][]models.ExchangeRate // by date
+}
+
+// to returns a converter to currency using the same rates.
+func (c *converter) to(currency money.Currency) *converter {
+	return &converter{base: currency, rates: c.rates}
+}
+
+func (c *converter) convert(m money.Money, on time.Time) (money.Amount, error) {
+	if m.Currency == c.base || m.Amount == 0 {
+		return m.Amount, nil
+	}
+	direct, hasDirect := latestRate(c.rates[currencyPair{m.Currency, c.base}], on)
+	inverse, hasInverse := latestRate(c.rates[currencyPair{c.base, m.Currency}], on)
+	switch {
+	case hasDirect && (!hasInverse || !inverse.Date.After(direct.Date)):
+		return direct.Rate.Convert(m.Amount, m.Currency, c.base), nil
+	case hasInverse:
+		return inverse.Rate.ConvertBack(m.Amount, c.base, m.Currency), nil
+	}
+	return 0, fmt.Errorf("%w from %s to %s on %s", ErrNoExchangeRate, m.Currency, c.base, on.Format("2006-01-02"))
+}
+
+// latestRate returns the last of rates, sorted by date, that is in effect
+// at t.
+func latestRate(rates []models.ExchangeRate, t time.Time) (models.ExchangeRate, bool) {
+	i := sort.Search(len(rates), func(i int) bool { return rates[i].Date.After(t) })
+	if i == 0 {
+		return models.ExchangeRate{}, false
+	}
+	return rates[i-1], true
+}
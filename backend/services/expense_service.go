@@ -2,76 +2,171 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"log"
+	"time"
 
 	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/store"
 
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
+const (
+	DefaultExpensePageSize = 100
+	MaxExpensePageSize     = 500
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
 type ExpenseService struct {
-	collection *mongo.Collection
+	expenses   store.ExpenseStore
+	categories store.CategoryStore
+	rules      store.CategoryRuleStore
+	// suggester learns from recategorised expenses; it may be nil.
+	suggester *CategorySuggester
 }
 
-func NewExpenseService(db *mongo.Database) *ExpenseService {
+func NewExpenseService(st store.Store, suggester *CategorySuggester) *ExpenseService {
 	return &ExpenseService{
-		collection: db.Collection("my-expenses"),
+		expenses:   st.Expenses(),
+		categories: st.Categories(),
+		rules:      st.CategoryRules(),
+		suggester:  suggester,
 	}
 }
 
-func (s *ExpenseService) GetExpenses(ctx context.Context) ([]models.Expense, error) {
-	pipeline := mongo.Pipeline{
-		{{
-			"$lookup", bson.D{
-				{"from", "categories"},
-				{"localField", "category_id"},
-				{"foreignField", "_id"},
-				{"as", "category"},
-			},
-		}},
-		{{"$unwind", "$category"}},
+// GetExpenses returns one page of the expenses matching query, which must
+// carry the ledger. cursor is the NextCursor of the previous page, or empty
+// for the first one.
+func (s *ExpenseService) GetExpenses(ctx context.Context, query store.ExpenseQuery, cursor string) (*models.ExpensePage, error) {
+	if query.SortBy == "" {
+		query.SortBy = store.SortByDate
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultExpensePageSize
+	}
+	if query.Limit > MaxExpensePageSize {
+		query.Limit = MaxExpensePageSize
+	}
+
+	if cursor != "" {
+		after, err := decodeExpenseCursor(cursor, query)
+		if err != nil {
+			return nil, err
+		}
+		query.After = after
 	}
 
-	cursor, err := s.collection.Aggregate(ctx, pipeline)
+	// Ask for one more than a page to know whether there is a next one.
+	pageSize := query.Limit
+	query.Limit++
+	expenses, err := s.expenses.List(ctx, query)
 	if err != nil {
 		return nil, err
 	}
-	defer cursor.Close(ctx)
 
-	var expenses []models.Expense
-	if err = cursor.All(ctx, &expenses); err != nil {
-		return nil, err
+	page := &models.ExpensePage{Expenses: expenses}
+	if len(expenses) > pageSize {
+		page.Expenses = expenses[:pageSize]
+		page.NextCursor = encodeExpenseCursor(page.Expenses[pageSize-1], query)
+	}
+	if page.Expenses == nil {
+		page.Expenses = []models.Expense{}
 	}
+	return page, nil
+}
 
-	return expenses, nil
+// expenseCursor is the opaque page cursor handed to clients. It remembers
+// the sort order it was made for so it cannot be replayed against another.
+type expenseCursor struct {
+	SortBy string             `json:"s"`
+	Desc   bool               `json:"d"`
+	ID     primitive.ObjectID `json:"i"`
+	Date   time.Time          `json:"t"`
+	Amount money.Amount       `json:"a"`
+	Name   string             `json:"n"`
 }
 
-func (s *ExpenseService) AddExpense(ctx context.Context, expense *models.Expense) error {
-	_, err := s.collection.InsertOne(ctx, expense)
-	return err
+func encodeExpenseCursor(last models.Expense, query store.ExpenseQuery) string {
+	c := expenseCursor{SortBy: query.SortBy, Desc: query.SortDesc, ID: last.ID}
+	switch query.SortBy {
+	case store.SortByAmount:
+		c.Amount = last.Amount
+	case store.SortByName:
+		c.Name = last.Name
+	default:
+		c.Date = last.Date
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
 }
 
-func (s *ExpenseService) UpdateExpense(ctx context.Context, updatedExpense *models.Expense, filter primitive.M) error {
-	update := bson.M{"$set": updatedExpense}
+func decodeExpenseCursor(cursor string, query store.ExpenseQuery) (*store.ExpenseCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c expenseCursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if c.SortBy != query.SortBy || c.Desc != query.SortDesc || c.ID.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &store.ExpenseCursor{ID: c.ID, Date: c.Date, Amount: c.Amount, Name: c.Name}, nil
+}
 
-	result, err := s.collection.UpdateOne(ctx, filter, update)
-	if result.ModifiedCount == 0 {
-		log.Println("No documents updated / Did not find the document to update")
-		return mongo.ErrNoDocuments
+// AddExpense stores a new expense. One without a category gets the category
+// of the first of the ledger's rules it matches, if any.
+func (s *ExpenseService) AddExpense(ctx context.Context, ledgerID primitive.ObjectID, expense *models.Expense) error {
+	expense.LedgerID = ledgerID
+	expense.CategoryConfidence = 0
+	if err := checkCurrency(&expense.Currency); err != nil {
+		return err
 	}
+	if expense.CategoryID.IsZero() {
+		rules, err := loadCategorizer(ctx, s.rules, ledgerID)
+		if err != nil {
+			return err
+		}
+		rules.categorize(expense)
+	}
+	if err := checkCategory(ctx, s.categories, ledgerID, expense.CategoryID); err != nil {
+		return err
+	}
+	return s.expenses.Insert(ctx, expense)
+}
 
+// UpdateExpense replaces the fields of an expense. The suggester retrains,
+// as the category may have changed.
+func (s *ExpenseService) UpdateExpense(ctx context.Context, ledgerID primitive.ObjectID, updatedExpense *models.Expense) error {
+	updatedExpense.LedgerID = ledgerID
+	updatedExpense.CategoryConfidence = 0
+	if err := checkCurrency(&updatedExpense.Currency); err != nil {
+		return err
+	}
+	if err := checkCategory(ctx, s.categories, ledgerID, updatedExpense.CategoryID); err != nil {
+		return err
+	}
+	err := s.expenses.Update(ctx, updatedExpense)
+	if err == store.ErrNotFound {
+		log.Println("No documents updated / Did not find the document to update")
+	}
+	if err == nil {
+		s.suggester.Retrain(ledgerID)
+	}
 	return err
 }
 
-func (s *ExpenseService) DeleteExpenses(ctx context.Context, filter primitive.M) (int64, error) {
-	// Implementation for deleting expenses
-	result, err := s.collection.DeleteMany(ctx, filter)
+func (s *ExpenseService) DeleteExpenses(ctx context.Context, ledgerID primitive.ObjectID, ids []primitive.ObjectID) (int64, error) {
+	deleted, err := s.expenses.DeleteMany(ctx, ledgerID, ids)
 	if err != nil {
 		log.Println("Error deleting expenses:", err)
 		return 0, err
 	}
-	return result.DeletedCount, nil
-
+	return deleted, nil
 }
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrNotLedgerMember = errors.New("not a member of this ledger")
+	ErrNotLedgerOwner  = errors.New("only ledger owners can manage the ledger")
+	ErrInvalidRole     = errors.New("role must be owner, editor or viewer")
+	ErrLastOwner       = errors.New("a ledger needs at least one owner")
+	ErrUnknownUser     = errors.New("no account is registered with this email")
+	ErrLedgerNameEmpty = errors.New("ledger name is required")
+)
+
+type LedgerService struct {
+	store   store.Transactor
+	ledgers store.LedgerStore
+	users   store.UserStore
+}
+
+func NewLedgerService(st store.Store) *LedgerService {
+	return &LedgerService{
+		store:   st,
+		ledgers: st.Ledgers(),
+		users:   st.Users(),
+	}
+}
+
+// Role returns the role of userID in the ledger, or ErrNotLedgerMember.
+// Missing ledgers are reported the same way so their IDs cannot be probed.
+func (s *LedgerService) Role(ctx context.Context, ledgerID, userID primitive.ObjectID) (models.Role, error) {
+	ledger, err := s.ledgers.Get(ctx, ledgerID)
+	if err == store.ErrNotFound {
+		return "", ErrNotLedgerMember
+	}
+	if err != nil {
+		return "", err
+	}
+	role, ok := ledger.Role(userID)
+	if !ok {
+		return "", ErrNotLedgerMember
+	}
+	return role, nil
+}
+
+func (s *LedgerService) GetLedgers(ctx context.Context, userID primitive.ObjectID) ([]models.Ledger, error) {
+	return s.ledgers.ListForUser(ctx, userID)
+}
+
+// GetLedger returns a ledger the user belongs to, with member emails.
+func (s *LedgerService) GetLedger(ctx context.Context, userID, ledgerID primitive.ObjectID) (*models.Ledger, error) {
+	if _, err := s.Role(ctx, ledgerID, userID); err != nil {
+		return nil, err
+	}
+	ledger, err := s.ledgers.Get(ctx, ledgerID)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range ledger.Members {
+		user, err := s.users.Get(ctx, m.UserID)
+		if err != nil {
+			return nil, err
+		}
+		ledger.Members[i].Email = user.Email
+	}
+	return ledger, nil
+}
+
+// CreateLedger creates a ledger owned by userID.
+func (s *LedgerService) CreateLedger(ctx context.Context, userID primitive.ObjectID, ledger *models.Ledger) error {
+	ledger.Name = strings.TrimSpace(ledger.Name)
+	if ledger.Name == "" {
+		return ErrLedgerNameEmpty
+	}
+	ledger.ID = primitive.NilObjectID
+	ledger.CreatedAt = time.Now()
+	ledger.Members = []models.LedgerMember{{UserID: userID, Role: models.RoleOwner}}
+	return s.ledgers.Insert(ctx, ledger)
+}
+
+// UpdateLedger renames the ledger and sets its base currency; an empty
+// currency means the server's default. Only owners may do this.
+func (s *LedgerService) UpdateLedger(ctx context.Context, userID, ledgerID primitive.ObjectID, settings models.LedgerSettings) (*models.Ledger, error) {
+	name := strings.TrimSpace(settings.Name)
+	if name == "" {
+		return nil, ErrLedgerNameEmpty
+	}
+	currency := settings.BaseCurrency
+	if currency != "" {
+		if err := checkCurrency(&currency); err != nil {
+			return nil, err
+		}
+	}
+
+	var ledger *models.Ledger
+	err := s.store.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		ledger, err = s.ownedLedger(ctx, userID, ledgerID)
+		if err != nil {
+			return err
+		}
+		ledger.Name = name
+		ledger.BaseCurrency = currency
+		return s.ledgers.Update(ctx, ledger)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ledger, nil
+}
+
+// AddMember gives the account registered with invite.Email a role in the
+// ledger, or changes the role they have. Only owners may do this.
+func (s *LedgerService) AddMember(ctx context.Context, userID, ledgerID primitive.ObjectID, invite models.MemberInvite) (*models.LedgerMember, error) {
+	if !invite.Role.Valid() {
+		return nil, ErrInvalidRole
+	}
+	email, err := normalizeEmail(invite.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	var member models.LedgerMember
+	err = s.store.WithTransaction(ctx, func(ctx context.Context) error {
+		ledger, err := s.ownedLedger(ctx, userID, ledgerID)
+		if err != nil {
+			return err
+		}
+		invitee, err := s.users.GetByEmail(ctx, email)
+		if err == store.ErrNotFound {
+			return ErrUnknownUser
+		}
+		if err != nil {
+			return err
+		}
+
+		member = models.LedgerMember{UserID: invitee.ID, Role: invite.Role, Email: invitee.Email}
+		if err := checkKeepsOwner(ledger, member.UserID, member.Role); err != nil {
+			return err
+		}
+		return s.ledgers.PutMember(ctx, ledgerID, member)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// RemoveMember takes memberID out of the ledger. Owners may remove anyone;
+// other members may only remove themselves, to leave the ledger.
+func (s *LedgerService) RemoveMember(ctx context.Context, userID, ledgerID, memberID primitive.ObjectID) error {
+	return s.store.WithTransaction(ctx, func(ctx context.Context) error {
+		var ledger *models.Ledger
+		var err error
+		if memberID == userID {
+			if _, err := s.Role(ctx, ledgerID, userID); err != nil {
+				return err
+			}
+			ledger, err = s.ledgers.Get(ctx, ledgerID)
+		} else {
+			ledger, err = s.ownedLedger(ctx, userID, ledgerID)
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, ok := ledger.Role(memberID); !ok {
+			return store.ErrNotFound
+		}
+		if err := checkKeepsOwner(ledger, memberID, ""); err != nil {
+			return err
+		}
+		return s.ledgers.RemoveMember(ctx, ledgerID, memberID)
+	})
+}
+
+// ownedLedger returns the ledger if userID is one of its owners.
+func (s *LedgerService) ownedLedger(ctx context.Context, userID, ledgerID primitive.ObjectID) (*models.Ledger, error) {
+	role, err := s.Role(ctx, ledgerID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if role != models.RoleOwner {
+		return nil, ErrNotLedgerOwner
+	}
+	return s.ledgers.Get(ctx, ledgerID)
+}
+
+// checkKeepsOwner returns ErrLastOwner when giving userID the role newRole
+// (none when empty) would leave the ledger without an owner.
+func checkKeepsOwner(ledger *models.Ledger, userID primitive.ObjectID, newRole models.Role) error {
+	if newRole == models.RoleOwner {
+		return nil
+	}
+	for _, m := range ledger.Members {
+		if m.Role == models.RoleOwner && m.UserID != userID {
+			return nil
+		}
+	}
+	return ErrLastOwner
+}
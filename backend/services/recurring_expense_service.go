@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidFrequency = errors.New("frequency must be daily, weekly, monthly or yearly")
+	ErrInvalidInterval  = errors.New("interval must be at least 1")
+	ErrMissingStartDate = errors.New("start date is required")
+	ErrEndBeforeStart   = errors.New("end date is before the start date")
+)
+
+type RecurringExpenseService struct {
+	store      store.Transactor
+	recurring  store.RecurringExpenseStore
+	expenses   store.ExpenseStore
+	categories store.CategoryStore
+}
+
+func NewRecurringExpenseService(st store.Store) *RecurringExpenseService {
+	return &RecurringExpenseService{
+		store:      st,
+		recurring:  st.RecurringExpenses(),
+		expenses:   st.Expenses(),
+		categories: st.Categories(),
+	}
+}
+
+func (s *RecurringExpenseService) GetRecurringExpenses(ctx context.Context, ledgerID primitive.ObjectID) ([]models.RecurringExpense, error) {
+	return s.recurring.List(ctx, ledgerID)
+}
+
+func (s *RecurringExpenseService) CreateRecurringExpense(ctx context.Context, ledgerID primitive.ObjectID, recurring *models.RecurringExpense) error {
+	recurring.LedgerID = ledgerID
+	if err := s.check(ctx, recurring); err != nil {
+		return err
+	}
+	recurring.NextIndex = 0
+	recurring.NextDate = recurring.StartDate
+	recurring.Done = recurring.After(recurring.NextDate)
+	recurring.CreatedAt = time.Now()
+	recurring.UpdatedAt = recurring.CreatedAt
+	return s.recurring.Insert(ctx, recurring)
+}
+
+// UpdateRecurringExpense replaces the template's details. When the schedule
+// changes, the next occurrence becomes the first one of the new schedule
+// after the last expense already created, so nothing is created twice.
+func (s *RecurringExpenseService) UpdateRecurringExpense(ctx context.Context, ledgerID primitive.ObjectID, recurring *models.RecurringExpense) error {
+	recurring.LedgerID = ledgerID
+	if err := s.check(ctx, recurring); err != nil {
+		return err
+	}
+
+	return s.store.WithTransaction(ctx, func(ctx context.Context) error {
+		old, err := s.recurring.Get(ctx, ledgerID, recurring.ID)
+		if err != nil {
+			return err
+		}
+		recurring.CreatedAt = old.CreatedAt
+		recurring.UpdatedAt = time.Now()
+		recurring.NextIndex, recurring.NextDate = old.NextIndex, old.NextDate
+		if !sameSchedule(old, recurring) {
+			var last time.Time
+			if old.NextIndex > 0 {
+				last = old.Occurrence(old.NextIndex - 1)
+			}
+			recurring.NextIndex = 0
+			for recurring.NextDate = recurring.StartDate; !recurring.NextDate.After(last); {
+				recurring.NextIndex++
+				recurring.NextDate = recurring.Occurrence(recurring.NextIndex)
+			}
+		}
+		recurring.Done = recurring.After(recurring.NextDate)
+		return s.recurring.Update(ctx, recurring)
+	})
+}
+
+func (s *RecurringExpenseService) DeleteRecurringExpense(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	return s.recurring.Delete(ctx, ledgerID, id)
+}
+
+func (s *RecurringExpenseService) check(ctx context.Context, recurring *models.RecurringExpense) error {
+	if !recurring.Frequency.Valid() {
+		return ErrInvalidFrequency
+	}
+	if recurring.Interval == 0 {
+		recurring.Interval = 1
+	}
+	if recurring.Interval < 0 {
+		return ErrInvalidInterval
+	}
+	if recurring.StartDate.IsZero() {
+		return ErrMissingStartDate
+	}
+	if recurring.EndDate != nil && recurring.EndDate.Before(recurring.StartDate) {
+		return ErrEndBeforeStart
+	}
+	if err := checkCurrency(&recurring.Currency); err != nil {
+		return err
+	}
+	return checkCategory(ctx, s.categories, recurring.LedgerID, recurring.CategoryID)
+}
+
+func sameSchedule(a, b *models.RecurringExpense) bool {
+	return a.Frequency == b.Frequency && a.Interval == b.Interval && a.StartDate.Equal(b.StartDate)
+}
+
+// Run materialises due occurrences straight away, catching up on whatever
+// fell due while the server was down, and then every interval until ctx is
+// cancelled.
+func (s *RecurringExpenseService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		n, err := s.MaterialiseDue(ctx, time.Now())
+		if err != nil {
+			log.Printf("Error materialising recurring expenses: %v", err)
+		} else if n > 0 {
+			log.Printf("Created %d recurring expenses", n)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// MaterialiseDue creates an expense for every occurrence of every ledger's
+// recurring expenses that is due at now, and returns how many it created.
+//
+// Each occurrence is created in its own transaction together with moving
+// the template on to the next one, and the expense ID is derived from the
+// template and the occurrence date. An occurrence therefore becomes exactly
+// one expense, even when the server stops halfway or two servers race.
+func (s *RecurringExpenseService) MaterialiseDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.recurring.Due(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, r := range due {
+		for !r.Done && !r.NextDate.After(now) {
+			err := s.materialise(ctx, &r)
+			if errors.Is(err, store.ErrNotFound) || errors.Is(err, store.ErrDuplicate) {
+				// Deleted, edited or handled elsewhere in the meantime.
+				break
+			}
+			if err != nil {
+				return created, err
+			}
+			created++
+		}
+	}
+	return created, nil
+}
+
+// materialise creates the expense for the next occurrence of recurring and
+// advances it to the following one.
+func (s *RecurringExpenseService) materialise(ctx context.Context, recurring *models.RecurringExpense) error {
+	next := *recurring
+	next.NextIndex++
+	next.NextDate = next.Occurrence(next.NextIndex)
+	next.Done = next.After(next.NextDate)
+
+	err := s.store.WithTransaction(ctx, func(ctx context.Context) error {
+		expense := models.Expense{
+			ID:         occurrenceID(recurring.ID, recurring.NextDate),
+			LedgerID:   recurring.LedgerID,
+			Name:       recurring.Name,
+			Amount:     recurring.Amount,
+			Currency:   recurring.Currency,
+			Date:       recurring.NextDate,
+			CategoryID: recurring.CategoryID,
+		}
+		if err := s.expenses.Insert(ctx, &expense); err != nil {
+			return err
+		}
+		return s.recurring.Advance(ctx, &next, recurring.NextIndex)
+	})
+	if err != nil {
+		return err
+	}
+	*recurring = next
+	return nil
+}
+
+// occurrenceID is the ID of the expense created for the occurrence of a
+// recurring expense on date.
+func occurrenceID(recurringID primitive.ObjectID, date time.Time) primitive.ObjectID {
+	sum := sha256.Sum256([]byte(recurringID.Hex() + date.UTC().Format(time.RFC3339Nano)))
+	var id primitive.ObjectID
+	copy(id[:], sum[:])
+	return id
+}
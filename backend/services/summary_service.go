@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"sort"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SummaryService struct {
+	expenses   store.ExpenseStore
+	categories store.CategoryStore
+	rates      *ExchangeRateService
+}
+
+func NewSummaryService(st store.Store, rates *ExchangeRateService) *SummaryService {
+	return &SummaryService{
+		expenses:   st.Expenses(),
+		categories: st.Categories(),
+		rates:      rates,
+	}
+}
+
+// GetSummary totals the expenses matching query, overall and per category,
+// converted to the ledger's base currency. The database sums them per
+// category, currency and day, each day's sum being converted at that day's
+// rate. Like the expense list, it leaves out expenses whose category is
+// gone. Sorting and paging in query are ignored.
+func (s *SummaryService) GetSummary(ctx context.Context, query store.ExpenseQuery) (*models.ExpenseSummary, error) {
+	conv, err := s.rates.converter(ctx, query.LedgerID)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := s.expenses.Totals(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	list, err := s.categories.List(ctx, query.LedgerID)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.ExpenseSummary{BaseCurrency: conv.base, Categories: []models.CategoryTotal{}}
+	original := make(originalTotals)
+	categories := make(map[primitive.ObjectID]*models.CategoryTotal, len(list))
+	categoryOriginal := make(map[primitive.ObjectID]originalTotals)
+	for _, c := range list {
+		categories[c.ID] = &models.CategoryTotal{CategoryID: c.ID, Name: c.Name, Color: c.Color}
+	}
+	for _, t := range totals {
+		total, ok := categories[t.CategoryID]
+		if !ok {
+			continue
+		}
+		m := money.Money{Amount: t.Amount, Currency: t.Currency}
+		converted, err := conv.convert(m, t.Day)
+		if err != nil {
+			return nil, err
+		}
+		summary.Count += t.Count
+		summary.Total += converted
+		original.add(m)
+
+		if categoryOriginal[t.CategoryID] == nil {
+			categoryOriginal[t.CategoryID] = make(originalTotals)
+		}
+		total.Count += t.Count
+		total.Total += converted
+		categoryOriginal[t.CategoryID].add(m)
+	}
+
+	summary.Original = original.list()
+	for id, total := range categories {
+		if total.Count == 0 {
+			continue
+		}
+		total.Original = categoryOriginal[id].list()
+		summary.Categories = append(summary.Categories, *total)
+	}
+	sort.Slice(summary.Categories, func(i, j int) bool {
+		a, b := summary.Categories[i], summary.Categories[j]
+		if a.Total != b.Total {
+			return a.Total > b.Total
+		}
+		return a.Name < b.Name
+	})
+	return summary, nil
+}
+
+// originalTotals sums amounts per currency, without converting them.
+type originalTotals map[money.Currency]money.Amount
+
+func (t originalTotals) add(m money.Money) {
+	t[m.Currency] += m.Amount
+}
+
+// list returns the sums ordered by currency code.
+func (t originalTotals) list() []money.Money {
+	list := make([]money.Money, 0, len(t))
+	for c, a := range t {
+		list = append(list, money.Money{Amount: a, Currency: c})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Currency < list[j].Currency })
+	return list
+}
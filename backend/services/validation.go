@@ -0,0 +1,39 @@
+package services
+
+import (
+	"sort"
+	"strings"
+)
+
+// ValidationError lists what is wrong with the fields of a request, by
+// their JSON name.
+type ValidationError struct {
+	Fields map[string]string `json:"fields"`
+}
+
+// add records err against field, keeping the first error of each field.
+func (e *ValidationError) add(field string, err error) {
+	if e.Fields == nil {
+		e.Fields = map[string]string{}
+	}
+	if _, ok := e.Fields[field]; !ok {
+		e.Fields[field] = err.Error()
+	}
+}
+
+// err returns e, or nil when no field was found wrong.
+func (e *ValidationError) err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for field, msg := range e.Fields {
+		fields = append(fields, field+": "+msg)
+	}
+	sort.Strings(fields)
+	return "invalid " + strings.Join(fields, ", ")
+}
@@ -0,0 +1,90 @@
+// Package fsblob implements store.BlobStore with files in a local
+// directory.
+package fsblob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/dhruwanga19/expense-tracker/store"
+)
+
+// Store keeps every blob in a file named after its hash, in a subdirectory
+// named after the hash's first two characters so that no directory grows
+// too large.
+type Store struct {
+	dir string
+}
+
+// New returns a Store keeping blobs in dir, which is created if needed.
+func New(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create blob directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+func (s *Store) path(hash string) string {
+	return filepath.Join(s.dir, hash[:2], hash)
+}
+
+func (s *Store) Put(ctx context.Context, content []byte) (string, error) {
+	hash := store.ContentHash(content)
+	path := s.path(hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return "", err
+	}
+
+	// Write to a temporary file and rename it into place, so that a blob is
+	// never seen half written.
+	f, err := os.CreateTemp(filepath.Dir(path), hash+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+func (s *Store) Open(ctx context.Context, hash string) (io.ReadCloser, error) {
+	if !store.IsContentHash(hash) {
+		return nil, store.ErrNotFound
+	}
+	f, err := os.Open(s.path(hash))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, store.ErrNotFound
+	}
+	return f, err
+}
+
+func (s *Store) Delete(ctx context.Context, hash string) error {
+	if !store.IsContentHash(hash) {
+		return nil
+	}
+	err := os.Remove(s.path(hash))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,15 @@
+package fsblob
+
+import (
+	"testing"
+
+	"github.com/dhruwanga19/expense-tracker/store/storetest"
+)
+
+func TestStore(t *testing.T) {
+	s, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	storetest.RunBlobs(t, s)
+}
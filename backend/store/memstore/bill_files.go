@@ -0,0 +1,65 @@
+package memstore
+
+import (
+	"context"
+	"slices"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type billFileStore struct {
+	s *Store
+}
+
+func (fs *billFileStore) Put(ctx context.Context, file *models.BillFile) error {
+	defer fs.s.lock(ctx)()
+
+	for i, f := range fs.s.data.billFiles {
+		if f.BillID == file.BillID && f.Kind == file.Kind {
+			fs.s.data.billFiles[i] = *file
+			return nil
+		}
+	}
+	fs.s.data.billFiles = append(fs.s.data.billFiles, *file)
+	return nil
+}
+
+func (fs *billFileStore) Get(ctx context.Context, ledgerID, billID primitive.ObjectID, kind string) (*models.BillFile, error) {
+	defer fs.s.rlock(ctx)()
+
+	for _, f := range fs.s.data.billFiles {
+		if f.LedgerID == ledgerID && f.BillID == billID && f.Kind == kind {
+			return &f, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
+
+func (fs *billFileStore) List(ctx context.Context, ledgerID, billID primitive.ObjectID) ([]models.BillFile, error) {
+	defer fs.s.rlock(ctx)()
+
+	var files []models.BillFile
+	for _, f := range fs.s.data.billFiles {
+		if f.LedgerID == ledgerID && f.BillID == billID {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+func (fs *billFileStore) DeleteForBill(ctx context.Context, ledgerID, billID primitive.ObjectID) error {
+	defer fs.s.lock(ctx)()
+
+	fs.s.data.billFiles = slices.DeleteFunc(fs.s.data.billFiles, func(f models.BillFile) bool {
+		return f.LedgerID == ledgerID && f.BillID == billID
+	})
+	return nil
+}
+
+func (fs *billFileStore) HashUsed(ctx context.Context, hash string) (bool, error) {
+	defer fs.s.rlock(ctx)()
+
+	return slices.ContainsFunc(fs.s.data.billFiles, func(f models.BillFile) bool { return f.Hash == hash }), nil
+}
@@ -0,0 +1,84 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type billJobStore struct {
+	s *Store
+}
+
+func (js *billJobStore) Insert(ctx context.Context, job *models.BillJob) error {
+	defer js.s.lock(ctx)()
+
+	if job.ID.IsZero() {
+		job.ID = primitive.NewObjectID()
+	}
+	js.s.data.billJobs = append(js.s.data.billJobs, *job)
+	return nil
+}
+
+func (js *billJobStore) Claim(ctx context.Context, now, leaseUntil time.Time) (*models.BillJob, error) {
+	defer js.s.lock(ctx)()
+
+	next := -1
+	for i, j := range js.s.data.billJobs {
+		if j.RunAt.After(now) {
+			continue
+		}
+		if next < 0 {
+			next = i
+			continue
+		}
+		if cmp := j.RunAt.Compare(js.s.data.billJobs[next].RunAt); cmp < 0 || cmp == 0 && bytes.Compare(j.ID[:], js.s.data.billJobs[next].ID[:]) < 0 {
+			next = i
+		}
+	}
+	if next < 0 {
+		return nil, store.ErrNotFound
+	}
+	job := &js.s.data.billJobs[next]
+	job.RunAt = leaseUntil
+	job.Attempts++
+	claimed := *job
+	return &claimed, nil
+}
+
+func (js *billJobStore) Retry(ctx context.Context, job *models.BillJob) error {
+	defer js.s.lock(ctx)()
+
+	for i := range js.s.data.billJobs {
+		if js.s.data.billJobs[i].ID == job.ID {
+			js.s.data.billJobs[i].RunAt = job.RunAt
+			js.s.data.billJobs[i].LastError = job.LastError
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
+
+func (js *billJobStore) Delete(ctx context.Context, id primitive.ObjectID) error {
+	defer js.s.lock(ctx)()
+
+	for i, j := range js.s.data.billJobs {
+		if j.ID == id {
+			js.s.data.billJobs = append(js.s.data.billJobs[:i], js.s.data.billJobs[i+1:]...)
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
+
+func (js *billJobStore) DeleteForBill(ctx context.Context, billID primitive.ObjectID) error {
+	defer js.s.lock(ctx)()
+
+	js.s.data.billJobs = slices.DeleteFunc(js.s.data.billJobs, func(j models.BillJob) bool { return j.BillID == billID })
+	return nil
+}
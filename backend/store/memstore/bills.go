@@ -0,0 +1,132 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type billStore struct {
+	s *Store
+}
+
+func (bs *billStore) List(ctx context.Context, query store.BillQuery) ([]models.Bill, error) {
+	defer bs.s.rlock(ctx)()
+
+	var bills []models.Bill
+	for _, b := range bs.s.data.bills {
+		if matchesBill(b, query) && (query.After == nil || compareBills(b, *query.After) > 0) {
+			bills = append(bills, cloneBill(b))
+		}
+	}
+	sort.SliceStable(bills, func(i, j int) bool {
+		return compareBills(bills[i], store.BillCursor{ID: bills[j].ID, UploadDate: bills[j].UploadDate}) < 0
+	})
+	if query.Limit > 0 && len(bills) > query.Limit {
+		bills = bills[:query.Limit]
+	}
+	return bills, nil
+}
+
+func matchesBill(b models.Bill, query store.BillQuery) bool {
+	if b.LedgerID != query.LedgerID {
+		return false
+	}
+	if len(query.Statuses) > 0 && !slices.Contains(query.Statuses, b.Status) {
+		return false
+	}
+	if !query.From.IsZero() && b.UploadDate.Before(query.From) {
+		return false
+	}
+	if !query.To.IsZero() && !b.UploadDate.Before(query.To) {
+		return false
+	}
+	if query.Merchant != "" && !strings.Contains(strings.ToLower(b.Merchant), strings.ToLower(query.Merchant)) {
+		return false
+	}
+	return true
+}
+
+// compareBills orders b against the cursor c, newest upload first.
+func compareBills(b models.Bill, c store.BillCursor) int {
+	cmp := c.UploadDate.Compare(b.UploadDate)
+	if cmp == 0 {
+		cmp = bytes.Compare(c.ID[:], b.ID[:])
+	}
+	return cmp
+}
+
+func (bs *billStore) Insert(ctx context.Context, bill *models.Bill) error {
+	defer bs.s.lock(ctx)()
+
+	if bill.ID.IsZero() {
+		bill.ID = primitive.NewObjectID()
+	}
+	bs.s.data.bills = append(bs.s.data.bills, cloneBill(*bill))
+	return nil
+}
+
+func (bs *billStore) Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.Bill, error) {
+	defer bs.s.rlock(ctx)()
+
+	i := bs.s.data.billIndex(ledgerID, id)
+	if i < 0 {
+		return nil, store.ErrNotFound
+	}
+	bill := cloneBill(bs.s.data.bills[i])
+	return &bill, nil
+}
+
+func (bs *billStore) Update(ctx context.Context, bill *models.Bill) error {
+	defer bs.s.lock(ctx)()
+
+	i := bs.s.data.billIndex(bill.LedgerID, bill.ID)
+	if i < 0 {
+		return store.ErrNotFound
+	}
+	bs.s.data.bills[i] = cloneBill(*bill)
+	return nil
+}
+
+func (bs *billStore) UpdateGeneratedExpense(ctx context.Context, ledgerID, billID primitive.ObjectID, expense *models.Expense) error {
+	defer bs.s.lock(ctx)()
+
+	i := bs.s.data.billIndex(ledgerID, billID)
+	if i < 0 {
+		return store.ErrNotFound
+	}
+	generated := bs.s.data.bills[i].GeneratedExpenses
+	for j := range generated {
+		if generated[j].ID == expense.ID {
+			generated[j] = cloneExpense(*expense)
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
+
+func (bs *billStore) Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	defer bs.s.lock(ctx)()
+
+	i := bs.s.data.billIndex(ledgerID, id)
+	if i < 0 {
+		return store.ErrNotFound
+	}
+	bs.s.data.bills = append(bs.s.data.bills[:i], bs.s.data.bills[i+1:]...)
+	return nil
+}
+
+func (d *data) billIndex(ledgerID, id primitive.ObjectID) int {
+	for i, b := range d.bills {
+		if b.ID == id && b.LedgerID == ledgerID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,48 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync"
+
+	"github.com/dhruwanga19/expense-tracker/store"
+)
+
+// BlobStore keeps blobs in memory. Like the blob stores backed by files, it
+// does not take part in transactions.
+type BlobStore struct {
+	mu    sync.RWMutex
+	blobs map[string][]byte
+}
+
+func NewBlobStore() *BlobStore {
+	return &BlobStore{blobs: make(map[string][]byte)}
+}
+
+func (bs *BlobStore) Put(ctx context.Context, content []byte) (string, error) {
+	hash := store.ContentHash(content)
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	if _, ok := bs.blobs[hash]; !ok {
+		bs.blobs[hash] = bytes.Clone(content)
+	}
+	return hash, nil
+}
+
+func (bs *BlobStore) Open(ctx context.Context, hash string) (io.ReadCloser, error) {
+	bs.mu.RLock()
+	defer bs.mu.RUnlock()
+	content, ok := bs.blobs[hash]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func (bs *BlobStore) Delete(ctx context.Context, hash string) error {
+	bs.mu.Lock()
+	defer bs.mu.Unlock()
+	delete(bs.blobs, hash)
+	return nil
+}
@@ -0,0 +1,56 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type budgetBalanceStore struct {
+	s *Store
+}
+
+func (bs *budgetBalanceStore) List(ctx context.Context, ledgerID, goalID primitive.ObjectID) ([]models.BudgetBalance, error) {
+	defer bs.s.rlock(ctx)()
+
+	var balances []models.BudgetBalance
+	for _, b := range bs.s.data.balances {
+		if b.LedgerID == ledgerID && b.GoalID == goalID {
+			balances = append(balances, b)
+		}
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].From.Before(balances[j].From) })
+	return balances, nil
+}
+
+func (bs *budgetBalanceStore) Put(ctx context.Context, balance *models.BudgetBalance) error {
+	defer bs.s.lock(ctx)()
+
+	for i, b := range bs.s.data.balances {
+		if b.LedgerID == balance.LedgerID && b.GoalID == balance.GoalID && b.From.Equal(balance.From) {
+			balance.ID = b.ID
+			bs.s.data.balances[i] = *balance
+			return nil
+		}
+	}
+	if balance.ID.IsZero() {
+		balance.ID = primitive.NewObjectID()
+	}
+	bs.s.data.balances = append(bs.s.data.balances, *balance)
+	return nil
+}
+
+func (bs *budgetBalanceStore) DeleteForGoal(ctx context.Context, ledgerID, goalID primitive.ObjectID) error {
+	defer bs.s.lock(ctx)()
+
+	kept := bs.s.data.balances[:0]
+	for _, b := range bs.s.data.balances {
+		if b.LedgerID != ledgerID || b.GoalID != goalID {
+			kept = append(kept, b)
+		}
+	}
+	bs.s.data.balances = kept
+	return nil
+}
@@ -0,0 +1,77 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type budgetGoalStore struct {
+	s *Store
+}
+
+func (gs *budgetGoalStore) List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.BudgetGoal, error) {
+	defer gs.s.rlock(ctx)()
+
+	var goals []models.BudgetGoal
+	for _, g := range gs.s.data.budgetGoals {
+		if g.LedgerID == ledgerID {
+			goals = append(goals, g)
+		}
+	}
+	return goals, nil
+}
+
+func (gs *budgetGoalStore) Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.BudgetGoal, error) {
+	defer gs.s.rlock(ctx)()
+
+	i := gs.s.data.budgetGoalIndex(ledgerID, id)
+	if i < 0 {
+		return nil, store.ErrNotFound
+	}
+	goal := gs.s.data.budgetGoals[i]
+	return &goal, nil
+}
+
+func (gs *budgetGoalStore) Insert(ctx context.Context, goal *models.BudgetGoal) error {
+	defer gs.s.lock(ctx)()
+
+	if goal.ID.IsZero() {
+		goal.ID = primitive.NewObjectID()
+	}
+	gs.s.data.budgetGoals = append(gs.s.data.budgetGoals, *goal)
+	return nil
+}
+
+func (gs *budgetGoalStore) Update(ctx context.Context, goal *models.BudgetGoal) error {
+	defer gs.s.lock(ctx)()
+
+	i := gs.s.data.budgetGoalIndex(goal.LedgerID, goal.ID)
+	if i < 0 {
+		return store.ErrNotFound
+	}
+	gs.s.data.budgetGoals[i] = *goal
+	return nil
+}
+
+func (gs *budgetGoalStore) Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	defer gs.s.lock(ctx)()
+
+	i := gs.s.data.budgetGoalIndex(ledgerID, id)
+	if i < 0 {
+		return store.ErrNotFound
+	}
+	gs.s.data.budgetGoals = append(gs.s.data.budgetGoals[:i], gs.s.data.budgetGoals[i+1:]...)
+	return nil
+}
+
+func (d *data) budgetGoalIndex(ledgerID, id primitive.ObjectID) int {
+	for i, g := range d.budgetGoals {
+		if g.ID == id && g.LedgerID == ledgerID {
+			return i
+		}
+	}
+	return -1
+}
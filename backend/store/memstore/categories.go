@@ -0,0 +1,101 @@
+package memstore
+
+import (
+	"context"
+	"strings"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type categoryStore struct {
+	s *Store
+}
+
+func (cs *categoryStore) List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.Category, error) {
+	defer cs.s.rlock(ctx)()
+
+	var categories []models.Category
+	for _, c := range cs.s.data.categories {
+		if c.LedgerID == ledgerID {
+			categories = append(categories, c)
+		}
+	}
+	return categories, nil
+}
+
+func (cs *categoryStore) Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.Category, error) {
+	defer cs.s.rlock(ctx)()
+
+	i := cs.s.data.categoryIndex(ledgerID, id)
+	if i < 0 {
+		return nil, store.ErrNotFound
+	}
+	category := cs.s.data.categories[i]
+	return &category, nil
+}
+
+func (cs *categoryStore) Insert(ctx context.Context, category *models.Category) error {
+	defer cs.s.lock(ctx)()
+
+	if category.ID.IsZero() {
+		category.ID = primitive.NewObjectID()
+	}
+	cs.s.data.categories = append(cs.s.data.categories, *category)
+	return nil
+}
+
+func (cs *categoryStore) Update(ctx context.Context, category *models.Category) error {
+	defer cs.s.lock(ctx)()
+
+	i := cs.s.data.categoryIndex(category.LedgerID, category.ID)
+	if i < 0 {
+		return store.ErrNotFound
+	}
+	cs.s.data.categories[i].Name = category.Name
+	cs.s.data.categories[i].Color = category.Color
+	return nil
+}
+
+func (cs *categoryStore) Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	defer cs.s.lock(ctx)()
+
+	i := cs.s.data.categoryIndex(ledgerID, id)
+	if i < 0 {
+		return store.ErrNotFound
+	}
+	cs.s.data.categories = append(cs.s.data.categories[:i], cs.s.data.categories[i+1:]...)
+	return nil
+}
+
+func (cs *categoryStore) NameExists(ctx context.Context, ledgerID primitive.ObjectID, name string, excludeID primitive.ObjectID) (bool, error) {
+	defer cs.s.rlock(ctx)()
+
+	for _, c := range cs.s.data.categories {
+		if c.LedgerID == ledgerID && c.ID != excludeID && strings.EqualFold(c.Name, name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (cs *categoryStore) ColorExists(ctx context.Context, ledgerID primitive.ObjectID, color string, excludeID primitive.ObjectID) (bool, error) {
+	defer cs.s.rlock(ctx)()
+
+	for _, c := range cs.s.data.categories {
+		if c.LedgerID == ledgerID && c.ID != excludeID && c.Color == color {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (d *data) categoryIndex(ledgerID, id primitive.ObjectID) int {
+	for i, c := range d.categories {
+		if c.ID == id && c.LedgerID == ledgerID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,101 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type categoryRuleStore struct {
+	s *Store
+}
+
+func (rs *categoryRuleStore) List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.CategoryRule, error) {
+	defer rs.s.rlock(ctx)()
+
+	var rules []models.CategoryRule
+	for _, r := range rs.s.data.categoryRules {
+		if r.LedgerID == ledgerID {
+			rules = append(rules, cloneCategoryRule(r))
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority < rules[j].Priority
+		}
+		return bytes.Compare(rules[i].ID[:], rules[j].ID[:]) < 0
+	})
+	return rules, nil
+}
+
+func (rs *categoryRuleStore) Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.CategoryRule, error) {
+	defer rs.s.rlock(ctx)()
+
+	i := rs.s.data.categoryRuleIndex(ledgerID, id)
+	if i < 0 {
+		return nil, store.ErrNotFound
+	}
+	r := cloneCategoryRule(rs.s.data.categoryRules[i])
+	return &r, nil
+}
+
+func (rs *categoryRuleStore) Insert(ctx context.Context, rule *models.CategoryRule) error {
+	defer rs.s.lock(ctx)()
+
+	if rule.ID.IsZero() {
+		rule.ID = primitive.NewObjectID()
+	}
+	rs.s.data.categoryRules = append(rs.s.data.categoryRules, cloneCategoryRule(*rule))
+	return nil
+}
+
+func (rs *categoryRuleStore) Update(ctx context.Context, rule *models.CategoryRule) error {
+	defer rs.s.lock(ctx)()
+
+	i := rs.s.data.categoryRuleIndex(rule.LedgerID, rule.ID)
+	if i < 0 {
+		return store.ErrNotFound
+	}
+	rs.s.data.categoryRules[i] = cloneCategoryRule(*rule)
+	return nil
+}
+
+func (rs *categoryRuleStore) Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	defer rs.s.lock(ctx)()
+
+	i := rs.s.data.categoryRuleIndex(ledgerID, id)
+	if i < 0 {
+		return store.ErrNotFound
+	}
+	rs.s.data.categoryRules = append(rs.s.data.categoryRules[:i], rs.s.data.categoryRules[i+1:]...)
+	return nil
+}
+
+func (rs *categoryRuleStore) DeleteByCategory(ctx context.Context, ledgerID, categoryID primitive.ObjectID) (int64, error) {
+	defer rs.s.lock(ctx)()
+
+	var deleted int64
+	kept := rs.s.data.categoryRules[:0]
+	for _, r := range rs.s.data.categoryRules {
+		if r.LedgerID == ledgerID && r.CategoryID == categoryID {
+			deleted++
+			continue
+		}
+		kept = append(kept, r)
+	}
+	rs.s.data.categoryRules = kept
+	return deleted, nil
+}
+
+func (d *data) categoryRuleIndex(ledgerID, id primitive.ObjectID) int {
+	for i, r := range d.categoryRules {
+		if r.ID == id && r.LedgerID == ledgerID {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,56 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type exchangeRateStore struct {
+	s *Store
+}
+
+func (rs *exchangeRateStore) List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.ExchangeRate, error) {
+	defer rs.s.rlock(ctx)()
+
+	var rates []models.ExchangeRate
+	for _, r := range rs.s.data.exchangeRates {
+		if r.LedgerID == ledgerID {
+			rates = append(rates, r)
+		}
+	}
+	sort.SliceStable(rates, func(i, j int) bool { return rates[i].Date.Before(rates[j].Date) })
+	return rates, nil
+}
+
+func (rs *exchangeRateStore) Put(ctx context.Context, rate *models.ExchangeRate) error {
+	defer rs.s.lock(ctx)()
+
+	for i, r := range rs.s.data.exchangeRates {
+		if r.LedgerID == rate.LedgerID && r.From == rate.From && r.To == rate.To && r.Date.Equal(rate.Date) {
+			rate.ID = r.ID
+			rs.s.data.exchangeRates[i] = *rate
+			return nil
+		}
+	}
+	if rate.ID.IsZero() {
+		rate.ID = primitive.NewObjectID()
+	}
+	rs.s.data.exchangeRates = append(rs.s.data.exchangeRates, *rate)
+	return nil
+}
+
+func (rs *exchangeRateStore) Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	defer rs.s.lock(ctx)()
+
+	for i, r := range rs.s.data.exchangeRates {
+		if r.ID == id && r.LedgerID == ledgerID {
+			rs.s.data.exchangeRates = append(rs.s.data.exchangeRates[:i], rs.s.data.exchangeRates[i+1:]...)
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
@@ -0,0 +1,210 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type expenseStore struct {
+	s *Store
+}
+
+func (es *expenseStore) List(ctx context.Context, query store.ExpenseQuery) ([]models.Expense, error) {
+	defer es.s.rlock(ctx)()
+
+	var expenses []models.Expense
+	for _, e := range es.s.data.expenses {
+		if !matchesExpense(e, query) || (query.After != nil && compareExpenses(e, *query.After, query) <= 0) {
+			continue
+		}
+		// Inner join on the category, expenses without one are dropped.
+		i := es.s.data.categoryIndex(e.LedgerID, e.CategoryID)
+		if i < 0 {
+			continue
+		}
+		category := es.s.data.categories[i]
+		e.Category = &category
+		expenses = append(expenses, e)
+	}
+
+	sort.SliceStable(expenses, func(i, j int) bool {
+		return compareExpenses(expenses[i], *store.CursorFor(expenses[j]), query) < 0
+	})
+	if query.Limit > 0 && len(expenses) > query.Limit {
+		expenses = expenses[:query.Limit]
+	}
+	return expenses, nil
+}
+
+func matchesExpense(e models.Expense, query store.ExpenseQuery) bool {
+	if e.LedgerID != query.LedgerID {
+		return false
+	}
+	if !query.From.IsZero() && e.Date.Before(query.From) {
+		return false
+	}
+	if !query.To.IsZero() && !e.Date.Before(query.To) {
+		return false
+	}
+	if query.MinAmount != nil && e.Amount < *query.MinAmount {
+		return false
+	}
+	if query.MaxAmount != nil && e.Amount > *query.MaxAmount {
+		return false
+	}
+	if len(query.CategoryIDs) > 0 && !slices.Contains(query.CategoryIDs, e.CategoryID) {
+		return false
+	}
+	if query.Name != "" && !strings.Contains(strings.ToLower(e.Name), strings.ToLower(query.Name)) {
+		return false
+	}
+	if !query.BillID.IsZero() && e.BillID != query.BillID {
+		return false
+	}
+	return true
+}
+
+// compareExpenses orders e against the cursor c in the sort order of query.
+func compareExpenses(e models.Expense, c store.ExpenseCursor, query store.ExpenseQuery) int {
+	var cmp int
+	switch query.SortBy {
+	case store.SortByAmount:
+		switch {
+		case e.Amount < c.Amount:
+			cmp = -1
+		case e.Amount > c.Amount:
+			cmp = 1
+		}
+	case store.SortByName:
+		cmp = strings.Compare(e.Name, c.Name)
+	default:
+		cmp = e.Date.Compare(c.Date)
+	}
+	if cmp == 0 {
+		cmp = bytes.Compare(e.ID[:], c.ID[:])
+	}
+	if query.SortDesc {
+		cmp = -cmp
+	}
+	return cmp
+}
+
+func (es *expenseStore) Totals(ctx context.Context, query store.ExpenseQuery) ([]store.ExpenseTotal, error) {
+	defer es.s.rlock(ctx)()
+
+	type key struct {
+		categoryID primitive.ObjectID
+		currency   money.Currency
+		day        time.Time
+	}
+	index := make(map[key]int)
+	var totals []store.ExpenseTotal
+	for _, e := range es.s.data.expenses {
+		if !matchesExpense(e, query) {
+			continue
+		}
+		d := e.Date.UTC()
+		k := key{e.CategoryID, e.Currency, time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, time.UTC)}
+		i, ok := index[k]
+		if !ok {
+			i = len(totals)
+			index[k] = i
+			totals = append(totals, store.ExpenseTotal{CategoryID: k.categoryID, Currency: k.currency, Day: k.day})
+		}
+		totals[i].Amount += e.Amount
+		totals[i].Count++
+	}
+	return totals, nil
+}
+
+func (es *expenseStore) Insert(ctx context.Context, expense *models.Expense) error {
+	defer es.s.lock(ctx)()
+
+	if expense.ID.IsZero() {
+		expense.ID = primitive.NewObjectID()
+	}
+	for _, e := range es.s.data.expenses {
+		if e.ID == expense.ID {
+			return store.ErrDuplicate
+		}
+	}
+	doc := *expense
+	doc.Category = nil
+	es.s.data.expenses = append(es.s.data.expenses, doc)
+	return nil
+}
+
+func (es *expenseStore) Update(ctx context.Context, expense *models.Expense) error {
+	defer es.s.lock(ctx)()
+
+	for i := range es.s.data.expenses {
+		if es.s.data.expenses[i].ID == expense.ID && es.s.data.expenses[i].LedgerID == expense.LedgerID {
+			doc := *expense
+			doc.Category = nil
+			doc.BillID = es.s.data.expenses[i].BillID
+			es.s.data.expenses[i] = doc
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
+
+func (es *expenseStore) DeleteMany(ctx context.Context, ledgerID primitive.ObjectID, ids []primitive.ObjectID) (int64, error) {
+	defer es.s.lock(ctx)()
+
+	remove := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+	return es.s.data.deleteExpenses(func(e models.Expense) bool { return e.LedgerID == ledgerID && remove[e.ID] }), nil
+}
+
+func (es *expenseStore) DeleteByCategory(ctx context.Context, ledgerID, categoryID primitive.ObjectID) (int64, error) {
+	defer es.s.lock(ctx)()
+
+	return es.s.data.deleteExpenses(func(e models.Expense) bool {
+		return e.LedgerID == ledgerID && e.CategoryID == categoryID
+	}), nil
+}
+
+func (es *expenseStore) DeleteByBill(ctx context.Context, ledgerID, billID primitive.ObjectID) (int64, error) {
+	defer es.s.lock(ctx)()
+
+	return es.s.data.deleteExpenses(func(e models.Expense) bool {
+		return e.LedgerID == ledgerID && e.BillID == billID
+	}), nil
+}
+
+func (es *expenseStore) UnlinkBill(ctx context.Context, ledgerID, billID primitive.ObjectID) error {
+	defer es.s.lock(ctx)()
+
+	for i, e := range es.s.data.expenses {
+		if e.LedgerID == ledgerID && e.BillID == billID {
+			es.s.data.expenses[i].BillID = primitive.NilObjectID
+		}
+	}
+	return nil
+}
+
+func (d *data) deleteExpenses(match func(models.Expense) bool) int64 {
+	var deleted int64
+	kept := d.expenses[:0]
+	for _, e := range d.expenses {
+		if match(e) {
+			deleted++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	d.expenses = kept
+	return deleted
+}
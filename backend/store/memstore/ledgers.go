@@ -0,0 +1,105 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ledgerStore struct {
+	s *Store
+}
+
+func (ls *ledgerStore) Insert(ctx context.Context, ledger *models.Ledger) error {
+	defer ls.s.lock(ctx)()
+
+	if ledger.ID.IsZero() {
+		ledger.ID = primitive.NewObjectID()
+	}
+	if ls.s.data.ledgerIndex(ledger.ID) >= 0 {
+		return store.ErrDuplicate
+	}
+	ls.s.data.ledgers = append(ls.s.data.ledgers, cloneLedger(*ledger))
+	return nil
+}
+
+func (ls *ledgerStore) Get(ctx context.Context, id primitive.ObjectID) (*models.Ledger, error) {
+	defer ls.s.rlock(ctx)()
+
+	i := ls.s.data.ledgerIndex(id)
+	if i < 0 {
+		return nil, store.ErrNotFound
+	}
+	ledger := cloneLedger(ls.s.data.ledgers[i])
+	return &ledger, nil
+}
+
+func (ls *ledgerStore) ListForUser(ctx context.Context, userID primitive.ObjectID) ([]models.Ledger, error) {
+	defer ls.s.rlock(ctx)()
+
+	var ledgers []models.Ledger
+	for _, l := range ls.s.data.ledgers {
+		if _, ok := l.Role(userID); ok {
+			ledgers = append(ledgers, cloneLedger(l))
+		}
+	}
+	return ledgers, nil
+}
+
+func (ls *ledgerStore) Update(ctx context.Context, ledger *models.Ledger) error {
+	defer ls.s.lock(ctx)()
+
+	i := ls.s.data.ledgerIndex(ledger.ID)
+	if i < 0 {
+		return store.ErrNotFound
+	}
+	ls.s.data.ledgers[i].Name = ledger.Name
+	ls.s.data.ledgers[i].BaseCurrency = ledger.BaseCurrency
+	return nil
+}
+
+func (ls *ledgerStore) PutMember(ctx context.Context, ledgerID primitive.ObjectID, member models.LedgerMember) error {
+	defer ls.s.lock(ctx)()
+
+	i := ls.s.data.ledgerIndex(ledgerID)
+	if i < 0 {
+		return store.ErrNotFound
+	}
+	ledger := &ls.s.data.ledgers[i]
+	for j := range ledger.Members {
+		if ledger.Members[j].UserID == member.UserID {
+			ledger.Members[j].Role = member.Role
+			return nil
+		}
+	}
+	ledger.Members = append(ledger.Members, models.LedgerMember{UserID: member.UserID, Role: member.Role})
+	return nil
+}
+
+func (ls *ledgerStore) RemoveMember(ctx context.Context, ledgerID, userID primitive.ObjectID) error {
+	defer ls.s.lock(ctx)()
+
+	i := ls.s.data.ledgerIndex(ledgerID)
+	if i < 0 {
+		return store.ErrNotFound
+	}
+	ledger := &ls.s.data.ledgers[i]
+	for j := range ledger.Members {
+		if ledger.Members[j].UserID == userID {
+			ledger.Members = append(ledger.Members[:j], ledger.Members[j+1:]...)
+			return nil
+		}
+	}
+	return store.ErrNotFound
+}
+
+func (d *data) ledgerIndex(id primitive.ObjectID) int {
+	for i, l := range d.ledgers {
+		if l.ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,18 @@
+package memstore
+
+import (
+	"testing"
+
+	"github.com/dhruwanga19/expense-tracker/store"
+	"github.com/dhruwanga19/expense-tracker/store/storetest"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		return New()
+	})
+}
+
+func TestBlobStore(t *testing.T) {
+	storetest.RunBlobs(t, NewBlobStore())
+}
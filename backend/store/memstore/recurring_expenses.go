@@ -0,0 +1,120 @@
+package memstore
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type recurringExpenseStore struct {
+	s *Store
+}
+
+func (rs *recurringExpenseStore) List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.RecurringExpense, error) {
+	defer rs.s.rlock(ctx)()
+
+	var recurring []models.RecurringExpense
+	for _, r := range rs.s.data.recurring {
+		if r.LedgerID == ledgerID {
+			recurring = append(recurring, cloneRecurringExpense(r))
+		}
+	}
+	return recurring, nil
+}
+
+func (rs *recurringExpenseStore) Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.RecurringExpense, error) {
+	defer rs.s.rlock(ctx)()
+
+	i := rs.s.data.recurringIndex(ledgerID, id)
+	if i < 0 {
+		return nil, store.ErrNotFound
+	}
+	r := cloneRecurringExpense(rs.s.data.recurring[i])
+	return &r, nil
+}
+
+func (rs *recurringExpenseStore) Insert(ctx context.Context, recurring *models.RecurringExpense) error {
+	defer rs.s.lock(ctx)()
+
+	if recurring.ID.IsZero() {
+		recurring.ID = primitive.NewObjectID()
+	}
+	rs.s.data.recurring = append(rs.s.data.recurring, cloneRecurringExpense(*recurring))
+	return nil
+}
+
+func (rs *recurringExpenseStore) Update(ctx context.Context, recurring *models.RecurringExpense) error {
+	defer rs.s.lock(ctx)()
+
+	i := rs.s.data.recurringIndex(recurring.LedgerID, recurring.ID)
+	if i < 0 {
+		return store.ErrNotFound
+	}
+	rs.s.data.recurring[i] = cloneRecurringExpense(*recurring)
+	return nil
+}
+
+func (rs *recurringExpenseStore) Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	defer rs.s.lock(ctx)()
+
+	i := rs.s.data.recurringIndex(ledgerID, id)
+	if i < 0 {
+		return store.ErrNotFound
+	}
+	rs.s.data.recurring = append(rs.s.data.recurring[:i], rs.s.data.recurring[i+1:]...)
+	return nil
+}
+
+func (rs *recurringExpenseStore) DeleteByCategory(ctx context.Context, ledgerID, categoryID primitive.ObjectID) (int64, error) {
+	defer rs.s.lock(ctx)()
+
+	var deleted int64
+	kept := rs.s.data.recurring[:0]
+	for _, r := range rs.s.data.recurring {
+		if r.LedgerID == ledgerID && r.CategoryID == categoryID {
+			deleted++
+			continue
+		}
+		kept = append(kept, r)
+	}
+	rs.s.data.recurring = kept
+	return deleted, nil
+}
+
+func (rs *recurringExpenseStore) Due(ctx context.Context, t time.Time) ([]models.RecurringExpense, error) {
+	defer rs.s.rlock(ctx)()
+
+	var due []models.RecurringExpense
+	for _, r := range rs.s.data.recurring {
+		if !r.Done && !r.NextDate.After(t) {
+			due = append(due, cloneRecurringExpense(r))
+		}
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].NextDate.Before(due[j].NextDate) })
+	return due, nil
+}
+
+func (rs *recurringExpenseStore) Advance(ctx context.Context, recurring *models.RecurringExpense, fromIndex int) error {
+	defer rs.s.lock(ctx)()
+
+	i := rs.s.data.recurringIndex(recurring.LedgerID, recurring.ID)
+	if i < 0 || rs.s.data.recurring[i].NextIndex != fromIndex {
+		return store.ErrNotFound
+	}
+	r := &rs.s.data.recurring[i]
+	r.NextIndex, r.NextDate, r.Done = recurring.NextIndex, recurring.NextDate, recurring.Done
+	return nil
+}
+
+func (d *data) recurringIndex(ledgerID, id primitive.ObjectID) int {
+	for i, r := range d.recurring {
+		if r.ID == id && r.LedgerID == ledgerID {
+			return i
+		}
+	}
+	return -1
+}
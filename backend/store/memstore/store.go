@@ -0,0 +1,206 @@
+// Package memstore implements the store interfaces in memory. It needs no
+// database and is meant for local development and tests.
+package memstore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type txKey struct{}
+
+type Store struct {
+	mu   sync.RWMutex
+	data data
+}
+
+// data holds every collection. Documents are kept in insertion order so
+// listings come back in the same order MongoDB would return them.
+type data struct {
+	expenses      []models.Expense
+	categories    []models.Category
+	bills         []models.Bill
+	billJobs      []models.BillJob
+	billFiles     []models.BillFile
+	budgetGoals   []models.BudgetGoal
+	balances      []models.BudgetBalance
+	recurring     []models.RecurringExpense
+	categoryRules []models.CategoryRule
+	exchangeRates []models.ExchangeRate
+	users         []models.User
+	ledgers       []models.Ledger
+}
+
+func New() *Store {
+	return &Store{}
+}
+
+func (s *Store) Expenses() store.ExpenseStore                   { return &expenseStore{s} }
+func (s *Store) Categories() store.CategoryStore                { return &categoryStore{s} }
+func (s *Store) Bills() store.BillStore                         { return &billStore{s} }
+func (s *Store) BillJobs() store.BillJobStore                   { return &billJobStore{s} }
+func (s *Store) BillFiles() store.BillFileStore                 { return &billFileStore{s} }
+func (s *Store) BudgetGoals() store.BudgetGoalStore             { return &budgetGoalStore{s} }
+func (s *Store) BudgetBalances() store.BudgetBalanceStore       { return &budgetBalanceStore{s} }
+func (s *Store) RecurringExpenses() store.RecurringExpenseStore { return &recurringExpenseStore{s} }
+func (s *Store) CategoryRules() store.CategoryRuleStore         { return &categoryRuleStore{s} }
+func (s *Store) ExchangeRates() store.ExchangeRateStore         { return &exchangeRateStore{s} }
+func (s *Store) Users() store.UserStore                         { return &userStore{s} }
+func (s *Store) Ledgers() store.LedgerStore                     { return &ledgerStore{s} }
+
+func (s *Store) ClaimUnowned(ctx context.Context, ledgerID primitive.ObjectID) error {
+	defer s.lock(ctx)()
+
+	for i := range s.data.expenses {
+		if s.data.expenses[i].LedgerID.IsZero() {
+			s.data.expenses[i].LedgerID = ledgerID
+		}
+	}
+	for i := range s.data.categories {
+		if s.data.categories[i].LedgerID.IsZero() {
+			s.data.categories[i].LedgerID = ledgerID
+		}
+	}
+	for i := range s.data.bills {
+		if s.data.bills[i].LedgerID.IsZero() {
+			s.data.bills[i].LedgerID = ledgerID
+		}
+	}
+	for i := range s.data.budgetGoals {
+		if s.data.budgetGoals[i].LedgerID.IsZero() {
+			s.data.budgetGoals[i].LedgerID = ledgerID
+		}
+	}
+	return nil
+}
+
+// WithTransaction holds the write lock for the duration of fn and restores a
+// snapshot of the data if fn fails.
+func (s *Store) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.inTx(ctx) {
+		return fn(ctx)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := s.data.clone()
+	if err := fn(context.WithValue(ctx, txKey{}, s)); err != nil {
+		s.data = snapshot
+		return err
+	}
+	return nil
+}
+
+func (s *Store) inTx(ctx context.Context) bool {
+	tx, _ := ctx.Value(txKey{}).(*Store)
+	return tx == s
+}
+
+// lock takes the write lock unless ctx already runs inside a transaction,
+// which holds it for us.
+func (s *Store) lock(ctx context.Context) func() {
+	if s.inTx(ctx) {
+		return func() {}
+	}
+	s.mu.Lock()
+	return s.mu.Unlock
+}
+
+func (s *Store) rlock(ctx context.Context) func() {
+	if s.inTx(ctx) {
+		return func() {}
+	}
+	s.mu.RLock()
+	return s.mu.RUnlock
+}
+
+func (d data) clone() data {
+	c := data{
+		expenses:      make([]models.Expense, len(d.expenses)),
+		categories:    append([]models.Category(nil), d.categories...),
+		bills:         make([]models.Bill, len(d.bills)),
+		billJobs:      append([]models.BillJob(nil), d.billJobs...),
+		billFiles:     append([]models.BillFile(nil), d.billFiles...),
+		budgetGoals:   append([]models.BudgetGoal(nil), d.budgetGoals...),
+		balances:      append([]models.BudgetBalance(nil), d.balances...),
+		recurring:     make([]models.RecurringExpense, len(d.recurring)),
+		categoryRules: make([]models.CategoryRule, len(d.categoryRules)),
+		exchangeRates: append([]models.ExchangeRate(nil), d.exchangeRates...),
+		users:         append([]models.User(nil), d.users...),
+		ledgers:       make([]models.Ledger, len(d.ledgers)),
+	}
+	for i, e := range d.expenses {
+		c.expenses[i] = cloneExpense(e)
+	}
+	for i, b := range d.bills {
+		c.bills[i] = cloneBill(b)
+	}
+	for i, l := range d.ledgers {
+		c.ledgers[i] = cloneLedger(l)
+	}
+	for i, r := range d.recurring {
+		c.recurring[i] = cloneRecurringExpense(r)
+	}
+	for i, r := range d.categoryRules {
+		c.categoryRules[i] = cloneCategoryRule(r)
+	}
+	return c
+}
+
+func cloneExpense(e models.Expense) models.Expense {
+	if e.Category != nil {
+		category := *e.Category
+		e.Category = &category
+	}
+	return e
+}
+
+func cloneBill(b models.Bill) models.Bill {
+	if b.TransactionDate != nil {
+		date := *b.TransactionDate
+		b.TransactionDate = &date
+	}
+	if b.AnalysisResults.Items != nil {
+		b.AnalysisResults.Items = append([]models.ReceiptItem(nil), b.AnalysisResults.Items...)
+	}
+	if b.GeneratedExpenses != nil {
+		expenses := make([]models.Expense, len(b.GeneratedExpenses))
+		for i, e := range b.GeneratedExpenses {
+			expenses[i] = cloneExpense(e)
+		}
+		b.GeneratedExpenses = expenses
+	}
+	return b
+}
+
+func cloneRecurringExpense(r models.RecurringExpense) models.RecurringExpense {
+	if r.EndDate != nil {
+		end := *r.EndDate
+		r.EndDate = &end
+	}
+	return r
+}
+
+func cloneCategoryRule(r models.CategoryRule) models.CategoryRule {
+	if r.MinAmount != nil {
+		min := *r.MinAmount
+		r.MinAmount = &min
+	}
+	if r.MaxAmount != nil {
+		max := *r.MaxAmount
+		r.MaxAmount = &max
+	}
+	r.Weekdays = append([]time.Weekday(nil), r.Weekdays...)
+	return r
+}
+
+func cloneLedger(l models.Ledger) models.Ledger {
+	l.Members = append([]models.LedgerMember(nil), l.Members...)
+	return l
+}
@@ -0,0 +1,54 @@
+package memstore
+
+import (
+	"context"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type userStore struct {
+	s *Store
+}
+
+func (us *userStore) Insert(ctx context.Context, user *models.User) error {
+	defer us.s.lock(ctx)()
+
+	for _, u := range us.s.data.users {
+		if u.Email == user.Email {
+			return store.ErrDuplicate
+		}
+	}
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	us.s.data.users = append(us.s.data.users, *user)
+	return nil
+}
+
+func (us *userStore) Get(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	return us.find(ctx, func(u models.User) bool { return u.ID == id })
+}
+
+func (us *userStore) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return us.find(ctx, func(u models.User) bool { return u.Email == email })
+}
+
+func (us *userStore) Count(ctx context.Context) (int64, error) {
+	defer us.s.rlock(ctx)()
+
+	return int64(len(us.s.data.users)), nil
+}
+
+func (us *userStore) find(ctx context.Context, match func(models.User) bool) (*models.User, error) {
+	defer us.s.rlock(ctx)()
+
+	for _, u := range us.s.data.users {
+		if match(u) {
+			user := u
+			return &user, nil
+		}
+	}
+	return nil, store.ErrNotFound
+}
@@ -0,0 +1,56 @@
+package mongostore
+
+import (
+	"context"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type billFileStore struct {
+	collection *mongo.Collection
+}
+
+func (s *billFileStore) Put(ctx context.Context, file *models.BillFile) error {
+	filter := bson.M{"bill_id": file.BillID, "kind": file.Kind}
+	_, err := s.collection.ReplaceOne(ctx, filter, file, options.Replace().SetUpsert(true))
+	return err
+}
+
+func (s *billFileStore) Get(ctx context.Context, ledgerID, billID primitive.ObjectID, kind string) (*models.BillFile, error) {
+	var file models.BillFile
+	err := s.collection.FindOne(ctx, bson.M{"ledger_id": ledgerID, "bill_id": billID, "kind": kind}).Decode(&file)
+	if err == mongo.ErrNoDocuments {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (s *billFileStore) List(ctx context.Context, ledgerID, billID primitive.ObjectID) ([]models.BillFile, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"ledger_id": ledgerID, "bill_id": billID})
+	if err != nil {
+		return nil, err
+	}
+	var files []models.BillFile
+	if err := cursor.All(ctx, &files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+func (s *billFileStore) DeleteForBill(ctx context.Context, ledgerID, billID primitive.ObjectID) error {
+	_, err := s.collection.DeleteMany(ctx, bson.M{"ledger_id": ledgerID, "bill_id": billID})
+	return err
+}
+
+func (s *billFileStore) HashUsed(ctx context.Context, hash string) (bool, error) {
+	n, err := s.collection.CountDocuments(ctx, bson.M{"hash": hash}, options.Count().SetLimit(1))
+	return n > 0, err
+}
@@ -0,0 +1,71 @@
+package mongostore
+
+import (
+	"context"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type billJobStore struct {
+	collection *mongo.Collection
+}
+
+func (s *billJobStore) Insert(ctx context.Context, job *models.BillJob) error {
+	if job.ID.IsZero() {
+		job.ID = primitive.NewObjectID()
+	}
+	_, err := s.collection.InsertOne(ctx, job)
+	return err
+}
+
+func (s *billJobStore) Claim(ctx context.Context, now, leaseUntil time.Time) (*models.BillJob, error) {
+	filter := bson.M{"run_at": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"run_at": leaseUntil}, "$inc": bson.M{"attempts": 1}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "run_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job models.BillJob
+	err := s.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func (s *billJobStore) Retry(ctx context.Context, job *models.BillJob) error {
+	update := bson.M{"$set": bson.M{"run_at": job.RunAt, "last_error": job.LastError}}
+	result, err := s.collection.UpdateOne(ctx, bson.M{"_id": job.ID}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *billJobStore) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := s.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *billJobStore) DeleteForBill(ctx context.Context, billID primitive.ObjectID) error {
+	_, err := s.collection.DeleteMany(ctx, bson.M{"bill_id": billID})
+	return err
+}
@@ -0,0 +1,117 @@
+package mongostore
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type billStore struct {
+	collection *mongo.Collection
+}
+
+func (s *billStore) List(ctx context.Context, query store.BillQuery) ([]models.Bill, error) {
+	filter := bson.M{"ledger_id": query.LedgerID}
+	if len(query.Statuses) > 0 {
+		filter["status"] = bson.M{"$in": query.Statuses}
+	}
+	uploaded := bson.M{}
+	if !query.From.IsZero() {
+		uploaded["$gte"] = query.From
+	}
+	if !query.To.IsZero() {
+		uploaded["$lt"] = query.To
+	}
+	if len(uploaded) > 0 {
+		filter["upload_date"] = uploaded
+	}
+	if query.Merchant != "" {
+		filter["merchant"] = primitive.Regex{Pattern: regexp.QuoteMeta(query.Merchant), Options: "i"}
+	}
+	if after := query.After; after != nil {
+		filter = bson.M{"$and": bson.A{filter, bson.M{"$or": bson.A{
+			bson.M{"upload_date": bson.M{"$lt": after.UploadDate}},
+			bson.M{"upload_date": after.UploadDate, "_id": bson.M{"$lt": after.ID}},
+		}}}}
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "upload_date", Value: -1}, {Key: "_id", Value: -1}})
+	if query.Limit > 0 {
+		opts.SetLimit(int64(query.Limit))
+	}
+	cursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	var bills []models.Bill
+	if err := cursor.All(ctx, &bills); err != nil {
+		return nil, err
+	}
+	return bills, nil
+}
+
+func (s *billStore) Insert(ctx context.Context, bill *models.Bill) error {
+	if bill.ID.IsZero() {
+		bill.ID = primitive.NewObjectID()
+	}
+	_, err := s.collection.InsertOne(ctx, bill)
+	return err
+}
+
+func (s *billStore) Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.Bill, error) {
+	var bill models.Bill
+	err := s.collection.FindOne(ctx, bson.M{"_id": id, "ledger_id": ledgerID}).Decode(&bill)
+	if err == mongo.ErrNoDocuments {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &bill, nil
+}
+
+func (s *billStore) Update(ctx context.Context, bill *models.Bill) error {
+	result, err := s.collection.ReplaceOne(ctx, bson.M{"_id": bill.ID, "ledger_id": bill.LedgerID}, bill)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *billStore) UpdateGeneratedExpense(ctx context.Context, ledgerID, billID primitive.ObjectID, expense *models.Expense) error {
+	filter := bson.M{"_id": billID, "ledger_id": ledgerID, "generated_expenses._id": expense.ID}
+	update := bson.M{
+		"$set": bson.M{
+			"generated_expenses.$": expense,
+		},
+	}
+
+	result, err := s.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *billStore) Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	result, err := s.collection.DeleteOne(ctx, bson.M{"_id": id, "ledger_id": ledgerID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package mongostore
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// BlobStore keeps blobs in GridFS, in the "blobs" bucket, as files named
+// after their hash. GridFS does not take part in transactions.
+type BlobStore struct {
+	bucket *gridfs.Bucket
+}
+
+func NewBlobStore(db *mongo.Database) (*BlobStore, error) {
+	bucket, err := gridfs.NewBucket(db, options.GridFSBucket().SetName("blobs"))
+	if err != nil {
+		return nil, err
+	}
+	return &BlobStore{bucket: bucket}, nil
+}
+
+// Blobs returns a BlobStore in the store's database.
+func (s *Store) Blobs() (*BlobStore, error) {
+	return NewBlobStore(s.db)
+}
+
+func (bs *BlobStore) Put(ctx context.Context, content []byte) (string, error) {
+	hash := store.ContentHash(content)
+	ids, err := bs.find(ctx, hash)
+	if err != nil {
+		return "", err
+	}
+	if len(ids) > 0 {
+		return hash, nil
+	}
+	_, err = bs.bucket.UploadFromStream(hash, bytes.NewReader(content))
+	return hash, err
+}
+
+func (bs *BlobStore) Open(ctx context.Context, hash string) (io.ReadCloser, error) {
+	stream, err := bs.bucket.OpenDownloadStreamByName(hash)
+	if err == gridfs.ErrFileNotFound {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+func (bs *BlobStore) Delete(ctx context.Context, hash string) error {
+	ids, err := bs.find(ctx, hash)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := bs.bucket.DeleteContext(ctx, id); err != nil && err != gridfs.ErrFileNotFound {
+			return err
+		}
+	}
+	return nil
+}
+
+// find returns the IDs of the GridFS files holding hash. Two uploads of the
+// same content racing each other may both store it.
+func (bs *BlobStore) find(ctx context.Context, hash string) ([]interface{}, error) {
+	cursor, err := bs.bucket.FindContext(ctx, bson.M{"filename": hash})
+	if err != nil {
+		return nil, err
+	}
+	var files []struct {
+		ID interface{} `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &files); err != nil {
+		return nil, err
+	}
+	ids := make([]interface{}, len(files))
+	for i, f := range files {
+		ids[i] = f.ID
+	}
+	return ids, nil
+}
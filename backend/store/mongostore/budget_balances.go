@@ -0,0 +1,61 @@
+package mongostore
+
+import (
+	"context"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type budgetBalanceStore struct {
+	collection *mongo.Collection
+}
+
+func (s *budgetBalanceStore) List(ctx context.Context, ledgerID, goalID primitive.ObjectID) ([]models.BudgetBalance, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "from", Value: 1}})
+	cursor, err := s.collection.Find(ctx, bson.M{"ledger_id": ledgerID, "goal_id": goalID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var balances []models.BudgetBalance
+	if err := cursor.All(ctx, &balances); err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+func (s *budgetBalanceStore) Put(ctx context.Context, balance *models.BudgetBalance) error {
+	if balance.ID.IsZero() {
+		balance.ID = primitive.NewObjectID()
+	}
+	filter := bson.M{"ledger_id": balance.LedgerID, "goal_id": balance.GoalID, "from": balance.From}
+	update := bson.M{
+		"$set": bson.M{
+			"to":          balance.To,
+			"carried_in":  balance.CarriedIn,
+			"budget":      balance.Budget,
+			"spent":       balance.Spent,
+			"carried_out": balance.CarriedOut,
+			"updated_at":  balance.UpdatedAt,
+		},
+		"$setOnInsert": bson.M{"_id": balance.ID},
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var stored models.BudgetBalance
+	if err := s.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&stored); err != nil {
+		return err
+	}
+	balance.ID = stored.ID
+	return nil
+}
+
+func (s *budgetBalanceStore) DeleteForGoal(ctx context.Context, ledgerID, goalID primitive.ObjectID) error {
+	_, err := s.collection.DeleteMany(ctx, bson.M{"ledger_id": ledgerID, "goal_id": goalID})
+	return err
+}
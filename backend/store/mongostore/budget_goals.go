@@ -0,0 +1,78 @@
+package mongostore
+
+import (
+	"context"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type budgetGoalStore struct {
+	collection *mongo.Collection
+}
+
+func (s *budgetGoalStore) List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.BudgetGoal, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"ledger_id": ledgerID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var goals []models.BudgetGoal
+	if err = cursor.All(ctx, &goals); err != nil {
+		return nil, err
+	}
+	return goals, nil
+}
+
+func (s *budgetGoalStore) Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.BudgetGoal, error) {
+	var goal models.BudgetGoal
+	err := s.collection.FindOne(ctx, bson.M{"_id": id, "ledger_id": ledgerID}).Decode(&goal)
+	if err == mongo.ErrNoDocuments {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &goal, nil
+}
+
+func (s *budgetGoalStore) Insert(ctx context.Context, goal *models.BudgetGoal) error {
+	if goal.ID.IsZero() {
+		goal.ID = primitive.NewObjectID()
+	}
+	_, err := s.collection.InsertOne(ctx, goal)
+	return err
+}
+
+func (s *budgetGoalStore) Update(ctx context.Context, goal *models.BudgetGoal) error {
+	// Categories are left out of $set when the goal has none, so a goal
+	// moved to another scope drops its old ones with an $unset.
+	update := bson.M{"$set": goal}
+	if len(goal.CategoryIDs) == 0 {
+		update["$unset"] = bson.M{"category_ids": ""}
+	}
+	filter := bson.M{"_id": goal.ID, "ledger_id": goal.LedgerID}
+	result, err := s.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *budgetGoalStore) Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	result, err := s.collection.DeleteOne(ctx, bson.M{"_id": id, "ledger_id": ledgerID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package mongostore
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type categoryStore struct {
+	collection *mongo.Collection
+}
+
+func (s *categoryStore) List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.Category, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"ledger_id": ledgerID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var categories []models.Category
+	if err = cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+
+	return categories, nil
+}
+
+func (s *categoryStore) Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.Category, error) {
+	var category models.Category
+	err := s.collection.FindOne(ctx, bson.M{"_id": id, "ledger_id": ledgerID}).Decode(&category)
+	if err == mongo.ErrNoDocuments {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (s *categoryStore) Insert(ctx context.Context, category *models.Category) error {
+	if category.ID.IsZero() {
+		category.ID = primitive.NewObjectID()
+	}
+	_, err := s.collection.InsertOne(ctx, category)
+	return err
+}
+
+func (s *categoryStore) Update(ctx context.Context, category *models.Category) error {
+	filter := bson.M{"_id": category.ID, "ledger_id": category.LedgerID}
+	update := bson.M{"$set": bson.M{"name": category.Name, "color": category.Color}}
+
+	result, err := s.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *categoryStore) Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	result, err := s.collection.DeleteOne(ctx, bson.M{"_id": id, "ledger_id": ledgerID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *categoryStore) NameExists(ctx context.Context, ledgerID primitive.ObjectID, name string, excludeID primitive.ObjectID) (bool, error) {
+	return s.exists(ctx, bson.M{
+		"_id":       bson.M{"$ne": excludeID},
+		"ledger_id": ledgerID,
+		"name":      bson.M{"$regex": primitive.Regex{Pattern: "^" + regexp.QuoteMeta(name) + "$", Options: "i"}},
+	})
+}
+
+func (s *categoryStore) ColorExists(ctx context.Context, ledgerID primitive.ObjectID, color string, excludeID primitive.ObjectID) (bool, error) {
+	return s.exists(ctx, bson.M{
+		"_id":       bson.M{"$ne": excludeID},
+		"ledger_id": ledgerID,
+		"color":     color,
+	})
+}
+
+func (s *categoryStore) exists(ctx context.Context, filter bson.M) (bool, error) {
+	err := s.collection.FindOne(ctx, filter).Err()
+	if err == mongo.ErrNoDocuments {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,82 @@
+package mongostore
+
+import (
+	"context"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type categoryRuleStore struct {
+	collection *mongo.Collection
+}
+
+func (s *categoryRuleStore) List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.CategoryRule, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "priority", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := s.collection.Find(ctx, bson.M{"ledger_id": ledgerID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rules []models.CategoryRule
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (s *categoryRuleStore) Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.CategoryRule, error) {
+	var rule models.CategoryRule
+	err := s.collection.FindOne(ctx, bson.M{"_id": id, "ledger_id": ledgerID}).Decode(&rule)
+	if err == mongo.ErrNoDocuments {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (s *categoryRuleStore) Insert(ctx context.Context, rule *models.CategoryRule) error {
+	if rule.ID.IsZero() {
+		rule.ID = primitive.NewObjectID()
+	}
+	_, err := s.collection.InsertOne(ctx, rule)
+	return err
+}
+
+func (s *categoryRuleStore) Update(ctx context.Context, rule *models.CategoryRule) error {
+	// Replace rather than $set so conditions that were cleared are removed.
+	result, err := s.collection.ReplaceOne(ctx, bson.M{"_id": rule.ID, "ledger_id": rule.LedgerID}, rule)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *categoryRuleStore) Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	result, err := s.collection.DeleteOne(ctx, bson.M{"_id": id, "ledger_id": ledgerID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *categoryRuleStore) DeleteByCategory(ctx context.Context, ledgerID, categoryID primitive.ObjectID) (int64, error) {
+	result, err := s.collection.DeleteMany(ctx, bson.M{"ledger_id": ledgerID, "category_id": categoryID})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
@@ -0,0 +1,58 @@
+package mongostore
+
+import (
+	"context"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type exchangeRateStore struct {
+	collection *mongo.Collection
+}
+
+func (s *exchangeRateStore) List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.ExchangeRate, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := s.collection.Find(ctx, bson.M{"ledger_id": ledgerID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var rates []models.ExchangeRate
+	if err := cursor.All(ctx, &rates); err != nil {
+		return nil, err
+	}
+	return rates, nil
+}
+
+func (s *exchangeRateStore) Put(ctx context.Context, rate *models.ExchangeRate) error {
+	if rate.ID.IsZero() {
+		rate.ID = primitive.NewObjectID()
+	}
+	filter := bson.M{"ledger_id": rate.LedgerID, "from": rate.From, "to": rate.To, "date": rate.Date}
+	update := bson.M{"$set": bson.M{"rate": rate.Rate}, "$setOnInsert": bson.M{"_id": rate.ID}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var stored models.ExchangeRate
+	if err := s.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&stored); err != nil {
+		return err
+	}
+	rate.ID = stored.ID
+	return nil
+}
+
+func (s *exchangeRateStore) Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	result, err := s.collection.DeleteOne(ctx, bson.M{"_id": id, "ledger_id": ledgerID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
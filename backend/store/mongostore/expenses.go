@@ -0,0 +1,339 @@
+package mongostore
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type expenseStore struct {
+	collection *mongo.Collection
+}
+
+func (s *expenseStore) List(ctx context.Context, query store.ExpenseQuery) ([]models.Expense, error) {
+	sortField, sortDir, after := expenseSort(query)
+
+	match := expenseFilter(query)
+	if after != nil {
+		match = bson.M{"$and": bson.A{match, after}}
+	}
+
+	// Filter and sort on the expense fields first so the indexes can be
+	// used, then join the category.
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$sort", Value: bson.D{{Key: sortField, Value: sortDir}, {Key: "_id", Value: sortDir}}}},
+		{{
+			Key: "$lookup", Value: bson.D{
+				{Key: "from", Value: "categories"},
+				{Key: "let", Value: bson.D{{Key: "categoryId", Value: "$category_id"}, {Key: "ledgerId", Value: "$ledger_id"}}},
+				{Key: "pipeline", Value: mongo.Pipeline{{{Key: "$match", Value: bson.M{"$expr": bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{"$_id", "$$categoryId"}},
+					bson.M{"$eq": bson.A{"$ledger_id", "$$ledgerId"}},
+				}}}}}}},
+				{Key: "as", Value: "category"},
+			},
+		}},
+		{{Key: "$unwind", Value: "$category"}},
+	}
+	if query.Limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: query.Limit}})
+	}
+
+	cursor, err := s.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var expenses []models.Expense
+	if err = cursor.All(ctx, &expenses); err != nil {
+		return nil, err
+	}
+
+	return expenses, nil
+}
+
+func (s *expenseStore) Totals(ctx context.Context, query store.ExpenseQuery) ([]store.ExpenseTotal, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: expenseFilter(query)}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: bson.D{
+				{Key: "category_id", Value: "$category_id"},
+				{Key: "currency", Value: "$currency"},
+				{Key: "day", Value: bson.M{"$dateToString": bson.M{"date": "$date", "format": "%Y-%m-%d"}}},
+			}},
+			{Key: "amount", Value: bson.M{"$sum": "$amount"}},
+			{Key: "count", Value: bson.M{"$sum": 1}},
+		}}},
+	}
+	cursor, err := s.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		ID struct {
+			CategoryID primitive.ObjectID `bson:"category_id"`
+			Currency   money.Currency     `bson:"currency"`
+			Day        string             `bson:"day"`
+		} `bson:"_id"`
+		Amount money.Amount `bson:"amount"`
+		Count  int          `bson:"count"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	totals := make([]store.ExpenseTotal, len(docs))
+	for i, d := range docs {
+		day, err := time.Parse("2006-01-02", d.ID.Day)
+		if err != nil {
+			return nil, err
+		}
+		totals[i] = store.ExpenseTotal{CategoryID: d.ID.CategoryID, Currency: d.ID.Currency, Day: day, Amount: d.Amount, Count: d.Count}
+	}
+	return totals, nil
+}
+
+// expenseFilter translates the filters of query into a $match document.
+func expenseFilter(query store.ExpenseQuery) bson.M {
+	filter := bson.M{"ledger_id": query.LedgerID}
+
+	date := bson.M{}
+	if !query.From.IsZero() {
+		date["$gte"] = query.From
+	}
+	if !query.To.IsZero() {
+		date["$lt"] = query.To
+	}
+	if len(date) > 0 {
+		filter["date"] = date
+	}
+
+	amount := bson.M{}
+	if query.MinAmount != nil {
+		amount["$gte"] = *query.MinAmount
+	}
+	if query.MaxAmount != nil {
+		amount["$lte"] = *query.MaxAmount
+	}
+	if len(amount) > 0 {
+		filter["amount"] = amount
+	}
+
+	if len(query.CategoryIDs) > 0 {
+		filter["category_id"] = bson.M{"$in": query.CategoryIDs}
+	}
+	if query.Name != "" {
+		filter["name"] = primitive.Regex{Pattern: regexp.QuoteMeta(query.Name), Options: "i"}
+	}
+	if !query.BillID.IsZero() {
+		filter["bill_id"] = query.BillID
+	}
+	return filter
+}
+
+// expenseSort returns the sort field and direction of query, and the
+// keyset condition selecting the expenses after query.After.
+func expenseSort(query store.ExpenseQuery) (string, int, bson.M) {
+	field, dir, op := "date", 1, "$gt"
+	if query.SortDesc {
+		dir, op = -1, "$lt"
+	}
+
+	var value interface{}
+	if query.After != nil {
+		value = query.After.Date
+	}
+	switch query.SortBy {
+	case store.SortByAmount:
+		field = "amount"
+		if query.After != nil {
+			value = query.After.Amount
+		}
+	case store.SortByName:
+		field = "name"
+		if query.After != nil {
+			value = query.After.Name
+		}
+	}
+
+	if query.After == nil {
+		return field, dir, nil
+	}
+	return field, dir, bson.M{"$or": bson.A{
+		bson.M{field: bson.M{op: value}},
+		bson.M{field: value, "_id": bson.M{op: query.After.ID}},
+	}}
+}
+
+// EnsureIndexes creates the indexes the listings rely on, the unique index
+// on user emails, the index used to find a user's ledgers and the ones the
+// bill workers and the recurring expense scheduler poll.
+func (s *Store) EnsureIndexes(ctx context.Context) error {
+	_, err := s.expenses.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "ledger_id", Value: 1}, {Key: "date", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "ledger_id", Value: 1}, {Key: "amount", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "ledger_id", Value: 1}, {Key: "name", Value: 1}, {Key: "_id", Value: 1}}},
+		{Keys: bson.D{{Key: "bill_id", Value: 1}}, Options: options.Index().SetSparse(true)},
+		{Keys: bson.D{{Key: "category_id", Value: 1}}},
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, collection := range []*mongo.Collection{s.categories.collection, s.bills.collection, s.budgetGoals.collection, s.recurring.collection} {
+		_, err := collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "ledger_id", Value: 1}}})
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = s.users.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "email", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.ledgers.collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "members.user_id", Value: 1}}})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.billJobs.collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "run_at", Value: 1}}})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.billFiles.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "bill_id", Value: 1}, {Key: "kind", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.billFiles.collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "hash", Value: 1}}})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.bills.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "ledger_id", Value: 1}, {Key: "upload_date", Value: -1}, {Key: "_id", Value: -1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.recurring.collection.Indexes().CreateOne(ctx, mongo.IndexModel{Keys: bson.D{{Key: "done", Value: 1}, {Key: "next_date", Value: 1}}})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.categoryRules.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "ledger_id", Value: 1}, {Key: "priority", Value: 1}, {Key: "_id", Value: 1}},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.exchangeRates.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "ledger_id", Value: 1}, {Key: "from", Value: 1}, {Key: "to", Value: 1}, {Key: "date", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.balances.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "ledger_id", Value: 1}, {Key: "goal_id", Value: 1}, {Key: "from", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+func (s *expenseStore) Insert(ctx context.Context, expense *models.Expense) error {
+	if expense.ID.IsZero() {
+		expense.ID = primitive.NewObjectID()
+	}
+	_, err := s.collection.InsertOne(ctx, expense)
+	if mongo.IsDuplicateKeyError(err) {
+		return store.ErrDuplicate
+	}
+	return err
+}
+
+func (s *expenseStore) Update(ctx context.Context, expense *models.Expense) error {
+	// The joined category is never stored on the expense document, and
+	// with BillID left empty $set keeps the stored one.
+	doc := *expense
+	doc.Category = nil
+	doc.BillID = primitive.NilObjectID
+
+	// Empty optional fields are left out of $set, so clearing one takes an
+	// $unset.
+	unset := bson.M{}
+	if doc.CategoryConfidence == 0 {
+		unset["category_confidence"] = ""
+	}
+	if doc.Merchant == "" {
+		unset["merchant"] = ""
+	}
+	if doc.PaymentMethod == "" {
+		unset["payment_method"] = ""
+	}
+	update := bson.M{"$set": doc}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+
+	filter := bson.M{"_id": expense.ID, "ledger_id": expense.LedgerID}
+	result, err := s.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *expenseStore) DeleteMany(ctx context.Context, ledgerID primitive.ObjectID, ids []primitive.ObjectID) (int64, error) {
+	result, err := s.collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}, "ledger_id": ledgerID})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (s *expenseStore) DeleteByCategory(ctx context.Context, ledgerID, categoryID primitive.ObjectID) (int64, error) {
+	result, err := s.collection.DeleteMany(ctx, bson.M{"category_id": categoryID, "ledger_id": ledgerID})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (s *expenseStore) DeleteByBill(ctx context.Context, ledgerID, billID primitive.ObjectID) (int64, error) {
+	result, err := s.collection.DeleteMany(ctx, bson.M{"bill_id": billID, "ledger_id": ledgerID})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (s *expenseStore) UnlinkBill(ctx context.Context, ledgerID, billID primitive.ObjectID) error {
+	_, err := s.collection.UpdateMany(ctx, bson.M{"bill_id": billID, "ledger_id": ledgerID}, bson.M{"$unset": bson.M{"bill_id": ""}})
+	return err
+}
@@ -0,0 +1,141 @@
+package mongostore
+
+import (
+	"context"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ledgerStore keeps the members embedded in the ledger document.
+type ledgerStore struct {
+	collection *mongo.Collection
+}
+
+func (s *ledgerStore) Insert(ctx context.Context, ledger *models.Ledger) error {
+	if ledger.ID.IsZero() {
+		ledger.ID = primitive.NewObjectID()
+	}
+	_, err := s.collection.InsertOne(ctx, ledger)
+	if mongo.IsDuplicateKeyError(err) {
+		return store.ErrDuplicate
+	}
+	return err
+}
+
+func (s *ledgerStore) Get(ctx context.Context, id primitive.ObjectID) (*models.Ledger, error) {
+	var ledger models.Ledger
+	err := s.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&ledger)
+	if err == mongo.ErrNoDocuments {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ledger, nil
+}
+
+func (s *ledgerStore) ListForUser(ctx context.Context, userID primitive.ObjectID) ([]models.Ledger, error) {
+	cursor, err := s.collection.Find(ctx, bson.M{"members.user_id": userID})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var ledgers []models.Ledger
+	if err := cursor.All(ctx, &ledgers); err != nil {
+		return nil, err
+	}
+	return ledgers, nil
+}
+
+func (s *ledgerStore) Update(ctx context.Context, ledger *models.Ledger) error {
+	result, err := s.collection.UpdateOne(ctx, bson.M{"_id": ledger.ID},
+		bson.M{"$set": bson.M{"name": ledger.Name, "base_currency": ledger.BaseCurrency}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *ledgerStore) PutMember(ctx context.Context, ledgerID primitive.ObjectID, member models.LedgerMember) error {
+	// Change the role of an existing member first, add them otherwise.
+	result, err := s.collection.UpdateOne(ctx,
+		bson.M{"_id": ledgerID, "members.user_id": member.UserID},
+		bson.M{"$set": bson.M{"members.$.role": member.Role}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	result, err = s.collection.UpdateOne(ctx,
+		bson.M{"_id": ledgerID, "members.user_id": bson.M{"$ne": member.UserID}},
+		bson.M{"$push": bson.M{"members": models.LedgerMember{UserID: member.UserID, Role: member.Role}}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *ledgerStore) RemoveMember(ctx context.Context, ledgerID, userID primitive.ObjectID) error {
+	result, err := s.collection.UpdateOne(ctx,
+		bson.M{"_id": ledgerID, "members.user_id": userID},
+		bson.M{"$pull": bson.M{"members": bson.M{"user_id": userID}}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// MigrateToLedgers moves data owned by a single user, as stored before
+// ledgers existed, into a personal ledger with the same ID as the user. It
+// is safe to run on every start.
+func (s *Store) MigrateToLedgers(ctx context.Context) error {
+	cursor, err := s.users.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	var users []models.User
+	if err := cursor.All(ctx, &users); err != nil {
+		return err
+	}
+	for _, user := range users {
+		personal := models.Ledger{
+			ID:        user.ID,
+			Name:      "Personal",
+			CreatedAt: user.CreatedAt,
+			Members:   []models.LedgerMember{{UserID: user.ID, Role: models.RoleOwner}},
+		}
+		_, err := s.ledgers.collection.UpdateOne(ctx, bson.M{"_id": user.ID},
+			bson.M{"$setOnInsert": personal}, options.Update().SetUpsert(true))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, collection := range []*mongo.Collection{
+		s.expenses.collection, s.categories.collection, s.bills.collection, s.budgetGoals.collection,
+	} {
+		_, err := collection.UpdateMany(ctx, bson.M{"owner_id": bson.M{"$exists": true}},
+			bson.M{"$rename": bson.M{"owner_id": "ledger_id"}})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package mongostore
+
+import (
+	"context"
+
+	"github.com/dhruwanga19/expense-tracker/money"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// MigrateMoney converts amounts stored as float dollars, as written before
+// amounts became integer minor units, to cents tagged with
+// money.DefaultCurrency. Only documents still holding doubles are touched,
+// so it is safe to run on every start.
+func (s *Store) MigrateMoney(ctx context.Context) error {
+	for _, collection := range []*mongo.Collection{s.expenses.collection, s.budgetGoals.collection} {
+		_, err := collection.UpdateMany(ctx, bson.M{"amount": bson.M{"$type": "double"}}, mongo.Pipeline{
+			{{Key: "$set", Value: bson.M{
+				"amount":   toMinorUnits("$amount"),
+				"currency": bson.M{"$ifNull": bson.A{"$currency", money.DefaultCurrency}},
+			}}},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	filter := bson.M{"$or": bson.A{
+		bson.M{"analysis_results.total": bson.M{"$type": "double"}},
+		bson.M{"generated_expenses.amount": bson.M{"$type": "double"}},
+	}}
+	_, err := s.bills.collection.UpdateMany(ctx, filter, mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"analysis_results.total":    ifDouble("$analysis_results.total"),
+			"analysis_results.currency": bson.M{"$ifNull": bson.A{"$analysis_results.currency", money.DefaultCurrency}},
+			"generated_expenses": bson.M{"$map": bson.M{
+				"input": bson.M{"$ifNull": bson.A{"$generated_expenses", bson.A{}}},
+				"as":    "e",
+				"in": bson.M{"$mergeObjects": bson.A{"$$e", bson.M{
+					"amount":   ifDouble("$$e.amount"),
+					"currency": bson.M{"$ifNull": bson.A{"$$e.currency", money.DefaultCurrency}},
+				}}},
+			}},
+		}}},
+	})
+	return err
+}
+
+// toMinorUnits is the aggregation expression rounding a dollar amount to
+// whole cents.
+func toMinorUnits(field string) bson.M {
+	return bson.M{"$toLong": bson.M{"$round": bson.A{bson.M{"$multiply": bson.A{field, 100}}, 0}}}
+}
+
+// ifDouble converts field with toMinorUnits unless it already is an integer.
+func ifDouble(field string) bson.M {
+	return bson.M{"$cond": bson.A{
+		bson.M{"$eq": bson.A{bson.M{"$type": field}, "double"}},
+		toMinorUnits(field),
+		field,
+	}}
+}
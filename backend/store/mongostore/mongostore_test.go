@@ -0,0 +1,107 @@
+package mongostore
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"github.com/dhruwanga19/expense-tracker/store/storetest"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// connect needs a MongoDB replica set (transactions are not available on
+// a standalone server), e.g. MONGODB_TEST_URI=mongodb://localhost:27017/?replicaSet=rs0
+func connect(t *testing.T) *mongo.Client {
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		t.Skip("MONGODB_TEST_URI not set")
+	}
+
+	ctx := context.Background()
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(func() { client.Disconnect(ctx) })
+	return client
+}
+
+// newDatabase returns an empty database that is dropped after the test.
+func newDatabase(t *testing.T, client *mongo.Client) *mongo.Database {
+	ctx := context.Background()
+	db := client.Database("expenses_test_" + primitive.NewObjectID().Hex())
+	t.Cleanup(func() { db.Drop(ctx) })
+
+	// Collections cannot be created inside a transaction on older
+	// servers, so create them up front.
+	for _, name := range []string{"my-expenses", "categories", "bills", "bill_jobs", "bill_files", "budget_goals", "recurring_expenses", "exchange_rates", "users", "ledgers"} {
+		if err := db.CreateCollection(ctx, name); err != nil {
+			t.Fatalf("create collection %s: %v", name, err)
+		}
+	}
+	return db
+}
+
+func TestStore(t *testing.T) {
+	client := connect(t)
+	storetest.Run(t, func(t *testing.T) store.Store {
+		return New(newDatabase(t, client))
+	})
+}
+
+func TestBlobStore(t *testing.T) {
+	blobs, err := NewBlobStore(newDatabase(t, connect(t)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	storetest.RunBlobs(t, blobs)
+}
+
+func TestMigrateMoney(t *testing.T) {
+	ctx := context.Background()
+	db := newDatabase(t, connect(t))
+	ledger, expense, bill := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	if _, err := db.Collection("my-expenses").InsertOne(ctx, bson.M{"_id": expense, "ledger_id": ledger, "name": "Lunch", "amount": 0.1 + 0.2}); err != nil {
+		t.Fatalf("seed expense: %v", err)
+	}
+	_, err := db.Collection("bills").InsertOne(ctx, bson.M{
+		"_id": bill, "ledger_id": ledger, "status": "processed",
+		"analysis_results":   bson.M{"extracted_text": "", "total": 12.34},
+		"generated_expenses": bson.A{bson.M{"_id": primitive.NewObjectID(), "name": "MILK", "amount": 4.35}},
+	})
+	if err != nil {
+		t.Fatalf("seed bill: %v", err)
+	}
+
+	st := New(db)
+	// Running twice must not scale the amounts twice.
+	for i := 0; i < 2; i++ {
+		if err := st.MigrateMoney(ctx); err != nil {
+			t.Fatalf("migrate: %v", err)
+		}
+	}
+
+	var e struct {
+		Amount   money.Amount   `bson:"amount"`
+		Currency money.Currency `bson:"currency"`
+	}
+	if err := db.Collection("my-expenses").FindOne(ctx, bson.M{"_id": expense}).Decode(&e); err != nil {
+		t.Fatalf("find expense: %v", err)
+	}
+	if e.Amount != 30 || e.Currency != money.DefaultCurrency {
+		t.Errorf("migrated expense: %+v", e)
+	}
+	got, err := st.Bills().Get(ctx, ledger, bill)
+	if err != nil {
+		t.Fatalf("get bill: %v", err)
+	}
+	if got.AnalysisResults.Total != 1234 || got.AnalysisResults.Currency != money.DefaultCurrency ||
+		len(got.GeneratedExpenses) != 1 || got.GeneratedExpenses[0].Amount != 435 || got.GeneratedExpenses[0].Currency != money.DefaultCurrency {
+		t.Errorf("migrated bill: %+v", got)
+	}
+}
@@ -0,0 +1,104 @@
+package mongostore
+
+import (
+	"context"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type recurringExpenseStore struct {
+	collection *mongo.Collection
+}
+
+func (s *recurringExpenseStore) List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.RecurringExpense, error) {
+	return s.find(ctx, bson.M{"ledger_id": ledgerID}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+}
+
+func (s *recurringExpenseStore) Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.RecurringExpense, error) {
+	var recurring models.RecurringExpense
+	err := s.collection.FindOne(ctx, bson.M{"_id": id, "ledger_id": ledgerID}).Decode(&recurring)
+	if err == mongo.ErrNoDocuments {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &recurring, nil
+}
+
+func (s *recurringExpenseStore) Insert(ctx context.Context, recurring *models.RecurringExpense) error {
+	if recurring.ID.IsZero() {
+		recurring.ID = primitive.NewObjectID()
+	}
+	_, err := s.collection.InsertOne(ctx, recurring)
+	return err
+}
+
+func (s *recurringExpenseStore) Update(ctx context.Context, recurring *models.RecurringExpense) error {
+	// Replace rather than $set so a cleared end date is removed.
+	result, err := s.collection.ReplaceOne(ctx, bson.M{"_id": recurring.ID, "ledger_id": recurring.LedgerID}, recurring)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *recurringExpenseStore) Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	result, err := s.collection.DeleteOne(ctx, bson.M{"_id": id, "ledger_id": ledgerID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *recurringExpenseStore) DeleteByCategory(ctx context.Context, ledgerID, categoryID primitive.ObjectID) (int64, error) {
+	result, err := s.collection.DeleteMany(ctx, bson.M{"ledger_id": ledgerID, "category_id": categoryID})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (s *recurringExpenseStore) Due(ctx context.Context, t time.Time) ([]models.RecurringExpense, error) {
+	filter := bson.M{"done": false, "next_date": bson.M{"$lte": t}}
+	return s.find(ctx, filter, options.Find().SetSort(bson.D{{Key: "next_date", Value: 1}, {Key: "_id", Value: 1}}))
+}
+
+func (s *recurringExpenseStore) Advance(ctx context.Context, recurring *models.RecurringExpense, fromIndex int) error {
+	filter := bson.M{"_id": recurring.ID, "ledger_id": recurring.LedgerID, "next_index": fromIndex}
+	update := bson.M{"$set": bson.M{"next_index": recurring.NextIndex, "next_date": recurring.NextDate, "done": recurring.Done}}
+	result, err := s.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+func (s *recurringExpenseStore) find(ctx context.Context, filter bson.M, opts *options.FindOptions) ([]models.RecurringExpense, error) {
+	cursor, err := s.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var recurring []models.RecurringExpense
+	if err := cursor.All(ctx, &recurring); err != nil {
+		return nil, err
+	}
+	return recurring, nil
+}
@@ -0,0 +1,92 @@
+// Package mongostore implements the store interfaces on top of MongoDB.
+package mongostore
+
+import (
+	"context"
+
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type Store struct {
+	db            *mongo.Database
+	expenses      *expenseStore
+	categories    *categoryStore
+	bills         *billStore
+	billJobs      *billJobStore
+	billFiles     *billFileStore
+	budgetGoals   *budgetGoalStore
+	balances      *budgetBalanceStore
+	recurring     *recurringExpenseStore
+	categoryRules *categoryRuleStore
+	exchangeRates *exchangeRateStore
+	users         *userStore
+	ledgers       *ledgerStore
+}
+
+func New(db *mongo.Database) *Store {
+	return &Store{
+		db:            db,
+		expenses:      &expenseStore{collection: db.Collection("my-expenses")},
+		categories:    &categoryStore{collection: db.Collection("categories")},
+		bills:         &billStore{collection: db.Collection("bills")},
+		billJobs:      &billJobStore{collection: db.Collection("bill_jobs")},
+		billFiles:     &billFileStore{collection: db.Collection("bill_files")},
+		budgetGoals:   &budgetGoalStore{collection: db.Collection("budget_goals")},
+		balances:      &budgetBalanceStore{collection: db.Collection("budget_balances")},
+		recurring:     &recurringExpenseStore{collection: db.Collection("recurring_expenses")},
+		categoryRules: &categoryRuleStore{collection: db.Collection("category_rules")},
+		exchangeRates: &exchangeRateStore{collection: db.Collection("exchange_rates")},
+		users:         &userStore{collection: db.Collection("users")},
+		ledgers:       &ledgerStore{collection: db.Collection("ledgers")},
+	}
+}
+
+func (s *Store) Expenses() store.ExpenseStore                   { return s.expenses }
+func (s *Store) Categories() store.CategoryStore                { return s.categories }
+func (s *Store) Bills() store.BillStore                         { return s.bills }
+func (s *Store) BillJobs() store.BillJobStore                   { return s.billJobs }
+func (s *Store) BillFiles() store.BillFileStore                 { return s.billFiles }
+func (s *Store) BudgetGoals() store.BudgetGoalStore             { return s.budgetGoals }
+func (s *Store) BudgetBalances() store.BudgetBalanceStore       { return s.balances }
+func (s *Store) RecurringExpenses() store.RecurringExpenseStore { return s.recurring }
+func (s *Store) CategoryRules() store.CategoryRuleStore         { return s.categoryRules }
+func (s *Store) ExchangeRates() store.ExchangeRateStore         { return s.exchangeRates }
+func (s *Store) Users() store.UserStore                         { return s.users }
+func (s *Store) Ledgers() store.LedgerStore                     { return s.ledgers }
+
+func (s *Store) ClaimUnowned(ctx context.Context, ledgerID primitive.ObjectID) error {
+	unowned := bson.M{"ledger_id": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"ledger_id": ledgerID}}
+	for _, collection := range []*mongo.Collection{
+		s.expenses.collection, s.categories.collection, s.bills.collection, s.budgetGoals.collection,
+	} {
+		if _, err := collection.UpdateMany(ctx, unowned, update); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithTransaction runs fn inside a MongoDB session transaction. The session
+// context handed to fn must be used for every call that should take part in
+// the transaction.
+func (s *Store) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		// Already inside a transaction, join it.
+		return fn(ctx)
+	}
+
+	session, err := s.db.Client().StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+	return err
+}
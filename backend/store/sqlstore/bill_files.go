@@ -0,0 +1,75 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type billFileStore struct {
+	s *Store
+}
+
+func (fs *billFileStore) Put(ctx context.Context, file *models.BillFile) error {
+	_, err := fs.s.exec(ctx, `
+		INSERT INTO bill_files (bill_id, kind, ledger_id, content_type, hash, size, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (bill_id, kind) DO UPDATE SET
+			ledger_id = excluded.ledger_id, content_type = excluded.content_type,
+			hash = excluded.hash, size = excluded.size, created_at = excluded.created_at`,
+		file.BillID.Hex(), file.Kind, file.LedgerID.Hex(), file.ContentType, file.Hash, file.Size, fs.s.dialect.timeValue(file.CreatedAt))
+	return err
+}
+
+const billFileColumns = `bill_id, kind, ledger_id, content_type, hash, size, created_at`
+
+func scanBillFile(row interface{ Scan(...interface{}) error }, file *models.BillFile) error {
+	return row.Scan(idScanner{&file.BillID}, &file.Kind, idScanner{&file.LedgerID}, &file.ContentType, &file.Hash, &file.Size, timeScanner{&file.CreatedAt})
+}
+
+func (fs *billFileStore) Get(ctx context.Context, ledgerID, billID primitive.ObjectID, kind string) (*models.BillFile, error) {
+	var file models.BillFile
+	row := fs.s.queryRow(ctx, `SELECT `+billFileColumns+` FROM bill_files WHERE ledger_id = ? AND bill_id = ? AND kind = ?`,
+		ledgerID.Hex(), billID.Hex(), kind)
+	err := scanBillFile(row, &file)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+func (fs *billFileStore) List(ctx context.Context, ledgerID, billID primitive.ObjectID) ([]models.BillFile, error) {
+	rows, err := fs.s.query(ctx, `SELECT `+billFileColumns+` FROM bill_files WHERE ledger_id = ? AND bill_id = ? ORDER BY kind`,
+		ledgerID.Hex(), billID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var files []models.BillFile
+	for rows.Next() {
+		var file models.BillFile
+		if err := scanBillFile(rows, &file); err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, rows.Err()
+}
+
+func (fs *billFileStore) DeleteForBill(ctx context.Context, ledgerID, billID primitive.ObjectID) error {
+	_, err := fs.s.exec(ctx, `DELETE FROM bill_files WHERE ledger_id = ? AND bill_id = ?`, ledgerID.Hex(), billID.Hex())
+	return err
+}
+
+func (fs *billFileStore) HashUsed(ctx context.Context, hash string) (bool, error) {
+	var used bool
+	err := fs.s.queryRow(ctx, `SELECT EXISTS (SELECT 1 FROM bill_files WHERE hash = ?)`, hash).Scan(&used)
+	return used, err
+}
@@ -0,0 +1,68 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type billJobStore struct {
+	s *Store
+}
+
+func (js *billJobStore) Insert(ctx context.Context, job *models.BillJob) error {
+	if job.ID.IsZero() {
+		job.ID = primitive.NewObjectID()
+	}
+	_, err := js.s.exec(ctx, `
+		INSERT INTO bill_jobs (id, ledger_id, bill_id, attempts, run_at, last_error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		job.ID.Hex(), job.LedgerID.Hex(), job.BillID.Hex(), job.Attempts,
+		js.s.dialect.timeValue(job.RunAt), job.LastError, js.s.dialect.timeValue(job.CreatedAt))
+	return err
+}
+
+// Claim picks the job and then leases it only if its run_at is unchanged,
+// so two workers picking the same job cannot both get it.
+func (js *billJobStore) Claim(ctx context.Context, now, leaseUntil time.Time) (*models.BillJob, error) {
+	var job models.BillJob
+	var runAt interface{}
+	err := js.s.queryRow(ctx, `
+		SELECT id, ledger_id, bill_id, attempts, run_at, last_error, created_at
+		FROM bill_jobs WHERE run_at <= ? ORDER BY run_at, id LIMIT 1`, js.s.dialect.timeValue(now)).Scan(
+		idScanner{&job.ID}, idScanner{&job.LedgerID}, idScanner{&job.BillID}, &job.Attempts,
+		&runAt, &job.LastError, timeScanner{&job.CreatedAt})
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = js.s.execAffected(ctx, `UPDATE bill_jobs SET run_at = ?, attempts = attempts + 1 WHERE id = ? AND run_at = ?`,
+		js.s.dialect.timeValue(leaseUntil), job.ID.Hex(), runAt)
+	if err != nil {
+		return nil, err
+	}
+	job.RunAt = leaseUntil
+	job.Attempts++
+	return &job, nil
+}
+
+func (js *billJobStore) Retry(ctx context.Context, job *models.BillJob) error {
+	return js.s.execAffected(ctx, `UPDATE bill_jobs SET run_at = ?, last_error = ? WHERE id = ?`,
+		js.s.dialect.timeValue(job.RunAt), job.LastError, job.ID.Hex())
+}
+
+func (js *billJobStore) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return js.s.execAffected(ctx, `DELETE FROM bill_jobs WHERE id = ?`, id.Hex())
+}
+
+func (js *billJobStore) DeleteForBill(ctx context.Context, billID primitive.ObjectID) error {
+	_, err := js.s.exec(ctx, `DELETE FROM bill_jobs WHERE bill_id = ?`, billID.Hex())
+	return err
+}
@@ -0,0 +1,240 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Bills are stored in the bills table; the items read from them live in
+// bill_items and their generated expenses in bill_expenses, both ordered by
+// position.
+type billStore struct {
+	s *Store
+}
+
+func (bs *billStore) Insert(ctx context.Context, bill *models.Bill) error {
+	if bill.ID.IsZero() {
+		bill.ID = primitive.NewObjectID()
+	}
+	a := &bill.AnalysisResults
+	return bs.s.WithTransaction(ctx, func(ctx context.Context) error {
+		_, err := bs.s.exec(ctx, `
+			INSERT INTO bills (id, ledger_id, file_name, file_type, upload_date, processed_date, status, error_message,
+				extracted_text, subtotal, discount, tax, tip, total, currency, reconciled, difference,
+				merchant, transaction_date, payment_method, card_last4)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			bill.ID.Hex(), nullableID(bill.LedgerID), bill.FileName, bill.FileType,
+			bs.s.dialect.timeValue(bill.UploadDate), bs.s.dialect.timeValue(bill.ProcessedDate),
+			bill.Status, bill.Error, a.ExtractedText, a.Subtotal, a.Discount, a.Tax, a.Tip, a.Total, a.Currency, a.Reconciled, a.Difference,
+			bill.Merchant, bs.s.dialect.nullableTime(bill.TransactionDate), bill.PaymentMethod, bill.CardLast4)
+		if err != nil {
+			return err
+		}
+		return bs.insertDetails(ctx, bill)
+	})
+}
+
+// billColumns are the columns of bills that scanBill reads.
+const billColumns = `id, ledger_id, file_name, file_type, upload_date, processed_date, status, error_message,
+	extracted_text, subtotal, discount, tax, tip, total, currency, reconciled, difference,
+	merchant, transaction_date, payment_method, card_last4`
+
+// scanBill reads the billColumns of a row.
+func scanBill(row interface{ Scan(...interface{}) error }, bill *models.Bill) error {
+	a := &bill.AnalysisResults
+	return row.Scan(
+		idScanner{&bill.ID}, idScanner{&bill.LedgerID}, &bill.FileName, &bill.FileType,
+		timeScanner{&bill.UploadDate}, timeScanner{&bill.ProcessedDate}, &bill.Status, &bill.Error,
+		&a.ExtractedText, &a.Subtotal, &a.Discount, &a.Tax, &a.Tip, &a.Total, &a.Currency, &a.Reconciled, &a.Difference,
+		&bill.Merchant, nullTimeScanner{&bill.TransactionDate}, &bill.PaymentMethod, &bill.CardLast4)
+}
+
+func (bs *billStore) List(ctx context.Context, query store.BillQuery) ([]models.Bill, error) {
+	conds := []string{"ledger_id = ?"}
+	args := []interface{}{query.LedgerID.Hex()}
+	if len(query.Statuses) > 0 {
+		conds = append(conds, "status IN ("+placeholders(len(query.Statuses))+")")
+		for _, status := range query.Statuses {
+			args = append(args, status)
+		}
+	}
+	if !query.From.IsZero() {
+		conds = append(conds, "upload_date >= ?")
+		args = append(args, bs.s.dialect.timeValue(query.From))
+	}
+	if !query.To.IsZero() {
+		conds = append(conds, "upload_date < ?")
+		args = append(args, bs.s.dialect.timeValue(query.To))
+	}
+	if query.Merchant != "" {
+		conds = append(conds, `LOWER(merchant) LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+likeEscaper.Replace(strings.ToLower(query.Merchant))+"%")
+	}
+	if after := query.After; after != nil {
+		date := bs.s.dialect.timeValue(after.UploadDate)
+		conds = append(conds, "(upload_date < ? OR (upload_date = ? AND id < ?))")
+		args = append(args, date, date, after.ID.Hex())
+	}
+
+	q := `SELECT ` + billColumns + ` FROM bills WHERE ` + strings.Join(conds, " AND ") + ` ORDER BY upload_date DESC, id DESC`
+	if query.Limit > 0 {
+		q += ` LIMIT ?`
+		args = append(args, query.Limit)
+	}
+	rows, err := bs.s.query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bills []models.Bill
+	for rows.Next() {
+		var bill models.Bill
+		if err := scanBill(rows, &bill); err != nil {
+			return nil, err
+		}
+		bills = append(bills, bill)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// The details are read once the listing is closed, since a transaction
+	// runs one query at a time.
+	rows.Close()
+	for i := range bills {
+		if err := bs.readDetails(ctx, &bills[i]); err != nil {
+			return nil, err
+		}
+	}
+	return bills, nil
+}
+
+func (bs *billStore) Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.Bill, error) {
+	var bill models.Bill
+	row := bs.s.queryRow(ctx, `SELECT `+billColumns+` FROM bills WHERE id = ? AND ledger_id = ?`, id.Hex(), ledgerID.Hex())
+	err := scanBill(row, &bill)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := bs.readDetails(ctx, &bill); err != nil {
+		return nil, err
+	}
+	return &bill, nil
+}
+
+// readDetails reads the bill's items and generated expenses.
+func (bs *billStore) readDetails(ctx context.Context, bill *models.Bill) error {
+	a := &bill.AnalysisResults
+	items, err := bs.s.query(ctx, `
+		SELECT name, quantity, unit_price, amount, discount
+		FROM bill_items WHERE bill_id = ? ORDER BY position`, bill.ID.Hex())
+	if err != nil {
+		return err
+	}
+	defer items.Close()
+
+	for items.Next() {
+		var it models.ReceiptItem
+		if err := items.Scan(&it.Name, &it.Quantity, &it.UnitPrice, &it.Amount, &it.Discount); err != nil {
+			return err
+		}
+		a.Items = append(a.Items, it)
+	}
+	if err := items.Err(); err != nil {
+		return err
+	}
+	items.Close()
+
+	rows, err := bs.s.query(ctx, `
+		SELECT id, ledger_id, name, amount, currency, date, category_id, category_confidence, merchant, payment_method
+		FROM bill_expenses WHERE bill_id = ? ORDER BY position`, bill.ID.Hex())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e models.Expense
+		err := rows.Scan(idScanner{&e.ID}, idScanner{&e.LedgerID}, &e.Name, &e.Amount, &e.Currency, timeScanner{&e.Date}, idScanner{&e.CategoryID},
+			&e.CategoryConfidence, &e.Merchant, &e.PaymentMethod)
+		if err != nil {
+			return err
+		}
+		bill.GeneratedExpenses = append(bill.GeneratedExpenses, e)
+	}
+	return rows.Err()
+}
+
+func (bs *billStore) Update(ctx context.Context, bill *models.Bill) error {
+	a := &bill.AnalysisResults
+	return bs.s.WithTransaction(ctx, func(ctx context.Context) error {
+		err := bs.s.execAffected(ctx, `
+			UPDATE bills SET file_name = ?, file_type = ?, upload_date = ?, processed_date = ?, status = ?, error_message = ?,
+				extracted_text = ?, subtotal = ?, discount = ?, tax = ?, tip = ?, total = ?, currency = ?, reconciled = ?, difference = ?,
+				merchant = ?, transaction_date = ?, payment_method = ?, card_last4 = ?
+			WHERE id = ? AND ledger_id = ?`,
+			bill.FileName, bill.FileType,
+			bs.s.dialect.timeValue(bill.UploadDate), bs.s.dialect.timeValue(bill.ProcessedDate), bill.Status, bill.Error,
+			a.ExtractedText, a.Subtotal, a.Discount, a.Tax, a.Tip, a.Total, a.Currency, a.Reconciled, a.Difference,
+			bill.Merchant, bs.s.dialect.nullableTime(bill.TransactionDate), bill.PaymentMethod, bill.CardLast4,
+			bill.ID.Hex(), bill.LedgerID.Hex())
+		if err != nil {
+			return err
+		}
+		if _, err := bs.s.exec(ctx, `DELETE FROM bill_items WHERE bill_id = ?`, bill.ID.Hex()); err != nil {
+			return err
+		}
+		if _, err := bs.s.exec(ctx, `DELETE FROM bill_expenses WHERE bill_id = ?`, bill.ID.Hex()); err != nil {
+			return err
+		}
+		return bs.insertDetails(ctx, bill)
+	})
+}
+
+func (bs *billStore) UpdateGeneratedExpense(ctx context.Context, ledgerID, billID primitive.ObjectID, expense *models.Expense) error {
+	return bs.s.execAffected(ctx, `
+		UPDATE bill_expenses SET ledger_id = ?, name = ?, amount = ?, currency = ?, date = ?, category_id = ?, category_confidence = ?,
+			merchant = ?, payment_method = ?
+		WHERE bill_id = (SELECT id FROM bills WHERE id = ? AND ledger_id = ?) AND id = ?`,
+		nullableID(expense.LedgerID), expense.Name, expense.Amount, expense.Currency, bs.s.dialect.timeValue(expense.Date),
+		nullableID(expense.CategoryID), expense.CategoryConfidence, expense.Merchant, expense.PaymentMethod, billID.Hex(), ledgerID.Hex(), expense.ID.Hex())
+}
+
+// Delete relies on the foreign keys of bill_items, bill_expenses, bill_jobs
+// and bill_files to remove the bill's rows there.
+func (bs *billStore) Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	return bs.s.execAffected(ctx, `DELETE FROM bills WHERE id = ? AND ledger_id = ?`, id.Hex(), ledgerID.Hex())
+}
+
+// insertDetails stores the bill's items and generated expenses.
+func (bs *billStore) insertDetails(ctx context.Context, bill *models.Bill) error {
+	for i, it := range bill.AnalysisResults.Items {
+		_, err := bs.s.exec(ctx, `
+			INSERT INTO bill_items (bill_id, position, name, quantity, unit_price, amount, discount)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			bill.ID.Hex(), i, it.Name, it.Quantity, it.UnitPrice, it.Amount, it.Discount)
+		if err != nil {
+			return err
+		}
+	}
+	for i, e := range bill.GeneratedExpenses {
+		_, err := bs.s.exec(ctx, `
+			INSERT INTO bill_expenses (bill_id, position, id, ledger_id, name, amount, currency, date, category_id, category_confidence,
+				merchant, payment_method)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			bill.ID.Hex(), i, e.ID.Hex(), nullableID(e.LedgerID), e.Name, e.Amount, e.Currency, bs.s.dialect.timeValue(e.Date), nullableID(e.CategoryID),
+			e.CategoryConfidence, e.Merchant, e.PaymentMethod)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
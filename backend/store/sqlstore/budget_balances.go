@@ -0,0 +1,55 @@
+package sqlstore
+
+import (
+	"context"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type budgetBalanceStore struct {
+	s *Store
+}
+
+func (bs *budgetBalanceStore) List(ctx context.Context, ledgerID, goalID primitive.ObjectID) ([]models.BudgetBalance, error) {
+	rows, err := bs.s.query(ctx, `
+		SELECT id, ledger_id, goal_id, period_start, period_end, carried_in, budget, spent, carried_out, updated_at
+		FROM budget_balances WHERE ledger_id = ? AND goal_id = ? ORDER BY period_start`, ledgerID.Hex(), goalID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var balances []models.BudgetBalance
+	for rows.Next() {
+		var b models.BudgetBalance
+		err := rows.Scan(idScanner{&b.ID}, idScanner{&b.LedgerID}, idScanner{&b.GoalID}, timeScanner{&b.From}, timeScanner{&b.To},
+			&b.CarriedIn, &b.Budget, &b.Spent, &b.CarriedOut, timeScanner{&b.UpdatedAt})
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, b)
+	}
+	return balances, rows.Err()
+}
+
+func (bs *budgetBalanceStore) Put(ctx context.Context, balance *models.BudgetBalance) error {
+	if balance.ID.IsZero() {
+		balance.ID = primitive.NewObjectID()
+	}
+	return bs.s.queryRow(ctx, `
+		INSERT INTO budget_balances (id, ledger_id, goal_id, period_start, period_end, carried_in, budget, spent, carried_out, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (goal_id, period_start) DO UPDATE SET period_end = excluded.period_end, carried_in = excluded.carried_in,
+			budget = excluded.budget, spent = excluded.spent, carried_out = excluded.carried_out, updated_at = excluded.updated_at
+		RETURNING id`,
+		balance.ID.Hex(), balance.LedgerID.Hex(), balance.GoalID.Hex(),
+		bs.s.dialect.timeValue(balance.From), bs.s.dialect.timeValue(balance.To),
+		balance.CarriedIn, balance.Budget, balance.Spent, balance.CarriedOut,
+		bs.s.dialect.timeValue(balance.UpdatedAt)).Scan(idScanner{&balance.ID})
+}
+
+func (bs *budgetBalanceStore) DeleteForGoal(ctx context.Context, ledgerID, goalID primitive.ObjectID) error {
+	_, err := bs.s.exec(ctx, `DELETE FROM budget_balances WHERE ledger_id = ? AND goal_id = ?`, ledgerID.Hex(), goalID.Hex())
+	return err
+}
@@ -0,0 +1,185 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"slices"
+	"strings"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// budgetGoalStore keeps the categories of multi-category goals in
+// budget_goal_categories. A unique index on scope_key refuses a second goal
+// counting the same expenses over the same period.
+type budgetGoalStore struct {
+	s *Store
+}
+
+func (gs *budgetGoalStore) List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.BudgetGoal, error) {
+	rows, err := gs.s.query(ctx, `
+		SELECT id, ledger_id, scope, category_id, amount, currency, period, rollover, rollover_cap, created_at, updated_at
+		FROM budget_goals WHERE ledger_id = ? ORDER BY id`, ledgerID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var goals []models.BudgetGoal
+	for rows.Next() {
+		var g models.BudgetGoal
+		err := rows.Scan(idScanner{&g.ID}, idScanner{&g.LedgerID}, &g.Scope, idScanner{&g.CategoryID}, &g.Amount, &g.Currency, &g.Period,
+			&g.Rollover, &g.RolloverCap, timeScanner{&g.CreatedAt}, timeScanner{&g.UpdatedAt})
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := gs.loadCategories(ctx, ledgerID, goals); err != nil {
+		return nil, err
+	}
+	return goals, nil
+}
+
+func (gs *budgetGoalStore) Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.BudgetGoal, error) {
+	var g models.BudgetGoal
+	err := gs.s.queryRow(ctx, `
+		SELECT id, ledger_id, scope, category_id, amount, currency, period, rollover, rollover_cap, created_at, updated_at
+		FROM budget_goals WHERE id = ? AND ledger_id = ?`, id.Hex(), ledgerID.Hex()).
+		Scan(idScanner{&g.ID}, idScanner{&g.LedgerID}, &g.Scope, idScanner{&g.CategoryID}, &g.Amount, &g.Currency, &g.Period,
+			&g.Rollover, &g.RolloverCap, timeScanner{&g.CreatedAt}, timeScanner{&g.UpdatedAt})
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := gs.s.query(ctx, `SELECT category_id FROM budget_goal_categories WHERE goal_id = ? ORDER BY category_id`, id.Hex())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var categoryID primitive.ObjectID
+		if err := rows.Scan(idScanner{&categoryID}); err != nil {
+			return nil, err
+		}
+		g.CategoryIDs = append(g.CategoryIDs, categoryID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+// loadCategories fills in the categories of the ledger's goals with a single
+// query.
+func (gs *budgetGoalStore) loadCategories(ctx context.Context, ledgerID primitive.ObjectID, goals []models.BudgetGoal) error {
+	if len(goals) == 0 {
+		return nil
+	}
+	index := make(map[primitive.ObjectID]int, len(goals))
+	for i, g := range goals {
+		index[g.ID] = i
+	}
+
+	rows, err := gs.s.query(ctx, `
+		SELECT c.goal_id, c.category_id FROM budget_goal_categories c JOIN budget_goals g ON g.id = c.goal_id
+		WHERE g.ledger_id = ? ORDER BY c.goal_id, c.category_id`, ledgerID.Hex())
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var goalID, categoryID primitive.ObjectID
+		if err := rows.Scan(idScanner{&goalID}, idScanner{&categoryID}); err != nil {
+			return err
+		}
+		if i, ok := index[goalID]; ok {
+			goals[i].CategoryIDs = append(goals[i].CategoryIDs, categoryID)
+		}
+	}
+	return rows.Err()
+}
+
+func (gs *budgetGoalStore) Insert(ctx context.Context, goal *models.BudgetGoal) error {
+	if goal.ID.IsZero() {
+		goal.ID = primitive.NewObjectID()
+	}
+	return gs.s.WithTransaction(ctx, func(ctx context.Context) error {
+		_, err := gs.s.exec(ctx, `
+			INSERT INTO budget_goals (id, ledger_id, scope, category_id, scope_key, amount, currency, period, rollover, rollover_cap, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			goal.ID.Hex(), nullableID(goal.LedgerID), goal.Scope, nullableID(goal.CategoryID), scopeKey(goal), goal.Amount, goal.Currency, goal.Period,
+			goal.Rollover, goal.RolloverCap,
+			gs.s.dialect.timeValue(goal.CreatedAt), gs.s.dialect.timeValue(goal.UpdatedAt))
+		if isUniqueViolation(err) {
+			return store.ErrDuplicate
+		}
+		if err != nil {
+			return err
+		}
+		return gs.insertCategories(ctx, goal)
+	})
+}
+
+func (gs *budgetGoalStore) Update(ctx context.Context, goal *models.BudgetGoal) error {
+	return gs.s.WithTransaction(ctx, func(ctx context.Context) error {
+		err := gs.s.execAffected(ctx, `
+			UPDATE budget_goals SET scope = ?, category_id = ?, scope_key = ?, amount = ?, currency = ?, period = ?, rollover = ?,
+				rollover_cap = ?, created_at = ?, updated_at = ?
+			WHERE id = ? AND ledger_id = ?`,
+			goal.Scope, nullableID(goal.CategoryID), scopeKey(goal), goal.Amount, goal.Currency, goal.Period, goal.Rollover,
+			goal.RolloverCap, gs.s.dialect.timeValue(goal.CreatedAt), gs.s.dialect.timeValue(goal.UpdatedAt), goal.ID.Hex(), goal.LedgerID.Hex())
+		if isUniqueViolation(err) {
+			return store.ErrDuplicate
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := gs.s.exec(ctx, `DELETE FROM budget_goal_categories WHERE goal_id = ?`, goal.ID.Hex()); err != nil {
+			return err
+		}
+		return gs.insertCategories(ctx, goal)
+	})
+}
+
+// Delete relies on the foreign keys of budget_goal_categories and
+// budget_balances to remove the goal's rows there.
+func (gs *budgetGoalStore) Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	return gs.s.execAffected(ctx, `DELETE FROM budget_goals WHERE id = ? AND ledger_id = ?`, id.Hex(), ledgerID.Hex())
+}
+
+func (gs *budgetGoalStore) insertCategories(ctx context.Context, goal *models.BudgetGoal) error {
+	for _, id := range goal.CategoryIDs {
+		_, err := gs.s.exec(ctx, `INSERT INTO budget_goal_categories (goal_id, category_id) VALUES (?, ?)`, goal.ID.Hex(), id.Hex())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scopeKey names the expenses a goal counts: "overall", or its categories
+// in order, comma-separated.
+func scopeKey(goal *models.BudgetGoal) string {
+	categories := goal.Categories()
+	if categories == nil {
+		return "overall"
+	}
+	keys := make([]string, len(categories))
+	for i, id := range categories {
+		keys[i] = id.Hex()
+	}
+	slices.Sort(keys)
+	return strings.Join(keys, ",")
+}
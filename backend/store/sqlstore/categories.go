@@ -0,0 +1,85 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type categoryStore struct {
+	s *Store
+}
+
+func (cs *categoryStore) List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.Category, error) {
+	rows, err := cs.s.query(ctx, `SELECT id, ledger_id, name, color FROM categories WHERE ledger_id = ? ORDER BY id`, ledgerID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var categories []models.Category
+	for rows.Next() {
+		var c models.Category
+		if err := rows.Scan(idScanner{&c.ID}, idScanner{&c.LedgerID}, &c.Name, &c.Color); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+func (cs *categoryStore) Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.Category, error) {
+	var c models.Category
+	err := cs.s.queryRow(ctx, `SELECT id, ledger_id, name, color FROM categories WHERE id = ? AND ledger_id = ?`,
+		id.Hex(), ledgerID.Hex()).Scan(idScanner{&c.ID}, idScanner{&c.LedgerID}, &c.Name, &c.Color)
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (cs *categoryStore) Insert(ctx context.Context, category *models.Category) error {
+	if category.ID.IsZero() {
+		category.ID = primitive.NewObjectID()
+	}
+	_, err := cs.s.exec(ctx, `INSERT INTO categories (id, ledger_id, name, color) VALUES (?, ?, ?, ?)`,
+		category.ID.Hex(), nullableID(category.LedgerID), category.Name, category.Color)
+	return err
+}
+
+func (cs *categoryStore) Update(ctx context.Context, category *models.Category) error {
+	return cs.s.execAffected(ctx, `UPDATE categories SET name = ?, color = ? WHERE id = ? AND ledger_id = ?`,
+		category.Name, category.Color, category.ID.Hex(), category.LedgerID.Hex())
+}
+
+func (cs *categoryStore) Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	return cs.s.execAffected(ctx, `DELETE FROM categories WHERE id = ? AND ledger_id = ?`, id.Hex(), ledgerID.Hex())
+}
+
+func (cs *categoryStore) NameExists(ctx context.Context, ledgerID primitive.ObjectID, name string, excludeID primitive.ObjectID) (bool, error) {
+	return cs.exists(ctx, `SELECT 1 FROM categories WHERE ledger_id = ? AND id <> ? AND LOWER(name) = LOWER(?)`,
+		ledgerID.Hex(), excludeID.Hex(), name)
+}
+
+func (cs *categoryStore) ColorExists(ctx context.Context, ledgerID primitive.ObjectID, color string, excludeID primitive.ObjectID) (bool, error) {
+	return cs.exists(ctx, `SELECT 1 FROM categories WHERE ledger_id = ? AND id <> ? AND color = ?`,
+		ledgerID.Hex(), excludeID.Hex(), color)
+}
+
+func (cs *categoryStore) exists(ctx context.Context, query string, args ...interface{}) (bool, error) {
+	var one int
+	err := cs.s.queryRow(ctx, query+` LIMIT 1`, args...).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
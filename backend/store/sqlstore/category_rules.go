@@ -0,0 +1,130 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type categoryRuleStore struct {
+	s *Store
+}
+
+const categoryRuleColumns = `id, ledger_id, name, priority, category_id, name_pattern, merchant, min_amount, max_amount, weekdays,
+	created_at, updated_at`
+
+func (rs *categoryRuleStore) List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.CategoryRule, error) {
+	return rs.find(ctx, `SELECT `+categoryRuleColumns+` FROM category_rules WHERE ledger_id = ? ORDER BY priority, id`, ledgerID.Hex())
+}
+
+func (rs *categoryRuleStore) Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.CategoryRule, error) {
+	rules, err := rs.find(ctx, `SELECT `+categoryRuleColumns+` FROM category_rules WHERE id = ? AND ledger_id = ?`, id.Hex(), ledgerID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return nil, store.ErrNotFound
+	}
+	return &rules[0], nil
+}
+
+func (rs *categoryRuleStore) Insert(ctx context.Context, rule *models.CategoryRule) error {
+	if rule.ID.IsZero() {
+		rule.ID = primitive.NewObjectID()
+	}
+	d := rs.s.dialect
+	_, err := rs.s.exec(ctx, `INSERT INTO category_rules (`+categoryRuleColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		rule.ID.Hex(), rule.LedgerID.Hex(), rule.Name, rule.Priority, rule.CategoryID.Hex(), rule.NamePattern, rule.Merchant,
+		nullableAmount(rule.MinAmount), nullableAmount(rule.MaxAmount), weekdayMask(rule.Weekdays),
+		d.timeValue(rule.CreatedAt), d.timeValue(rule.UpdatedAt))
+	return err
+}
+
+func (rs *categoryRuleStore) Update(ctx context.Context, rule *models.CategoryRule) error {
+	d := rs.s.dialect
+	return rs.s.execAffected(ctx, `
+		UPDATE category_rules SET name = ?, priority = ?, category_id = ?, name_pattern = ?, merchant = ?, min_amount = ?,
+			max_amount = ?, weekdays = ?, created_at = ?, updated_at = ?
+		WHERE id = ? AND ledger_id = ?`,
+		rule.Name, rule.Priority, rule.CategoryID.Hex(), rule.NamePattern, rule.Merchant, nullableAmount(rule.MinAmount),
+		nullableAmount(rule.MaxAmount), weekdayMask(rule.Weekdays), d.timeValue(rule.CreatedAt), d.timeValue(rule.UpdatedAt),
+		rule.ID.Hex(), rule.LedgerID.Hex())
+}
+
+func (rs *categoryRuleStore) Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	return rs.s.execAffected(ctx, `DELETE FROM category_rules WHERE id = ? AND ledger_id = ?`, id.Hex(), ledgerID.Hex())
+}
+
+func (rs *categoryRuleStore) DeleteByCategory(ctx context.Context, ledgerID, categoryID primitive.ObjectID) (int64, error) {
+	result, err := rs.s.exec(ctx, `DELETE FROM category_rules WHERE ledger_id = ? AND category_id = ?`, ledgerID.Hex(), categoryID.Hex())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (rs *categoryRuleStore) find(ctx context.Context, query string, args ...interface{}) ([]models.CategoryRule, error) {
+	rows, err := rs.s.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.CategoryRule
+	for rows.Next() {
+		var r models.CategoryRule
+		var min, max sql.NullInt64
+		var weekdays int64
+		err := rows.Scan(idScanner{&r.ID}, idScanner{&r.LedgerID}, &r.Name, &r.Priority, idScanner{&r.CategoryID}, &r.NamePattern,
+			&r.Merchant, &min, &max, &weekdays, timeScanner{&r.CreatedAt}, timeScanner{&r.UpdatedAt})
+		if err != nil {
+			return nil, err
+		}
+		r.MinAmount = amountOrNil(min)
+		r.MaxAmount = amountOrNil(max)
+		r.Weekdays = weekdaysOf(weekdays)
+		rules = append(rules, r)
+	}
+	return rules, rows.Err()
+}
+
+// nullableAmount stores a nil amount as NULL.
+func nullableAmount(a *money.Amount) interface{} {
+	if a == nil {
+		return nil
+	}
+	return int64(*a)
+}
+
+func amountOrNil(n sql.NullInt64) *money.Amount {
+	if !n.Valid {
+		return nil
+	}
+	a := money.Amount(n.Int64)
+	return &a
+}
+
+// weekdayMask stores days of the week as a bit mask, bit 0 for Sunday.
+func weekdayMask(days []time.Weekday) int64 {
+	var mask int64
+	for _, d := range days {
+		mask |= 1 << d
+	}
+	return mask
+}
+
+func weekdaysOf(mask int64) []time.Weekday {
+	var days []time.Weekday
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if mask&(1<<d) != 0 {
+			days = append(days, d)
+		}
+	}
+	return days
+}
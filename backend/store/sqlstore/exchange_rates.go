@@ -0,0 +1,48 @@
+package sqlstore
+
+import (
+	"context"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type exchangeRateStore struct {
+	s *Store
+}
+
+func (rs *exchangeRateStore) List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.ExchangeRate, error) {
+	rows, err := rs.s.query(ctx, `
+		SELECT id, ledger_id, from_currency, to_currency, rate, date
+		FROM exchange_rates WHERE ledger_id = ? ORDER BY date, id`, ledgerID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rates []models.ExchangeRate
+	for rows.Next() {
+		var r models.ExchangeRate
+		err := rows.Scan(idScanner{&r.ID}, idScanner{&r.LedgerID}, &r.From, &r.To, &r.Rate, timeScanner{&r.Date})
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, r)
+	}
+	return rates, rows.Err()
+}
+
+func (rs *exchangeRateStore) Put(ctx context.Context, rate *models.ExchangeRate) error {
+	if rate.ID.IsZero() {
+		rate.ID = primitive.NewObjectID()
+	}
+	return rs.s.queryRow(ctx, `
+		INSERT INTO exchange_rates (id, ledger_id, from_currency, to_currency, rate, date) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (ledger_id, from_currency, to_currency, date) DO UPDATE SET rate = excluded.rate
+		RETURNING id`,
+		rate.ID.Hex(), rate.LedgerID.Hex(), rate.From, rate.To, rate.Rate, rs.s.dialect.timeValue(rate.Date)).Scan(idScanner{&rate.ID})
+}
+
+func (rs *exchangeRateStore) Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	return rs.s.execAffected(ctx, `DELETE FROM exchange_rates WHERE id = ? AND ledger_id = ?`, id.Hex(), ledgerID.Hex())
+}
@@ -0,0 +1,219 @@
+package sqlstore
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type expenseStore struct {
+	s *Store
+}
+
+func (es *expenseStore) List(ctx context.Context, query store.ExpenseQuery) ([]models.Expense, error) {
+	where, args := es.where(query)
+	order := "DESC"
+	if !query.SortDesc {
+		order = "ASC"
+	}
+	column := sortColumn(query.SortBy)
+
+	q := `
+		SELECT e.id, e.name, e.amount, e.currency, e.date, e.category_id, e.merchant, e.payment_method, e.bill_id, c.id, c.name, c.color
+		FROM expenses e
+		JOIN categories c ON c.id = e.category_id AND c.ledger_id = e.ledger_id
+		WHERE ` + where + `
+		ORDER BY ` + column + ` ` + order + `, e.id ` + order
+	if query.Limit > 0 {
+		q += ` LIMIT ?`
+		args = append(args, query.Limit)
+	}
+
+	rows, err := es.s.query(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var expenses []models.Expense
+	for rows.Next() {
+		var e models.Expense
+		var c models.Category
+		err := rows.Scan(idScanner{&e.ID}, &e.Name, &e.Amount, &e.Currency, timeScanner{&e.Date}, idScanner{&e.CategoryID},
+			&e.Merchant, &e.PaymentMethod, idScanner{&e.BillID}, idScanner{&c.ID}, &c.Name, &c.Color)
+		if err != nil {
+			return nil, err
+		}
+		e.Category = &c
+		expenses = append(expenses, e)
+	}
+	return expenses, rows.Err()
+}
+
+// where builds the WHERE clause for the filters and cursor of query.
+func (es *expenseStore) where(query store.ExpenseQuery) (string, []interface{}) {
+	conds := []string{"e.ledger_id = ?"}
+	args := []interface{}{query.LedgerID.Hex()}
+
+	if !query.From.IsZero() {
+		conds = append(conds, "e.date >= ?")
+		args = append(args, es.s.dialect.timeValue(query.From))
+	}
+	if !query.To.IsZero() {
+		conds = append(conds, "e.date < ?")
+		args = append(args, es.s.dialect.timeValue(query.To))
+	}
+	if query.MinAmount != nil {
+		conds = append(conds, "e.amount >= ?")
+		args = append(args, *query.MinAmount)
+	}
+	if query.MaxAmount != nil {
+		conds = append(conds, "e.amount <= ?")
+		args = append(args, *query.MaxAmount)
+	}
+	if len(query.CategoryIDs) > 0 {
+		conds = append(conds, "e.category_id IN ("+placeholders(len(query.CategoryIDs))+")")
+		for _, id := range query.CategoryIDs {
+			args = append(args, id.Hex())
+		}
+	}
+	if query.Name != "" {
+		conds = append(conds, `LOWER(e.name) LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+likeEscaper.Replace(strings.ToLower(query.Name))+"%")
+	}
+	if !query.BillID.IsZero() {
+		conds = append(conds, "e.bill_id = ?")
+		args = append(args, query.BillID.Hex())
+	}
+
+	if after := query.After; after != nil {
+		op := ">"
+		if query.SortDesc {
+			op = "<"
+		}
+		var value interface{}
+		switch query.SortBy {
+		case store.SortByAmount:
+			value = after.Amount
+		case store.SortByName:
+			value = after.Name
+		default:
+			value = es.s.dialect.timeValue(after.Date)
+		}
+		column := sortColumn(query.SortBy)
+		conds = append(conds, "("+column+" "+op+" ? OR ("+column+" = ? AND e.id "+op+" ?))")
+		args = append(args, value, value, after.ID.Hex())
+	}
+
+	return strings.Join(conds, " AND "), args
+}
+
+func sortColumn(sortBy string) string {
+	switch sortBy {
+	case store.SortByAmount:
+		return "e.amount"
+	case store.SortByName:
+		return "e.name"
+	default:
+		return "e.date"
+	}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (es *expenseStore) Totals(ctx context.Context, query store.ExpenseQuery) ([]store.ExpenseTotal, error) {
+	query.After = nil
+	where, args := es.where(query)
+	day := es.s.dialect.day("e.date")
+	rows, err := es.s.query(ctx, `
+		SELECT e.category_id, e.currency, `+day+`, SUM(e.amount), COUNT(*)
+		FROM expenses e
+		WHERE `+where+`
+		GROUP BY e.category_id, e.currency, `+day, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []store.ExpenseTotal
+	for rows.Next() {
+		var t store.ExpenseTotal
+		var day string
+		if err := rows.Scan(idScanner{&t.CategoryID}, &t.Currency, &day, &t.Amount, &t.Count); err != nil {
+			return nil, err
+		}
+		if t.Day, err = time.Parse("2006-01-02", day); err != nil {
+			return nil, err
+		}
+		totals = append(totals, t)
+	}
+	return totals, rows.Err()
+}
+
+func (es *expenseStore) Insert(ctx context.Context, expense *models.Expense) error {
+	if expense.ID.IsZero() {
+		expense.ID = primitive.NewObjectID()
+	}
+	_, err := es.s.exec(ctx, `
+		INSERT INTO expenses (id, ledger_id, name, amount, currency, date, category_id, merchant, payment_method, bill_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		expense.ID.Hex(), nullableID(expense.LedgerID), expense.Name, expense.Amount, expense.Currency, es.s.dialect.timeValue(expense.Date),
+		nullableID(expense.CategoryID), expense.Merchant, expense.PaymentMethod, nullableID(expense.BillID))
+	if isUniqueViolation(err) {
+		return store.ErrDuplicate
+	}
+	return err
+}
+
+func (es *expenseStore) Update(ctx context.Context, expense *models.Expense) error {
+	return es.s.execAffected(ctx, `
+		UPDATE expenses SET name = ?, amount = ?, currency = ?, date = ?, category_id = ?, merchant = ?, payment_method = ?
+		WHERE id = ? AND ledger_id = ?`,
+		expense.Name, expense.Amount, expense.Currency, es.s.dialect.timeValue(expense.Date), nullableID(expense.CategoryID),
+		expense.Merchant, expense.PaymentMethod, expense.ID.Hex(), expense.LedgerID.Hex())
+}
+
+func (es *expenseStore) DeleteMany(ctx context.Context, ledgerID primitive.ObjectID, ids []primitive.ObjectID) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	args := []interface{}{ledgerID.Hex()}
+	for _, id := range ids {
+		args = append(args, id.Hex())
+	}
+	result, err := es.s.exec(ctx, `DELETE FROM expenses WHERE ledger_id = ? AND id IN (`+placeholders(len(ids))+`)`, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (es *expenseStore) DeleteByCategory(ctx context.Context, ledgerID, categoryID primitive.ObjectID) (int64, error) {
+	result, err := es.s.exec(ctx, `DELETE FROM expenses WHERE ledger_id = ? AND category_id = ?`, ledgerID.Hex(), categoryID.Hex())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (es *expenseStore) DeleteByBill(ctx context.Context, ledgerID, billID primitive.ObjectID) (int64, error) {
+	result, err := es.s.exec(ctx, `DELETE FROM expenses WHERE ledger_id = ? AND bill_id = ?`, ledgerID.Hex(), billID.Hex())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (es *expenseStore) UnlinkBill(ctx context.Context, ledgerID, billID primitive.ObjectID) error {
+	_, err := es.s.exec(ctx, `UPDATE expenses SET bill_id = NULL WHERE ledger_id = ? AND bill_id = ?`, ledgerID.Hex(), billID.Hex())
+	return err
+}
+
+// placeholders returns "?, ?, ..." with n placeholders.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,144 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type ledgerStore struct {
+	s *Store
+}
+
+func (ls *ledgerStore) Insert(ctx context.Context, ledger *models.Ledger) error {
+	if ledger.ID.IsZero() {
+		ledger.ID = primitive.NewObjectID()
+	}
+	return ls.s.WithTransaction(ctx, func(ctx context.Context) error {
+		_, err := ls.s.exec(ctx, `INSERT INTO ledgers (id, name, base_currency, created_at) VALUES (?, ?, ?, ?)`,
+			ledger.ID.Hex(), ledger.Name, ledger.BaseCurrency, ls.s.dialect.timeValue(ledger.CreatedAt))
+		if isUniqueViolation(err) {
+			return store.ErrDuplicate
+		}
+		if err != nil {
+			return err
+		}
+		for _, m := range ledger.Members {
+			_, err := ls.s.exec(ctx, `INSERT INTO ledger_members (ledger_id, user_id, role) VALUES (?, ?, ?)`,
+				ledger.ID.Hex(), m.UserID.Hex(), string(m.Role))
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (ls *ledgerStore) Get(ctx context.Context, id primitive.ObjectID) (*models.Ledger, error) {
+	var ledger models.Ledger
+	err := ls.s.queryRow(ctx, `SELECT id, name, base_currency, created_at FROM ledgers WHERE id = ?`, id.Hex()).Scan(
+		idScanner{&ledger.ID}, &ledger.Name, &ledger.BaseCurrency, timeScanner{&ledger.CreatedAt})
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ledgers := []models.Ledger{ledger}
+	if err := ls.loadMembers(ctx, ledgers); err != nil {
+		return nil, err
+	}
+	return &ledgers[0], nil
+}
+
+func (ls *ledgerStore) ListForUser(ctx context.Context, userID primitive.ObjectID) ([]models.Ledger, error) {
+	rows, err := ls.s.query(ctx, `
+		SELECT l.id, l.name, l.base_currency, l.created_at
+		FROM ledgers l JOIN ledger_members m ON m.ledger_id = l.id
+		WHERE m.user_id = ? ORDER BY l.id`, userID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ledgers []models.Ledger
+	for rows.Next() {
+		var l models.Ledger
+		if err := rows.Scan(idScanner{&l.ID}, &l.Name, &l.BaseCurrency, timeScanner{&l.CreatedAt}); err != nil {
+			return nil, err
+		}
+		ledgers = append(ledgers, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err := ls.loadMembers(ctx, ledgers); err != nil {
+		return nil, err
+	}
+	return ledgers, nil
+}
+
+// loadMembers fills in the members of ledgers with a single query.
+func (ls *ledgerStore) loadMembers(ctx context.Context, ledgers []models.Ledger) error {
+	if len(ledgers) == 0 {
+		return nil
+	}
+	index := make(map[primitive.ObjectID]int, len(ledgers))
+	args := make([]interface{}, len(ledgers))
+	for i, l := range ledgers {
+		index[l.ID] = i
+		args[i] = l.ID.Hex()
+	}
+
+	rows, err := ls.s.query(ctx, `
+		SELECT ledger_id, user_id, role FROM ledger_members
+		WHERE ledger_id IN (`+placeholders(len(args))+`) ORDER BY ledger_id, user_id`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var ledgerID primitive.ObjectID
+		var m models.LedgerMember
+		if err := rows.Scan(idScanner{&ledgerID}, idScanner{&m.UserID}, &m.Role); err != nil {
+			return err
+		}
+		i := index[ledgerID]
+		ledgers[i].Members = append(ledgers[i].Members, m)
+	}
+	return rows.Err()
+}
+
+func (ls *ledgerStore) Update(ctx context.Context, ledger *models.Ledger) error {
+	return ls.s.execAffected(ctx, `UPDATE ledgers SET name = ?, base_currency = ? WHERE id = ?`,
+		ledger.Name, ledger.BaseCurrency, ledger.ID.Hex())
+}
+
+func (ls *ledgerStore) PutMember(ctx context.Context, ledgerID primitive.ObjectID, member models.LedgerMember) error {
+	return ls.s.WithTransaction(ctx, func(ctx context.Context) error {
+		var n int
+		if err := ls.s.queryRow(ctx, `SELECT COUNT(*) FROM ledgers WHERE id = ?`, ledgerID.Hex()).Scan(&n); err != nil {
+			return err
+		}
+		if n == 0 {
+			return store.ErrNotFound
+		}
+		_, err := ls.s.exec(ctx, `
+			INSERT INTO ledger_members (ledger_id, user_id, role) VALUES (?, ?, ?)
+			ON CONFLICT (ledger_id, user_id) DO UPDATE SET role = excluded.role`,
+			ledgerID.Hex(), member.UserID.Hex(), string(member.Role))
+		return err
+	})
+}
+
+func (ls *ledgerStore) RemoveMember(ctx context.Context, ledgerID, userID primitive.ObjectID) error {
+	return ls.s.execAffected(ctx, `DELETE FROM ledger_members WHERE ledger_id = ? AND user_id = ?`,
+		ledgerID.Hex(), userID.Hex())
+}
@@ -0,0 +1,168 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"log"
+	"math"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed migrations
+var migrationFiles embed.FS
+
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// loadMigrations reads migrations/<dialect>/NNNN_name.sql in version order.
+func loadMigrations(dialect string) ([]migration, error) {
+	dir := path.Join("migrations", dialect)
+	entries, err := migrationFiles.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []migration
+	for _, entry := range entries {
+		base := strings.TrimSuffix(entry.Name(), ".sql")
+		prefix, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s is not named NNNN_name.sql", entry.Name())
+		}
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s: bad version: %v", entry.Name(), err)
+		}
+		content, err := migrationFiles.ReadFile(path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, migration{version: version, name: name, sql: string(content)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].version < migrations[j].version })
+	for i := 1; i < len(migrations); i++ {
+		if migrations[i].version == migrations[i-1].version {
+			return nil, fmt.Errorf("duplicate migration version %d", migrations[i].version)
+		}
+	}
+	return migrations, nil
+}
+
+// Migrate applies, in order, every migration that is not yet recorded in the
+// schema_migrations table. Each migration runs in its own transaction.
+func (s *Store) Migrate(ctx context.Context) error {
+	return s.migrateTo(ctx, math.MaxInt)
+}
+
+// migrateTo is Migrate stopping after the given version.
+func (s *Store) migrateTo(ctx context.Context, version int) error {
+	migrations, err := loadMigrations(s.dialect.name)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at TEXT NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+
+	applied := make(map[int]bool)
+	rows, err := s.db.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			rows.Close()
+			return err
+		}
+		applied[version] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// SQLite only allows switching foreign keys off outside a transaction;
+	// the store has a single connection, so this covers every migration.
+	// Violations are looked for before each migration commits instead.
+	if s.dialect.rebuildsTables {
+		if _, err := s.db.ExecContext(ctx, `PRAGMA foreign_keys = OFF`); err != nil {
+			return err
+		}
+		defer s.db.ExecContext(context.Background(), `PRAGMA foreign_keys = ON`)
+	}
+
+	for _, m := range migrations {
+		if m.version > version {
+			break
+		}
+		if applied[m.version] {
+			continue
+		}
+		err := s.WithTransaction(ctx, func(ctx context.Context) error {
+			if s.dialect.lockMigrations != "" {
+				if _, err := s.exec(ctx, s.dialect.lockMigrations); err != nil {
+					return err
+				}
+				// Another server may have applied it while we waited.
+				var n int
+				err := s.queryRow(ctx, `SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, m.version).Scan(&n)
+				if err != nil || n > 0 {
+					return err
+				}
+			}
+
+			log.Printf("Applying %s migration %04d_%s", s.dialect.name, m.version, m.name)
+			if _, err := s.exec(ctx, m.sql); err != nil {
+				return err
+			}
+			if s.dialect.rebuildsTables {
+				if err := s.checkForeignKeys(ctx); err != nil {
+					return err
+				}
+			}
+			_, err := s.exec(ctx, `INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+				m.version, m.name, time.Now().UTC().Format(timeLayout))
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("migration %04d_%s: %w", m.version, m.name, err)
+		}
+	}
+	return nil
+}
+
+// checkForeignKeys fails when a row references a missing row.
+func (s *Store) checkForeignKeys(ctx context.Context) error {
+	rows, err := s.query(ctx, `PRAGMA foreign_key_check`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	if rows.Next() {
+		var table, parent string
+		var rowid sql.NullInt64
+		var fkid int
+		if err := rows.Scan(&table, &rowid, &parent, &fkid); err != nil {
+			return err
+		}
+		return fmt.Errorf("foreign key violation: a row of %s references a missing %s", table, parent)
+	}
+	return rows.Err()
+}
@@ -0,0 +1,25 @@
+CREATE TABLE users (
+    id            TEXT PRIMARY KEY,
+    email         TEXT NOT NULL UNIQUE,
+    password_hash TEXT NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL
+);
+
+-- Rows created before accounts existed have no owner until the first user
+-- registers and claims them.
+ALTER TABLE categories ADD COLUMN owner_id TEXT REFERENCES users (id);
+ALTER TABLE expenses ADD COLUMN owner_id TEXT REFERENCES users (id);
+ALTER TABLE bills ADD COLUMN owner_id TEXT REFERENCES users (id);
+ALTER TABLE budget_goals ADD COLUMN owner_id TEXT REFERENCES users (id);
+ALTER TABLE bill_expenses ADD COLUMN owner_id TEXT;
+
+CREATE INDEX categories_owner_id ON categories (owner_id);
+CREATE INDEX bills_owner_id ON bills (owner_id);
+CREATE INDEX budget_goals_owner_id ON budget_goals (owner_id);
+
+DROP INDEX expenses_date;
+DROP INDEX expenses_amount;
+DROP INDEX expenses_name;
+CREATE INDEX expenses_date ON expenses (owner_id, date, id);
+CREATE INDEX expenses_amount ON expenses (owner_id, amount, id);
+CREATE INDEX expenses_name ON expenses (owner_id, name, id);
@@ -0,0 +1,40 @@
+CREATE TABLE ledgers (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE TABLE ledger_members (
+    ledger_id TEXT NOT NULL REFERENCES ledgers (id) ON DELETE CASCADE,
+    user_id   TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role      TEXT NOT NULL,
+    PRIMARY KEY (ledger_id, user_id)
+);
+
+CREATE INDEX ledger_members_user_id ON ledger_members (user_id);
+
+-- Every user gets a personal ledger with the same ID, so what they owned so
+-- far moves into it unchanged.
+INSERT INTO ledgers (id, name, created_at) SELECT id, 'Personal', created_at FROM users;
+INSERT INTO ledger_members (ledger_id, user_id, role) SELECT id, id, 'owner' FROM users;
+
+ALTER TABLE categories RENAME COLUMN owner_id TO ledger_id;
+ALTER TABLE categories DROP CONSTRAINT categories_owner_id_fkey;
+ALTER TABLE categories ADD FOREIGN KEY (ledger_id) REFERENCES ledgers (id);
+ALTER INDEX categories_owner_id RENAME TO categories_ledger_id;
+
+ALTER TABLE expenses RENAME COLUMN owner_id TO ledger_id;
+ALTER TABLE expenses DROP CONSTRAINT expenses_owner_id_fkey;
+ALTER TABLE expenses ADD FOREIGN KEY (ledger_id) REFERENCES ledgers (id);
+
+ALTER TABLE bills RENAME COLUMN owner_id TO ledger_id;
+ALTER TABLE bills DROP CONSTRAINT bills_owner_id_fkey;
+ALTER TABLE bills ADD FOREIGN KEY (ledger_id) REFERENCES ledgers (id);
+ALTER INDEX bills_owner_id RENAME TO bills_ledger_id;
+
+ALTER TABLE budget_goals RENAME COLUMN owner_id TO ledger_id;
+ALTER TABLE budget_goals DROP CONSTRAINT budget_goals_owner_id_fkey;
+ALTER TABLE budget_goals ADD FOREIGN KEY (ledger_id) REFERENCES ledgers (id);
+ALTER INDEX budget_goals_owner_id RENAME TO budget_goals_ledger_id;
+
+ALTER TABLE bill_expenses RENAME COLUMN owner_id TO ledger_id;
@@ -0,0 +1,14 @@
+-- Amounts become integer minor units (cents) with a currency code. Every
+-- amount stored so far was in dollars, so it is rounded to whole cents and
+-- tagged USD.
+ALTER TABLE expenses ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+ALTER TABLE expenses ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+
+ALTER TABLE bills ALTER COLUMN total TYPE BIGINT USING ROUND(total * 100)::BIGINT;
+ALTER TABLE bills ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+
+ALTER TABLE bill_expenses ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+ALTER TABLE bill_expenses ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
+
+ALTER TABLE budget_goals ALTER COLUMN amount TYPE BIGINT USING ROUND(amount * 100)::BIGINT;
+ALTER TABLE budget_goals ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
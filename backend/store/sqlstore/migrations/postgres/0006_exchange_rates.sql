@@ -0,0 +1,13 @@
+ALTER TABLE ledgers ADD COLUMN base_currency TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE exchange_rates (
+    id            TEXT PRIMARY KEY,
+    ledger_id     TEXT NOT NULL REFERENCES ledgers (id),
+    from_currency TEXT NOT NULL,
+    to_currency   TEXT NOT NULL,
+    rate          BIGINT NOT NULL,
+    date          TIMESTAMPTZ NOT NULL,
+    UNIQUE (ledger_id, from_currency, to_currency, date)
+);
+
+CREATE INDEX exchange_rates_date ON exchange_rates (ledger_id, date);
@@ -0,0 +1,20 @@
+CREATE TABLE recurring_expenses (
+    id             TEXT PRIMARY KEY,
+    ledger_id      TEXT NOT NULL REFERENCES ledgers (id),
+    name           TEXT NOT NULL,
+    amount         BIGINT NOT NULL,
+    currency       TEXT NOT NULL DEFAULT 'USD',
+    category_id    TEXT REFERENCES categories (id) ON DELETE CASCADE,
+    frequency      TEXT NOT NULL,
+    interval_count INTEGER NOT NULL,
+    start_date     TIMESTAMPTZ NOT NULL,
+    end_date       TIMESTAMPTZ,
+    next_index     INTEGER NOT NULL,
+    next_date      TIMESTAMPTZ NOT NULL,
+    done           BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at     TIMESTAMPTZ NOT NULL,
+    updated_at     TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX recurring_expenses_ledger ON recurring_expenses (ledger_id);
+CREATE INDEX recurring_expenses_due ON recurring_expenses (done, next_date);
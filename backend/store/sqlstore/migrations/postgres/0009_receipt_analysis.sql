@@ -0,0 +1,18 @@
+ALTER TABLE bills
+    ADD COLUMN subtotal   BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN discount   BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN tax        BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN tip        BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN reconciled BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN difference BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE bill_items (
+    bill_id    TEXT NOT NULL REFERENCES bills (id) ON DELETE CASCADE,
+    position   INTEGER NOT NULL,
+    name       TEXT NOT NULL,
+    quantity   DOUBLE PRECISION NOT NULL,
+    unit_price BIGINT NOT NULL,
+    amount     BIGINT NOT NULL,
+    discount   BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (bill_id, position)
+);
@@ -0,0 +1,13 @@
+ALTER TABLE bills
+    ADD COLUMN merchant         TEXT NOT NULL DEFAULT '',
+    ADD COLUMN transaction_date TIMESTAMPTZ,
+    ADD COLUMN payment_method   TEXT NOT NULL DEFAULT '',
+    ADD COLUMN card_last4       TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE expenses
+    ADD COLUMN merchant       TEXT NOT NULL DEFAULT '',
+    ADD COLUMN payment_method TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE bill_expenses
+    ADD COLUMN merchant       TEXT NOT NULL DEFAULT '',
+    ADD COLUMN payment_method TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,13 @@
+-- File contents move to the blob store, and bill_files only refers to them.
+-- Contents kept in the database until now cannot be carried over, so bills
+-- still waiting for OCR fail and have to be uploaded again.
+UPDATE bills SET status = 'error', error_message = 'the uploaded file was not kept, upload the bill again'
+WHERE id IN (SELECT bill_id FROM bill_jobs);
+DELETE FROM bill_jobs;
+DELETE FROM bill_files;
+
+ALTER TABLE bill_jobs DROP COLUMN content;
+ALTER TABLE bill_files
+    DROP COLUMN content,
+    ADD COLUMN hash TEXT NOT NULL DEFAULT '',
+    ADD COLUMN size BIGINT NOT NULL DEFAULT 0;
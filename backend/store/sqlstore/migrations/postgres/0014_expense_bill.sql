@@ -0,0 +1,3 @@
+ALTER TABLE expenses ADD COLUMN bill_id TEXT;
+
+CREATE INDEX expenses_bill ON expenses (bill_id);
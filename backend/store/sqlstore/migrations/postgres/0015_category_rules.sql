@@ -0,0 +1,17 @@
+-- weekdays is a bit mask, bit 0 for Sunday; 0 matches any day.
+CREATE TABLE category_rules (
+    id           TEXT PRIMARY KEY,
+    ledger_id    TEXT NOT NULL REFERENCES ledgers (id),
+    name         TEXT NOT NULL,
+    priority     INTEGER NOT NULL,
+    category_id  TEXT NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    name_pattern TEXT NOT NULL DEFAULT '',
+    merchant     TEXT NOT NULL DEFAULT '',
+    min_amount   BIGINT,
+    max_amount   BIGINT,
+    weekdays     INTEGER NOT NULL DEFAULT 0,
+    created_at   TIMESTAMPTZ NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX category_rules_ledger_priority ON category_rules (ledger_id, priority, id);
@@ -0,0 +1 @@
+ALTER TABLE bill_expenses ADD COLUMN category_confidence DOUBLE PRECISION NOT NULL DEFAULT 0;
@@ -0,0 +1,19 @@
+ALTER TABLE budget_goals
+    ADD COLUMN rollover     TEXT NOT NULL DEFAULT '',
+    ADD COLUMN rollover_cap BIGINT NOT NULL DEFAULT 0;
+
+CREATE TABLE budget_balances (
+    id           TEXT PRIMARY KEY,
+    ledger_id    TEXT NOT NULL REFERENCES ledgers (id),
+    goal_id      TEXT NOT NULL REFERENCES budget_goals (id) ON DELETE CASCADE,
+    period_start TIMESTAMPTZ NOT NULL,
+    period_end   TIMESTAMPTZ NOT NULL,
+    carried_in   BIGINT NOT NULL,
+    budget       BIGINT NOT NULL,
+    spent        BIGINT NOT NULL,
+    carried_out  BIGINT NOT NULL,
+    updated_at   TIMESTAMPTZ NOT NULL,
+    UNIQUE (goal_id, period_start)
+);
+
+CREATE INDEX budget_balances_goal ON budget_balances (ledger_id, goal_id, period_start);
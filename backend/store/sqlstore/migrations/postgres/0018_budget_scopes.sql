@@ -0,0 +1,7 @@
+ALTER TABLE budget_goals ADD COLUMN scope TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE budget_goal_categories (
+    goal_id     TEXT NOT NULL REFERENCES budget_goals (id) ON DELETE CASCADE,
+    category_id TEXT NOT NULL,
+    PRIMARY KEY (goal_id, category_id)
+);
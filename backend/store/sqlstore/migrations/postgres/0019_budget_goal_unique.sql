@@ -0,0 +1,20 @@
+-- scope_key is what a goal counts, its categories comma-separated or
+-- 'overall'; no two goals of a ledger count the same over the same period.
+ALTER TABLE budget_goals ADD COLUMN scope_key TEXT;
+
+UPDATE budget_goals SET scope_key = CASE
+    WHEN scope = 'overall' THEN 'overall'
+    WHEN scope = 'categories' THEN (
+        SELECT string_agg(category_id, ',' ORDER BY category_id) FROM budget_goal_categories WHERE goal_id = budget_goals.id)
+    ELSE category_id
+END;
+
+-- Duplicates saved before they were refused are kept, unchecked until they
+-- are next updated.
+UPDATE budget_goals SET scope_key = NULL
+WHERE EXISTS (
+    SELECT 1 FROM budget_goals o
+    WHERE o.ledger_id = budget_goals.ledger_id AND o.period = budget_goals.period
+      AND o.scope_key = budget_goals.scope_key AND o.id < budget_goals.id);
+
+CREATE UNIQUE INDEX budget_goals_unique ON budget_goals (ledger_id, period, scope_key);
@@ -0,0 +1,46 @@
+CREATE TABLE categories (
+    id    TEXT PRIMARY KEY,
+    name  TEXT NOT NULL,
+    color TEXT NOT NULL
+);
+
+CREATE TABLE expenses (
+    id          TEXT PRIMARY KEY,
+    name        TEXT NOT NULL,
+    amount      REAL NOT NULL,
+    date        TEXT NOT NULL,
+    category_id TEXT
+);
+
+CREATE INDEX expenses_category_id ON expenses (category_id);
+
+CREATE TABLE bills (
+    id             TEXT PRIMARY KEY,
+    file_name      TEXT NOT NULL,
+    file_type      TEXT NOT NULL,
+    upload_date    TEXT NOT NULL,
+    processed_date TEXT NOT NULL,
+    status         TEXT NOT NULL,
+    extracted_text TEXT NOT NULL,
+    total          REAL NOT NULL
+);
+
+CREATE TABLE bill_expenses (
+    bill_id     TEXT NOT NULL REFERENCES bills (id) ON DELETE CASCADE,
+    position    INTEGER NOT NULL,
+    id          TEXT NOT NULL,
+    name        TEXT NOT NULL,
+    amount      REAL NOT NULL,
+    date        TEXT NOT NULL,
+    category_id TEXT,
+    PRIMARY KEY (bill_id, id)
+);
+
+CREATE TABLE budget_goals (
+    id          TEXT PRIMARY KEY,
+    category_id TEXT,
+    amount      REAL NOT NULL,
+    period      TEXT NOT NULL,
+    created_at  TEXT NOT NULL,
+    updated_at  TEXT NOT NULL
+);
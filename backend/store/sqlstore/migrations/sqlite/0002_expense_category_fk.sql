@@ -0,0 +1,19 @@
+-- SQLite cannot add a constraint to an existing table, so rebuild expenses
+-- with a foreign key on category_id. Expenses pointing at a category that
+-- no longer exists lose their category.
+CREATE TABLE expenses_new (
+    id          TEXT PRIMARY KEY,
+    name        TEXT NOT NULL,
+    amount      REAL NOT NULL,
+    date        TEXT NOT NULL,
+    category_id TEXT REFERENCES categories (id) ON DELETE CASCADE
+);
+
+INSERT INTO expenses_new (id, name, amount, date, category_id)
+SELECT id, name, amount, date,
+       CASE WHEN category_id IN (SELECT id FROM categories) THEN category_id END
+FROM expenses;
+
+DROP TABLE expenses;
+ALTER TABLE expenses_new RENAME TO expenses;
+CREATE INDEX expenses_category_id ON expenses (category_id);
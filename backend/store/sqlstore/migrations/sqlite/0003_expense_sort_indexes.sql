@@ -0,0 +1,3 @@
+CREATE INDEX expenses_date ON expenses (date, id);
+CREATE INDEX expenses_amount ON expenses (amount, id);
+CREATE INDEX expenses_name ON expenses (name, id);
@@ -0,0 +1,83 @@
+CREATE TABLE ledgers (
+    id         TEXT PRIMARY KEY,
+    name       TEXT NOT NULL,
+    created_at TEXT NOT NULL
+);
+
+CREATE TABLE ledger_members (
+    ledger_id TEXT NOT NULL REFERENCES ledgers (id) ON DELETE CASCADE,
+    user_id   TEXT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role      TEXT NOT NULL,
+    PRIMARY KEY (ledger_id, user_id)
+);
+
+CREATE INDEX ledger_members_user_id ON ledger_members (user_id);
+
+-- Every user gets a personal ledger with the same ID, so what they owned so
+-- far moves into it unchanged.
+INSERT INTO ledgers (id, name, created_at) SELECT id, 'Personal', created_at FROM users;
+INSERT INTO ledger_members (ledger_id, user_id, role) SELECT id, id, 'owner' FROM users;
+
+-- owner_id becomes ledger_id and references ledgers. SQLite cannot change a
+-- constraint in place, so rebuild the tables.
+CREATE TABLE categories_new (
+    id        TEXT PRIMARY KEY,
+    name      TEXT NOT NULL,
+    color     TEXT NOT NULL,
+    ledger_id TEXT REFERENCES ledgers (id)
+);
+INSERT INTO categories_new (id, name, color, ledger_id) SELECT id, name, color, owner_id FROM categories;
+DROP TABLE categories;
+ALTER TABLE categories_new RENAME TO categories;
+CREATE INDEX categories_ledger_id ON categories (ledger_id);
+
+CREATE TABLE expenses_new (
+    id          TEXT PRIMARY KEY,
+    name        TEXT NOT NULL,
+    amount      REAL NOT NULL,
+    date        TEXT NOT NULL,
+    category_id TEXT REFERENCES categories (id) ON DELETE CASCADE,
+    ledger_id   TEXT REFERENCES ledgers (id)
+);
+INSERT INTO expenses_new (id, name, amount, date, category_id, ledger_id)
+SELECT id, name, amount, date, category_id, owner_id FROM expenses;
+DROP TABLE expenses;
+ALTER TABLE expenses_new RENAME TO expenses;
+CREATE INDEX expenses_category_id ON expenses (category_id);
+CREATE INDEX expenses_date ON expenses (ledger_id, date, id);
+CREATE INDEX expenses_amount ON expenses (ledger_id, amount, id);
+CREATE INDEX expenses_name ON expenses (ledger_id, name, id);
+
+CREATE TABLE bills_new (
+    id             TEXT PRIMARY KEY,
+    file_name      TEXT NOT NULL,
+    file_type      TEXT NOT NULL,
+    upload_date    TEXT NOT NULL,
+    processed_date TEXT NOT NULL,
+    status         TEXT NOT NULL,
+    extracted_text TEXT NOT NULL,
+    total          REAL NOT NULL,
+    ledger_id      TEXT REFERENCES ledgers (id)
+);
+INSERT INTO bills_new (id, file_name, file_type, upload_date, processed_date, status, extracted_text, total, ledger_id)
+SELECT id, file_name, file_type, upload_date, processed_date, status, extracted_text, total, owner_id FROM bills;
+DROP TABLE bills;
+ALTER TABLE bills_new RENAME TO bills;
+CREATE INDEX bills_ledger_id ON bills (ledger_id);
+
+CREATE TABLE budget_goals_new (
+    id          TEXT PRIMARY KEY,
+    category_id TEXT,
+    amount      REAL NOT NULL,
+    period      TEXT NOT NULL,
+    created_at  TEXT NOT NULL,
+    updated_at  TEXT NOT NULL,
+    ledger_id   TEXT REFERENCES ledgers (id)
+);
+INSERT INTO budget_goals_new (id, category_id, amount, period, created_at, updated_at, ledger_id)
+SELECT id, category_id, amount, period, created_at, updated_at, owner_id FROM budget_goals;
+DROP TABLE budget_goals;
+ALTER TABLE budget_goals_new RENAME TO budget_goals;
+CREATE INDEX budget_goals_ledger_id ON budget_goals (ledger_id);
+
+ALTER TABLE bill_expenses RENAME COLUMN owner_id TO ledger_id;
@@ -0,0 +1,72 @@
+-- Amounts become integer minor units (cents) with a currency code. Every
+-- amount stored so far was in dollars, so it is rounded to whole cents and
+-- tagged USD.
+CREATE TABLE expenses_new (
+    id          TEXT PRIMARY KEY,
+    name        TEXT NOT NULL,
+    amount      INTEGER NOT NULL,
+    currency    TEXT NOT NULL DEFAULT 'USD',
+    date        TEXT NOT NULL,
+    category_id TEXT REFERENCES categories (id) ON DELETE CASCADE,
+    ledger_id   TEXT REFERENCES ledgers (id)
+);
+INSERT INTO expenses_new (id, name, amount, date, category_id, ledger_id)
+SELECT id, name, CAST(ROUND(amount * 100) AS INTEGER), date, category_id, ledger_id FROM expenses;
+DROP TABLE expenses;
+ALTER TABLE expenses_new RENAME TO expenses;
+CREATE INDEX expenses_category_id ON expenses (category_id);
+CREATE INDEX expenses_date ON expenses (ledger_id, date, id);
+CREATE INDEX expenses_amount ON expenses (ledger_id, amount, id);
+CREATE INDEX expenses_name ON expenses (ledger_id, name, id);
+
+CREATE TABLE bills_new (
+    id             TEXT PRIMARY KEY,
+    file_name      TEXT NOT NULL,
+    file_type      TEXT NOT NULL,
+    upload_date    TEXT NOT NULL,
+    processed_date TEXT NOT NULL,
+    status         TEXT NOT NULL,
+    extracted_text TEXT NOT NULL,
+    total          INTEGER NOT NULL,
+    currency       TEXT NOT NULL DEFAULT 'USD',
+    ledger_id      TEXT REFERENCES ledgers (id)
+);
+INSERT INTO bills_new (id, file_name, file_type, upload_date, processed_date, status, extracted_text, total, ledger_id)
+SELECT id, file_name, file_type, upload_date, processed_date, status, extracted_text, CAST(ROUND(total * 100) AS INTEGER), ledger_id
+FROM bills;
+DROP TABLE bills;
+ALTER TABLE bills_new RENAME TO bills;
+CREATE INDEX bills_ledger_id ON bills (ledger_id);
+
+CREATE TABLE bill_expenses_new (
+    bill_id     TEXT NOT NULL REFERENCES bills (id) ON DELETE CASCADE,
+    position    INTEGER NOT NULL,
+    id          TEXT NOT NULL,
+    name        TEXT NOT NULL,
+    amount      INTEGER NOT NULL,
+    currency    TEXT NOT NULL DEFAULT 'USD',
+    date        TEXT NOT NULL,
+    category_id TEXT,
+    ledger_id   TEXT,
+    PRIMARY KEY (bill_id, id)
+);
+INSERT INTO bill_expenses_new (bill_id, position, id, name, amount, date, category_id, ledger_id)
+SELECT bill_id, position, id, name, CAST(ROUND(amount * 100) AS INTEGER), date, category_id, ledger_id FROM bill_expenses;
+DROP TABLE bill_expenses;
+ALTER TABLE bill_expenses_new RENAME TO bill_expenses;
+
+CREATE TABLE budget_goals_new (
+    id          TEXT PRIMARY KEY,
+    category_id TEXT,
+    amount      INTEGER NOT NULL,
+    currency    TEXT NOT NULL DEFAULT 'USD',
+    period      TEXT NOT NULL,
+    created_at  TEXT NOT NULL,
+    updated_at  TEXT NOT NULL,
+    ledger_id   TEXT REFERENCES ledgers (id)
+);
+INSERT INTO budget_goals_new (id, category_id, amount, period, created_at, updated_at, ledger_id)
+SELECT id, category_id, CAST(ROUND(amount * 100) AS INTEGER), period, created_at, updated_at, ledger_id FROM budget_goals;
+DROP TABLE budget_goals;
+ALTER TABLE budget_goals_new RENAME TO budget_goals;
+CREATE INDEX budget_goals_ledger_id ON budget_goals (ledger_id);
@@ -0,0 +1,20 @@
+CREATE TABLE recurring_expenses (
+    id             TEXT PRIMARY KEY,
+    ledger_id      TEXT NOT NULL REFERENCES ledgers (id),
+    name           TEXT NOT NULL,
+    amount         INTEGER NOT NULL,
+    currency       TEXT NOT NULL DEFAULT 'USD',
+    category_id    TEXT REFERENCES categories (id) ON DELETE CASCADE,
+    frequency      TEXT NOT NULL,
+    interval_count INTEGER NOT NULL,
+    start_date     TEXT NOT NULL,
+    end_date       TEXT,
+    next_index     INTEGER NOT NULL,
+    next_date      TEXT NOT NULL,
+    done           INTEGER NOT NULL DEFAULT 0,
+    created_at     TEXT NOT NULL,
+    updated_at     TEXT NOT NULL
+);
+
+CREATE INDEX recurring_expenses_ledger ON recurring_expenses (ledger_id);
+CREATE INDEX recurring_expenses_due ON recurring_expenses (done, next_date);
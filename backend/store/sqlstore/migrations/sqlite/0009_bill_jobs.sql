@@ -0,0 +1,14 @@
+ALTER TABLE bills ADD COLUMN error_message TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE bill_jobs (
+    id         TEXT PRIMARY KEY,
+    ledger_id  TEXT NOT NULL REFERENCES ledgers (id),
+    bill_id    TEXT NOT NULL REFERENCES bills (id) ON DELETE CASCADE,
+    content    BLOB NOT NULL,
+    attempts   INTEGER NOT NULL DEFAULT 0,
+    run_at     TEXT NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TEXT NOT NULL
+);
+
+CREATE INDEX bill_jobs_run_at ON bill_jobs (run_at);
@@ -0,0 +1,17 @@
+ALTER TABLE bills ADD COLUMN subtotal INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE bills ADD COLUMN discount INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE bills ADD COLUMN tax INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE bills ADD COLUMN tip INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE bills ADD COLUMN reconciled INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE bills ADD COLUMN difference INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE bill_items (
+    bill_id    TEXT NOT NULL REFERENCES bills (id) ON DELETE CASCADE,
+    position   INTEGER NOT NULL,
+    name       TEXT NOT NULL,
+    quantity   REAL NOT NULL,
+    unit_price INTEGER NOT NULL,
+    amount     INTEGER NOT NULL,
+    discount   INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (bill_id, position)
+);
@@ -0,0 +1,10 @@
+ALTER TABLE bills ADD COLUMN merchant TEXT NOT NULL DEFAULT '';
+ALTER TABLE bills ADD COLUMN transaction_date TEXT;
+ALTER TABLE bills ADD COLUMN payment_method TEXT NOT NULL DEFAULT '';
+ALTER TABLE bills ADD COLUMN card_last4 TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE expenses ADD COLUMN merchant TEXT NOT NULL DEFAULT '';
+ALTER TABLE expenses ADD COLUMN payment_method TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE bill_expenses ADD COLUMN merchant TEXT NOT NULL DEFAULT '';
+ALTER TABLE bill_expenses ADD COLUMN payment_method TEXT NOT NULL DEFAULT '';
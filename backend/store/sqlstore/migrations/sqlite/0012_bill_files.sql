@@ -0,0 +1,9 @@
+CREATE TABLE bill_files (
+    bill_id      TEXT NOT NULL REFERENCES bills (id) ON DELETE CASCADE,
+    kind         TEXT NOT NULL,
+    ledger_id    TEXT NOT NULL REFERENCES ledgers (id),
+    content_type TEXT NOT NULL,
+    content      BLOB NOT NULL,
+    created_at   TEXT NOT NULL,
+    PRIMARY KEY (bill_id, kind)
+);
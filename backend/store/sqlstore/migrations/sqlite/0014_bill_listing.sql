@@ -0,0 +1,2 @@
+CREATE INDEX bills_ledger_upload_date ON bills (ledger_id, upload_date, id);
+CREATE INDEX bill_files_hash ON bill_files (hash);
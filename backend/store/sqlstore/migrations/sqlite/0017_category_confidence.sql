@@ -0,0 +1 @@
+ALTER TABLE bill_expenses ADD COLUMN category_confidence REAL NOT NULL DEFAULT 0;
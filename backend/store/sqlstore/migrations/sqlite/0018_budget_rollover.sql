@@ -0,0 +1,18 @@
+ALTER TABLE budget_goals ADD COLUMN rollover TEXT NOT NULL DEFAULT '';
+ALTER TABLE budget_goals ADD COLUMN rollover_cap INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE budget_balances (
+    id           TEXT PRIMARY KEY,
+    ledger_id    TEXT NOT NULL REFERENCES ledgers (id),
+    goal_id      TEXT NOT NULL REFERENCES budget_goals (id) ON DELETE CASCADE,
+    period_start TEXT NOT NULL,
+    period_end   TEXT NOT NULL,
+    carried_in   INTEGER NOT NULL,
+    budget       INTEGER NOT NULL,
+    spent        INTEGER NOT NULL,
+    carried_out  INTEGER NOT NULL,
+    updated_at   TEXT NOT NULL,
+    UNIQUE (goal_id, period_start)
+);
+
+CREATE INDEX budget_balances_goal ON budget_balances (ledger_id, goal_id, period_start);
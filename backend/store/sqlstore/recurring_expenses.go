@@ -0,0 +1,104 @@
+package sqlstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type recurringExpenseStore struct {
+	s *Store
+}
+
+const recurringExpenseColumns = `id, ledger_id, name, amount, currency, category_id, frequency, interval_count,
+	start_date, end_date, next_index, next_date, done, created_at, updated_at`
+
+func (rs *recurringExpenseStore) List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.RecurringExpense, error) {
+	return rs.find(ctx, `SELECT `+recurringExpenseColumns+` FROM recurring_expenses WHERE ledger_id = ? ORDER BY id`, ledgerID.Hex())
+}
+
+func (rs *recurringExpenseStore) Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.RecurringExpense, error) {
+	recurring, err := rs.find(ctx, `SELECT `+recurringExpenseColumns+` FROM recurring_expenses WHERE id = ? AND ledger_id = ?`,
+		id.Hex(), ledgerID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if len(recurring) == 0 {
+		return nil, store.ErrNotFound
+	}
+	return &recurring[0], nil
+}
+
+func (rs *recurringExpenseStore) Insert(ctx context.Context, recurring *models.RecurringExpense) error {
+	if recurring.ID.IsZero() {
+		recurring.ID = primitive.NewObjectID()
+	}
+	d := rs.s.dialect
+	_, err := rs.s.exec(ctx, `INSERT INTO recurring_expenses (`+recurringExpenseColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		recurring.ID.Hex(), recurring.LedgerID.Hex(), recurring.Name, recurring.Amount, recurring.Currency,
+		nullableID(recurring.CategoryID), recurring.Frequency, recurring.Interval,
+		d.timeValue(recurring.StartDate), d.nullableTime(recurring.EndDate), recurring.NextIndex, d.timeValue(recurring.NextDate),
+		recurring.Done, d.timeValue(recurring.CreatedAt), d.timeValue(recurring.UpdatedAt))
+	return err
+}
+
+func (rs *recurringExpenseStore) Update(ctx context.Context, recurring *models.RecurringExpense) error {
+	d := rs.s.dialect
+	return rs.s.execAffected(ctx, `
+		UPDATE recurring_expenses SET name = ?, amount = ?, currency = ?, category_id = ?, frequency = ?, interval_count = ?,
+			start_date = ?, end_date = ?, next_index = ?, next_date = ?, done = ?, created_at = ?, updated_at = ?
+		WHERE id = ? AND ledger_id = ?`,
+		recurring.Name, recurring.Amount, recurring.Currency, nullableID(recurring.CategoryID), recurring.Frequency, recurring.Interval,
+		d.timeValue(recurring.StartDate), d.nullableTime(recurring.EndDate), recurring.NextIndex, d.timeValue(recurring.NextDate),
+		recurring.Done, d.timeValue(recurring.CreatedAt), d.timeValue(recurring.UpdatedAt), recurring.ID.Hex(), recurring.LedgerID.Hex())
+}
+
+func (rs *recurringExpenseStore) Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error {
+	return rs.s.execAffected(ctx, `DELETE FROM recurring_expenses WHERE id = ? AND ledger_id = ?`, id.Hex(), ledgerID.Hex())
+}
+
+func (rs *recurringExpenseStore) DeleteByCategory(ctx context.Context, ledgerID, categoryID primitive.ObjectID) (int64, error) {
+	result, err := rs.s.exec(ctx, `DELETE FROM recurring_expenses WHERE ledger_id = ? AND category_id = ?`, ledgerID.Hex(), categoryID.Hex())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (rs *recurringExpenseStore) Due(ctx context.Context, t time.Time) ([]models.RecurringExpense, error) {
+	return rs.find(ctx, `SELECT `+recurringExpenseColumns+` FROM recurring_expenses WHERE NOT done AND next_date <= ? ORDER BY next_date, id`,
+		rs.s.dialect.timeValue(t))
+}
+
+func (rs *recurringExpenseStore) Advance(ctx context.Context, recurring *models.RecurringExpense, fromIndex int) error {
+	return rs.s.execAffected(ctx, `
+		UPDATE recurring_expenses SET next_index = ?, next_date = ?, done = ?
+		WHERE id = ? AND ledger_id = ? AND next_index = ?`,
+		recurring.NextIndex, rs.s.dialect.timeValue(recurring.NextDate), recurring.Done,
+		recurring.ID.Hex(), recurring.LedgerID.Hex(), fromIndex)
+}
+
+func (rs *recurringExpenseStore) find(ctx context.Context, query string, args ...interface{}) ([]models.RecurringExpense, error) {
+	rows, err := rs.s.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var recurring []models.RecurringExpense
+	for rows.Next() {
+		var r models.RecurringExpense
+		err := rows.Scan(idScanner{&r.ID}, idScanner{&r.LedgerID}, &r.Name, &r.Amount, &r.Currency, idScanner{&r.CategoryID},
+			&r.Frequency, &r.Interval, timeScanner{&r.StartDate}, nullTimeScanner{&r.EndDate}, &r.NextIndex,
+			timeScanner{&r.NextDate}, &r.Done, timeScanner{&r.CreatedAt}, timeScanner{&r.UpdatedAt})
+		if err != nil {
+			return nil, err
+		}
+		recurring = append(recurring, r)
+	}
+	return recurring, rows.Err()
+}
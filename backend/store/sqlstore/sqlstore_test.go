@@ -0,0 +1,322 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"github.com/dhruwanga19/expense-tracker/store/storetest"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newSQLiteStore(t *testing.T) *Store {
+	t.Helper()
+	st, err := OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+	if err := st.Migrate(context.Background()); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return st
+}
+
+// newPostgresStore opens a store in a fresh schema of the database at
+// POSTGRES_TEST_URI, and skips the test when it is not set.
+func newPostgresStore(t *testing.T) *Store {
+	t.Helper()
+	uri := os.Getenv("POSTGRES_TEST_URI")
+	if uri == "" {
+		t.Skip("POSTGRES_TEST_URI not set")
+	}
+	ctx := context.Background()
+
+	admin, err := sql.Open("pgx", uri)
+	if err != nil {
+		t.Fatalf("open postgres: %v", err)
+	}
+	schema := "expenses_test_" + primitive.NewObjectID().Hex()
+	if _, err := admin.ExecContext(ctx, `CREATE SCHEMA `+schema); err != nil {
+		t.Fatalf("create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		admin.ExecContext(ctx, `DROP SCHEMA `+schema+` CASCADE`)
+		admin.Close()
+	})
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		t.Fatalf("parse POSTGRES_TEST_URI: %v", err)
+	}
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+
+	st, err := OpenPostgres(ctx, u.String())
+	if err != nil {
+		t.Fatalf("open postgres: %v", err)
+	}
+	t.Cleanup(func() { st.Close() })
+	if err := st.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return st
+}
+
+func TestSQLiteStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store { return newSQLiteStore(t) })
+	t.Run("ForeignKeys", func(t *testing.T) { testForeignKeys(t, newSQLiteStore(t)) })
+}
+
+func TestPostgresStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store { return newPostgresStore(t) })
+	t.Run("ForeignKeys", func(t *testing.T) { testForeignKeys(t, newPostgresStore(t)) })
+}
+
+func testForeignKeys(t *testing.T, st *Store) {
+	ctx := context.Background()
+	expense := models.Expense{Name: "Lost", Amount: 1, Date: time.Now(), CategoryID: primitive.NewObjectID()}
+	if err := st.Expenses().Insert(ctx, &expense); err == nil {
+		t.Error("inserted an expense pointing at a missing category")
+	}
+}
+
+func TestRebind(t *testing.T) {
+	got := postgres.rebind(`UPDATE t SET a = ?, b = ? WHERE id IN (?, ?)`)
+	want := `UPDATE t SET a = $1, b = $2 WHERE id IN ($3, $4)`
+	if got != want {
+		t.Errorf("rebind = %q, want %q", got, want)
+	}
+	if got := sqlite.rebind(`SELECT ?`); got != `SELECT ?` {
+		t.Errorf("sqlite rebind changed the query: %q", got)
+	}
+}
+
+func TestSQLiteMigrateIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "expenses.db")
+
+	st, err := OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	if err := st.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	st.Close()
+
+	// Migrating again must find every migration already applied.
+	st, err = OpenSQLite(path)
+	if err != nil {
+		t.Fatalf("reopen sqlite: %v", err)
+	}
+	defer st.Close()
+	if err := st.Migrate(ctx); err != nil {
+		t.Fatalf("second migrate: %v", err)
+	}
+
+	var count int
+	if err := st.db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	migrations, err := loadMigrations("sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != len(migrations) {
+		t.Errorf("schema_migrations has %d rows, want %d", count, len(migrations))
+	}
+}
+
+func TestSQLiteMigrateOwnersToLedgers(t *testing.T) {
+	ctx := context.Background()
+	st, err := OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer st.Close()
+	if err := st.migrateTo(ctx, 4); err != nil {
+		t.Fatalf("migrate to 4: %v", err)
+	}
+
+	user, category, expense := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	for _, q := range []struct {
+		sql  string
+		args []interface{}
+	}{
+		{`INSERT INTO users (id, email, password_hash, created_at) VALUES (?, 'a@example.com', 'x', ?)`,
+			[]interface{}{user.Hex(), sqlite.timeValue(time.Now())}},
+		{`INSERT INTO categories (id, name, color, owner_id) VALUES (?, 'Food', '#000000', ?)`,
+			[]interface{}{category.Hex(), user.Hex()}},
+		{`INSERT INTO expenses (id, name, amount, date, category_id, owner_id) VALUES (?, 'Lunch', 9, ?, ?, ?)`,
+			[]interface{}{expense.Hex(), sqlite.timeValue(time.Now()), category.Hex(), user.Hex()}},
+	} {
+		if _, err := st.db.ExecContext(ctx, q.sql, q.args...); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	if err := st.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	ledger, err := st.Ledgers().Get(ctx, user)
+	if err != nil {
+		t.Fatalf("get personal ledger: %v", err)
+	}
+	if role, ok := ledger.Role(user); !ok || role != models.RoleOwner {
+		t.Errorf("personal ledger members: %+v", ledger.Members)
+	}
+	expenses, err := st.Expenses().List(ctx, store.ExpenseQuery{LedgerID: user})
+	if err != nil {
+		t.Fatalf("list expenses: %v", err)
+	}
+	if len(expenses) != 1 || expenses[0].ID != expense || expenses[0].Category == nil || expenses[0].Category.ID != category {
+		t.Errorf("migrated expenses: %+v", expenses)
+	}
+
+	// Foreign keys are enforced again after migrating.
+	orphan := models.Category{LedgerID: primitive.NewObjectID(), Name: "Orphan", Color: "#ffffff"}
+	if err := st.Categories().Insert(ctx, &orphan); err == nil {
+		t.Error("inserted a category of a missing ledger")
+	}
+}
+
+func TestSQLiteMigrateMoney(t *testing.T) {
+	ctx := context.Background()
+	st, err := OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer st.Close()
+	if err := st.migrateTo(ctx, 5); err != nil {
+		t.Fatalf("migrate to 5: %v", err)
+	}
+
+	ledger, category, expense, bill := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	now := sqlite.timeValue(time.Now())
+	for _, q := range []struct {
+		sql  string
+		args []interface{}
+	}{
+		{`INSERT INTO ledgers (id, name, created_at) VALUES (?, 'Personal', ?)`, []interface{}{ledger.Hex(), now}},
+		{`INSERT INTO categories (id, name, color, ledger_id) VALUES (?, 'Food', '#000000', ?)`,
+			[]interface{}{category.Hex(), ledger.Hex()}},
+		{`INSERT INTO expenses (id, name, amount, date, category_id, ledger_id) VALUES (?, 'Lunch', 0.1 + 0.2, ?, ?, ?)`,
+			[]interface{}{expense.Hex(), now, category.Hex(), ledger.Hex()}},
+		{`INSERT INTO bills (id, file_name, file_type, upload_date, processed_date, status, extracted_text, total, ledger_id)
+			VALUES (?, 'r.jpg', 'image/jpeg', ?, ?, 'processed', '', 12.34, ?)`, []interface{}{bill.Hex(), now, now, ledger.Hex()}},
+		{`INSERT INTO bill_expenses (bill_id, position, id, name, amount, date, ledger_id) VALUES (?, 0, ?, 'MILK', 4.35, ?, ?)`,
+			[]interface{}{bill.Hex(), primitive.NewObjectID().Hex(), now, ledger.Hex()}},
+		{`INSERT INTO budget_goals (id, category_id, amount, period, created_at, updated_at, ledger_id)
+			VALUES (?, ?, 250.5, 'monthly', ?, ?, ?)`, []interface{}{primitive.NewObjectID().Hex(), category.Hex(), now, now, ledger.Hex()}},
+	} {
+		if _, err := st.db.ExecContext(ctx, q.sql, q.args...); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	if err := st.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	expenses, err := st.Expenses().List(ctx, store.ExpenseQuery{LedgerID: ledger})
+	if err != nil {
+		t.Fatalf("list expenses: %v", err)
+	}
+	if len(expenses) != 1 || expenses[0].Amount != 30 || expenses[0].Currency != money.DefaultCurrency {
+		t.Errorf("migrated expenses: %+v", expenses)
+	}
+	got, err := st.Bills().Get(ctx, ledger, bill)
+	if err != nil {
+		t.Fatalf("get bill: %v", err)
+	}
+	if got.AnalysisResults.Total != 1234 || got.AnalysisResults.Currency != money.DefaultCurrency ||
+		len(got.GeneratedExpenses) != 1 || got.GeneratedExpenses[0].Amount != 435 {
+		t.Errorf("migrated bill: %+v", got)
+	}
+	goals, err := st.BudgetGoals().List(ctx, ledger)
+	if err != nil {
+		t.Fatalf("list budget goals: %v", err)
+	}
+	if len(goals) != 1 || goals[0].Amount != 25050 || goals[0].Currency != money.DefaultCurrency {
+		t.Errorf("migrated budget goals: %+v", goals)
+	}
+}
+
+func TestSQLiteBudgetGoalsUnique(t *testing.T) {
+	ctx := context.Background()
+	st, err := OpenSQLite(":memory:")
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	defer st.Close()
+	if err := st.migrateTo(ctx, 19); err != nil {
+		t.Fatalf("migrate to 19: %v", err)
+	}
+
+	ledger, food, fun := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	first, second, multi := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	now := sqlite.timeValue(time.Now())
+	for _, q := range []struct {
+		sql  string
+		args []interface{}
+	}{
+		{`INSERT INTO ledgers (id, name, created_at) VALUES (?, 'Personal', ?)`, []interface{}{ledger.Hex(), now}},
+		{`INSERT INTO categories (id, name, color, ledger_id) VALUES (?, 'Food', '#000000', ?), (?, 'Fun', '#000000', ?)`,
+			[]interface{}{food.Hex(), ledger.Hex(), fun.Hex(), ledger.Hex()}},
+		// Two goals for Food over the same period, saved before they were refused.
+		{`INSERT INTO budget_goals (id, category_id, amount, currency, period, created_at, updated_at, ledger_id)
+			VALUES (?, ?, 100, 'USD', 'monthly', ?, ?, ?), (?, ?, 200, 'USD', 'monthly', ?, ?, ?)`,
+			[]interface{}{first.Hex(), food.Hex(), now, now, ledger.Hex(), second.Hex(), food.Hex(), now, now, ledger.Hex()}},
+		{`INSERT INTO budget_goals (id, scope, amount, currency, period, created_at, updated_at, ledger_id)
+			VALUES (?, 'categories', 300, 'USD', 'monthly', ?, ?, ?)`, []interface{}{multi.Hex(), now, now, ledger.Hex()}},
+		{`INSERT INTO budget_goal_categories (goal_id, category_id) VALUES (?, ?), (?, ?)`,
+			[]interface{}{multi.Hex(), fun.Hex(), multi.Hex(), food.Hex()}},
+	} {
+		if _, err := st.db.ExecContext(ctx, q.sql, q.args...); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	if err := st.Migrate(ctx); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	goals, err := st.BudgetGoals().List(ctx, ledger)
+	if err != nil {
+		t.Fatalf("list budget goals: %v", err)
+	}
+	if len(goals) != 3 {
+		t.Fatalf("migrated budget goals: %+v", goals)
+	}
+
+	// The duplicate kept by the migration is refused once it is saved again.
+	duplicate, err := st.BudgetGoals().Get(ctx, ledger, second)
+	if err != nil {
+		t.Fatalf("get budget goal: %v", err)
+	}
+	if err := st.BudgetGoals().Update(ctx, duplicate); err != store.ErrDuplicate {
+		t.Errorf("update duplicate goal: %v, want ErrDuplicate", err)
+	}
+	overall := models.BudgetGoal{LedgerID: ledger, Scope: models.ScopeOverall, Amount: 500, Currency: "USD", Period: goals[0].Period}
+	if err := st.BudgetGoals().Insert(ctx, &overall); err != nil {
+		t.Fatalf("insert overall goal: %v", err)
+	}
+	for _, goal := range []models.BudgetGoal{
+		{LedgerID: ledger, CategoryID: food, Amount: 100, Currency: "USD", Period: goals[0].Period},
+		{LedgerID: ledger, Scope: models.ScopeCategories, CategoryIDs: []primitive.ObjectID{food, fun}, Amount: 100, Currency: "USD", Period: goals[0].Period},
+		{LedgerID: ledger, Scope: models.ScopeOverall, Amount: 100, Currency: "USD", Period: goals[0].Period},
+	} {
+		if err := st.BudgetGoals().Insert(ctx, &goal); err != store.ErrDuplicate {
+			t.Errorf("insert %s goal: %v, want ErrDuplicate", goal.Scope, err)
+		}
+	}
+}
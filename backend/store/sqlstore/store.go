@@ -0,0 +1,292 @@
+// Package sqlstore implements the store interfaces on top of database/sql,
+// for SQLite and PostgreSQL. Queries are written with '?' placeholders and
+// rewritten per dialect; each dialect has its own versioned migrations in
+// the migrations directory.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	_ "modernc.org/sqlite"
+)
+
+type txKey struct{}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// dialect holds what differs between the supported databases.
+type dialect struct {
+	name string
+	// numberedParams is set when the driver wants $1, $2, ... instead of '?'.
+	numberedParams bool
+	// timeValue converts a time into the value stored in a timestamp column.
+	timeValue func(t time.Time) interface{}
+	// day returns the expression for the UTC day, as YYYY-MM-DD, of a
+	// timestamp column.
+	day func(column string) string
+	// lockMigrations is run at the start of every migration transaction so
+	// that servers starting at the same time do not race each other.
+	lockMigrations string
+	// rebuildsTables is set when changing a constraint means rebuilding the
+	// table, which needs foreign key enforcement off while migrating.
+	rebuildsTables bool
+}
+
+// timeLayout is a fixed-width UTC layout, so that times stored as text sort
+// and compare correctly.
+const timeLayout = "2006-01-02T15:04:05.000000000Z"
+
+var sqlite = dialect{
+	name:           "sqlite",
+	timeValue:      func(t time.Time) interface{} { return t.UTC().Format(timeLayout) },
+	day:            func(column string) string { return "substr(" + column + ", 1, 10)" },
+	rebuildsTables: true,
+}
+
+var postgres = dialect{
+	name:           "postgres",
+	numberedParams: true,
+	timeValue:      func(t time.Time) interface{} { return t.UTC() },
+	day:            func(column string) string { return "to_char(" + column + " AT TIME ZONE 'UTC', 'YYYY-MM-DD')" },
+	lockMigrations: `LOCK TABLE schema_migrations IN EXCLUSIVE MODE`,
+}
+
+// rebind rewrites the '?' placeholders of query for the dialect.
+func (d dialect) rebind(query string) string {
+	if !d.numberedParams || !strings.Contains(query, "?") {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+type Store struct {
+	db      *sql.DB
+	dialect dialect
+}
+
+// OpenSQLite opens (creating it if needed) the SQLite database at path. Use
+// ":memory:" for a throw-away database. Call Migrate before using the store.
+func OpenSQLite(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer; sharing one connection serialises
+	// writers instead of failing with "database is locked", and keeps an
+	// in-memory database alive.
+	db.SetMaxOpenConns(1)
+
+	return &Store{db: db, dialect: sqlite}, nil
+}
+
+// OpenPostgres connects to the PostgreSQL database at uri. Call Migrate
+// before using the store.
+func OpenPostgres(ctx context.Context, uri string) (*Store, error) {
+	db, err := sql.Open("pgx", uri)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db, dialect: postgres}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func (s *Store) Expenses() store.ExpenseStore                   { return &expenseStore{s} }
+func (s *Store) Categories() store.CategoryStore                { return &categoryStore{s} }
+func (s *Store) Bills() store.BillStore                         { return &billStore{s} }
+func (s *Store) BillJobs() store.BillJobStore                   { return &billJobStore{s} }
+func (s *Store) BillFiles() store.BillFileStore                 { return &billFileStore{s} }
+func (s *Store) BudgetGoals() store.BudgetGoalStore             { return &budgetGoalStore{s} }
+func (s *Store) BudgetBalances() store.BudgetBalanceStore       { return &budgetBalanceStore{s} }
+func (s *Store) RecurringExpenses() store.RecurringExpenseStore { return &recurringExpenseStore{s} }
+func (s *Store) CategoryRules() store.CategoryRuleStore         { return &categoryRuleStore{s} }
+func (s *Store) ExchangeRates() store.ExchangeRateStore         { return &exchangeRateStore{s} }
+func (s *Store) Users() store.UserStore                         { return &userStore{s} }
+func (s *Store) Ledgers() store.LedgerStore                     { return &ledgerStore{s} }
+
+func (s *Store) ClaimUnowned(ctx context.Context, ledgerID primitive.ObjectID) error {
+	return s.WithTransaction(ctx, func(ctx context.Context) error {
+		for _, table := range []string{"categories", "expenses", "bills", "bill_expenses", "budget_goals"} {
+			_, err := s.exec(ctx, `UPDATE `+table+` SET ledger_id = ? WHERE ledger_id IS NULL`, ledgerID.Hex())
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// WithTransaction runs fn inside a database transaction. Calls made with
+// the context passed to fn use that transaction.
+func (s *Store) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s *Store) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return s.db
+}
+
+func (s *Store) exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return s.conn(ctx).ExecContext(ctx, s.dialect.rebind(query), args...)
+}
+
+func (s *Store) query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return s.conn(ctx).QueryContext(ctx, s.dialect.rebind(query), args...)
+}
+
+func (s *Store) queryRow(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return s.conn(ctx).QueryRowContext(ctx, s.dialect.rebind(query), args...)
+}
+
+// execAffected runs an INSERT/UPDATE/DELETE and returns store.ErrNotFound
+// when it did not touch any row.
+func (s *Store) execAffected(ctx context.Context, query string, args ...interface{}) error {
+	result, err := s.exec(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return store.ErrNotFound
+	}
+	return nil
+}
+
+// nullableID stores the zero ObjectID as NULL.
+func nullableID(id primitive.ObjectID) interface{} {
+	if id.IsZero() {
+		return nil
+	}
+	return id.Hex()
+}
+
+// nullableTime stores a nil time as NULL.
+func (d dialect) nullableTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return d.timeValue(*t)
+}
+
+// idScanner scans a hex ObjectID column; NULL becomes the zero ObjectID.
+type idScanner struct {
+	id *primitive.ObjectID
+}
+
+func (s idScanner) Scan(src interface{}) error {
+	var hex string
+	switch v := src.(type) {
+	case nil:
+		*s.id = primitive.NilObjectID
+		return nil
+	case string:
+		hex = v
+	case []byte:
+		hex = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into an ObjectID", src)
+	}
+	id, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return err
+	}
+	*s.id = id
+	return nil
+}
+
+// timeScanner scans times stored either natively or as timeLayout text.
+type timeScanner struct {
+	t *time.Time
+}
+
+func (s timeScanner) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*s.t = time.Time{}
+	case time.Time:
+		*s.t = v.UTC()
+	case string:
+		return s.parse(v)
+	case []byte:
+		return s.parse(string(v))
+	default:
+		return fmt.Errorf("cannot scan %T into a time", src)
+	}
+	return nil
+}
+
+func (s timeScanner) parse(v string) error {
+	t, err := time.Parse(timeLayout, v)
+	if err != nil {
+		return err
+	}
+	*s.t = t
+	return nil
+}
+
+// nullTimeScanner scans a nullable time column; NULL becomes a nil pointer.
+type nullTimeScanner struct {
+	t **time.Time
+}
+
+func (s nullTimeScanner) Scan(src interface{}) error {
+	if src == nil {
+		*s.t = nil
+		return nil
+	}
+	var t time.Time
+	if err := (timeScanner{&t}).Scan(src); err != nil {
+		return err
+	}
+	*s.t = &t
+	return nil
+}
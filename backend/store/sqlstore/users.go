@@ -0,0 +1,64 @@
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type userStore struct {
+	s *Store
+}
+
+func (us *userStore) Insert(ctx context.Context, user *models.User) error {
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	_, err := us.s.exec(ctx, `INSERT INTO users (id, email, password_hash, created_at) VALUES (?, ?, ?, ?)`,
+		user.ID.Hex(), user.Email, user.PasswordHash, us.s.dialect.timeValue(user.CreatedAt))
+	if isUniqueViolation(err) {
+		return store.ErrDuplicate
+	}
+	return err
+}
+
+func (us *userStore) Get(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
+	return us.getOne(ctx, `WHERE id = ?`, id.Hex())
+}
+
+func (us *userStore) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return us.getOne(ctx, `WHERE email = ?`, email)
+}
+
+func (us *userStore) Count(ctx context.Context) (int64, error) {
+	var n int64
+	err := us.s.queryRow(ctx, `SELECT COUNT(*) FROM users`).Scan(&n)
+	return n, err
+}
+
+func (us *userStore) getOne(ctx context.Context, where string, args ...interface{}) (*models.User, error) {
+	var user models.User
+	err := us.s.queryRow(ctx, `SELECT id, email, password_hash, created_at FROM users `+where, args...).Scan(
+		idScanner{&user.ID}, &user.Email, &user.PasswordHash, timeScanner{&user.CreatedAt})
+	if err == sql.ErrNoRows {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// isUniqueViolation recognises unique constraint errors of both SQLite
+// ("UNIQUE constraint failed") and PostgreSQL (SQLSTATE 23505).
+func isUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "UNIQUE constraint failed") || strings.Contains(msg, "SQLSTATE 23505")
+}
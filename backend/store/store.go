@@ -0,0 +1,332 @@
+// Package store defines the persistence interfaces the services depend on.
+// Concrete implementations live in the sub-packages (mongostore, sqlstore,
+// memstore).
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	// ErrNotFound is returned when the requested document does not exist,
+	// or belongs to another ledger.
+	ErrNotFound = errors.New("document not found")
+	// ErrDuplicate is returned when an insert violates a uniqueness rule.
+	ErrDuplicate = errors.New("duplicate document")
+)
+
+// Fields expenses can be sorted by. Ties are broken by ID.
+const (
+	SortByDate   = "date"
+	SortByAmount = "amount"
+	SortByName   = "name"
+)
+
+// ExpenseQuery filters, sorts and pages ExpenseStore.List. Zero values mean
+// "no restriction", except for LedgerID which is always applied.
+type ExpenseQuery struct {
+	LedgerID primitive.ObjectID
+
+	From        time.Time // inclusive
+	To          time.Time // exclusive
+	CategoryIDs []primitive.ObjectID
+	MinAmount   *money.Amount
+	MaxAmount   *money.Amount
+	// Name matches expenses whose name contains it, ignoring case.
+	Name string
+	// BillID, when set, matches the expenses confirmed from that bill.
+	BillID primitive.ObjectID
+
+	SortBy   string // one of the SortBy constants, SortByDate when empty
+	SortDesc bool
+
+	// After continues a listing after the given expense, in the same sort
+	// order.
+	After *ExpenseCursor
+	Limit int
+}
+
+// ExpenseCursor is the position of an expense in a sorted listing: the
+// value of the sort field and the ID.
+type ExpenseCursor struct {
+	ID     primitive.ObjectID
+	Date   time.Time
+	Amount money.Amount
+	Name   string
+}
+
+// CursorFor returns the cursor pointing at expense.
+func CursorFor(expense models.Expense) *ExpenseCursor {
+	return &ExpenseCursor{ID: expense.ID, Date: expense.Date, Amount: expense.Amount, Name: expense.Name}
+}
+
+// BillQuery filters and pages BillStore.List, which returns bills most
+// recently uploaded first. Zero values mean "no restriction", except for
+// LedgerID which is always applied.
+type BillQuery struct {
+	LedgerID primitive.ObjectID
+
+	Statuses []string
+	From     time.Time // inclusive, on the upload date
+	To       time.Time // exclusive
+	// Merchant matches bills whose merchant contains it, ignoring case.
+	Merchant string
+
+	// After continues a listing after the given bill.
+	After *BillCursor
+	Limit int
+}
+
+// BillCursor is the position of a bill in a listing.
+type BillCursor struct {
+	ID         primitive.ObjectID
+	UploadDate time.Time
+}
+
+// ExpenseTotal sums the expenses of one category in one currency on one
+// day (UTC), which is as fine as exchange rates go.
+type ExpenseTotal struct {
+	CategoryID primitive.ObjectID
+	Currency   money.Currency
+	Day        time.Time // midnight UTC
+	Amount     money.Amount
+	Count      int
+}
+
+// Every document belongs to a ledger. Lookups take the ledger's ID and
+// never see documents of other ledgers; Insert and Update use the LedgerID
+// field of the document.
+
+type ExpenseStore interface {
+	// List returns the expenses matching query, joined with their category.
+	// Expenses whose category does not exist are left out, like the
+	// $unwind stage does.
+	List(ctx context.Context, query ExpenseQuery) ([]models.Expense, error)
+	// Totals sums the expenses matching query per category, currency and
+	// day, in no particular order. Unlike List it does not join the
+	// category; sorting and paging in query are ignored.
+	Totals(ctx context.Context, query ExpenseQuery) ([]ExpenseTotal, error)
+	// Insert stores a new expense and returns ErrDuplicate when one with the
+	// same ID exists.
+	Insert(ctx context.Context, expense *models.Expense) error
+	// Update replaces the fields of a stored expense, except for its BillID.
+	Update(ctx context.Context, expense *models.Expense) error
+	DeleteMany(ctx context.Context, ledgerID primitive.ObjectID, ids []primitive.ObjectID) (int64, error)
+	DeleteByCategory(ctx context.Context, ledgerID, categoryID primitive.ObjectID) (int64, error)
+	// DeleteByBill removes the expenses confirmed from a bill.
+	DeleteByBill(ctx context.Context, ledgerID, billID primitive.ObjectID) (int64, error)
+	// UnlinkBill clears the BillID of the expenses confirmed from a bill,
+	// keeping the expenses.
+	UnlinkBill(ctx context.Context, ledgerID, billID primitive.ObjectID) error
+}
+
+type CategoryStore interface {
+	List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.Category, error)
+	Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.Category, error)
+	Insert(ctx context.Context, category *models.Category) error
+	Update(ctx context.Context, category *models.Category) error
+	Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error
+	// NameExists reports whether another category of the ledger (other than
+	// excludeID) already uses name, compared case-insensitively.
+	NameExists(ctx context.Context, ledgerID primitive.ObjectID, name string, excludeID primitive.ObjectID) (bool, error)
+	// ColorExists reports whether another category of the ledger (other than
+	// excludeID) already uses color.
+	ColorExists(ctx context.Context, ledgerID primitive.ObjectID, color string, excludeID primitive.ObjectID) (bool, error)
+}
+
+type BillStore interface {
+	List(ctx context.Context, query BillQuery) ([]models.Bill, error)
+	Insert(ctx context.Context, bill *models.Bill) error
+	Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.Bill, error)
+	// Update replaces the stored bill with bill.
+	Update(ctx context.Context, bill *models.Bill) error
+	// UpdateGeneratedExpense replaces the generated expense with the same ID
+	// inside the given bill.
+	UpdateGeneratedExpense(ctx context.Context, ledgerID, billID primitive.ObjectID, expense *models.Expense) error
+	// Delete removes the bill. Its jobs and files are removed with
+	// BillJobStore.DeleteForBill and BillFileStore.DeleteForBill.
+	Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error
+}
+
+// BillJobStore is the queue of bills waiting for OCR.
+type BillJobStore interface {
+	Insert(ctx context.Context, job *models.BillJob) error
+	// Claim takes the job that has been due at now for longest, moves its
+	// RunAt to leaseUntil and counts the attempt, so that no other worker
+	// claims it meanwhile. It returns ErrNotFound when no job is due.
+	Claim(ctx context.Context, now, leaseUntil time.Time) (*models.BillJob, error)
+	// Retry stores the RunAt and LastError of job.
+	Retry(ctx context.Context, job *models.BillJob) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// DeleteForBill removes the jobs of the bill, if there are any.
+	DeleteForBill(ctx context.Context, billID primitive.ObjectID) error
+}
+
+// BlobStore keeps file contents by their ContentHash, so that content
+// uploaded twice is stored once. Blobs are written outside of
+// transactions: a blob whose transaction is rolled back is left behind
+// unreferenced.
+type BlobStore interface {
+	// Put stores content and returns its hash.
+	Put(ctx context.Context, content []byte) (string, error)
+	// Open returns the content with the given hash, or ErrNotFound. The
+	// caller closes it.
+	Open(ctx context.Context, hash string) (io.ReadCloser, error)
+	// Delete removes the content with the given hash, if it is stored.
+	Delete(ctx context.Context, hash string) error
+}
+
+// ContentHash returns the hex-encoded SHA-256 hash of content, the key
+// BlobStore keeps it under.
+func ContentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// IsContentHash reports whether hash may be a ContentHash, which keeps
+// other strings from being used as file names.
+func IsContentHash(hash string) bool {
+	if len(hash) != sha256.Size*2 {
+		return false
+	}
+	for _, c := range hash {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// BillFileStore keeps the files of bills, one of each kind per bill. Their
+// contents are in a BlobStore.
+type BillFileStore interface {
+	// Put stores file, replacing the bill's file of the same kind.
+	Put(ctx context.Context, file *models.BillFile) error
+	Get(ctx context.Context, ledgerID, billID primitive.ObjectID, kind string) (*models.BillFile, error)
+	// List returns the files of the bill.
+	List(ctx context.Context, ledgerID, billID primitive.ObjectID) ([]models.BillFile, error)
+	DeleteForBill(ctx context.Context, ledgerID, billID primitive.ObjectID) error
+	// HashUsed reports whether any bill of any ledger has a file with the
+	// given content hash.
+	HashUsed(ctx context.Context, hash string) (bool, error)
+}
+
+type BudgetGoalStore interface {
+	List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.BudgetGoal, error)
+	Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.BudgetGoal, error)
+	// Insert and Update may return ErrDuplicate when another goal of the
+	// ledger counts the same expenses over the same period.
+	Insert(ctx context.Context, goal *models.BudgetGoal) error
+	Update(ctx context.Context, goal *models.BudgetGoal) error
+	Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error
+}
+
+// BudgetBalanceStore keeps the balances of the past periods of budget
+// goals, one per goal and period start.
+type BudgetBalanceStore interface {
+	// List returns the balances of the goal by period start.
+	List(ctx context.Context, ledgerID, goalID primitive.ObjectID) ([]models.BudgetBalance, error)
+	// Put stores balance, replacing the one of the same goal and period
+	// start if there is one (whose ID balance then takes).
+	Put(ctx context.Context, balance *models.BudgetBalance) error
+	DeleteForGoal(ctx context.Context, ledgerID, goalID primitive.ObjectID) error
+}
+
+type RecurringExpenseStore interface {
+	List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.RecurringExpense, error)
+	Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.RecurringExpense, error)
+	Insert(ctx context.Context, recurring *models.RecurringExpense) error
+	Update(ctx context.Context, recurring *models.RecurringExpense) error
+	Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error
+	DeleteByCategory(ctx context.Context, ledgerID, categoryID primitive.ObjectID) (int64, error)
+	// Due returns the recurring expenses of every ledger that are not done
+	// and whose next occurrence is at or before t, by next date.
+	Due(ctx context.Context, t time.Time) ([]models.RecurringExpense, error)
+	// Advance stores the NextIndex, NextDate and Done fields of recurring,
+	// provided its stored NextIndex is still fromIndex. Otherwise someone
+	// else advanced it first and ErrNotFound is returned.
+	Advance(ctx context.Context, recurring *models.RecurringExpense, fromIndex int) error
+}
+
+type CategoryRuleStore interface {
+	// List returns the rules of the ledger in the order they are tried: by
+	// priority, then by ID.
+	List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.CategoryRule, error)
+	Get(ctx context.Context, ledgerID, id primitive.ObjectID) (*models.CategoryRule, error)
+	Insert(ctx context.Context, rule *models.CategoryRule) error
+	Update(ctx context.Context, rule *models.CategoryRule) error
+	Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error
+	DeleteByCategory(ctx context.Context, ledgerID, categoryID primitive.ObjectID) (int64, error)
+}
+
+type ExchangeRateStore interface {
+	// List returns the rates of the ledger ordered by date.
+	List(ctx context.Context, ledgerID primitive.ObjectID) ([]models.ExchangeRate, error)
+	// Put stores rate, replacing the rate of the same ledger, currency pair
+	// and date if there is one (whose ID rate then takes).
+	Put(ctx context.Context, rate *models.ExchangeRate) error
+	Delete(ctx context.Context, ledgerID, id primitive.ObjectID) error
+}
+
+type UserStore interface {
+	// Insert stores a new user and returns ErrDuplicate when the email is
+	// already registered.
+	Insert(ctx context.Context, user *models.User) error
+	Get(ctx context.Context, id primitive.ObjectID) (*models.User, error)
+	// GetByEmail looks a user up by their (lower-cased) email.
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	Count(ctx context.Context) (int64, error)
+}
+
+type LedgerStore interface {
+	// Insert stores a new ledger together with its members.
+	Insert(ctx context.Context, ledger *models.Ledger) error
+	Get(ctx context.Context, id primitive.ObjectID) (*models.Ledger, error)
+	// ListForUser returns the ledgers userID is a member of.
+	ListForUser(ctx context.Context, userID primitive.ObjectID) ([]models.Ledger, error)
+	// Update stores the name and base currency of ledger; members are
+	// changed with PutMember and RemoveMember.
+	Update(ctx context.Context, ledger *models.Ledger) error
+	// PutMember adds member to the ledger, or changes their role when they
+	// already belong to it.
+	PutMember(ctx context.Context, ledgerID primitive.ObjectID, member models.LedgerMember) error
+	RemoveMember(ctx context.Context, ledgerID, userID primitive.ObjectID) error
+}
+
+// Transactor runs a function atomically. Store calls made with the context
+// passed to fn take part in the transaction; if fn returns an error every
+// change made through that context is rolled back.
+type Transactor interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Store groups the individual stores of one backend.
+type Store interface {
+	Transactor
+	Expenses() ExpenseStore
+	Categories() CategoryStore
+	Bills() BillStore
+	BillJobs() BillJobStore
+	BillFiles() BillFileStore
+	BudgetGoals() BudgetGoalStore
+	BudgetBalances() BudgetBalanceStore
+	RecurringExpenses() RecurringExpenseStore
+	CategoryRules() CategoryRuleStore
+	ExchangeRates() ExchangeRateStore
+	Users() UserStore
+	Ledgers() LedgerStore
+
+	// ClaimUnowned moves every expense, category, bill and budget goal that
+	// belongs to no ledger into ledgerID. Data created before accounts
+	// existed is adopted this way by the first user to register.
+	ClaimUnowned(ctx context.Context, ledgerID primitive.ObjectID) error
+}
@@ -0,0 +1,1487 @@
+// Package storetest holds the behavioural test-suite every store
+// implementation has to pass.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dhruwanga19/expense-tracker/models"
+	"github.com/dhruwanga19/expense-tracker/money"
+	"github.com/dhruwanga19/expense-tracker/period"
+	"github.com/dhruwanga19/expense-tracker/store"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Run runs the suite. newStore must return an empty store for every call.
+func Run(t *testing.T, newStore func(t *testing.T) store.Store) {
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, st store.Store)
+	}{
+		{"ExpenseListJoinsCategory", testExpenseListJoinsCategory},
+		{"ExpenseUpdateAndDelete", testExpenseUpdateAndDelete},
+		{"ExpenseQueryFilters", testExpenseQueryFilters},
+		{"ExpenseQueryPagination", testExpenseQueryPagination},
+		{"ExpenseBillLink", testExpenseBillLink},
+		{"ExpenseTotals", testExpenseTotals},
+		{"CategoryUniqueness", testCategoryUniqueness},
+		{"CategoryDeleteCascades", testCategoryDeleteCascades},
+		{"BillRoundTrip", testBillRoundTrip},
+		{"BillJobQueue", testBillJobQueue},
+		{"BillFiles", testBillFiles},
+		{"BillList", testBillList},
+		{"BillDelete", testBillDelete},
+		{"BudgetGoalCRUD", testBudgetGoalCRUD},
+		{"BudgetBalances", testBudgetBalances},
+		{"TransactionRollback", testTransactionRollback},
+		{"UserEmailUnique", testUserEmailUnique},
+		{"LedgerIsolation", testLedgerIsolation},
+		{"ClaimUnowned", testClaimUnowned},
+		{"LedgerMembers", testLedgerMembers},
+		{"LedgerUpdate", testLedgerUpdate},
+		{"ExchangeRates", testExchangeRates},
+		{"RecurringExpenses", testRecurringExpenses},
+		{"CategoryRules", testCategoryRules},
+		{"ExpenseInsertDuplicate", testExpenseInsertDuplicate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newStore(t))
+		})
+	}
+}
+
+// date returns noon UTC on the given day, which every backend stores exactly.
+func date(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+}
+
+func mustInsertUser(t *testing.T, st store.Store, email string) primitive.ObjectID {
+	t.Helper()
+	user := models.User{Email: email, PasswordHash: "hash", CreatedAt: date(2024, 1, 1)}
+	if err := st.Users().Insert(context.Background(), &user); err != nil {
+		t.Fatalf("insert user: %v", err)
+	}
+	return user.ID
+}
+
+// mustInsertLedger registers a user with a personal ledger and returns the
+// ledger's ID.
+func mustInsertLedger(t *testing.T, st store.Store, email string) primitive.ObjectID {
+	t.Helper()
+	userID := mustInsertUser(t, st, email)
+	ledger := models.Ledger{
+		ID:        userID,
+		Name:      "Personal",
+		CreatedAt: date(2024, 1, 1),
+		Members:   []models.LedgerMember{{UserID: userID, Role: models.RoleOwner}},
+	}
+	if err := st.Ledgers().Insert(context.Background(), &ledger); err != nil {
+		t.Fatalf("insert ledger: %v", err)
+	}
+	return ledger.ID
+}
+
+func mustInsertCategory(t *testing.T, st store.Store, ledger primitive.ObjectID, name, color string) models.Category {
+	t.Helper()
+	category := models.Category{LedgerID: ledger, Name: name, Color: color}
+	if err := st.Categories().Insert(context.Background(), &category); err != nil {
+		t.Fatalf("insert category: %v", err)
+	}
+	if category.ID.IsZero() {
+		t.Fatal("insert category did not assign an ID")
+	}
+	return category
+}
+
+func mustInsertExpense(t *testing.T, st store.Store, ledger primitive.ObjectID, name string, amount money.Amount, categoryID primitive.ObjectID) models.Expense {
+	t.Helper()
+	expense := models.Expense{LedgerID: ledger, Name: name, Amount: amount, Currency: money.DefaultCurrency, Date: date(2024, 3, 1), CategoryID: categoryID}
+	if err := st.Expenses().Insert(context.Background(), &expense); err != nil {
+		t.Fatalf("insert expense: %v", err)
+	}
+	if expense.ID.IsZero() {
+		t.Fatal("insert expense did not assign an ID")
+	}
+	return expense
+}
+
+func testExpenseListJoinsCategory(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	groceries := mustInsertCategory(t, st, ledger, "Groceries", "#00ff00")
+	mustInsertExpense(t, st, ledger, "Milk", 450, groceries.ID)
+	mustInsertExpense(t, st, ledger, "Uncategorised", 100, primitive.NilObjectID)
+
+	expenses, err := st.Expenses().List(ctx, store.ExpenseQuery{LedgerID: ledger})
+	if err != nil {
+		t.Fatalf("list expenses: %v", err)
+	}
+	if len(expenses) != 1 {
+		t.Fatalf("got %d expenses, want 1 (expenses without a category are left out)", len(expenses))
+	}
+	got := expenses[0]
+	if got.Name != "Milk" || got.Amount != 450 || got.Currency != money.DefaultCurrency || !got.Date.Equal(date(2024, 3, 1)) {
+		t.Errorf("unexpected expense %+v", got)
+	}
+	if got.Category == nil || got.Category.ID != groceries.ID || got.Category.Name != "Groceries" {
+		t.Errorf("category not joined: %+v", got.Category)
+	}
+}
+
+func testExpenseUpdateAndDelete(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	category := mustInsertCategory(t, st, ledger, "Rent", "#111111")
+	a := mustInsertExpense(t, st, ledger, "January", 1000, category.ID)
+	b := mustInsertExpense(t, st, ledger, "February", 1000, category.ID)
+
+	a.Amount = 1100
+	a.Merchant = "Landlord Ltd"
+	a.PaymentMethod = "debit"
+	a.CategoryConfidence = 0.75
+	if err := st.Expenses().Update(ctx, &a); err != nil {
+		t.Fatalf("update expense: %v", err)
+	}
+	// A category the user picks is no longer a suggestion.
+	a.CategoryConfidence = 0
+	if err := st.Expenses().Update(ctx, &a); err != nil {
+		t.Fatalf("update expense: %v", err)
+	}
+	// Optional details can be cleared again.
+	c := mustInsertExpense(t, st, ledger, "March", 1000, category.ID)
+	c.Merchant, c.PaymentMethod = "Landlord Ltd", "debit"
+	if err := st.Expenses().Update(ctx, &c); err != nil {
+		t.Fatalf("update expense: %v", err)
+	}
+	c.Merchant, c.PaymentMethod = "", ""
+	if err := st.Expenses().Update(ctx, &c); err != nil {
+		t.Fatalf("update expense: %v", err)
+	}
+	expenses, err := st.Expenses().List(ctx, store.ExpenseQuery{LedgerID: ledger})
+	if err != nil {
+		t.Fatalf("list expenses: %v", err)
+	}
+	for _, e := range expenses {
+		if e.ID == c.ID && (e.Merchant != "" || e.PaymentMethod != "") {
+			t.Errorf("details not cleared: %+v", e)
+		}
+	}
+	missing := models.Expense{ID: primitive.NewObjectID(), LedgerID: ledger, Name: "Nope", CategoryID: category.ID}
+	if err := st.Expenses().Update(ctx, &missing); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("update missing expense: got %v, want ErrNotFound", err)
+	}
+
+	deleted, err := st.Expenses().DeleteMany(ctx, ledger, []primitive.ObjectID{b.ID, c.ID, primitive.NewObjectID()})
+	if err != nil {
+		t.Fatalf("delete expenses: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("deleted %d expenses, want 2", deleted)
+	}
+
+	expenses, err = st.Expenses().List(ctx, store.ExpenseQuery{LedgerID: ledger})
+	if err != nil {
+		t.Fatalf("list expenses: %v", err)
+	}
+	if len(expenses) != 1 || expenses[0].ID != a.ID || expenses[0].Amount != 1100 ||
+		expenses[0].Merchant != "Landlord Ltd" || expenses[0].PaymentMethod != "debit" || expenses[0].CategoryConfidence != 0 {
+		t.Errorf("unexpected expenses after update and delete: %+v", expenses)
+	}
+}
+
+func testExpenseQueryFilters(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	food := mustInsertCategory(t, st, ledger, "Food", "#000010")
+	rent := mustInsertCategory(t, st, ledger, "Rent", "#000020")
+	for _, e := range []models.Expense{
+		{LedgerID: ledger, Name: "Coffee beans", Amount: 1500, Date: date(2024, 1, 10), CategoryID: food.ID},
+		{LedgerID: ledger, Name: "Lunch 50% off", Amount: 850, Date: date(2024, 2, 1), CategoryID: food.ID},
+		{LedgerID: ledger, Name: "Rent February", Amount: 120000, Date: date(2024, 2, 1), CategoryID: rent.ID},
+		{LedgerID: ledger, Name: "Rent March", Amount: 120000, Date: date(2024, 3, 1), CategoryID: rent.ID},
+	} {
+		if err := st.Expenses().Insert(ctx, &e); err != nil {
+			t.Fatalf("insert expense: %v", err)
+		}
+	}
+
+	amount := func(v money.Amount) *money.Amount { return &v }
+	tests := []struct {
+		name  string
+		query store.ExpenseQuery
+		want  []string
+	}{
+		{"all by date", store.ExpenseQuery{LedgerID: ledger}, []string{"Coffee beans", "Lunch 50% off", "Rent February", "Rent March"}},
+		{"date range", store.ExpenseQuery{LedgerID: ledger, From: date(2024, 2, 1), To: date(2024, 3, 1)}, []string{"Lunch 50% off", "Rent February"}},
+		{"category", store.ExpenseQuery{LedgerID: ledger, CategoryIDs: []primitive.ObjectID{rent.ID}}, []string{"Rent February", "Rent March"}},
+		{"amount range", store.ExpenseQuery{LedgerID: ledger, MinAmount: amount(850), MaxAmount: amount(1500)}, []string{"Coffee beans", "Lunch 50% off"}},
+		{"name ignores case", store.ExpenseQuery{LedgerID: ledger, Name: "rENT"}, []string{"Rent February", "Rent March"}},
+		{"name is literal", store.ExpenseQuery{LedgerID: ledger, Name: "50%"}, []string{"Lunch 50% off"}},
+		{"name wildcard is literal", store.ExpenseQuery{LedgerID: ledger, Name: "R_nt"}, nil},
+		{"amount descending", store.ExpenseQuery{LedgerID: ledger, SortBy: store.SortByAmount, SortDesc: true, Limit: 3},
+			[]string{"Rent March", "Rent February", "Coffee beans"}},
+		{"name", store.ExpenseQuery{LedgerID: ledger, SortBy: store.SortByName}, []string{"Coffee beans", "Lunch 50% off", "Rent February", "Rent March"}},
+	}
+	for _, tt := range tests {
+		expenses, err := st.Expenses().List(ctx, tt.query)
+		if err != nil {
+			t.Fatalf("%s: list expenses: %v", tt.name, err)
+		}
+		var got []string
+		for _, e := range expenses {
+			got = append(got, e.Name)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func testExpenseQueryPagination(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	category := mustInsertCategory(t, st, ledger, "Misc", "#000030")
+	var want []primitive.ObjectID
+	for i := 0; i < 7; i++ {
+		// Pairs of expenses share a date so the ID has to break ties.
+		e := models.Expense{LedgerID: ledger, Name: "item", Amount: 1, Date: date(2024, 4, 1+i/2), CategoryID: category.ID}
+		if err := st.Expenses().Insert(ctx, &e); err != nil {
+			t.Fatalf("insert expense: %v", err)
+		}
+		want = append(want, e.ID)
+	}
+	// Expenses without a category must not shorten a page.
+	mustInsertExpense(t, st, ledger, "uncategorised", 1, primitive.NilObjectID)
+
+	for _, desc := range []bool{false, true} {
+		query := store.ExpenseQuery{LedgerID: ledger, SortDesc: desc, Limit: 3}
+		var got []primitive.ObjectID
+		for page := 0; page < 5; page++ {
+			expenses, err := st.Expenses().List(ctx, query)
+			if err != nil {
+				t.Fatalf("list expenses: %v", err)
+			}
+			if len(expenses) > 3 {
+				t.Fatalf("page has %d expenses, limit is 3", len(expenses))
+			}
+			for _, e := range expenses {
+				got = append(got, e.ID)
+			}
+			if len(expenses) < 3 {
+				break
+			}
+			query.After = store.CursorFor(expenses[len(expenses)-1])
+		}
+
+		expected := want
+		if desc {
+			expected = make([]primitive.ObjectID, len(want))
+			for i, id := range want {
+				expected[len(want)-1-i] = id
+			}
+		}
+		if len(got) != len(expected) {
+			t.Fatalf("desc=%v: paged through %d expenses, want %d", desc, len(got), len(expected))
+		}
+		for i := range got {
+			if got[i] != expected[i] {
+				t.Fatalf("desc=%v: expense %d is %s, want %s", desc, i, got[i].Hex(), expected[i].Hex())
+			}
+		}
+	}
+}
+
+func testExpenseTotals(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	other := mustInsertLedger(t, st, "other@example.com")
+	groceries := mustInsertCategory(t, st, ledger, "Groceries", "#00ff00")
+	fuel := mustInsertCategory(t, st, ledger, "Fuel", "#ff0000")
+	insert := func(ledger primitive.ObjectID, amount money.Amount, currency money.Currency, at time.Time, categoryID primitive.ObjectID) {
+		t.Helper()
+		expense := models.Expense{LedgerID: ledger, Name: "item", Amount: amount, Currency: currency, Date: at, CategoryID: categoryID}
+		if err := st.Expenses().Insert(ctx, &expense); err != nil {
+			t.Fatalf("insert expense: %v", err)
+		}
+	}
+	insert(ledger, 100, "USD", date(2024, 3, 1), groceries.ID)
+	insert(ledger, 250, "USD", time.Date(2024, 3, 1, 23, 59, 0, 0, time.UTC), groceries.ID)
+	// 23:30 in New York is already the next day in UTC.
+	insert(ledger, 300, "USD", time.Date(2024, 3, 1, 23, 30, 0, 0, time.FixedZone("EST", -5*3600)), groceries.ID)
+	insert(ledger, 400, "EUR", date(2024, 3, 1), groceries.ID)
+	insert(ledger, 4000, "USD", date(2024, 3, 1), fuel.ID)
+	insert(ledger, 999, "USD", date(2024, 4, 1), groceries.ID)
+	insert(other, 999, "USD", date(2024, 3, 1), mustInsertCategory(t, st, other, "Groceries", "#00ff00").ID)
+
+	totals, err := st.Expenses().Totals(ctx, store.ExpenseQuery{
+		LedgerID:    ledger,
+		From:        date(2024, 3, 1).AddDate(0, 0, -1),
+		To:          date(2024, 4, 1),
+		CategoryIDs: []primitive.ObjectID{groceries.ID},
+		Limit:       1,
+	})
+	if err != nil {
+		t.Fatalf("expense totals: %v", err)
+	}
+	slices.SortFunc(totals, func(a, b store.ExpenseTotal) int {
+		if c := a.Day.Compare(b.Day); c != 0 {
+			return c
+		}
+		return strings.Compare(string(a.Currency), string(b.Currency))
+	})
+	want := []store.ExpenseTotal{
+		{CategoryID: groceries.ID, Currency: "EUR", Day: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Amount: 400, Count: 1},
+		{CategoryID: groceries.ID, Currency: "USD", Day: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), Amount: 350, Count: 2},
+		{CategoryID: groceries.ID, Currency: "USD", Day: time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC), Amount: 300, Count: 1},
+	}
+	if len(totals) != len(want) {
+		t.Fatalf("totals: %+v, want %+v", totals, want)
+	}
+	for i := range want {
+		if got := totals[i]; got.CategoryID != want[i].CategoryID || got.Currency != want[i].Currency || !got.Day.Equal(want[i].Day) ||
+			got.Amount != want[i].Amount || got.Count != want[i].Count {
+			t.Errorf("total %d: %+v, want %+v", i, got, want[i])
+		}
+	}
+}
+
+func testExpenseBillLink(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	category := mustInsertCategory(t, st, ledger, "Groceries", "#00ff00")
+	bill, other := primitive.NewObjectID(), primitive.NewObjectID()
+	insert := func(name string, billID primitive.ObjectID) models.Expense {
+		t.Helper()
+		expense := models.Expense{LedgerID: ledger, Name: name, Amount: 100, Currency: money.DefaultCurrency, Date: date(2024, 3, 1), CategoryID: category.ID, BillID: billID}
+		if err := st.Expenses().Insert(ctx, &expense); err != nil {
+			t.Fatalf("insert expense: %v", err)
+		}
+		return expense
+	}
+	bread := insert("Bread", bill)
+	insert("Milk", bill)
+	insert("Soap", other)
+	insert("Rent", primitive.NilObjectID)
+
+	names := func(billID primitive.ObjectID) []string {
+		t.Helper()
+		expenses, err := st.Expenses().List(ctx, store.ExpenseQuery{LedgerID: ledger, BillID: billID, SortBy: store.SortByName})
+		if err != nil {
+			t.Fatalf("list expenses: %v", err)
+		}
+		var names []string
+		for _, e := range expenses {
+			if e.BillID != billID && !billID.IsZero() {
+				t.Errorf("%s has bill %s, want %s", e.Name, e.BillID.Hex(), billID.Hex())
+			}
+			names = append(names, e.Name)
+		}
+		return names
+	}
+	if got := names(bill); !slices.Equal(got, []string{"Bread", "Milk"}) {
+		t.Errorf("expenses of bill: %v", got)
+	}
+
+	// Updates keep the link to the bill, whatever the update carries.
+	bread.Amount = 120
+	bread.BillID = primitive.NilObjectID
+	if err := st.Expenses().Update(ctx, &bread); err != nil {
+		t.Fatalf("update expense: %v", err)
+	}
+	if got := names(bill); !slices.Equal(got, []string{"Bread", "Milk"}) {
+		t.Errorf("expenses of bill after update: %v", got)
+	}
+
+	if err := st.Expenses().UnlinkBill(ctx, ledger, other); err != nil {
+		t.Fatalf("unlink bill: %v", err)
+	}
+	if got := names(other); len(got) != 0 {
+		t.Errorf("expenses of unlinked bill: %v", got)
+	}
+	deleted, err := st.Expenses().DeleteByBill(ctx, ledger, bill)
+	if err != nil {
+		t.Fatalf("delete expenses of bill: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("deleted %d expenses, want 2", deleted)
+	}
+	if got := names(primitive.NilObjectID); !slices.Equal(got, []string{"Rent", "Soap"}) {
+		t.Errorf("expenses left: %v", got)
+	}
+}
+
+func testCategoryUniqueness(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	food := mustInsertCategory(t, st, ledger, "Food", "#aa0000")
+	travel := mustInsertCategory(t, st, ledger, "Travel", "#0000aa")
+
+	exists, err := st.Categories().NameExists(ctx, ledger, "fOOd", travel.ID)
+	if err != nil || !exists {
+		t.Errorf("NameExists(fOOd) = %v, %v; want true", exists, err)
+	}
+	exists, err = st.Categories().NameExists(ctx, ledger, "Food", food.ID)
+	if err != nil || exists {
+		t.Errorf("NameExists ignoring itself = %v, %v; want false", exists, err)
+	}
+	exists, err = st.Categories().NameExists(ctx, ledger, "F.od", travel.ID)
+	if err != nil || exists {
+		t.Errorf("NameExists(F.od) = %v, %v; want false", exists, err)
+	}
+	exists, err = st.Categories().ColorExists(ctx, ledger, "#0000aa", food.ID)
+	if err != nil || !exists {
+		t.Errorf("ColorExists = %v, %v; want true", exists, err)
+	}
+	exists, err = st.Categories().ColorExists(ctx, ledger, "#0000aa", travel.ID)
+	if err != nil || exists {
+		t.Errorf("ColorExists ignoring itself = %v, %v; want false", exists, err)
+	}
+
+	food.Name = "Dining"
+	if err := st.Categories().Update(ctx, &food); err != nil {
+		t.Fatalf("update category: %v", err)
+	}
+	categories, err := st.Categories().List(ctx, ledger)
+	if err != nil {
+		t.Fatalf("list categories: %v", err)
+	}
+	if len(categories) != 2 || categories[0].Name != "Dining" {
+		t.Errorf("unexpected categories: %+v", categories)
+	}
+}
+
+func testCategoryDeleteCascades(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	keep := mustInsertCategory(t, st, ledger, "Keep", "#000001")
+	drop := mustInsertCategory(t, st, ledger, "Drop", "#000002")
+	mustInsertExpense(t, st, ledger, "kept", 1, keep.ID)
+	mustInsertExpense(t, st, ledger, "dropped", 2, drop.ID)
+
+	err := st.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := st.Categories().Delete(ctx, ledger, drop.ID); err != nil {
+			return err
+		}
+		_, err := st.Expenses().DeleteByCategory(ctx, ledger, drop.ID)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("delete category: %v", err)
+	}
+	if err := st.Categories().Delete(ctx, ledger, drop.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("second delete: got %v, want ErrNotFound", err)
+	}
+
+	// Re-create the category to make sure its expenses are really gone and
+	// not just hidden by the join.
+	drop.Name = "Drop again"
+	if err := st.Categories().Insert(ctx, &drop); err != nil {
+		t.Fatalf("re-insert category: %v", err)
+	}
+	expenses, err := st.Expenses().List(ctx, store.ExpenseQuery{LedgerID: ledger})
+	if err != nil {
+		t.Fatalf("list expenses: %v", err)
+	}
+	if len(expenses) != 1 || expenses[0].Name != "kept" {
+		t.Errorf("unexpected expenses after cascade: %+v", expenses)
+	}
+}
+
+func testBillRoundTrip(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	bill := models.Bill{
+		LedgerID:   ledger,
+		FileName:   "receipt.jpg",
+		FileType:   "image/jpeg",
+		UploadDate: date(2024, 5, 2),
+		Status:     "uploaded",
+	}
+	if err := st.Bills().Insert(ctx, &bill); err != nil {
+		t.Fatalf("insert bill: %v", err)
+	}
+
+	bill.Status = "error"
+	bill.Error = "no text detected"
+	if err := st.Bills().Update(ctx, &bill); err != nil {
+		t.Fatalf("update bill: %v", err)
+	}
+	if got, err := st.Bills().Get(ctx, ledger, bill.ID); err != nil || got.Error != "no text detected" {
+		t.Fatalf("bill error not stored: %+v, %v", got, err)
+	}
+
+	bill.Status = "processed"
+	bill.Error = ""
+	bill.ProcessedDate = date(2024, 5, 3)
+	bill.AnalysisResults.ExtractedText = "MILK\n$4.50"
+	bill.AnalysisResults.Items = []models.ReceiptItem{
+		{Name: "MILK", Quantity: 2, UnitPrice: 250, Amount: 500, Discount: -50},
+		{Name: "EGGS", Quantity: 1, UnitPrice: 300, Amount: 300},
+	}
+	bill.AnalysisResults.Discount = -50
+	bill.AnalysisResults.Tax = 39
+	bill.AnalysisResults.Total = 789
+	bill.AnalysisResults.Difference = 0
+	bill.AnalysisResults.Reconciled = true
+	bill.AnalysisResults.Currency = "EUR"
+	bill.Merchant = "Corner Grocer"
+	purchased := date(2024, 5, 1).Add(14*time.Hour + 32*time.Minute)
+	bill.TransactionDate = &purchased
+	bill.PaymentMethod = "visa"
+	bill.CardLast4 = "1234"
+	bill.GeneratedExpenses = []models.Expense{
+		{ID: primitive.NewObjectID(), Name: "MILK", Amount: 450, Currency: "EUR", Date: date(2024, 5, 3), Merchant: "Corner Grocer", PaymentMethod: "visa"},
+		{ID: primitive.NewObjectID(), Name: "EGGS", Amount: 300, Currency: "EUR", Date: date(2024, 5, 3)},
+	}
+	if err := st.Bills().Update(ctx, &bill); err != nil {
+		t.Fatalf("update bill: %v", err)
+	}
+
+	edited := bill.GeneratedExpenses[1]
+	edited.Name = "Eggs (dozen)"
+	edited.CategoryID = primitive.NewObjectID()
+	if err := st.Bills().UpdateGeneratedExpense(ctx, ledger, bill.ID, &edited); err != nil {
+		t.Fatalf("update generated expense: %v", err)
+	}
+	missing := models.Expense{ID: primitive.NewObjectID()}
+	if err := st.Bills().UpdateGeneratedExpense(ctx, ledger, bill.ID, &missing); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("update missing generated expense: got %v, want ErrNotFound", err)
+	}
+
+	got, err := st.Bills().Get(ctx, ledger, bill.ID)
+	if err != nil {
+		t.Fatalf("get bill: %v", err)
+	}
+	if got.Status != "processed" || got.Error != "" || got.FileName != "receipt.jpg" || got.AnalysisResults.Total != 789 || got.AnalysisResults.Currency != "EUR" ||
+		got.AnalysisResults.ExtractedText != "MILK\n$4.50" || !got.ProcessedDate.Equal(date(2024, 5, 3)) {
+		t.Errorf("unexpected bill %+v", got)
+	}
+	if a := got.AnalysisResults; a.Discount != -50 || a.Tax != 39 || !a.Reconciled || len(a.Items) != 2 ||
+		a.Items[0] != bill.AnalysisResults.Items[0] || a.Items[1] != bill.AnalysisResults.Items[1] {
+		t.Errorf("analysis results not stored: %+v", a)
+	}
+	if got.Merchant != "Corner Grocer" || got.TransactionDate == nil || !got.TransactionDate.Equal(purchased) ||
+		got.PaymentMethod != "visa" || got.CardLast4 != "1234" {
+		t.Errorf("receipt details not stored: %+v", got)
+	}
+	if len(got.GeneratedExpenses) != 2 {
+		t.Fatalf("got %d generated expenses, want 2", len(got.GeneratedExpenses))
+	}
+	if g := got.GeneratedExpenses[1]; g.ID != edited.ID || g.Name != "Eggs (dozen)" || g.CategoryID != edited.CategoryID {
+		t.Errorf("generated expense not updated: %+v", g)
+	}
+	if g := got.GeneratedExpenses[0]; g.Name != "MILK" || g.Merchant != "Corner Grocer" || g.PaymentMethod != "visa" {
+		t.Errorf("generated expenses reordered: %+v", got.GeneratedExpenses)
+	}
+
+	if _, err := st.Bills().Get(ctx, ledger, primitive.NewObjectID()); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("get missing bill: got %v, want ErrNotFound", err)
+	}
+}
+
+func testBillFiles(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	bill := models.Bill{LedgerID: ledger, FileName: "receipt.jpg", UploadDate: date(2024, 5, 2), Status: "processed"}
+	if err := st.Bills().Insert(ctx, &bill); err != nil {
+		t.Fatalf("insert bill: %v", err)
+	}
+
+	put := func(kind, contentType, content string) {
+		t.Helper()
+		file := models.BillFile{
+			LedgerID: ledger, BillID: bill.ID, Kind: kind, ContentType: contentType,
+			Hash: store.ContentHash([]byte(content)), Size: int64(len(content)), CreatedAt: date(2024, 5, 2),
+		}
+		if err := st.BillFiles().Put(ctx, &file); err != nil {
+			t.Fatalf("put %s file: %v", kind, err)
+		}
+	}
+	put(models.OriginalBillFile, "image/jpeg", "original")
+	put(models.ProcessedBillFile, "image/png", "first try")
+	put(models.ProcessedBillFile, "image/png", "second try")
+
+	for kind, want := range map[string]string{models.OriginalBillFile: "original", models.ProcessedBillFile: "second try"} {
+		got, err := st.BillFiles().Get(ctx, ledger, bill.ID, kind)
+		if err != nil {
+			t.Fatalf("get %s file: %v", kind, err)
+		}
+		if got.Hash != store.ContentHash([]byte(want)) || got.Size != int64(len(want)) || got.BillID != bill.ID || got.Kind != kind || !got.CreatedAt.Equal(date(2024, 5, 2)) {
+			t.Errorf("%s file: %+v", kind, got)
+		}
+	}
+	if got, _ := st.BillFiles().Get(ctx, ledger, bill.ID, models.OriginalBillFile); got.ContentType != "image/jpeg" {
+		t.Errorf("original file content type %q", got.ContentType)
+	}
+
+	other := mustInsertLedger(t, st, "other@example.com")
+	if _, err := st.BillFiles().Get(ctx, other, bill.ID, models.OriginalBillFile); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("get file of another ledger: got %v, want ErrNotFound", err)
+	}
+}
+
+func testBillList(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	insert := func(name, status, merchant string, uploaded time.Time) {
+		t.Helper()
+		bill := models.Bill{LedgerID: ledger, FileName: name, Status: status, Merchant: merchant, UploadDate: uploaded}
+		if err := st.Bills().Insert(ctx, &bill); err != nil {
+			t.Fatalf("insert bill: %v", err)
+		}
+	}
+	insert("a", models.BillProcessed, "Corner Grocer", date(2024, 5, 1))
+	insert("b", models.BillError, "", date(2024, 5, 2))
+	insert("c", models.BillProcessed, "CORNER GROCER 100%", date(2024, 5, 2))
+	insert("d", models.BillConfirmed, "Fuel Stop", date(2024, 5, 3))
+	other := mustInsertLedger(t, st, "other@example.com")
+	if err := st.Bills().Insert(ctx, &models.Bill{LedgerID: other, FileName: "x", Status: models.BillProcessed, UploadDate: date(2024, 5, 2)}); err != nil {
+		t.Fatalf("insert bill: %v", err)
+	}
+
+	list := func(query store.BillQuery) []string {
+		t.Helper()
+		query.LedgerID = ledger
+		bills, err := st.Bills().List(ctx, query)
+		if err != nil {
+			t.Fatalf("list %+v: %v", query, err)
+		}
+		var names []string
+		for _, b := range bills {
+			names = append(names, b.FileName)
+		}
+		return names
+	}
+	// Bills uploaded at the same time are ordered by ID, newest first.
+	tests := []struct {
+		name  string
+		query store.BillQuery
+		want  []string
+	}{
+		{"all", store.BillQuery{}, []string{"d", "c", "b", "a"}},
+		{"statuses", store.BillQuery{Statuses: []string{models.BillProcessed, models.BillError}}, []string{"c", "b", "a"}},
+		{"uploaded", store.BillQuery{From: date(2024, 5, 2), To: date(2024, 5, 3)}, []string{"c", "b"}},
+		{"merchant", store.BillQuery{Merchant: "corner"}, []string{"c", "a"}},
+		{"merchant with wildcards", store.BillQuery{Merchant: "100%"}, []string{"c"}},
+		{"limit", store.BillQuery{Limit: 2}, []string{"d", "c"}},
+	}
+	for _, tt := range tests {
+		if got := list(tt.query); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// Pages continue after the last bill of the previous one.
+	bills, err := st.Bills().List(ctx, store.BillQuery{LedgerID: ledger, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := &store.BillCursor{ID: bills[1].ID, UploadDate: bills[1].UploadDate}
+	if got := list(store.BillQuery{After: after}); !slices.Equal(got, []string{"b", "a"}) {
+		t.Errorf("second page: %v", got)
+	}
+}
+
+func testBillDelete(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	insert := func() models.Bill {
+		t.Helper()
+		bill := models.Bill{
+			LedgerID: ledger, FileName: "receipt.jpg", Status: models.BillProcessed, UploadDate: date(2024, 5, 2),
+			AnalysisResults:   models.AnalysisResults{Items: []models.ReceiptItem{{Name: "MILK", Quantity: 1, Amount: 450}}},
+			GeneratedExpenses: []models.Expense{{ID: primitive.NewObjectID(), LedgerID: ledger, Name: "MILK", Amount: 450, Date: date(2024, 5, 2)}},
+		}
+		if err := st.Bills().Insert(ctx, &bill); err != nil {
+			t.Fatalf("insert bill: %v", err)
+		}
+		file := models.BillFile{LedgerID: ledger, BillID: bill.ID, Kind: models.OriginalBillFile, Hash: "shared", CreatedAt: date(2024, 5, 2)}
+		if err := st.BillFiles().Put(ctx, &file); err != nil {
+			t.Fatalf("put file: %v", err)
+		}
+		if err := st.BillJobs().Insert(ctx, &models.BillJob{LedgerID: ledger, BillID: bill.ID, RunAt: date(2024, 5, 2)}); err != nil {
+			t.Fatalf("insert job: %v", err)
+		}
+		return bill
+	}
+	deleted, kept := insert(), insert()
+
+	if files, err := st.BillFiles().List(ctx, ledger, deleted.ID); err != nil || len(files) != 1 || files[0].Hash != "shared" {
+		t.Errorf("list files: %+v, %v", files, err)
+	}
+	other := mustInsertLedger(t, st, "other@example.com")
+	if err := st.Bills().Delete(ctx, other, deleted.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("delete bill of another ledger: got %v, want ErrNotFound", err)
+	}
+
+	err := st.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := st.BillJobs().DeleteForBill(ctx, deleted.ID); err != nil {
+			return err
+		}
+		if err := st.BillFiles().DeleteForBill(ctx, ledger, deleted.ID); err != nil {
+			return err
+		}
+		return st.Bills().Delete(ctx, ledger, deleted.ID)
+	})
+	if err != nil {
+		t.Fatalf("delete bill: %v", err)
+	}
+	if _, err := st.Bills().Get(ctx, ledger, deleted.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("get deleted bill: got %v, want ErrNotFound", err)
+	}
+	if files, err := st.BillFiles().List(ctx, ledger, deleted.ID); err != nil || len(files) != 0 {
+		t.Errorf("files of deleted bill: %+v, %v", files, err)
+	}
+	if used, err := st.BillFiles().HashUsed(ctx, "shared"); err != nil || !used {
+		t.Errorf("hash still used by the other bill: %v, %v", used, err)
+	}
+
+	// Only the other bill's job is left.
+	job, err := st.BillJobs().Claim(ctx, date(2024, 5, 3), date(2024, 5, 4))
+	if err != nil || job.BillID != kept.ID {
+		t.Errorf("claim after delete: %+v, %v", job, err)
+	}
+	if got, err := st.Bills().Get(ctx, ledger, kept.ID); err != nil || len(got.GeneratedExpenses) != 1 {
+		t.Errorf("other bill: %+v, %v", got, err)
+	}
+
+	if err := st.BillFiles().DeleteForBill(ctx, ledger, kept.ID); err != nil {
+		t.Fatal(err)
+	}
+	if used, err := st.BillFiles().HashUsed(ctx, "shared"); err != nil || used {
+		t.Errorf("hash of no file: %v, %v", used, err)
+	}
+}
+
+func testBillJobQueue(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	insert := func(fileName string, runAt time.Time) models.BillJob {
+		t.Helper()
+		bill := models.Bill{LedgerID: ledger, FileName: fileName, Status: models.BillUploaded, UploadDate: runAt}
+		if err := st.Bills().Insert(ctx, &bill); err != nil {
+			t.Fatalf("insert bill: %v", err)
+		}
+		job := models.BillJob{LedgerID: ledger, BillID: bill.ID, RunAt: runAt, CreatedAt: runAt}
+		if err := st.BillJobs().Insert(ctx, &job); err != nil {
+			t.Fatalf("insert job: %v", err)
+		}
+		return job
+	}
+	second := insert("second", date(2024, 5, 2))
+	first := insert("first", date(2024, 5, 1))
+	insert("later", date(2024, 6, 1))
+
+	now, lease := date(2024, 5, 3), date(2024, 5, 4)
+	claim := func(now time.Time) *models.BillJob {
+		t.Helper()
+		job, err := st.BillJobs().Claim(ctx, now, lease)
+		if err != nil {
+			t.Fatalf("claim: %v", err)
+		}
+		return job
+	}
+	job := claim(now)
+	if job.ID != first.ID || job.BillID != first.BillID || job.Attempts != 1 || !job.RunAt.Equal(lease) {
+		t.Errorf("first claim: %+v", job)
+	}
+	job = claim(now)
+	if job.ID != second.ID {
+		t.Errorf("second claim: %+v", job)
+	}
+	if _, err := st.BillJobs().Claim(ctx, now, lease); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("claim with every due job leased: got %v, want ErrNotFound", err)
+	}
+
+	retried := *job
+	retried.RunAt = date(2024, 5, 20)
+	retried.LastError = "vision unavailable"
+	if err := st.BillJobs().Retry(ctx, &retried); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+
+	// A lease that runs out makes the job due again.
+	job = claim(lease)
+	if job.ID != first.ID || job.Attempts != 2 {
+		t.Errorf("claim after the lease: %+v", job)
+	}
+	if err := st.BillJobs().Delete(ctx, first.ID); err != nil {
+		t.Fatalf("delete job: %v", err)
+	}
+	if err := st.BillJobs().Delete(ctx, first.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("delete deleted job: got %v, want ErrNotFound", err)
+	}
+	job = claim(date(2024, 5, 21))
+	if job.ID != second.ID || job.Attempts != 2 || job.LastError != "vision unavailable" {
+		t.Errorf("claim after retry: %+v", job)
+	}
+}
+
+func testBudgetGoalCRUD(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	category := mustInsertCategory(t, st, ledger, "Fun", "#123456")
+	goal := models.BudgetGoal{LedgerID: ledger, CategoryID: category.ID, Amount: 20000, Currency: "GBP", Period: period.Period{Kind: period.Monthly, Day: 1}, CreatedAt: date(2024, 1, 1), UpdatedAt: date(2024, 1, 1)}
+	if err := st.BudgetGoals().Insert(ctx, &goal); err != nil {
+		t.Fatalf("insert goal: %v", err)
+	}
+
+	goal.Amount = 25000
+	goal.Period = period.Period{Kind: period.Weekly, WeekStart: time.Monday}
+	goal.Rollover, goal.RolloverCap = models.RolloverCapped, 5000
+	if err := st.BudgetGoals().Update(ctx, &goal); err != nil {
+		t.Fatalf("update goal: %v", err)
+	}
+	goals, err := st.BudgetGoals().List(ctx, ledger)
+	if err != nil {
+		t.Fatalf("list goals: %v", err)
+	}
+	if len(goals) != 1 || goals[0].Amount != 25000 || goals[0].Currency != "GBP" || goals[0].Period != goal.Period || goals[0].CategoryID != category.ID ||
+		goals[0].Rollover != models.RolloverCapped || goals[0].RolloverCap != 5000 {
+		t.Errorf("unexpected goals: %+v", goals)
+	}
+
+	// Goals spanning several categories keep them in order.
+	travel := mustInsertCategory(t, st, ledger, "Travel", "#654321")
+	dining := mustInsertCategory(t, st, ledger, "Dining", "#abcdef")
+	fun := models.BudgetGoal{LedgerID: ledger, Scope: models.ScopeCategories, CategoryIDs: []primitive.ObjectID{category.ID, travel.ID, dining.ID},
+		Amount: 50000, Currency: "GBP", Period: period.Period{Kind: period.Monthly, Day: 1}, CreatedAt: date(2024, 1, 1), UpdatedAt: date(2024, 1, 1)}
+	if err := st.BudgetGoals().Insert(ctx, &fun); err != nil {
+		t.Fatalf("insert multi-category goal: %v", err)
+	}
+	fun.CategoryIDs = []primitive.ObjectID{category.ID, dining.ID}
+	if err := st.BudgetGoals().Update(ctx, &fun); err != nil {
+		t.Fatalf("update multi-category goal: %v", err)
+	}
+	goals, err = st.BudgetGoals().List(ctx, ledger)
+	if err != nil {
+		t.Fatalf("list goals: %v", err)
+	}
+	if len(goals) != 2 || goals[1].Scope != models.ScopeCategories || !slices.Equal(goals[1].CategoryIDs, fun.CategoryIDs) ||
+		goals[0].Scope != "" || len(goals[0].CategoryIDs) != 0 {
+		t.Errorf("unexpected goals: %+v", goals)
+	}
+	got, err := st.BudgetGoals().Get(ctx, ledger, fun.ID)
+	if err != nil {
+		t.Fatalf("get goal: %v", err)
+	}
+	if got.Scope != models.ScopeCategories || !slices.Equal(got.CategoryIDs, fun.CategoryIDs) || got.Amount != 50000 || !got.CreatedAt.Equal(fun.CreatedAt) {
+		t.Errorf("got goal %+v", got)
+	}
+	if _, err := st.BudgetGoals().Get(ctx, primitive.NewObjectID(), fun.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("get goal of another ledger: got %v, want ErrNotFound", err)
+	}
+
+	// A goal moved to a single category drops the others.
+	fun.Scope, fun.CategoryID, fun.CategoryIDs = models.ScopeCategory, dining.ID, nil
+	if err := st.BudgetGoals().Update(ctx, &fun); err != nil {
+		t.Fatalf("update goal scope: %v", err)
+	}
+	goals, err = st.BudgetGoals().List(ctx, ledger)
+	if err != nil {
+		t.Fatalf("list goals: %v", err)
+	}
+	if len(goals) != 2 || goals[1].Scope != models.ScopeCategory || goals[1].CategoryID != dining.ID || len(goals[1].CategoryIDs) != 0 {
+		t.Errorf("goal after leaving several categories: %+v", goals[1])
+	}
+
+	// Periods saved before they were checked still list.
+	fun.Period = period.Period{Invalid: "fortnightly"}
+	if err := st.BudgetGoals().Update(ctx, &fun); err != nil {
+		t.Fatalf("update goal period: %v", err)
+	}
+	goals, err = st.BudgetGoals().List(ctx, ledger)
+	if err != nil {
+		t.Fatalf("list goals with a legacy period: %v", err)
+	}
+	if len(goals) != 2 || goals[1].Period.Valid() || goals[1].Period.String() != "fortnightly" {
+		t.Errorf("goal with a legacy period: %+v", goals[1])
+	}
+
+	if err := st.BudgetGoals().Delete(ctx, ledger, goal.ID); err != nil {
+		t.Fatalf("delete goal: %v", err)
+	}
+	if err := st.BudgetGoals().Delete(ctx, ledger, goal.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("second delete: got %v, want ErrNotFound", err)
+	}
+}
+
+func testBudgetBalances(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	category := mustInsertCategory(t, st, ledger, "Fun", "#123456")
+	goal := models.BudgetGoal{LedgerID: ledger, CategoryID: category.ID, Amount: 20000, Currency: "GBP", Period: period.Period{Kind: period.Monthly, Day: 1}, CreatedAt: date(2024, 1, 1), UpdatedAt: date(2024, 1, 1)}
+	if err := st.BudgetGoals().Insert(ctx, &goal); err != nil {
+		t.Fatalf("insert goal: %v", err)
+	}
+
+	february := models.BudgetBalance{LedgerID: ledger, GoalID: goal.ID, From: date(2024, 2, 1), To: date(2024, 3, 1), Budget: 20000, Spent: 15000, CarriedOut: 5000, UpdatedAt: date(2024, 3, 1)}
+	january := models.BudgetBalance{LedgerID: ledger, GoalID: goal.ID, From: date(2024, 1, 1), To: date(2024, 2, 1), Budget: 20000, Spent: 25000, UpdatedAt: date(2024, 2, 1)}
+	for _, b := range []*models.BudgetBalance{&february, &january} {
+		if err := st.BudgetBalances().Put(ctx, b); err != nil {
+			t.Fatalf("put balance: %v", err)
+		}
+	}
+
+	// Putting the balance of a period again replaces it.
+	again := february
+	again.ID = primitive.NilObjectID
+	again.Spent, again.CarriedOut = 12000, 8000
+	if err := st.BudgetBalances().Put(ctx, &again); err != nil {
+		t.Fatalf("put balance again: %v", err)
+	}
+	if again.ID != february.ID {
+		t.Errorf("replaced balance got ID %s, want %s", again.ID.Hex(), february.ID.Hex())
+	}
+
+	balances, err := st.BudgetBalances().List(ctx, ledger, goal.ID)
+	if err != nil {
+		t.Fatalf("list balances: %v", err)
+	}
+	if len(balances) != 2 || !balances[0].From.Equal(january.From) || balances[0].Spent != 25000 ||
+		!balances[1].To.Equal(february.To) || balances[1].Spent != 12000 || balances[1].CarriedOut != 8000 {
+		t.Errorf("unexpected balances: %+v", balances)
+	}
+
+	if err := st.BudgetBalances().DeleteForGoal(ctx, ledger, goal.ID); err != nil {
+		t.Fatalf("delete balances: %v", err)
+	}
+	if balances, err := st.BudgetBalances().List(ctx, ledger, goal.ID); err != nil || len(balances) != 0 {
+		t.Errorf("balances after delete: %+v, %v", balances, err)
+	}
+}
+
+func testTransactionRollback(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	category := mustInsertCategory(t, st, ledger, "Bills", "#abcdef")
+	bill := models.Bill{LedgerID: ledger, FileName: "b.png", Status: "processed", UploadDate: date(2024, 6, 1)}
+	if err := st.Bills().Insert(ctx, &bill); err != nil {
+		t.Fatalf("insert bill: %v", err)
+	}
+
+	errBoom := errors.New("boom")
+	err := st.WithTransaction(ctx, func(ctx context.Context) error {
+		expense := models.Expense{LedgerID: ledger, Name: "rolled back", Amount: 1, Date: date(2024, 6, 1), CategoryID: category.ID}
+		if err := st.Expenses().Insert(ctx, &expense); err != nil {
+			return err
+		}
+		bill.Status = "confirmed"
+		if err := st.Bills().Update(ctx, &bill); err != nil {
+			return err
+		}
+		return errBoom
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("transaction: got %v, want %v", err, errBoom)
+	}
+
+	expenses, err := st.Expenses().List(ctx, store.ExpenseQuery{LedgerID: ledger})
+	if err != nil {
+		t.Fatalf("list expenses: %v", err)
+	}
+	if len(expenses) != 0 {
+		t.Errorf("expenses survived rollback: %+v", expenses)
+	}
+	got, err := st.Bills().Get(ctx, ledger, bill.ID)
+	if err != nil {
+		t.Fatalf("get bill: %v", err)
+	}
+	if got.Status != "processed" {
+		t.Errorf("bill status %q survived rollback", got.Status)
+	}
+}
+
+func testUserEmailUnique(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	id := mustInsertUser(t, st, "a@example.com")
+
+	dup := models.User{Email: "a@example.com", PasswordHash: "other", CreatedAt: date(2024, 1, 2)}
+	if err := st.Users().Insert(ctx, &dup); !errors.Is(err, store.ErrDuplicate) {
+		t.Errorf("duplicate email: got %v, want ErrDuplicate", err)
+	}
+
+	got, err := st.Users().GetByEmail(ctx, "a@example.com")
+	if err != nil {
+		t.Fatalf("get by email: %v", err)
+	}
+	if got.ID != id || got.PasswordHash != "hash" || !got.CreatedAt.Equal(date(2024, 1, 1)) {
+		t.Errorf("unexpected user %+v", got)
+	}
+	if _, err := st.Users().Get(ctx, id); err != nil {
+		t.Errorf("get user: %v", err)
+	}
+	if _, err := st.Users().GetByEmail(ctx, "b@example.com"); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("get missing user: got %v, want ErrNotFound", err)
+	}
+	if n, err := st.Users().Count(ctx); err != nil || n != 1 {
+		t.Errorf("count users: got %d, %v, want 1", n, err)
+	}
+}
+
+func testLedgerIsolation(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	alice := mustInsertLedger(t, st, "alice@example.com")
+	bob := mustInsertLedger(t, st, "bob@example.com")
+
+	// Both ledgers may use the same category name and color.
+	aliceFood := mustInsertCategory(t, st, alice, "Food", "#111111")
+	bobFood := mustInsertCategory(t, st, bob, "Food", "#111111")
+	aliceLunch := mustInsertExpense(t, st, alice, "Alice lunch", 10, aliceFood.ID)
+	mustInsertExpense(t, st, bob, "Bob lunch", 12, bobFood.ID)
+
+	expenses, err := st.Expenses().List(ctx, store.ExpenseQuery{LedgerID: alice})
+	if err != nil {
+		t.Fatalf("list expenses: %v", err)
+	}
+	if len(expenses) != 1 || expenses[0].ID != aliceLunch.ID {
+		t.Errorf("alice sees %+v", expenses)
+	}
+	categories, err := st.Categories().List(ctx, bob)
+	if err != nil {
+		t.Fatalf("list categories: %v", err)
+	}
+	if len(categories) != 1 || categories[0].ID != bobFood.ID {
+		t.Errorf("bob sees %+v", categories)
+	}
+
+	if _, err := st.Categories().Get(ctx, bob, aliceFood.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("get other ledger's category: got %v, want ErrNotFound", err)
+	}
+	if err := st.Categories().Delete(ctx, bob, aliceFood.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("delete other ledger's category: got %v, want ErrNotFound", err)
+	}
+	stolen := aliceLunch
+	stolen.LedgerID = bob
+	stolen.Name = "Stolen"
+	if err := st.Expenses().Update(ctx, &stolen); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("update other ledger's expense: got %v, want ErrNotFound", err)
+	}
+	if n, err := st.Expenses().DeleteMany(ctx, bob, []primitive.ObjectID{aliceLunch.ID}); err != nil || n != 0 {
+		t.Errorf("delete other ledger's expense: got %d, %v, want 0", n, err)
+	}
+
+	bill := models.Bill{LedgerID: alice, FileName: "a.png", Status: "uploaded", UploadDate: date(2024, 6, 1)}
+	if err := st.Bills().Insert(ctx, &bill); err != nil {
+		t.Fatalf("insert bill: %v", err)
+	}
+	if _, err := st.Bills().Get(ctx, bob, bill.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("get other ledger's bill: got %v, want ErrNotFound", err)
+	}
+	goal := models.BudgetGoal{LedgerID: alice, CategoryID: aliceFood.ID, Amount: 100, Period: period.Period{Kind: period.Monthly, Day: 1}}
+	if err := st.BudgetGoals().Insert(ctx, &goal); err != nil {
+		t.Fatalf("insert goal: %v", err)
+	}
+	if goals, err := st.BudgetGoals().List(ctx, bob); err != nil || len(goals) != 0 {
+		t.Errorf("bob sees goals %+v, %v", goals, err)
+	}
+}
+
+func testClaimUnowned(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	unowned := models.Category{Name: "Legacy", Color: "#222222"}
+	if err := st.Categories().Insert(ctx, &unowned); err != nil {
+		t.Fatalf("insert category: %v", err)
+	}
+	expense := models.Expense{Name: "Old lunch", Amount: 9, Date: date(2023, 12, 1), CategoryID: unowned.ID}
+	if err := st.Expenses().Insert(ctx, &expense); err != nil {
+		t.Fatalf("insert expense: %v", err)
+	}
+	bill := models.Bill{FileName: "old.png", Status: "uploaded", UploadDate: date(2023, 12, 1)}
+	if err := st.Bills().Insert(ctx, &bill); err != nil {
+		t.Fatalf("insert bill: %v", err)
+	}
+
+	ledger := mustInsertLedger(t, st, "first@example.com")
+	if err := st.ClaimUnowned(ctx, ledger); err != nil {
+		t.Fatalf("claim unowned: %v", err)
+	}
+
+	expenses, err := st.Expenses().List(ctx, store.ExpenseQuery{LedgerID: ledger})
+	if err != nil {
+		t.Fatalf("list expenses: %v", err)
+	}
+	if len(expenses) != 1 || expenses[0].Category == nil || expenses[0].Category.Name != "Legacy" {
+		t.Errorf("claimed expenses: %+v", expenses)
+	}
+	if _, err := st.Bills().Get(ctx, ledger, bill.ID); err != nil {
+		t.Errorf("get claimed bill: %v", err)
+	}
+}
+
+func testLedgerMembers(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	alice := mustInsertUser(t, st, "alice@example.com")
+	bob := mustInsertUser(t, st, "bob@example.com")
+
+	house := models.Ledger{Name: "House", CreatedAt: date(2024, 2, 1),
+		Members: []models.LedgerMember{{UserID: alice, Role: models.RoleOwner}}}
+	if err := st.Ledgers().Insert(ctx, &house); err != nil {
+		t.Fatalf("insert ledger: %v", err)
+	}
+	dup := models.Ledger{ID: house.ID, Name: "Again"}
+	if err := st.Ledgers().Insert(ctx, &dup); !errors.Is(err, store.ErrDuplicate) {
+		t.Errorf("duplicate ledger: got %v, want ErrDuplicate", err)
+	}
+
+	if err := st.Ledgers().PutMember(ctx, house.ID, models.LedgerMember{UserID: bob, Role: models.RoleViewer}); err != nil {
+		t.Fatalf("add member: %v", err)
+	}
+	if err := st.Ledgers().PutMember(ctx, house.ID, models.LedgerMember{UserID: bob, Role: models.RoleEditor}); err != nil {
+		t.Fatalf("change role: %v", err)
+	}
+	got, err := st.Ledgers().Get(ctx, house.ID)
+	if err != nil {
+		t.Fatalf("get ledger: %v", err)
+	}
+	if role, ok := got.Role(bob); !ok || role != models.RoleEditor || len(got.Members) != 2 || got.Name != "House" {
+		t.Errorf("unexpected ledger %+v", got)
+	}
+
+	ledgers, err := st.Ledgers().ListForUser(ctx, bob)
+	if err != nil {
+		t.Fatalf("list ledgers: %v", err)
+	}
+	if len(ledgers) != 1 || ledgers[0].ID != house.ID || len(ledgers[0].Members) != 2 {
+		t.Errorf("bob's ledgers: %+v", ledgers)
+	}
+
+	if err := st.Ledgers().RemoveMember(ctx, house.ID, bob); err != nil {
+		t.Fatalf("remove member: %v", err)
+	}
+	if err := st.Ledgers().RemoveMember(ctx, house.ID, bob); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("remove member twice: got %v, want ErrNotFound", err)
+	}
+	if ledgers, err := st.Ledgers().ListForUser(ctx, bob); err != nil || len(ledgers) != 0 {
+		t.Errorf("bob's ledgers after removal: %+v, %v", ledgers, err)
+	}
+
+	missing := primitive.NewObjectID()
+	if err := st.Ledgers().PutMember(ctx, missing, models.LedgerMember{UserID: bob, Role: models.RoleViewer}); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("add member to missing ledger: got %v, want ErrNotFound", err)
+	}
+	if _, err := st.Ledgers().Get(ctx, missing); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("get missing ledger: got %v, want ErrNotFound", err)
+	}
+}
+
+func testLedgerUpdate(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	update := models.Ledger{ID: ledger, Name: "Travel", BaseCurrency: "EUR"}
+	if err := st.Ledgers().Update(ctx, &update); err != nil {
+		t.Fatalf("update ledger: %v", err)
+	}
+	got, err := st.Ledgers().Get(ctx, ledger)
+	if err != nil {
+		t.Fatalf("get ledger: %v", err)
+	}
+	if got.Name != "Travel" || got.BaseCurrency != "EUR" || len(got.Members) != 1 {
+		t.Errorf("unexpected ledger %+v", got)
+	}
+
+	missing := models.Ledger{ID: primitive.NewObjectID(), Name: "Missing"}
+	if err := st.Ledgers().Update(ctx, &missing); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("update missing ledger: got %v, want ErrNotFound", err)
+	}
+}
+
+func testExchangeRates(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	other := mustInsertLedger(t, st, "other@example.com")
+
+	put := func(ledger primitive.ObjectID, from, to money.Currency, rate money.Rate, day time.Time) models.ExchangeRate {
+		t.Helper()
+		r := models.ExchangeRate{LedgerID: ledger, From: from, To: to, Rate: rate, Date: day}
+		if err := st.ExchangeRates().Put(ctx, &r); err != nil {
+			t.Fatalf("put rate: %v", err)
+		}
+		if r.ID.IsZero() {
+			t.Fatal("put rate did not assign an ID")
+		}
+		return r
+	}
+	march := put(ledger, "EUR", "USD", 108000000, date(2024, 3, 1))
+	put(ledger, "EUR", "USD", 110000000, date(2024, 1, 1))
+	put(other, "EUR", "USD", 200000000, date(2024, 1, 1))
+	// Same pair and day: replaces the rate, keeps the ID.
+	again := put(ledger, "EUR", "USD", 109000000, date(2024, 3, 1))
+	if again.ID != march.ID {
+		t.Errorf("replacing a rate changed its ID from %s to %s", march.ID.Hex(), again.ID.Hex())
+	}
+
+	rates, err := st.ExchangeRates().List(ctx, ledger)
+	if err != nil {
+		t.Fatalf("list rates: %v", err)
+	}
+	if len(rates) != 2 || !rates[0].Date.Equal(date(2024, 1, 1)) || rates[1].Rate != 109000000 || rates[1].From != "EUR" {
+		t.Fatalf("unexpected rates %+v", rates)
+	}
+
+	if err := st.ExchangeRates().Delete(ctx, other, march.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("delete another ledger's rate: got %v, want ErrNotFound", err)
+	}
+	if err := st.ExchangeRates().Delete(ctx, ledger, march.ID); err != nil {
+		t.Fatalf("delete rate: %v", err)
+	}
+	if rates, _ := st.ExchangeRates().List(ctx, ledger); len(rates) != 1 {
+		t.Errorf("rates after delete: %+v", rates)
+	}
+}
+
+func testRecurringExpenses(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	other := mustInsertLedger(t, st, "other@example.com")
+	rent := mustInsertCategory(t, st, ledger, "Rent", "#000000")
+	gym := mustInsertCategory(t, st, ledger, "Gym", "#ffffff")
+
+	insert := func(ledger, categoryID primitive.ObjectID, name string, next time.Time) models.RecurringExpense {
+		t.Helper()
+		r := models.RecurringExpense{
+			LedgerID: ledger, Name: name, Amount: 120000, Currency: money.DefaultCurrency, CategoryID: categoryID,
+			Frequency: models.Monthly, Interval: 1, StartDate: next, NextDate: next,
+			CreatedAt: date(2024, 1, 1), UpdatedAt: date(2024, 1, 1),
+		}
+		if err := st.RecurringExpenses().Insert(ctx, &r); err != nil {
+			t.Fatalf("insert recurring expense: %v", err)
+		}
+		if r.ID.IsZero() {
+			t.Fatal("insert recurring expense did not assign an ID")
+		}
+		return r
+	}
+	flat := insert(ledger, rent.ID, "Flat", date(2024, 3, 1))
+	membership := insert(ledger, gym.ID, "Membership", date(2024, 2, 1))
+	insert(other, primitive.NilObjectID, "Elsewhere", date(2024, 4, 1))
+
+	end := date(2024, 12, 1)
+	flat.EndDate = &end
+	flat.Name = "Flat rent"
+	if err := st.RecurringExpenses().Update(ctx, &flat); err != nil {
+		t.Fatalf("update recurring expense: %v", err)
+	}
+	got, err := st.RecurringExpenses().Get(ctx, ledger, flat.ID)
+	if err != nil {
+		t.Fatalf("get recurring expense: %v", err)
+	}
+	if got.Name != "Flat rent" || got.EndDate == nil || !got.EndDate.Equal(end) || got.Frequency != models.Monthly || got.CategoryID != rent.ID {
+		t.Errorf("unexpected recurring expense %+v", got)
+	}
+	if _, err := st.RecurringExpenses().Get(ctx, other, flat.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("get from another ledger: got %v, want ErrNotFound", err)
+	}
+
+	due, err := st.RecurringExpenses().Due(ctx, date(2024, 3, 15))
+	if err != nil {
+		t.Fatalf("due: %v", err)
+	}
+	if len(due) != 2 || due[0].ID != membership.ID || due[1].ID != flat.ID {
+		t.Fatalf("unexpected due recurring expenses %+v", due)
+	}
+
+	// Advancing is conditional on the index it starts from, so a second
+	// scheduler working from the same state loses.
+	advanced := *got
+	advanced.NextIndex, advanced.NextDate, advanced.Done = 1, date(2024, 4, 1), false
+	if err := st.RecurringExpenses().Advance(ctx, &advanced, 0); err != nil {
+		t.Fatalf("advance: %v", err)
+	}
+	if err := st.RecurringExpenses().Advance(ctx, &advanced, 0); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("advance from a stale index: got %v, want ErrNotFound", err)
+	}
+	membership.NextIndex, membership.Done = 1, true
+	if err := st.RecurringExpenses().Advance(ctx, &membership, 0); err != nil {
+		t.Fatalf("advance to done: %v", err)
+	}
+	if due, _ := st.RecurringExpenses().Due(ctx, date(2024, 3, 15)); len(due) != 0 {
+		t.Errorf("due after advancing: %+v", due)
+	}
+	if got, _ := st.RecurringExpenses().Get(ctx, ledger, flat.ID); got.NextIndex != 1 || !got.NextDate.Equal(date(2024, 4, 1)) || got.Name != "Flat rent" {
+		t.Errorf("advanced recurring expense %+v", got)
+	}
+
+	if n, err := st.RecurringExpenses().DeleteByCategory(ctx, ledger, gym.ID); err != nil || n != 1 {
+		t.Errorf("delete by category: deleted %d, %v", n, err)
+	}
+	if err := st.RecurringExpenses().Delete(ctx, other, flat.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("delete from another ledger: got %v, want ErrNotFound", err)
+	}
+	if err := st.RecurringExpenses().Delete(ctx, ledger, flat.ID); err != nil {
+		t.Fatalf("delete recurring expense: %v", err)
+	}
+	if list, _ := st.RecurringExpenses().List(ctx, ledger); len(list) != 0 {
+		t.Errorf("recurring expenses after delete: %+v", list)
+	}
+	if list, _ := st.RecurringExpenses().List(ctx, other); len(list) != 1 {
+		t.Errorf("other ledger's recurring expenses: %+v", list)
+	}
+}
+
+func testExpenseInsertDuplicate(t *testing.T, st store.Store) {
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	expense := mustInsertExpense(t, st, ledger, "Lunch", 1500, primitive.NilObjectID)
+
+	again := expense
+	if err := st.Expenses().Insert(context.Background(), &again); !errors.Is(err, store.ErrDuplicate) {
+		t.Errorf("insert with an existing ID: got %v, want ErrDuplicate", err)
+	}
+}
+
+// RunBlobs runs the suite for blob stores on blobs, which must be empty.
+func RunBlobs(t *testing.T, blobs store.BlobStore) {
+	ctx := context.Background()
+	read := func(hash string) (string, error) {
+		t.Helper()
+		r, err := blobs.Open(ctx, hash)
+		if err != nil {
+			return "", err
+		}
+		defer r.Close()
+		content, err := io.ReadAll(r)
+		return string(content), err
+	}
+
+	hash, err := blobs.Put(ctx, []byte("receipt"))
+	if err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	if hash != store.ContentHash([]byte("receipt")) {
+		t.Errorf("put returned hash %q", hash)
+	}
+	// Storing the same content again is harmless.
+	if again, err := blobs.Put(ctx, []byte("receipt")); err != nil || again != hash {
+		t.Errorf("put again: %q, %v", again, err)
+	}
+	empty, err := blobs.Put(ctx, nil)
+	if err != nil {
+		t.Fatalf("put empty: %v", err)
+	}
+
+	for want, hash := range map[string]string{"receipt": hash, "": empty} {
+		if got, err := read(hash); err != nil || got != want {
+			t.Errorf("open %s: %q, %v", hash, got, err)
+		}
+	}
+	for _, hash := range []string{store.ContentHash([]byte("other")), "../../etc/passwd"} {
+		if _, err := read(hash); !errors.Is(err, store.ErrNotFound) {
+			t.Errorf("open %q: got %v, want ErrNotFound", hash, err)
+		}
+	}
+
+	if err := blobs.Delete(ctx, hash); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := read(hash); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("open deleted blob: got %v, want ErrNotFound", err)
+	}
+	if err := blobs.Delete(ctx, hash); err != nil {
+		t.Errorf("delete twice: %v", err)
+	}
+}
+
+func testCategoryRules(t *testing.T, st store.Store) {
+	ctx := context.Background()
+	ledger := mustInsertLedger(t, st, "owner@example.com")
+	other := mustInsertLedger(t, st, "other@example.com")
+	groceries := mustInsertCategory(t, st, ledger, "Groceries", "#00ff00")
+	dining := mustInsertCategory(t, st, ledger, "Dining", "#ff0000")
+
+	min, max := money.Amount(100), money.Amount(5000)
+	insert := func(rule models.CategoryRule) models.CategoryRule {
+		t.Helper()
+		rule.CreatedAt, rule.UpdatedAt = date(2024, 1, 1), date(2024, 1, 1)
+		if err := st.CategoryRules().Insert(ctx, &rule); err != nil {
+			t.Fatalf("insert rule: %v", err)
+		}
+		if rule.ID.IsZero() {
+			t.Fatal("insert rule did not assign an ID")
+		}
+		return rule
+	}
+	lunch := insert(models.CategoryRule{LedgerID: ledger, Name: "Lunch", Priority: 2, CategoryID: dining.ID,
+		NamePattern: "lunch|sandwich", Weekdays: []time.Weekday{time.Monday, time.Friday}})
+	grocer := insert(models.CategoryRule{LedgerID: ledger, Name: "Grocer", Priority: 1, CategoryID: groceries.ID,
+		Merchant: "Corner Grocer", MinAmount: &min, MaxAmount: &max})
+	late := insert(models.CategoryRule{LedgerID: ledger, Name: "Late", Priority: 2, CategoryID: groceries.ID, NamePattern: "milk"})
+
+	list := func(ledger primitive.ObjectID) []string {
+		t.Helper()
+		rules, err := st.CategoryRules().List(ctx, ledger)
+		if err != nil {
+			t.Fatalf("list rules: %v", err)
+		}
+		var names []string
+		for _, r := range rules {
+			names = append(names, r.Name)
+		}
+		return names
+	}
+	// By priority, then in the order the rules were created.
+	if got := list(ledger); !slices.Equal(got, []string{"Grocer", "Lunch", "Late"}) {
+		t.Errorf("rules: %v", got)
+	}
+	if got := list(other); len(got) != 0 {
+		t.Errorf("rules of another ledger: %v", got)
+	}
+
+	got, err := st.CategoryRules().Get(ctx, ledger, grocer.ID)
+	if err != nil {
+		t.Fatalf("get rule: %v", err)
+	}
+	if got.Merchant != "Corner Grocer" || got.CategoryID != groceries.ID || got.MinAmount == nil || *got.MinAmount != min ||
+		got.MaxAmount == nil || *got.MaxAmount != max || got.NamePattern != "" || len(got.Weekdays) != 0 {
+		t.Errorf("rule read back as %+v", got)
+	}
+	if _, err := st.CategoryRules().Get(ctx, other, grocer.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("get rule of another ledger: got %v, want ErrNotFound", err)
+	}
+
+	// Conditions that are cleared are gone after an update.
+	lunch.Priority = 0
+	lunch.NamePattern = ""
+	lunch.MaxAmount = &max
+	if err := st.CategoryRules().Update(ctx, &lunch); err != nil {
+		t.Fatalf("update rule: %v", err)
+	}
+	got, err = st.CategoryRules().Get(ctx, ledger, lunch.ID)
+	if err != nil {
+		t.Fatalf("get updated rule: %v", err)
+	}
+	if got.NamePattern != "" || got.MinAmount != nil || got.MaxAmount == nil || *got.MaxAmount != max ||
+		!slices.Equal(got.Weekdays, []time.Weekday{time.Monday, time.Friday}) {
+		t.Errorf("updated rule read back as %+v", got)
+	}
+	if got := list(ledger); !slices.Equal(got, []string{"Lunch", "Grocer", "Late"}) {
+		t.Errorf("rules after changing a priority: %v", got)
+	}
+	missing := models.CategoryRule{ID: primitive.NewObjectID(), LedgerID: ledger, CategoryID: dining.ID}
+	if err := st.CategoryRules().Update(ctx, &missing); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("update missing rule: got %v, want ErrNotFound", err)
+	}
+
+	if err := st.CategoryRules().Delete(ctx, ledger, late.ID); err != nil {
+		t.Fatalf("delete rule: %v", err)
+	}
+	if err := st.CategoryRules().Delete(ctx, ledger, late.ID); !errors.Is(err, store.ErrNotFound) {
+		t.Errorf("delete rule twice: got %v, want ErrNotFound", err)
+	}
+	deleted, err := st.CategoryRules().DeleteByCategory(ctx, ledger, groceries.ID)
+	if err != nil {
+		t.Fatalf("delete rules of category: %v", err)
+	}
+	if deleted != 1 {
+		t.Errorf("deleted %d rules, want 1", deleted)
+	}
+	if got := list(ledger); !slices.Equal(got, []string{"Lunch"}) {
+		t.Errorf("rules left: %v", got)
+	}
+}
@@ -1 +1,292 @@
 package utils
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"strings"
+
+	"github.com/disintegration/imaging"
+)
+
+// MaxImagePixels is the largest image PreprocessImage decodes, 50
+// megapixels, several times a phone photo. Decoded images take 4 bytes a
+// pixel however well the file is compressed.
+const MaxImagePixels = 50_000_000
+
+var (
+	// ErrNotAnImage is returned by PreprocessImage for content that is not
+	// a JPEG, PNG, GIF, TIFF or BMP image.
+	ErrNotAnImage = errors.New("not an image")
+	// ErrImageTooLarge is returned by PreprocessImage for images of more
+	// than MaxImagePixels.
+	ErrImageTooLarge = errors.New("image is too large to process")
+)
+
+// ImageOptions selects the steps PreprocessImage runs, in this order. The
+// zero value runs none of them.
+type ImageOptions struct {
+	// AutoRotate turns the photo upright as recorded in its EXIF data.
+	AutoRotate bool
+	// MaxDimension downscales images whose width or height is larger,
+	// keeping the aspect ratio. 0 keeps the size.
+	MaxDimension int
+	Grayscale    bool
+	// Contrast stretches the darkest and lightest percent of pixels to
+	// black and white.
+	Contrast bool
+	// Deskew straightens text lines that are up to maxSkew degrees off.
+	Deskew bool
+	// CropBorders cuts away plain margins and background around the receipt.
+	CropBorders bool
+}
+
+// ParseImageOptions reads a comma-separated list of steps: autorotate,
+// downscale, grayscale, contrast, deskew and crop, or "none". Downscaling
+// keeps images at most maxDimension pixels on a side.
+func ParseImageOptions(steps string, maxDimension int) (ImageOptions, error) {
+	var opts ImageOptions
+	if strings.TrimSpace(steps) == "none" {
+		return opts, nil
+	}
+	for _, step := range strings.Split(steps, ",") {
+		switch strings.ToLower(strings.TrimSpace(step)) {
+		case "autorotate":
+			opts.AutoRotate = true
+		case "downscale":
+			opts.MaxDimension = maxDimension
+		case "grayscale":
+			opts.Grayscale = true
+		case "contrast":
+			opts.Contrast = true
+		case "deskew":
+			opts.Deskew = true
+		case "crop":
+			opts.CropBorders = true
+		case "":
+		default:
+			return ImageOptions{}, fmt.Errorf("unknown image preprocessing step %q", step)
+		}
+	}
+	return opts, nil
+}
+
+// Enabled reports whether any step is selected.
+func (o ImageOptions) Enabled() bool {
+	return o != ImageOptions{}
+}
+
+// PreprocessImage prepares a photo or scan of a receipt for OCR and returns
+// it as a PNG.
+func PreprocessImage(content []byte, opts ImageOptions) ([]byte, error) {
+	// The header gives the size before any memory is spent on the pixels.
+	config, _, err := image.DecodeConfig(bytes.NewReader(content))
+	if err != nil {
+		return nil, ErrNotAnImage
+	}
+	if int64(config.Width)*int64(config.Height) > MaxImagePixels {
+		return nil, fmt.Errorf("%w: %dx%d", ErrImageTooLarge, config.Width, config.Height)
+	}
+	img, err := imaging.Decode(bytes.NewReader(content), imaging.AutoOrientation(opts.AutoRotate))
+	if err != nil {
+		return nil, ErrNotAnImage
+	}
+
+	if b := img.Bounds(); opts.MaxDimension > 0 && (b.Dx() > opts.MaxDimension || b.Dy() > opts.MaxDimension) {
+		img = imaging.Fit(img, opts.MaxDimension, opts.MaxDimension, imaging.Lanczos)
+	}
+	if opts.Grayscale {
+		img = imaging.Grayscale(img)
+	}
+	if opts.Contrast {
+		img = stretchContrast(img)
+	}
+	if opts.Deskew {
+		if angle := skewAngle(img); angle != 0 {
+			img = imaging.Rotate(img, angle, color.White)
+		}
+	}
+	if opts.CropBorders {
+		img = cropBorders(img)
+	}
+
+	var out bytes.Buffer
+	if err := png.Encode(&out, img); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// luminance is the brightness of c from 0 to 255.
+func luminance(c color.Color) uint8 {
+	return color.GrayModel.Convert(c).(color.Gray).Y
+}
+
+// stretchContrast maps the 1st percentile of brightness to black and the
+// 99th to white, so that faded thermal paper and dim photos read as dark
+// text on white.
+func stretchContrast(img image.Image) image.Image {
+	var histogram [256]int
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			histogram[luminance(img.At(x, y))]++
+		}
+	}
+
+	total := b.Dx() * b.Dy()
+	low, high := percentile(&histogram, total/100), percentile(&histogram, total-total/100)
+	if high <= low {
+		return img
+	}
+	scale := 255 / float64(high-low)
+	stretch := func(v uint8) uint8 {
+		return uint8(math.Round(math.Max(0, math.Min(255, (float64(v)-float64(low))*scale))))
+	}
+	return imaging.AdjustFunc(img, func(c color.NRGBA) color.NRGBA {
+		return color.NRGBA{R: stretch(c.R), G: stretch(c.G), B: stretch(c.B), A: c.A}
+	})
+}
+
+// percentile returns the brightness at which the histogram reaches n pixels.
+func percentile(histogram *[256]int, n int) int {
+	sum := 0
+	for v, count := range histogram {
+		sum += count
+		if sum > n {
+			return v
+		}
+	}
+	return 255
+}
+
+// Skew is looked for between -maxSkew and maxSkew degrees in steps of
+// skewStep, on a copy of the image at most skewSample pixels on a side.
+const (
+	maxSkew    = 10
+	skewStep   = 0.5
+	skewSample = 600
+)
+
+// skewAngle returns the angle, counter-clockwise in degrees, that levels the
+// text of img, or 0 when it is already level.
+//
+// Dark pixels are projected onto the vertical axis as if the image were
+// rotated by each candidate angle. Level lines of text give the sharpest
+// profile, with full rows for the lines and empty ones in between, which is
+// measured as the sum of squared differences between neighbouring rows.
+func skewAngle(img image.Image) float64 {
+	sample := imaging.Fit(img, skewSample, skewSample, imaging.Box)
+	b := sample.Bounds()
+	threshold := meanLuminance(sample) * 3 / 4
+
+	var dark []image.Point
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if float64(luminance(sample.At(x, y))) < threshold {
+				dark = append(dark, image.Pt(x-b.Min.X, y-b.Min.Y))
+			}
+		}
+	}
+	if len(dark) == 0 {
+		return 0
+	}
+
+	cx, cy := float64(b.Dx())/2, float64(b.Dy())/2
+	size := b.Dx() + b.Dy()
+	best, bestScore := 0.0, -1.0
+	rows := make([]int, 2*size)
+	for step := -int(maxSkew / skewStep); step <= int(maxSkew/skewStep); step++ {
+		angle := float64(step) * skewStep
+		sin, cos := math.Sincos(angle * math.Pi / 180)
+		clear(rows)
+		for _, p := range dark {
+			// The row p lands in when the image is turned by angle
+			// counter-clockwise.
+			y := -(float64(p.X)-cx)*sin + (float64(p.Y)-cy)*cos
+			rows[int(math.Floor(y))+size]++
+		}
+		score := 0.0
+		for i := 1; i < len(rows); i++ {
+			d := float64(rows[i] - rows[i-1])
+			score += d * d
+		}
+		if score > bestScore {
+			best, bestScore = angle, score
+		}
+	}
+	return best
+}
+
+func meanLuminance(img image.Image) float64 {
+	b := img.Bounds()
+	sum := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			sum += int(luminance(img.At(x, y)))
+		}
+	}
+	return float64(sum) / float64(b.Dx()*b.Dy())
+}
+
+// cropPadding is how much of the plain margin cropBorders leaves around the
+// content.
+const cropPadding = 10
+
+// cropBorders cuts away the rows and columns at the edges of img that are
+// all one shade, such as a table around the receipt and then the blank paper
+// margins inside it.
+func cropBorders(img image.Image) image.Image {
+	b := img.Bounds()
+	r := b
+	for {
+		next := r
+		for next.Min.Y < next.Max.Y && plain(img, image.Rect(next.Min.X, next.Min.Y, next.Max.X, next.Min.Y+1)) {
+			next.Min.Y++
+		}
+		for next.Max.Y > next.Min.Y && plain(img, image.Rect(next.Min.X, next.Max.Y-1, next.Max.X, next.Max.Y)) {
+			next.Max.Y--
+		}
+		for next.Min.X < next.Max.X && plain(img, image.Rect(next.Min.X, next.Min.Y, next.Min.X+1, next.Max.Y)) {
+			next.Min.X++
+		}
+		for next.Max.X > next.Min.X && plain(img, image.Rect(next.Max.X-1, next.Min.Y, next.Max.X, next.Max.Y)) {
+			next.Max.X--
+		}
+		if next.Empty() {
+			// Nothing but margin.
+			return img
+		}
+		if next == r {
+			break
+		}
+		r = next
+	}
+
+	crop := r.Inset(-cropPadding).Intersect(b)
+	if crop == b {
+		return img
+	}
+	return imaging.Crop(img, crop)
+}
+
+// plain reports whether the pixels of r in img are all close to the same
+// brightness.
+func plain(img image.Image, r image.Rectangle) bool {
+	const tolerance = 24
+	lo, hi := uint8(255), uint8(0)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			v := luminance(img.At(x, y))
+			lo, hi = min(lo, v), max(hi, v)
+			if hi-lo > tolerance {
+				return false
+			}
+		}
+	}
+	return true
+}
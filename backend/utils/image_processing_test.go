@@ -0,0 +1,147 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+
+	"github.com/disintegration/imaging"
+)
+
+// receiptImage draws lines of "text" as dark bars on white paper, with a
+// margin of the given shade around the paper.
+func receiptImage(margin int, background color.Color) *image.NRGBA {
+	img := imaging.New(400+2*margin, 300+2*margin, background)
+	paper := imaging.New(400, 300, color.White)
+	for y := 20; y+12 < 300; y += 30 {
+		for x := 20; x < 380; x++ {
+			if (x/25)%4 == 3 {
+				continue // gaps between words
+			}
+			for dy := 0; dy < 12; dy++ {
+				paper.Set(x, y+dy, color.Black)
+			}
+		}
+	}
+	return imaging.Paste(img, paper, image.Pt(margin, margin))
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decodePNG(t *testing.T, content []byte) image.Image {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("processed image is not a PNG: %v", err)
+	}
+	return img
+}
+
+func TestSkewAngle(t *testing.T) {
+	level := receiptImage(0, color.White)
+	if angle := skewAngle(level); angle != 0 {
+		t.Errorf("level receipt: skew %v, want 0", angle)
+	}
+	for _, turned := range []float64{-6, -2.5, 4} {
+		img := imaging.Rotate(level, turned, color.White)
+		if angle := skewAngle(img); math.Abs(angle+turned) > skewStep {
+			t.Errorf("receipt turned by %v: skew %v, want %v", turned, angle, -turned)
+		}
+	}
+}
+
+func TestPreprocessImageCropsBorders(t *testing.T) {
+	content := encodePNG(t, receiptImage(60, color.Gray{Y: 40}))
+	out, err := PreprocessImage(content, ImageOptions{Grayscale: true, CropBorders: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The dark table and the paper margin go, except for some padding
+	// around the text.
+	b := decodePNG(t, out).Bounds()
+	if b.Dx() > 360+2*cropPadding+5 || b.Dy() > 270+2*cropPadding || b.Dx() < 340 || b.Dy() < 260 {
+		t.Errorf("cropped to %v", b)
+	}
+}
+
+func TestPreprocessImageStretchesContrast(t *testing.T) {
+	img := imaging.New(100, 100, color.Gray{Y: 150})
+	for y := 40; y < 60; y++ {
+		for x := 0; x < 100; x++ {
+			img.Set(x, y, color.Gray{Y: 100})
+		}
+	}
+	out, err := PreprocessImage(encodePNG(t, img), ImageOptions{Contrast: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	processed := decodePNG(t, out)
+	if text, paper := luminance(processed.At(50, 50)), luminance(processed.At(50, 10)); text != 0 || paper != 255 {
+		t.Errorf("text %d on paper %d, want 0 on 255", text, paper)
+	}
+}
+
+func TestPreprocessImageDownscales(t *testing.T) {
+	content := encodePNG(t, imaging.New(3000, 1200, color.White))
+	out, err := PreprocessImage(content, ImageOptions{MaxDimension: 2000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := decodePNG(t, out).Bounds(); b.Dx() != 2000 || b.Dy() != 800 {
+		t.Errorf("downscaled to %v, want 2000x800", b.Size())
+	}
+
+	out, err = PreprocessImage(content, ImageOptions{MaxDimension: 4000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := decodePNG(t, out).Bounds(); b.Dx() != 3000 {
+		t.Errorf("small enough image resized to %v", b.Size())
+	}
+}
+
+func TestPreprocessImageRejectsHugeImages(t *testing.T) {
+	// A 1x1 PNG whose header claims 100000x100000 pixels.
+	content := encodePNG(t, imaging.New(1, 1, color.White))
+	ihdr := content[12:29]
+	binary.BigEndian.PutUint32(ihdr[4:], 100000)
+	binary.BigEndian.PutUint32(ihdr[8:], 100000)
+	binary.BigEndian.PutUint32(content[29:], crc32.ChecksumIEEE(ihdr))
+
+	if _, err := PreprocessImage(content, ImageOptions{Grayscale: true}); !errors.Is(err, ErrImageTooLarge) {
+		t.Errorf("got %v, want ErrImageTooLarge", err)
+	}
+}
+
+func TestPreprocessImageRejectsOtherFiles(t *testing.T) {
+	opts := ImageOptions{AutoRotate: true, Grayscale: true}
+	if _, err := PreprocessImage([]byte("MILK\n$4.50"), opts); !errors.Is(err, ErrNotAnImage) {
+		t.Errorf("text file: got %v, want ErrNotAnImage", err)
+	}
+}
+
+func TestParseImageOptions(t *testing.T) {
+	opts, err := ParseImageOptions("autorotate, Downscale,deskew", 1500)
+	if err != nil || opts != (ImageOptions{AutoRotate: true, MaxDimension: 1500, Deskew: true}) {
+		t.Errorf("ParseImageOptions = %+v, %v", opts, err)
+	}
+	if opts, err := ParseImageOptions("none", 1500); err != nil || opts.Enabled() {
+		t.Errorf("ParseImageOptions(none) = %+v, %v", opts, err)
+	}
+	if _, err := ParseImageOptions("grayscale,sharpen", 1500); err == nil {
+		t.Error("ParseImageOptions accepted an unknown step")
+	}
+}
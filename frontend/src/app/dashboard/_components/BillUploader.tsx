@@ -1,7 +1,7 @@
 import React, { useState, useCallback, useRef, useEffect } from "react";
 import { Button } from "@/components/ui/button";
 import { Input } from "@/components/ui/input";
-import { uploadBill, getBillDetails, confirmExpenses } from "@/utils/api";
+import { uploadBill, waitForBill, confirmExpenses } from "@/utils/api";
 import ReactCrop, {
   Crop,
   PixelCrop,
@@ -162,7 +162,10 @@ export function BillUploader({
 
       const uploadResponse = await uploadBill(formData);
       setBillId(uploadResponse.id);
-      const details: Bill = await getBillDetails(uploadResponse.id);
+      const details: Bill = await waitForBill(uploadResponse.id);
+      if (details.status === "error") {
+        throw new Error(details.error || "Bill processing failed");
+      }
       setBillDetails(details);
 
       setEditedExpenses(
@@ -424,7 +427,23 @@ export function BillUploader({
             add or edit your expenses and hit the "Update".
           </span>
           <h3 className="text-lg font-semibold">Processed Bill Details:</h3>
+          {billDetails.merchant && <p>Merchant: {billDetails.merchant}</p>}
+          {billDetails.transactionDate && (
+            <p>Date: {new Date(billDetails.transactionDate).toLocaleString()}</p>
+          )}
+          {billDetails.paymentMethod && (
+            <p>
+              Paid with: {billDetails.paymentMethod}
+              {billDetails.cardLast4 && ` ending in ${billDetails.cardLast4}`}
+            </p>
+          )}
           <p>Total Amount: ${billDetails.analysisResults.total.toFixed(2)}</p>
+          {!!billDetails.analysisResults.difference && (
+            <p className="text-amber-600 text-sm">
+              The items do not add up to the total on the receipt, please check
+              them before confirming.
+            </p>
+          )}
           <h4 className="text-md font-semibold mt-2">Extracted Text:</h4>
           <pre className="bg-gray-100 p-2 rounded mt-1 text-sm">
             {billDetails.analysisResults.extractedText}
@@ -1,7 +1,6 @@
-import React, { useMemo } from "react";
-import { BudgetGoal, Expense, Category } from "@/types";
+import React, { useEffect, useMemo, useState } from "react";
+import { BudgetGoal, BudgetProgress, Category } from "@/types";
 import { Card, CardContent, CardHeader, CardTitle } from "@/components/ui/card";
-import { Progress } from "@/components/ui/progress";
 import {
   Select,
   SelectContent,
@@ -9,66 +8,68 @@ import {
   SelectTrigger,
   SelectValue,
 } from "@/components/ui/select";
-import {
-  BarChart,
-  Bar,
-  XAxis,
-  YAxis,
-  CartesianGrid,
-  Tooltip,
-  Legend,
-  ResponsiveContainer,
-} from "recharts";
+import { getBudgetProgress } from "@/utils/api";
 import { ColoredProgress } from "./ColoredProgress";
 
+// budgetGoalLabel names what a goal counts: its category, its categories or
+// all spending.
+export const budgetGoalLabel = (goal: BudgetGoal, categories: Category[]): string => {
+  const name = (id: string) => categories.find((c) => c._id === id)?.name || "Unknown";
+  switch (goal.scope) {
+    case "overall":
+      return "All spending";
+    case "categories":
+      return (goal.categoryIds || []).map(name).join(" + ");
+    default:
+      return name(goal.categoryId || "");
+  }
+};
+
 interface BudgetForecastProps {
   budgetGoals: BudgetGoal[];
-  expenses: Expense[];
   categories: Category[];
 }
 
 export const BudgetForecast: React.FC<BudgetForecastProps> = ({
   budgetGoals = [],
-  expenses,
   categories,
 }) => {
-  const [selectedPeriod, setSelectedPeriod] = React.useState<
-    "weekly" | "monthly"
-  >("weekly");
+  const [selectedPeriod, setSelectedPeriod] = React.useState<string>("weekly");
+  const [progress, setProgress] = useState<BudgetProgress[]>([]);
 
-  const budgetData = useMemo(() => {
-    const currentDate = new Date();
-    const startOfPeriod = new Date(currentDate);
-    if (selectedPeriod === "weekly") {
-      startOfPeriod.setDate(currentDate.getDate() - currentDate.getDay());
-    } else {
-      startOfPeriod.setDate(1);
-    }
-
-    return budgetGoals
-      .filter((goal) => goal.period === selectedPeriod)
-      .map((goal) => {
-        const category = categories.find((c) => c._id === goal.categoryId);
-        const spent = expenses
-          .filter(
-            (e) =>
-              e.categoryId === goal.categoryId &&
-              new Date(e.date) >= startOfPeriod
-          )
-          .reduce((sum, e) => sum + e.amount, 0);
-        const percentage = Math.min((spent / goal.amount) * 100, 100);
+  // The server sums each goal's period; fetch again when goals change.
+  useEffect(() => {
+    getBudgetProgress()
+      .then(setProgress)
+      .catch((error) => console.error("Error fetching budget progress:", error));
+  }, [budgetGoals]);
 
+  const budgetData = useMemo(() => {
+    return progress
+      .filter((item) => item.goal.period.split(":")[0] === selectedPeriod)
+      .map((item) => {
+        const category =
+          (!item.goal.scope || item.goal.scope === "category") &&
+          categories.find((c) => c._id === item.goal.categoryId);
+        // Overlapping goals count the same expenses, each against its own
+        // budget.
+        const overlaps = progress
+          .filter((other) => item.overlaps?.includes(other.goal._id))
+          .map((other) => budgetGoalLabel(other.goal, categories));
         return {
-          categoryName: category?.name || "Unknown",
-          categoryColor: category?.color || "#000000",
-          budget: goal.amount,
-          spent,
-          remaining: Math.max(goal.amount - spent, 0),
-          percentage,
+          categoryName: budgetGoalLabel(item.goal, categories),
+          categoryColor: category ? category.color : "#000000",
+          overlaps,
+          budget: item.budget,
+          spent: item.spent,
+          remaining: Math.max(item.remaining, 0),
+          projected: item.projected,
+          status: item.status,
+          percentage: Math.min(item.percent, 100),
         };
       })
       .sort((a, b) => b.percentage - a.percentage); // Sort by percentage descending
-  }, [budgetGoals, expenses, categories, selectedPeriod]);
+  }, [progress, categories, selectedPeriod]);
 
   return (
     <Card className="w-full">
@@ -77,9 +78,7 @@ export const BudgetForecast: React.FC<BudgetForecastProps> = ({
           Budget Forecast
           <Select
             value={selectedPeriod}
-            onValueChange={(value: "weekly" | "monthly") =>
-              setSelectedPeriod(value)
-            }
+            onValueChange={(value: string) => setSelectedPeriod(value)}
           >
             <SelectTrigger className="w-[180px]">
               <SelectValue placeholder="Select period" />
@@ -87,6 +86,9 @@ export const BudgetForecast: React.FC<BudgetForecastProps> = ({
             <SelectContent>
               <SelectItem value="weekly">Weekly</SelectItem>
               <SelectItem value="monthly">Monthly</SelectItem>
+              <SelectItem value="quarterly">Quarterly</SelectItem>
+              <SelectItem value="yearly">Yearly</SelectItem>
+              <SelectItem value="custom">Custom</SelectItem>
             </SelectContent>
           </Select>
         </CardTitle>
@@ -99,6 +101,8 @@ export const BudgetForecast: React.FC<BudgetForecastProps> = ({
                 <span className="font-medium">{item.categoryName}</span>
                 <span className="text-sm text-gray-500">
                   ${item.spent.toFixed(2)} / ${item.budget.toFixed(2)}
+                  {item.status !== "on_track" &&
+                    ` (${item.status === "over" ? "over budget" : `on pace for $${item.projected.toFixed(2)}`})`}
                 </span>
               </div>
               <ColoredProgress
@@ -106,6 +110,11 @@ export const BudgetForecast: React.FC<BudgetForecastProps> = ({
                 className="h-2"
                 color={item.categoryColor}
               />
+              {item.overlaps.length > 0 && (
+                <p className="text-xs text-gray-500">
+                  Also counted in {item.overlaps.join(", ")}
+                </p>
+              )}
             </div>
           ))}
         </div>
@@ -15,11 +15,14 @@ import { BudgetGoal, Category } from "@/types";
 import {
   addBudgetGoal,
   addCategory,
+  budgetGoalErrors,
   deleteBudgetGoal,
   updateBudgetGoal,
 } from "@/utils/api";
 import { useToast } from "@/components/hooks/use-toast";
 import { Plus, Pencil, Trash2 } from "lucide-react";
+import { Checkbox } from "@/components/ui/checkbox";
+import { budgetGoalLabel } from "./BudgetForecast";
 import {
   Dialog,
   DialogContent,
@@ -46,12 +49,29 @@ import {
 } from "@/components/ui/table";
 
 const budgetGoalSchema = z.object({
-  categoryId: z.string().min(1, { message: "Please select a category." }),
+  // A goal counts one category, several, or all spending.
+  scope: z.enum(["category", "categories", "overall"]),
+  categoryId: z.string(),
+  categoryIds: z.array(z.string()),
   amount: z.string().refine((val) => !isNaN(Number(val)) && Number(val) > 0, {
     message: "Amount must be a positive number.",
   }),
-  period: z.enum(["weekly", "monthly"]),
-});
+  // Periods may carry an anchor, like "monthly:15" or "weekly:monday".
+  period: z.string().regex(/^(weekly|monthly|quarterly|yearly|custom)(:.+)?$/, {
+    message: "Please select a period.",
+  }),
+  rollover: z.enum(["none", "surplus", "both", "capped"]),
+  rolloverCap: z.string(),
+}).refine(
+  (goal) => goal.rollover !== "capped" || Number(goal.rolloverCap) > 0,
+  { message: "A capped rollover needs a cap above 0.", path: ["rolloverCap"] }
+).refine(
+  (goal) => goal.scope !== "category" || goal.categoryId !== "",
+  { message: "Please select a category.", path: ["categoryId"] }
+).refine(
+  (goal) => goal.scope !== "categories" || goal.categoryIds.length > 0,
+  { message: "Please select the categories.", path: ["categoryIds"] }
+);
 
 const categorySchema = z.object({
   name: z
@@ -88,9 +108,13 @@ export const BudgetGoalSetting: React.FC<BudgetGoalSettingProps> = ({
   const budgetGoalForm = useForm<z.infer<typeof budgetGoalSchema>>({
     resolver: zodResolver(budgetGoalSchema),
     defaultValues: {
+      scope: "category",
       categoryId: "",
+      categoryIds: [],
       amount: "",
       period: "monthly",
+      rollover: "none",
+      rolloverCap: "",
     },
   });
 
@@ -127,18 +151,26 @@ export const BudgetGoalSetting: React.FC<BudgetGoalSettingProps> = ({
   const handleCancelEdit = () => {
     setEditingGoal(null);
     budgetGoalForm.reset({
+      scope: "category",
       categoryId: "",
+      categoryIds: [],
       amount: "",
       period: "monthly",
+      rollover: "none",
+      rolloverCap: "",
     });
   };
 
   const handleEditGoal = (goal: BudgetGoal) => {
     setEditingGoal(goal);
     budgetGoalForm.reset({
-      categoryId: goal.categoryId,
+      scope: goal.scope || "category",
+      categoryId: goal.categoryId || "",
+      categoryIds: goal.categoryIds || [],
       amount: goal.amount.toString(),
       period: goal.period,
+      rollover: goal.rollover || "none",
+      rolloverCap: goal.rolloverCap ? goal.rolloverCap.toString() : "",
     });
   };
 
@@ -147,9 +179,15 @@ export const BudgetGoalSetting: React.FC<BudgetGoalSettingProps> = ({
   ) => {
     try {
       const goalData: Omit<BudgetGoal, "_id"> = {
-        categoryId: values.categoryId,
+        scope: values.scope,
+        categoryId: values.scope === "category" ? values.categoryId : undefined,
+        categoryIds:
+          values.scope === "categories" ? values.categoryIds : undefined,
         amount: parseFloat(values.amount),
         period: values.period,
+        rollover: values.rollover,
+        rolloverCap:
+          values.rollover === "capped" ? parseFloat(values.rolloverCap) : 0,
       };
 
       if (editingGoal) {
@@ -172,6 +210,16 @@ export const BudgetGoalSetting: React.FC<BudgetGoalSettingProps> = ({
       }
       budgetGoalForm.reset();
     } catch (error) {
+      // Show what the server found wrong next to the fields of the form.
+      const fields = budgetGoalErrors(error) || {};
+      for (const [name, message] of Object.entries(fields)) {
+        if (name in budgetGoalForm.getValues()) {
+          budgetGoalForm.setError(
+            name as keyof z.infer<typeof budgetGoalSchema>,
+            { message }
+          );
+        }
+      }
       toast({
         title: "Error",
         description: `Failed to ${editingGoal ? "update" : "add"} budget goal.`,
@@ -209,42 +257,97 @@ export const BudgetGoalSetting: React.FC<BudgetGoalSettingProps> = ({
         >
           <FormField
             control={budgetGoalForm.control}
-            name="categoryId"
+            name="scope"
             render={({ field }) => (
               <FormItem>
                 <Select onValueChange={field.onChange} value={field.value}>
                   <FormControl>
                     <SelectTrigger>
-                      <SelectValue placeholder="Select category" />
+                      <SelectValue placeholder="Select what to budget" />
                     </SelectTrigger>
                   </FormControl>
                   <SelectContent>
-                    <Button
-                      type="button"
-                      variant="ghost"
-                      onClick={() => setIsAddCategoryDialogOpen(true)}
-                      className="flex w-full"
-                    >
-                      <Plus className="mr-2 h-4 w-4" />
-                      Add Category
-                    </Button>
-                    {categories.map((cat) => (
-                      <SelectItem key={cat._id} value={cat._id}>
-                        <div className="flex items-center">
-                          <div
-                            className="w-4 h-4 rounded-full mr-2"
-                            style={{ backgroundColor: cat.color }}
-                          ></div>
-                          {cat.name}
-                        </div>
-                      </SelectItem>
-                    ))}
+                    <SelectItem value="category">One category</SelectItem>
+                    <SelectItem value="categories">Several categories</SelectItem>
+                    <SelectItem value="overall">All spending</SelectItem>
                   </SelectContent>
                 </Select>
                 <FormMessage />
               </FormItem>
             )}
           />
+          {budgetGoalForm.watch("scope") === "categories" && (
+            <FormField
+              control={budgetGoalForm.control}
+              name="categoryIds"
+              render={({ field }) => (
+                <FormItem>
+                  <div className="grid grid-cols-2 gap-2">
+                    {categories.map((cat) => (
+                      <label key={cat._id} className="flex items-center gap-2">
+                        <Checkbox
+                          checked={field.value.includes(cat._id)}
+                          onCheckedChange={(checked) =>
+                            field.onChange(
+                              checked
+                                ? [...field.value, cat._id]
+                                : field.value.filter((id) => id !== cat._id)
+                            )
+                          }
+                        />
+                        <div
+                          className="w-4 h-4 rounded-full"
+                          style={{ backgroundColor: cat.color }}
+                        ></div>
+                        {cat.name}
+                      </label>
+                    ))}
+                  </div>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+          )}
+          {budgetGoalForm.watch("scope") === "category" && (
+            <FormField
+              control={budgetGoalForm.control}
+              name="categoryId"
+              render={({ field }) => (
+                <FormItem>
+                  <Select onValueChange={field.onChange} value={field.value}>
+                    <FormControl>
+                      <SelectTrigger>
+                        <SelectValue placeholder="Select category" />
+                      </SelectTrigger>
+                    </FormControl>
+                    <SelectContent>
+                      <Button
+                        type="button"
+                        variant="ghost"
+                        onClick={() => setIsAddCategoryDialogOpen(true)}
+                        className="flex w-full"
+                      >
+                        <Plus className="mr-2 h-4 w-4" />
+                        Add Category
+                      </Button>
+                      {categories.map((cat) => (
+                        <SelectItem key={cat._id} value={cat._id}>
+                          <div className="flex items-center">
+                            <div
+                              className="w-4 h-4 rounded-full mr-2"
+                              style={{ backgroundColor: cat.color }}
+                            ></div>
+                            {cat.name}
+                          </div>
+                        </SelectItem>
+                      ))}
+                    </SelectContent>
+                  </Select>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+          )}
           <FormField
             control={budgetGoalForm.control}
             name="amount"
@@ -271,12 +374,50 @@ export const BudgetGoalSetting: React.FC<BudgetGoalSettingProps> = ({
                   <SelectContent>
                     <SelectItem value="weekly">Weekly</SelectItem>
                     <SelectItem value="monthly">Monthly</SelectItem>
+                    <SelectItem value="quarterly">Quarterly</SelectItem>
+                    <SelectItem value="yearly">Yearly</SelectItem>
+                  </SelectContent>
+                </Select>
+                <FormMessage />
+              </FormItem>
+            )}
+          />
+          <FormField
+            control={budgetGoalForm.control}
+            name="rollover"
+            render={({ field }) => (
+              <FormItem>
+                <Select onValueChange={field.onChange} value={field.value}>
+                  <FormControl>
+                    <SelectTrigger>
+                      <SelectValue placeholder="Select rollover" />
+                    </SelectTrigger>
+                  </FormControl>
+                  <SelectContent>
+                    <SelectItem value="none">No rollover</SelectItem>
+                    <SelectItem value="surplus">Carry what is left</SelectItem>
+                    <SelectItem value="both">Carry what is left or overspent</SelectItem>
+                    <SelectItem value="capped">Carry up to a cap</SelectItem>
                   </SelectContent>
                 </Select>
                 <FormMessage />
               </FormItem>
             )}
           />
+          {budgetGoalForm.watch("rollover") === "capped" && (
+            <FormField
+              control={budgetGoalForm.control}
+              name="rolloverCap"
+              render={({ field }) => (
+                <FormItem>
+                  <FormControl>
+                    <Input type="number" placeholder="Rollover cap" {...field} />
+                  </FormControl>
+                  <FormMessage />
+                </FormItem>
+              )}
+            />
+          )}
 
           <Button type="submit">
             {editingGoal ? "Update" : "Set"} Budget Goal
@@ -302,6 +443,7 @@ export const BudgetGoalSetting: React.FC<BudgetGoalSettingProps> = ({
             <TableHead>Category</TableHead>
             <TableHead>Amount</TableHead>
             <TableHead>Period</TableHead>
+            <TableHead>Rollover</TableHead>
             <TableHead>Actions</TableHead>
           </TableRow>
         </TableHeader>
@@ -310,19 +452,26 @@ export const BudgetGoalSetting: React.FC<BudgetGoalSettingProps> = ({
             <TableRow key={goal._id}>
               <TableCell>
                 <div className="flex items-center">
-                  <div
-                    className="w-4 h-4 rounded-full mr-2"
-                    style={{
-                      backgroundColor: categories.find(
-                        (c) => c._id === goal.categoryId
-                      )?.color,
-                    }}
-                  ></div>
-                  {categories.find((c) => c._id === goal.categoryId)?.name}
+                  {(!goal.scope || goal.scope === "category") && (
+                    <div
+                      className="w-4 h-4 rounded-full mr-2"
+                      style={{
+                        backgroundColor: categories.find(
+                          (c) => c._id === goal.categoryId
+                        )?.color,
+                      }}
+                    ></div>
+                  )}
+                  {budgetGoalLabel(goal, categories)}
                 </div>
               </TableCell>
               <TableCell>${goal.amount.toFixed(2)}</TableCell>
               <TableCell>{goal.period}</TableCell>
+              <TableCell>
+                {goal.rollover === "capped"
+                  ? `capped at $${goal.rolloverCap?.toFixed(2)}`
+                  : goal.rollover || "none"}
+              </TableCell>
               <TableCell>
                 <Button size="sm" onClick={() => handleEditGoal(goal)}>
                   <Pencil className="h-4 w-4" />
@@ -76,6 +76,9 @@ interface ExpensesDataTableProps {
     categoryId: string;
   }) => Promise<void>;
   onAddCategory: (name: string, color: string) => Promise<void>;
+  // hasMore is set while older expenses are left to load with onLoadMore.
+  hasMore?: boolean;
+  onLoadMore?: () => Promise<void>;
 }
 
 export function ExpensesDataTable({
@@ -85,6 +88,8 @@ export function ExpensesDataTable({
   onDeleteExpenses,
   onAddExpense,
   onAddCategory,
+  hasMore,
+  onLoadMore,
 }: ExpensesDataTableProps) {
   const [sorting, setSorting] = React.useState<SortingState>([]);
   const [columnFilters, setColumnFilters] = React.useState<ColumnFiltersState>(
@@ -501,6 +506,11 @@ export function ExpensesDataTable({
               <ChevronRight className="h-4 w-4" />
             </Button>
           </div>
+          {hasMore && onLoadMore && (
+            <Button variant="outline" className="h-8" onClick={onLoadMore}>
+              Load older expenses
+            </Button>
+          )}
         </div>
       </div>
       {editingExpense && (
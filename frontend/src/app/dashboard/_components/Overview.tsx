@@ -160,11 +160,7 @@ const Overview: React.FC<OverviewProps> = ({
         </Card>
       </div>
 
-      <BudgetForecast
-        budgetGoals={budgetGoals}
-        expenses={expenses}
-        categories={categories}
-      />
+      <BudgetForecast budgetGoals={budgetGoals} categories={categories} />
 
       <Carousel graphs={graphs} loadGraphData={loadGraphData} />
     </div>
@@ -1,5 +1,6 @@
 "use client";
 import { useState, useEffect } from "react";
+import { useRouter } from "next/navigation";
 import { Expense, Category, BudgetGoal } from "@/types";
 import {
   getExpenses,
@@ -14,6 +15,7 @@ import {
   getBudgetGoals,
   updateBudgetGoal,
   deleteBudgetGoal,
+  isLoggedIn,
 } from "@/utils/api";
 import * as z from "zod";
 
@@ -53,19 +55,29 @@ const formSchema = z.object({
 export default function Dashboard() {
   const { toast } = useToast();
   const [expenses, setExpenses] = useState<Expense[]>([]);
+  const [nextCursor, setNextCursor] = useState<string | undefined>();
   const [categories, setCategories] = useState<Category[]>([]);
   const [budgetGoals, setBudgetGoals] = useState<BudgetGoal[]>([]);
 
+  const router = useRouter();
+
   useEffect(() => {
+    if (!isLoggedIn()) {
+      router.replace("/login");
+      return;
+    }
     fetchExpenses();
     fetchCategories();
     fetchBudgetGoals();
   }, []);
 
+  // fetchExpenses loads the newest page of expenses again; older ones are
+  // loaded on demand by loadMoreExpenses.
   const fetchExpenses = async () => {
     try {
-      const expensesData = await getExpenses();
-      setExpenses(expensesData);
+      const page = await getExpenses();
+      setExpenses(page.expenses);
+      setNextCursor(page.nextCursor);
     } catch (error) {
       toast({
         title: "Error",
@@ -75,6 +87,21 @@ export default function Dashboard() {
     }
   };
 
+  const loadMoreExpenses = async () => {
+    if (!nextCursor) return;
+    try {
+      const page = await getExpenses(nextCursor);
+      setExpenses((loaded) => [...loaded, ...page.expenses]);
+      setNextCursor(page.nextCursor);
+    } catch (error) {
+      toast({
+        title: "Error",
+        description: "Failed to fetch more expenses.",
+        variant: "destructive",
+      });
+    }
+  };
+
   const fetchCategories = async () => {
     try {
       const categoriesData = await getCategories();
@@ -371,6 +398,8 @@ export default function Dashboard() {
                 onDeleteExpenses={handleDeleteExpenses}
                 onAddExpense={handleAddExpense}
                 onAddCategory={handleAddCategory}
+                hasMore={!!nextCursor}
+                onLoadMore={loadMoreExpenses}
               />
             </CardContent>
           </Card>
@@ -0,0 +1,118 @@
+"use client";
+import { Suspense, useState } from "react";
+import { useRouter, useSearchParams } from "next/navigation";
+import { authError, login, register } from "@/utils/api";
+
+import { Button } from "@/components/ui/button";
+import {
+  Card,
+  CardContent,
+  CardDescription,
+  CardFooter,
+  CardHeader,
+  CardTitle,
+} from "@/components/ui/card";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import { useToast } from "@/components/hooks/use-toast";
+
+// LoginForm logs in, or registers with ?mode=register, and then opens the
+// dashboard on the user's personal ledger.
+function LoginForm() {
+  const { toast } = useToast();
+  const router = useRouter();
+  const searchParams = useSearchParams();
+  const [isRegistering, setIsRegistering] = useState(
+    searchParams.get("mode") === "register"
+  );
+  const [email, setEmail] = useState("");
+  const [password, setPassword] = useState("");
+  const [isSubmitting, setIsSubmitting] = useState(false);
+
+  const handleSubmit = async (event: React.FormEvent) => {
+    event.preventDefault();
+    setIsSubmitting(true);
+    try {
+      if (isRegistering) {
+        await register(email, password);
+      } else {
+        await login(email, password);
+      }
+      router.push("/dashboard");
+    } catch (error) {
+      toast({
+        title: "Error",
+        description:
+          authError(error) ||
+          (isRegistering ? "Failed to sign up." : "Failed to log in."),
+        variant: "destructive",
+      });
+    } finally {
+      setIsSubmitting(false);
+    }
+  };
+
+  return (
+    <Card className="w-full max-w-sm">
+      <form onSubmit={handleSubmit}>
+        <CardHeader>
+          <CardTitle>{isRegistering ? "Sign Up" : "Login"}</CardTitle>
+          <CardDescription>
+            {isRegistering
+              ? "Create an account to start tracking your expenses."
+              : "Log in to see your expenses."}
+          </CardDescription>
+        </CardHeader>
+        <CardContent className="space-y-4">
+          <div className="space-y-2">
+            <Label htmlFor="email">Email</Label>
+            <Input
+              id="email"
+              type="email"
+              autoComplete="email"
+              value={email}
+              onChange={(e) => setEmail(e.target.value)}
+              required
+            />
+          </div>
+          <div className="space-y-2">
+            <Label htmlFor="password">Password</Label>
+            <Input
+              id="password"
+              type="password"
+              autoComplete={isRegistering ? "new-password" : "current-password"}
+              value={password}
+              onChange={(e) => setPassword(e.target.value)}
+              required
+            />
+          </div>
+        </CardContent>
+        <CardFooter className="flex flex-col gap-2">
+          <Button type="submit" className="w-full" disabled={isSubmitting}>
+            {isRegistering ? "Sign Up" : "Login"}
+          </Button>
+          <Button
+            type="button"
+            variant="ghost"
+            className="w-full"
+            onClick={() => setIsRegistering(!isRegistering)}
+          >
+            {isRegistering
+              ? "Already have an account? Login"
+              : "New? Sign Up"}
+          </Button>
+        </CardFooter>
+      </form>
+    </Card>
+  );
+}
+
+export default function LoginPage() {
+  return (
+    <div className="min-h-screen flex items-center justify-center bg-gradient-to-b from-green-100 to-white px-4">
+      <Suspense>
+        <LoginForm />
+      </Suspense>
+    </div>
+  );
+}
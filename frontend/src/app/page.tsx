@@ -23,10 +23,14 @@ export default function Home() {
           </h1>
         </div>
         <div className="flex gap-2">
-          <Button size="lg" className="text-md" variant="ghost">
-            Login
-          </Button>
-          <Button size="lg">New? Sign Up</Button>
+          <Link href="/login">
+            <Button size="lg" className="text-md" variant="ghost">
+              Login
+            </Button>
+          </Link>
+          <Link href="/login?mode=register">
+            <Button size="lg">New? Sign Up</Button>
+          </Link>
         </div>
       </header>
 
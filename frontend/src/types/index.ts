@@ -2,14 +2,26 @@ export interface Expense {
     _id: string;
     name: string;
     amount: number;
+    currency?: string;
     date: Date;
     categoryId: string;
+    merchant?: string;
+    paymentMethod?: string;
+    billId?: string;
+    categoryConfidence?: number;
     category?: {
       _id: string;
       name: string;
       color: string;
     };
   }
+
+  // One page of the expense listing, newest first; nextCursor is missing on
+  // the last page.
+  export interface ExpensePage {
+    expenses: Expense[];
+    nextCursor?: string;
+  }
   
   export interface Category {
     _id: string;
@@ -17,9 +29,25 @@ export interface Expense {
     color: string;
   }
 
+  export interface ReceiptItem {
+    name: string;
+    quantity: number;
+    unitPrice: number;
+    amount: number;
+    discount?: number;
+  }
+
   interface AnalysisResults {
     extractedText: string;
+    items?: ReceiptItem[];
+    subtotal?: number;
+    discount?: number;
+    tax?: number;
+    tip?: number;
     total: number;
+    currency?: string;
+    reconciled: boolean;
+    difference?: number;
   }
   
   export interface Bill {
@@ -28,14 +56,126 @@ export interface Expense {
     fileType: string;
     uploadDate: string;
     processedDate: string;
-    status: string;
+    status: "uploaded" | "processing" | "processed" | "error" | "confirmed" | "discarded";
+    error?: string;
     analysisResults: AnalysisResults;
     generatedExpenses: Expense[];
+    merchant?: string;
+    transactionDate?: string;
+    paymentMethod?: string;
+    cardLast4?: string;
   }
 
   export interface BudgetGoal {
     _id: string;
+    // What the goal counts: the expenses of categoryId ("category", the
+    // default), of any of categoryIds ("categories") or all ("overall").
+    scope?: "category" | "categories" | "overall";
+    categoryId?: string;
+    categoryIds?: string[];
+    amount: number;
+    currency?: string;
+    // "weekly", "monthly", "quarterly" or "yearly", optionally anchored
+    // ("monthly:15", "weekly:monday"), or "custom:YYYY-MM-DD..YYYY-MM-DD".
+    period: string;
+    // What of a period's balance carries into the next one; rolloverCap
+    // bounds it either way for "capped".
+    rollover?: "none" | "surplus" | "both" | "capped";
+    rolloverCap?: number;
+  }
+
+  export interface BudgetProgress {
+    goal: BudgetGoal;
+    from: string;
+    to: string;
+    asOf: string;
+    carriedIn: number;
+    budget: number;
+    spent: number;
+    count: number;
+    remaining: number;
+    percent: number;
+    projected: number;
+    status: "on_track" | "at_risk" | "over";
+    // Goals counting some of the same expenses, whose budgets overlap.
+    overlaps?: string[];
+  }
+
+  export interface BudgetBalance {
+    _id: string;
+    goalId: string;
+    from: string;
+    to: string;
+    carriedIn: number;
+    budget: number;
+    spent: number;
+    carriedOut: number;
+  }
+
+  export interface RecurringExpense {
+    _id: string;
+    name: string;
+    amount: number;
+    currency?: string;
+    categoryId: string;
+    frequency: "daily" | "weekly" | "monthly" | "yearly";
+    interval: number;
+    startDate: string;
+    endDate?: string;
+    nextDate?: string;
+    done?: boolean;
+  }
+
+  export interface CategoryRule {
+    _id: string;
+    name: string;
+    priority: number;
     categoryId: string;
+    namePattern?: string;
+    merchant?: string;
+    minAmount?: number;
+    maxAmount?: number;
+    weekdays?: number[];
+  }
+
+  export interface ExchangeRate {
+    _id: string;
+    from: string;
+    to: string;
+    rate: number;
+    date: string;
+  }
+
+  export interface MoneyAmount {
     amount: number;
-    period: "weekly" | "monthly";
-  }
\ No newline at end of file
+    currency: string;
+  }
+
+  export interface CategoryTotal {
+    categoryId: string;
+    name: string;
+    color: string;
+    count: number;
+    total: number;
+    original: MoneyAmount[];
+  }
+
+  export interface ExpenseSummary {
+    baseCurrency: string;
+    count: number;
+    total: number;
+    original: MoneyAmount[];
+    categories: CategoryTotal[];
+  }
+
+  export interface User {
+    _id: string;
+    email: string;
+    createdAt: string;
+  }
+
+  export interface AuthResponse {
+    token: string;
+    expiresAt: string;
+    user: User;
+  }
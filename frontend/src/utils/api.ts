@@ -1,16 +1,151 @@
 import axios from 'axios';
-import { Expense, Category, BudgetGoal } from '@/types';
+import { AuthResponse, Expense, Category, BudgetBalance, BudgetGoal, BudgetProgress, Bill, ExchangeRate, ExpensePage, ExpenseSummary, MoneyAmount, RecurringExpense } from '@/types';
 
 const API_URL = 'http://localhost:8080/api';
+const TOKEN_KEY = 'authToken';
+const LEDGER_KEY = 'ledgerId';
+const LOGIN_PATH = '/login';
 
-export const getExpenses = async (): Promise<Expense[]> => {
-  const response = await axios.get(`${API_URL}/expenses`);
-  return response.data;
+// Every endpoint except register and login needs the session token. Data
+// goes to the selected ledger, or to the personal one when none is selected.
+axios.interceptors.request.use((config) => {
+  if (typeof window === 'undefined') {
+    return config;
+  }
+  const token = localStorage.getItem(TOKEN_KEY);
+  if (token) {
+    config.headers.Authorization = `Bearer ${token}`;
+  }
+  const ledgerId = localStorage.getItem(LEDGER_KEY);
+  if (ledgerId) {
+    config.headers['X-Ledger-ID'] = ledgerId;
+  }
+  return config;
+});
+
+// An expired or revoked session answers 401 anywhere; drop it and go back to
+// the login screen. Failed logins answer 401 too, and are left to the form.
+axios.interceptors.response.use(undefined, (error) => {
+  if (
+    typeof window !== 'undefined' &&
+    error.response?.status === 401 &&
+    !error.config?.url?.startsWith(`${API_URL}/auth/`)
+  ) {
+    logout();
+    window.location.assign(LOGIN_PATH);
+  }
+  return Promise.reject(error);
+});
+
+// The API exchanges amounts as integer minor units (cents) of an ISO 4217
+// currency; the UI works in major units, so convert at this boundary.
+const DEFAULT_CURRENCY = 'USD';
+
+const minorUnitDigits = (currency?: string): number =>
+  new Intl.NumberFormat('en-US', { style: 'currency', currency: currency || DEFAULT_CURRENCY })
+    .resolvedOptions().maximumFractionDigits ?? 2;
+
+const toMinor = (amount: number, currency?: string): number =>
+  Math.round(amount * 10 ** minorUnitDigits(currency));
+
+const fromMinor = (amount: number, currency?: string): number =>
+  amount / 10 ** minorUnitDigits(currency);
+
+const expenseFromApi = (expense: Expense): Expense => ({
+  ...expense,
+  amount: fromMinor(expense.amount, expense.currency),
+});
+
+const expenseToApi = <T extends { amount: number; currency?: string }>(expense: T): T => ({
+  ...expense,
+  amount: toMinor(expense.amount, expense.currency),
+});
+
+const billFromApi = (bill: Bill): Bill => {
+  const { currency } = bill.analysisResults;
+  const amount = (minor?: number) => fromMinor(minor || 0, currency);
+  return {
+    ...bill,
+    analysisResults: {
+      ...bill.analysisResults,
+      items: (bill.analysisResults.items || []).map((item) => ({
+        ...item,
+        unitPrice: amount(item.unitPrice),
+        amount: amount(item.amount),
+        discount: amount(item.discount),
+      })),
+      subtotal: amount(bill.analysisResults.subtotal),
+      discount: amount(bill.analysisResults.discount),
+      tax: amount(bill.analysisResults.tax),
+      tip: amount(bill.analysisResults.tip),
+      total: amount(bill.analysisResults.total),
+      difference: amount(bill.analysisResults.difference),
+    },
+    generatedExpenses: (bill.generatedExpenses || []).map(expenseFromApi),
+  };
+};
+
+const goalFromApi = (goal: BudgetGoal): BudgetGoal => ({
+  ...goal,
+  amount: fromMinor(goal.amount, goal.currency),
+  rolloverCap: goal.rolloverCap && fromMinor(goal.rolloverCap, goal.currency),
+});
+
+const goalToApi = <T extends Omit<BudgetGoal, '_id'>>(goal: T): T => ({
+  ...expenseToApi(goal),
+  rolloverCap: goal.rolloverCap && toMinor(goal.rolloverCap, goal.currency),
+});
+
+export const selectLedger = (ledgerId: string | null): void => {
+  if (ledgerId) {
+    localStorage.setItem(LEDGER_KEY, ledgerId);
+  } else {
+    localStorage.removeItem(LEDGER_KEY);
+  }
+};
+
+// A new session starts on the user's personal ledger, which has the user's ID.
+const startSession = (session: AuthResponse): void => {
+  localStorage.setItem(TOKEN_KEY, session.token);
+  selectLedger(session.user._id);
+};
+
+export const isLoggedIn = (): boolean => localStorage.getItem(TOKEN_KEY) !== null;
+
+export const register = async (email: string, password: string): Promise<void> => {
+  const response = await axios.post<AuthResponse>(`${API_URL}/auth/register`, { email, password });
+  startSession(response.data);
+};
+
+export const login = async (email: string, password: string): Promise<void> => {
+  const response = await axios.post<AuthResponse>(`${API_URL}/auth/login`, { email, password });
+  startSession(response.data);
+};
+
+// authError returns why the server refused a login or registration, such as
+// a taken email or a short password, or undefined for other errors.
+export const authError = (error: unknown): string | undefined =>
+  axios.isAxiosError(error) && typeof error.response?.data === 'string'
+    ? error.response.data.trim()
+    : undefined;
+
+export const logout = (): void => {
+  localStorage.removeItem(TOKEN_KEY);
+  localStorage.removeItem(LEDGER_KEY);
+};
+
+export const getExpenses = async (cursor?: string): Promise<ExpensePage> => {
+  // One page at a time; pass the previous page's nextCursor for the next one.
+  const response = await axios.get(`${API_URL}/expenses`, { params: { cursor } });
+  return {
+    expenses: response.data.expenses.map(expenseFromApi),
+    nextCursor: response.data.nextCursor,
+  };
 };
 
 export const addExpense = async (expense: Omit<Expense, '_id' | 'category'>): Promise<Expense> => {
-  const response = await axios.post(`${API_URL}/expenses`, expense);
-  return response.data;
+  const response = await axios.post(`${API_URL}/expenses`, expenseToApi(expense));
+  return expenseFromApi(response.data);
 };
 
 export const getCategories = async (): Promise<Category[]> => {
@@ -28,8 +163,8 @@ export const addCategory = async (category: Omit<Category, '_id'>): Promise<Cate
   };
 
   export const updateExpense = async (expense: Expense): Promise<Expense> => {
-    const response = await axios.put(`${API_URL}/expenses/${expense._id}`, expense);
-    return response.data;
+    const response = await axios.put(`${API_URL}/expenses/${expense._id}`, expenseToApi(expense));
+    return expenseFromApi(response.data);
   };
 
   export const updateCategory = async (category: Category): Promise<Category> => {
@@ -52,34 +187,153 @@ export const uploadBill = async (formData: FormData): Promise<any> => {
   return response.data;
 };
 
-export const getBillDetails = async (billId: string): Promise<any> => {
+export const getBillDetails = async (billId: string): Promise<Bill> => {
   const response = await axios.get(`${API_URL}/bills/${billId}`);
-  return response.data;
+  return billFromApi(response.data);
+};
+
+// Bills are processed in the background after the upload; poll until the
+// bill is processed or has failed.
+export const waitForBill = async (billId: string, intervalMs = 1000, timeoutMs = 120000): Promise<Bill> => {
+  const deadline = Date.now() + timeoutMs;
+  for (;;) {
+    const bill = await getBillDetails(billId);
+    if (bill.status !== 'uploaded' && bill.status !== 'processing') {
+      return bill;
+    }
+    if (Date.now() > deadline) {
+      throw new Error('Timed out waiting for the bill to be processed');
+    }
+    await new Promise((resolve) => setTimeout(resolve, intervalMs));
+  }
 };
 
 export const confirmExpenses = async (billId: string, expenses: Expense[]): Promise<void> => {
-  await axios.post(`${API_URL}/bills/${billId}/confirm`, expenses);
+  await axios.post(`${API_URL}/bills/${billId}/confirm`, expenses.map(expenseToApi));
 };
 
 export const updateBillExpense = async (billId: string, expense: Expense): Promise<void> => {
-  await axios.put(`${API_URL}/bills/${billId}/expenses/${expense._id}`, expense);
+  await axios.put(`${API_URL}/bills/${billId}/expenses/${expense._id}`, expenseToApi(expense));
 };
 
 export const getBudgetGoals = async (): Promise<BudgetGoal[]> => {
   const response = await axios.get(`${API_URL}/budget-goals`);
-  return response.data;
+  return response.data.map(goalFromApi);
 };
 
 export const addBudgetGoal = async (goal: Omit<BudgetGoal, '_id'>): Promise<BudgetGoal> => {
-  const response = await axios.post(`${API_URL}/budget-goals`, goal);
-  return response.data;
+  const response = await axios.post(`${API_URL}/budget-goals`, goalToApi(goal));
+  return goalFromApi(response.data);
 };
 
 export const updateBudgetGoal = async (goal: BudgetGoal): Promise<BudgetGoal> => {
-  const response = await axios.put(`${API_URL}/budget-goals/${goal._id}`, goal);
-  return response.data;
+  const response = await axios.put(`${API_URL}/budget-goals/${goal._id}`, goalToApi(goal));
+  return goalFromApi(response.data);
 };
 
 export const deleteBudgetGoal = async (id: string): Promise<void> => {
   await axios.delete(`${API_URL}/budget-goals/${id}`);
-};
\ No newline at end of file
+};
+
+// budgetGoalErrors returns what the server found wrong with each field of a
+// budget goal, by field name, or undefined for other errors.
+export const budgetGoalErrors = (error: unknown): Record<string, string> | undefined =>
+  axios.isAxiosError(error) ? error.response?.data?.fields : undefined;
+
+// getBudgetProgress returns how far each goal is into its current period,
+// or the period containing `at` (YYYY-MM-DD) when given.
+export const getBudgetProgress = async (at?: string): Promise<BudgetProgress[]> => {
+  const response = await axios.get(`${API_URL}/budget-goals/progress`, { params: { at } });
+  return response.data.map((progress: BudgetProgress) => {
+    const { currency } = progress.goal;
+    return {
+      ...progress,
+      goal: goalFromApi(progress.goal),
+      carriedIn: fromMinor(progress.carriedIn, currency),
+      budget: fromMinor(progress.budget, currency),
+      spent: fromMinor(progress.spent, currency),
+      remaining: fromMinor(progress.remaining, currency),
+      projected: fromMinor(progress.projected, currency),
+    };
+  });
+};
+
+// getBudgetBalances returns how the goal ended each of its past periods.
+export const getBudgetBalances = async (goal: BudgetGoal): Promise<BudgetBalance[]> => {
+  const response = await axios.get(`${API_URL}/budget-goals/${goal._id}/balances`);
+  const amount = (minor: number) => fromMinor(minor, goal.currency);
+  return response.data.map((balance: BudgetBalance) => ({
+    ...balance,
+    carriedIn: amount(balance.carriedIn),
+    budget: amount(balance.budget),
+    spent: amount(balance.spent),
+    carriedOut: amount(balance.carriedOut),
+  }));
+};
+
+const recurringFromApi = (recurring: RecurringExpense): RecurringExpense => ({
+  ...recurring,
+  amount: fromMinor(recurring.amount, recurring.currency),
+});
+
+export const getRecurringExpenses = async (): Promise<RecurringExpense[]> => {
+  const response = await axios.get(`${API_URL}/recurring-expenses`);
+  return response.data.map(recurringFromApi);
+};
+
+export const addRecurringExpense = async (recurring: Omit<RecurringExpense, '_id'>): Promise<RecurringExpense> => {
+  const response = await axios.post(`${API_URL}/recurring-expenses`, expenseToApi(recurring));
+  return recurringFromApi(response.data);
+};
+
+export const updateRecurringExpense = async (recurring: RecurringExpense): Promise<RecurringExpense> => {
+  const response = await axios.put(`${API_URL}/recurring-expenses/${recurring._id}`, expenseToApi(recurring));
+  return recurringFromApi(response.data);
+};
+
+export const deleteRecurringExpense = async (id: string): Promise<void> => {
+  await axios.delete(`${API_URL}/recurring-expenses/${id}`);
+};
+
+export const getExchangeRates = async (): Promise<ExchangeRate[]> => {
+  const response = await axios.get(`${API_URL}/exchange-rates`);
+  return response.data;
+};
+
+export const addExchangeRate = async (rate: Omit<ExchangeRate, '_id'>): Promise<ExchangeRate> => {
+  const response = await axios.post(`${API_URL}/exchange-rates`, rate);
+  return response.data;
+};
+
+// importExchangeRates uploads a CSV file of date,from,to,rate lines.
+export const importExchangeRates = async (file: File): Promise<number> => {
+  const formData = new FormData();
+  formData.append('file', file);
+  const response = await axios.post(`${API_URL}/exchange-rates/import`, formData, {
+    headers: {
+      'Content-Type': 'multipart/form-data',
+    },
+  });
+  return response.data.imported;
+};
+
+export const deleteExchangeRate = async (id: string): Promise<void> => {
+  await axios.delete(`${API_URL}/exchange-rates/${id}`);
+};
+
+const moneyFromApi = (m: MoneyAmount): MoneyAmount => ({ ...m, amount: fromMinor(m.amount, m.currency) });
+
+export const getExpenseSummary = async (params: { from?: string; to?: string } = {}): Promise<ExpenseSummary> => {
+  const response = await axios.get(`${API_URL}/expenses/summary`, { params });
+  const summary: ExpenseSummary = response.data;
+  return {
+    ...summary,
+    total: fromMinor(summary.total, summary.baseCurrency),
+    original: summary.original.map(moneyFromApi),
+    categories: summary.categories.map((c) => ({
+      ...c,
+      total: fromMinor(c.total, summary.baseCurrency),
+      original: c.original.map(moneyFromApi),
+    })),
+  };
+};